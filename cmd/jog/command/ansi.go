@@ -0,0 +1,89 @@
+package command
+
+import "io"
+
+// ansiState is the state of an ansiStripper between writes
+type ansiState int
+
+const (
+	ansiText ansiState = iota
+	// ansiEscape follows an ESC byte
+	ansiEscape
+	// ansiCSI is inside a control sequence, ESC [ ... final byte
+	ansiCSI
+	// ansiOSC is inside an operating system command, ESC ] ... BEL or ESC \
+	ansiOSC
+	// ansiOSCEscape follows an ESC byte inside an operating system command
+	ansiOSCEscape
+)
+
+const (
+	esc = 0x1b
+	bel = 0x07
+)
+
+// ansiStripper is an io.Writer that removes ANSI escape sequences before writing to w.
+// The state is kept between writes, so sequences split across output chunks are removed too.
+type ansiStripper struct {
+	w     io.Writer
+	state ansiState
+	buf   []byte
+}
+
+func newANSIStripper(w io.Writer) *ansiStripper {
+	return &ansiStripper{w: w}
+}
+
+// Write writes p to the underlying writer without escape sequences. The length of p is
+// returned on success, even though fewer bytes may have been written.
+func (a *ansiStripper) Write(p []byte) (int, error) {
+	a.buf = a.buf[:0]
+	for _, b := range p {
+		switch a.state {
+		case ansiText:
+			if b == esc {
+				a.state = ansiEscape
+				continue
+			}
+			a.buf = append(a.buf, b)
+		case ansiEscape:
+			switch b {
+			case '[':
+				a.state = ansiCSI
+			case ']':
+				a.state = ansiOSC
+			case esc:
+				// stay in the escape state
+			default:
+				// a two byte sequence, like ESC c or ESC 7
+				a.state = ansiText
+			}
+		case ansiCSI:
+			// parameter and intermediate bytes are 0x20-0x3f, the final byte is 0x40-0x7e
+			if b >= 0x40 && b <= 0x7e {
+				a.state = ansiText
+			}
+		case ansiOSC:
+			switch b {
+			case bel:
+				a.state = ansiText
+			case esc:
+				a.state = ansiOSCEscape
+			}
+		case ansiOSCEscape:
+			// ESC \ terminates the command, anything else is part of it
+			if b == '\\' {
+				a.state = ansiText
+			} else if b != esc {
+				a.state = ansiOSC
+			}
+		}
+	}
+	if len(a.buf) == 0 {
+		return len(p), nil
+	}
+	if _, err := a.w.Write(a.buf); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
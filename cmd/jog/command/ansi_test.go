@@ -0,0 +1,89 @@
+package command
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestANSIStripper(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		chunks []string
+		want   string
+	}{
+		{
+			name:   "no escape sequences",
+			chunks: []string{"plain ", "text\n"},
+			want:   "plain text\n",
+		},
+		{
+			name:   "color codes",
+			chunks: []string{"\x1b[31mred\x1b[0m and \x1b[1;32mbold green\x1b[m\n"},
+			want:   "red and bold green\n",
+		},
+		{
+			name:   "split after ESC",
+			chunks: []string{"before\x1b", "[31mafter"},
+			want:   "beforeafter",
+		},
+		{
+			name:   "split inside the parameters",
+			chunks: []string{"a\x1b[1;", "3", "2mb"},
+			want:   "ab",
+		},
+		{
+			name:   "split before the final byte",
+			chunks: []string{"a\x1b[2", "Kb"},
+			want:   "ab",
+		},
+		{
+			name:   "cursor movement and erase",
+			chunks: []string{"\x1b[2J\x1b[H\x1b[?25lscreen\x1b[?25h"},
+			want:   "screen",
+		},
+		{
+			name:   "OSC title terminated by BEL, split",
+			chunks: []string{"x\x1b]0;ti", "tle\x07y"},
+			want:   "xy",
+		},
+		{
+			name:   "OSC hyperlink terminated by ESC backslash, split",
+			chunks: []string{"\x1b]8;;http://example.com\x1b", "\\link\x1b]8;;\x1b\\"},
+			want:   "link",
+		},
+		{
+			name:   "two byte sequence",
+			chunks: []string{"a\x1b", "cb\x1b7c"},
+			want:   "abc",
+		},
+		{
+			name:   "utf-8 text is kept",
+			chunks: []string{"\x1b[35mhéllo ", "wörld\x1b[0m"},
+			want:   "héllo wörld",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var out bytes.Buffer
+			s := newANSIStripper(&out)
+			for _, c := range tt.chunks {
+				n, err := s.Write([]byte(c))
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if n != len(c) {
+					t.Fatalf("expected %d bytes written, got %d", len(c), n)
+				}
+			}
+			if out.String() != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, out.String())
+			}
+		})
+	}
+}
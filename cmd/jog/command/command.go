@@ -2,7 +2,13 @@ package command
 
 import (
 	"fmt"
+	"math"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
+
+	"github.com/dustinevan/jogger/pkg/humanize"
 )
 
 type SubCommand int
@@ -12,6 +18,14 @@ const (
 	Stop
 	Status
 	Output
+	Config
+	Exists
+	Events
+	Update
+	Doctor
+	Watch
+	List
+	Diff
 )
 
 var subCommandStrings = [...]string{
@@ -19,6 +33,25 @@ var subCommandStrings = [...]string{
 	"stop",
 	"status",
 	"output",
+	"config",
+	"exists",
+	"events",
+	"update",
+	"doctor",
+	"watch",
+	"list",
+	"diff",
+}
+
+// jobless reports whether the subcommand runs without a job id: config and doctor don't use
+// a job, and list is about all of the user's jobs
+func (s SubCommand) jobless() bool {
+	return s == Config || s == Doctor || s == List
+}
+
+// mutating reports whether the subcommand changes jobs on the server, which observers can't do
+func (s SubCommand) mutating() bool {
+	return s == Start || s == Stop || s == Update
 }
 
 func ParseSubCommand(s string) (SubCommand, error) {
@@ -36,6 +69,42 @@ const (
 	Help Flag = iota
 	Host
 	RemoteCommandDelimiter
+	Compress
+	ExitCode
+	MaxOutput
+	StripANSI
+	TTY
+	Save
+	JSON
+	Grace
+	Authority
+	TagStreams
+	Reverse
+	Capture
+	Name
+	MaxBytes
+	Chunk
+	Raw
+	Shell
+	Quiet
+	NoSummary
+	NoProgress
+	Number
+	Memory
+	CPUs
+	Observer
+	Encoding
+	Umask
+	NoFile
+	NProc
+	Follow
+	Gzip
+	After
+	IfCompleted
+	Redact
+	RedactRegex
+	Stderr
+	Attach
 )
 
 var (
@@ -43,13 +112,88 @@ var (
 		"--help",
 		"--host",
 		"--",
+		"--compress",
+		"--exit-code",
+		"--max-output",
+		"--strip-ansi",
+		"--tty",
+		"--save",
+		"--json",
+		"--grace",
+		"--authority",
+		"--tag-streams",
+		"--reverse",
+		"--capture",
+		"--name",
+		"--max-bytes",
+		"--chunk",
+		"--raw",
+		"--shell",
+		"--quiet",
+		"--no-summary",
+		"--no-progress",
+		"--number",
+		"--memory",
+		"--cpus",
+		"--observer",
+		"--encoding",
+		"--umask",
+		"--nofile",
+		"--nproc",
+		"--follow",
+		"--gzip",
+		"--after",
+		"--if-completed",
+		"--redact",
+		"--redact-regex",
+		"--stderr",
+		"--attach",
 	}
 	flagStringMap = map[string]Flag{
-		"--help": Help,
-		"-h":     Help,
-		"--host": Host,
-		"-D":     Host,
-		"--":     RemoteCommandDelimiter,
+		"--help":         Help,
+		"-h":             Help,
+		"--host":         Host,
+		"-D":             Host,
+		"--":             RemoteCommandDelimiter,
+		"--compress":     Compress,
+		"--exit-code":    ExitCode,
+		"--max-output":   MaxOutput,
+		"--strip-ansi":   StripANSI,
+		"--tty":          TTY,
+		"--save":         Save,
+		"--json":         JSON,
+		"--grace":        Grace,
+		"--authority":    Authority,
+		"--tag-streams":  TagStreams,
+		"--reverse":      Reverse,
+		"--capture":      Capture,
+		"--name":         Name,
+		"--max-bytes":    MaxBytes,
+		"--chunk":        Chunk,
+		"--raw":          Raw,
+		"--shell":        Shell,
+		"--quiet":        Quiet,
+		"-q":             Quiet,
+		"--no-summary":   NoSummary,
+		"--no-progress":  NoProgress,
+		"--number":       Number,
+		"-n":             Number,
+		"--memory":       Memory,
+		"--cpus":         CPUs,
+		"--observer":     Observer,
+		"--encoding":     Encoding,
+		"--umask":        Umask,
+		"--nofile":       NoFile,
+		"--nproc":        NProc,
+		"--follow":       Follow,
+		"-f":             Follow,
+		"--gzip":         Gzip,
+		"--after":        After,
+		"--if-completed": IfCompleted,
+		"--redact":       Redact,
+		"--redact-regex": RedactRegex,
+		"--stderr":       Stderr,
+		"--attach":       Attach,
 	}
 )
 
@@ -78,10 +222,48 @@ func (f Flag) String() string {
 type Command struct {
 	SubCommand    SubCommand
 	Host          string
+	Authority     string
 	JobID         string
+	OtherJobID    string
 	RemoteCommand string
 	RemoteArgs    []string
+	Redact        []string
+	RedactRegex   []string
 	HelpWanted    bool
+	Observer      bool
+	Compress      bool
+	ExitCode      bool
+	MaxOutput     int64
+	MaxBytes      int64
+	StripANSI     bool
+	TTY           bool
+	Shell         bool
+	Quiet         bool
+	Follow        bool
+	Attach        bool
+	After         string
+	IfCompleted   bool
+	NoSummary     bool
+	NoProgress    bool
+	SavePath      string
+	Gzip          bool
+	JSON          bool
+	TagStreams    bool
+	Stderr        bool
+	Reverse       bool
+	Raw           bool
+	Number        bool
+	Capture       string
+	Name          string
+	Chunking      string
+	Encoding      string
+	ChunkSize     int64
+	GracePeriod   time.Duration
+	MemoryLimit   int64
+	CPULimit      float64
+	Umask         string
+	NoFile        uint64
+	NProc         uint64
 }
 
 func NewCommand(args []string) (*Command, error) {
@@ -132,6 +314,177 @@ func NewCommand(args []string) (*Command, error) {
 			case Host:
 				c.Host = value
 				continue
+			case Observer:
+				c.Observer = true
+				continue
+			case Authority:
+				if err := ValidateAuthority(value); err != nil {
+					return nil, fmt.Errorf("invalid authority: %s: %w", args[i], err)
+				}
+				c.Authority = value
+				continue
+			case Compress:
+				c.Compress = true
+				continue
+			case ExitCode:
+				c.ExitCode = true
+				continue
+			case Save:
+				if value == "" {
+					return nil, fmt.Errorf("no save file provided: use --save=FILE")
+				}
+				c.SavePath = value
+				continue
+			case Gzip:
+				c.Gzip = true
+				continue
+			case JSON:
+				c.JSON = true
+				continue
+			case TagStreams:
+				c.TagStreams = true
+				continue
+			case Stderr:
+				c.Stderr = true
+				continue
+			case Reverse:
+				c.Reverse = true
+				continue
+			case Raw:
+				c.Raw = true
+				continue
+			case Number:
+				c.Number = true
+				continue
+			case Name:
+				if value == "" {
+					return nil, fmt.Errorf("no job name provided: use --name=NAME")
+				}
+				c.Name = value
+				continue
+			case Chunk:
+				chunking, size, err := parseChunk(value)
+				if err != nil {
+					return nil, fmt.Errorf("invalid chunk policy: %s: %w", args[i], err)
+				}
+				c.Chunking, c.ChunkSize = chunking, size
+				continue
+			case Encoding:
+				if _, ok := encodings[value]; !ok {
+					return nil, fmt.Errorf("invalid encoding: %s: use --encoding=raw, base64, or hex", args[i])
+				}
+				c.Encoding = value
+				continue
+			case Capture:
+				if _, ok := captures[value]; !ok {
+					return nil, fmt.Errorf("invalid capture: %s: use --capture=both, stdout, or stderr", args[i])
+				}
+				c.Capture = value
+				continue
+			case Grace:
+				d, err := humanize.ParseDuration(value)
+				if err != nil {
+					return nil, fmt.Errorf("invalid grace period: %s: %w", args[i], err)
+				}
+				c.GracePeriod = d
+				continue
+			case TTY:
+				c.TTY = true
+				continue
+			case Shell:
+				c.Shell = true
+				continue
+			case Quiet:
+				c.Quiet = true
+				continue
+			case Follow:
+				c.Follow = true
+				continue
+			case Attach:
+				c.Attach = true
+				continue
+			case After:
+				if value == "" {
+					return nil, fmt.Errorf("no job id provided: use --after=JOB_ID")
+				}
+				c.After = value
+				continue
+			case IfCompleted:
+				c.IfCompleted = true
+				continue
+			case NoSummary:
+				c.NoSummary = true
+				continue
+			case NoProgress:
+				c.NoProgress = true
+				continue
+			case StripANSI:
+				c.StripANSI = true
+				continue
+			case Umask:
+				if n, err := strconv.ParseUint(value, 8, 32); err != nil || n > 0o777 {
+					return nil, fmt.Errorf("invalid umask: %s: use an octal mask like --umask=022", args[i])
+				}
+				c.Umask = value
+				continue
+			case NoFile, NProc:
+				n, err := strconv.ParseUint(value, 10, 64)
+				if err != nil || n == 0 {
+					return nil, fmt.Errorf("invalid limit: %s: use a number greater than 0, e.g. %s=4096", args[i], flag)
+				}
+				if flag == NoFile {
+					c.NoFile = n
+				} else {
+					c.NProc = n
+				}
+				continue
+			case MaxOutput:
+				n, err := humanize.ParseBytes(value)
+				if err != nil {
+					return nil, fmt.Errorf("invalid output limit: %s: %w", args[i], err)
+				}
+				c.MaxOutput = n
+				continue
+			case MaxBytes:
+				n, err := humanize.ParseBytes(value)
+				if err != nil {
+					return nil, fmt.Errorf("invalid byte cap: %s: %w", args[i], err)
+				}
+				c.MaxBytes = n
+				continue
+			case Memory:
+				n, err := humanize.ParseBytes(value)
+				if err != nil {
+					return nil, fmt.Errorf("invalid memory limit: %s: %w", args[i], err)
+				}
+				if n < 1 {
+					return nil, fmt.Errorf("invalid memory limit: %s: must be greater than 0", args[i])
+				}
+				c.MemoryLimit = n
+				continue
+			case CPUs:
+				n, err := strconv.ParseFloat(value, 64)
+				if err != nil || !(n > 0) || math.IsInf(n, 0) {
+					return nil, fmt.Errorf("invalid cpu limit: %s: use a number of CPUs like --cpus=1.5", args[i])
+				}
+				c.CPULimit = n
+				continue
+			case Redact:
+				if value == "" {
+					return nil, fmt.Errorf("no secret provided: use --redact=SECRET")
+				}
+				c.Redact = append(c.Redact, value)
+				continue
+			case RedactRegex:
+				re, err := regexp.Compile(value)
+				if err != nil {
+					return nil, fmt.Errorf("invalid redact pattern: %s: %w", args[i], err)
+				}
+				if re.MatchString("") {
+					return nil, fmt.Errorf("invalid redact pattern: %s: it matches empty output", args[i])
+				}
+				c.RedactRegex = append(c.RedactRegex, value)
+				continue
 			default:
 				// This means the flag was parsed successfully but no handler exists for it, a programming error
 				// this is a CLI, so we return an error instead of panicking
@@ -140,6 +493,18 @@ func NewCommand(args []string) (*Command, error) {
 
 		}
 		// The argument is not a flag
+		if c.SubCommand.jobless() {
+			return nil, fmt.Errorf("unexpected argument: %s: %s doesn't take a job id", args[i], subCommandStrings[c.SubCommand])
+		}
+		// diff takes two job ids
+		if c.SubCommand == Diff && c.JobID == "" {
+			c.JobID = args[i]
+			continue
+		}
+		if c.SubCommand == Diff {
+			c.OtherJobID = args[i]
+			break
+		}
 		if c.SubCommand != Start {
 			c.JobID = args[i]
 			break
@@ -151,19 +516,107 @@ func NewCommand(args []string) (*Command, error) {
 		}
 	}
 
+	if c.Observer && c.SubCommand.mutating() {
+		return nil, errObserver(c.SubCommand)
+	}
+
 	// Check for required fields
 	if c.SubCommand == Start {
 		if c.RemoteCommand == "" {
 			return nil, fmt.Errorf("no remote command provided")
 		}
+	} else if c.SubCommand.jobless() {
+		if c.RemoteCommand != "" {
+			return nil, fmt.Errorf("unexpected remote command: %s doesn't run a job", subCommandStrings[c.SubCommand])
+		}
 	} else {
 		if c.JobID == "" {
 			return nil, fmt.Errorf("no job id provided")
 		}
+		if c.SubCommand == Diff && c.OtherJobID == "" {
+			return nil, fmt.Errorf("no second job id provided: diff compares the output of two jobs")
+		}
+	}
+	if c.SubCommand == Update && c.MemoryLimit == 0 && c.CPULimit == 0 {
+		return nil, fmt.Errorf("no limits provided: use --memory=SIZE and/or --cpus=N")
+	}
+	if c.IfCompleted && c.After == "" {
+		return nil, fmt.Errorf("--if-completed only starts the job if the --after job completed: use it with --after=JOB_ID")
+	}
+	// a pending job has no output to attach to until it's started
+	if c.Attach && c.After != "" {
+		return nil, fmt.Errorf("--attach can't be used with --after: use --follow to stream the output once the job starts")
+	}
+	// --follow reopens the stream where it ended, --max-bytes caps a single stream
+	if c.Follow && c.MaxBytes > 0 {
+		return nil, fmt.Errorf("--follow can't be used with --max-bytes")
+	}
+	// --reverse waits for all of the output, which keeps growing while it's followed
+	if c.Follow && c.Reverse {
+		return nil, fmt.Errorf("--follow can't be used with --reverse")
+	}
+	if c.Gzip && c.SavePath == "" {
+		return nil, fmt.Errorf("--gzip compresses the --save file: use it with --save=FILE")
+	}
+	// --json without --save writes NDJSON to the screen, which is always in output order
+	if c.Reverse && c.JSON && c.SavePath == "" {
+		return nil, fmt.Errorf("--reverse can't be used with --json unless the NDJSON goes to a --save file")
+	}
+	// --raw writes the bytes to the screen exactly as the job wrote them
+	if c.Raw && (c.StripANSI || c.TagStreams || c.Reverse || c.JSON && c.SavePath == "") {
+		return nil, fmt.Errorf("--raw can't be used with --strip-ansi, --tag-streams, --reverse, or --json without --save")
+	}
+	// line numbers are only added to text on the screen
+	if c.Number && (c.Raw || c.JSON && c.SavePath == "") {
+		return nil, fmt.Errorf("--number can't be used with --raw, or --json without --save")
 	}
 	return c, nil
 }
 
+// errObserver is the error for a subcommand that observers can't use
+func errObserver(s SubCommand) error {
+	return fmt.Errorf("%s isn't allowed with --observer: observers can only use status, output, exists, events, watch, list, diff, config, and doctor", subCommandStrings[s])
+}
+
+// parseChunk parses a --chunk value: line, size, or size:N where N is a byte size like 16K.
+// A size of 0 is returned when N isn't given, for the server's default.
+func parseChunk(s string) (chunking string, size int64, err error) {
+	chunking, n, hasSize := strings.Cut(s, ":")
+	if _, ok := chunkings[chunking]; !ok || hasSize && chunking != "size" {
+		return "", 0, fmt.Errorf("use --chunk=line, size, or size:N")
+	}
+	if hasSize {
+		size, err = humanize.ParseBytes(n)
+		if err != nil {
+			return "", 0, err
+		}
+		if size < 1 {
+			return "", 0, fmt.Errorf("chunk size must be greater than 0")
+		}
+	}
+	return chunking, size, nil
+}
+
+// ValidateAuthority checks that s is a hostname that can be used as the gRPC authority and
+// the name the server's certificate is verified against, e.g. jogger.example.com. Ports
+// aren't allowed, the port is always taken from the host.
+func ValidateAuthority(s string) error {
+	if s == "" || len(s) > 253 {
+		return fmt.Errorf("use a hostname like --authority=jogger.example.com")
+	}
+	for _, label := range strings.Split(s, ".") {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return fmt.Errorf("use a hostname like --authority=jogger.example.com")
+		}
+		for _, r := range label {
+			if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-') {
+				return fmt.Errorf("use a hostname like --authority=jogger.example.com")
+			}
+		}
+	}
+	return nil
+}
+
 func (c *Command) String() string {
 	var sb strings.Builder
 	sb.WriteString("jog ")
@@ -172,12 +625,194 @@ func (c *Command) String() string {
 		sb.WriteString(" ")
 		sb.WriteString(flagStrings[Help])
 	}
+	if c.Observer {
+		sb.WriteString(" ")
+		sb.WriteString(flagStrings[Observer])
+	}
 	if c.Host != "" {
 		sb.WriteString(" ")
 		sb.WriteString(flagStrings[Host])
 		sb.WriteString("=")
 		sb.WriteString(c.Host)
 	}
+	if c.Authority != "" {
+		sb.WriteString(" ")
+		sb.WriteString(flagStrings[Authority])
+		sb.WriteString("=")
+		sb.WriteString(c.Authority)
+	}
+	if c.Compress {
+		sb.WriteString(" ")
+		sb.WriteString(flagStrings[Compress])
+	}
+	if c.ExitCode {
+		sb.WriteString(" ")
+		sb.WriteString(flagStrings[ExitCode])
+	}
+	if c.StripANSI {
+		sb.WriteString(" ")
+		sb.WriteString(flagStrings[StripANSI])
+	}
+	if c.SavePath != "" {
+		sb.WriteString(" ")
+		sb.WriteString(flagStrings[Save])
+		sb.WriteString("=")
+		sb.WriteString(c.SavePath)
+	}
+	if c.Gzip {
+		sb.WriteString(" ")
+		sb.WriteString(flagStrings[Gzip])
+	}
+	if c.JSON {
+		sb.WriteString(" ")
+		sb.WriteString(flagStrings[JSON])
+	}
+	if c.TagStreams {
+		sb.WriteString(" ")
+		sb.WriteString(flagStrings[TagStreams])
+	}
+	if c.Stderr {
+		sb.WriteString(" ")
+		sb.WriteString(flagStrings[Stderr])
+	}
+	if c.MaxBytes > 0 {
+		sb.WriteString(" ")
+		sb.WriteString(flagStrings[MaxBytes])
+		sb.WriteString("=")
+		sb.WriteString(strconv.FormatInt(c.MaxBytes, 10))
+	}
+	if c.Chunking != "" {
+		sb.WriteString(" ")
+		sb.WriteString(flagStrings[Chunk])
+		sb.WriteString("=")
+		sb.WriteString(c.Chunking)
+		if c.ChunkSize > 0 {
+			sb.WriteString(":")
+			sb.WriteString(strconv.FormatInt(c.ChunkSize, 10))
+		}
+	}
+	if c.Encoding != "" {
+		sb.WriteString(" ")
+		sb.WriteString(flagStrings[Encoding])
+		sb.WriteString("=")
+		sb.WriteString(c.Encoding)
+	}
+	if c.Reverse {
+		sb.WriteString(" ")
+		sb.WriteString(flagStrings[Reverse])
+	}
+	if c.Raw {
+		sb.WriteString(" ")
+		sb.WriteString(flagStrings[Raw])
+	}
+	if c.Number {
+		sb.WriteString(" ")
+		sb.WriteString(flagStrings[Number])
+	}
+	if c.NoSummary {
+		sb.WriteString(" ")
+		sb.WriteString(flagStrings[NoSummary])
+	}
+	if c.NoProgress {
+		sb.WriteString(" ")
+		sb.WriteString(flagStrings[NoProgress])
+	}
+	if c.TTY {
+		sb.WriteString(" ")
+		sb.WriteString(flagStrings[TTY])
+	}
+	if c.Shell {
+		sb.WriteString(" ")
+		sb.WriteString(flagStrings[Shell])
+	}
+	if c.Quiet {
+		sb.WriteString(" ")
+		sb.WriteString(flagStrings[Quiet])
+	}
+	if c.Follow {
+		sb.WriteString(" ")
+		sb.WriteString(flagStrings[Follow])
+	}
+	if c.Attach {
+		sb.WriteString(" ")
+		sb.WriteString(flagStrings[Attach])
+	}
+	if c.After != "" {
+		sb.WriteString(" ")
+		sb.WriteString(flagStrings[After])
+		sb.WriteString("=")
+		sb.WriteString(c.After)
+	}
+	if c.IfCompleted {
+		sb.WriteString(" ")
+		sb.WriteString(flagStrings[IfCompleted])
+	}
+	if c.Name != "" {
+		sb.WriteString(" ")
+		sb.WriteString(flagStrings[Name])
+		sb.WriteString("=")
+		sb.WriteString(c.Name)
+	}
+	if c.Capture != "" {
+		sb.WriteString(" ")
+		sb.WriteString(flagStrings[Capture])
+		sb.WriteString("=")
+		sb.WriteString(c.Capture)
+	}
+	if c.GracePeriod > 0 {
+		sb.WriteString(" ")
+		sb.WriteString(flagStrings[Grace])
+		sb.WriteString("=")
+		sb.WriteString(c.GracePeriod.String())
+	}
+	if c.MemoryLimit > 0 {
+		sb.WriteString(" ")
+		sb.WriteString(flagStrings[Memory])
+		sb.WriteString("=")
+		sb.WriteString(strconv.FormatInt(c.MemoryLimit, 10))
+	}
+	if c.CPULimit > 0 {
+		sb.WriteString(" ")
+		sb.WriteString(flagStrings[CPUs])
+		sb.WriteString("=")
+		sb.WriteString(strconv.FormatFloat(c.CPULimit, 'g', -1, 64))
+	}
+	if c.Umask != "" {
+		sb.WriteString(" ")
+		sb.WriteString(flagStrings[Umask])
+		sb.WriteString("=")
+		sb.WriteString(c.Umask)
+	}
+	if c.NoFile > 0 {
+		sb.WriteString(" ")
+		sb.WriteString(flagStrings[NoFile])
+		sb.WriteString("=")
+		sb.WriteString(strconv.FormatUint(c.NoFile, 10))
+	}
+	if c.NProc > 0 {
+		sb.WriteString(" ")
+		sb.WriteString(flagStrings[NProc])
+		sb.WriteString("=")
+		sb.WriteString(strconv.FormatUint(c.NProc, 10))
+	}
+	if c.MaxOutput > 0 {
+		sb.WriteString(" ")
+		sb.WriteString(flagStrings[MaxOutput])
+		sb.WriteString("=")
+		sb.WriteString(strconv.FormatInt(c.MaxOutput, 10))
+	}
+	for _, r := range c.Redact {
+		sb.WriteString(" ")
+		sb.WriteString(flagStrings[Redact])
+		sb.WriteString("=")
+		sb.WriteString(r)
+	}
+	for _, r := range c.RedactRegex {
+		sb.WriteString(" ")
+		sb.WriteString(flagStrings[RedactRegex])
+		sb.WriteString("=")
+		sb.WriteString(r)
+	}
 	if c.RemoteCommand != "" {
 		sb.WriteString(" -- ")
 		sb.WriteString(c.RemoteCommand)
@@ -194,8 +829,15 @@ NAME
     jog - a simple job runner
 
 SYNOPSIS
-    jog start [-D --host address[:port]] -- [command [argument ...]]
-    jog [stop | status | output] [-D --host address[:port]] [job_id]
+    jog start [-D --host address[:port]] [--authority hostname] [--max-output size] [--tty] [--shell] [--capture stream] [-q --quiet] [-f --follow] [--attach] [--after job_id [--if-completed]] [--name name] [--umask mask] [--nofile n] [--nproc n] [--redact secret ...] [--redact-regex regex ...] [--no-progress] -- [command [argument ...]]
+    jog stop [-D --host address[:port]] [--authority hostname] [--grace duration] [--no-progress] [job_id]
+    jog update [-D --host address[:port]] [--authority hostname] [--memory size] [--cpus n] [job_id]
+    jog [status | exists | events | watch] [-D --host address[:port]] [--authority hostname] [--observer] [job_id]
+    jog output [-D --host address[:port]] [--authority hostname] [--observer] [--compress] [--exit-code] [--strip-ansi] [--save file [--gzip]] [--json] [--tag-streams] [--stderr] [--reverse] [--max-bytes size] [--chunk line|size[:N]] [--encoding raw|base64|hex] [--raw] [-n --number] [--no-summary] [-f --follow] [job_id]
+    jog list [-D --host address[:port]] [--authority hostname] [--observer]
+    jog diff [-D --host address[:port]] [--authority hostname] [--observer] [--max-bytes size] [job_id] [job_id]
+    jog config [-D --host address[:port]] [--authority hostname] [--observer]
+    jog doctor [-D --host address[:port]] [--authority hostname] [--observer]
     jog [-h | --help]
 
 ENVIRONMENT VARIABLES -- The following must be set to securely connect to the host:
@@ -203,14 +845,124 @@ ENVIRONMENT VARIABLES -- The following must be set to securely connect to the ho
     export JOGGER_USER_CERT_FILE= [Absolute path to the user certificate pem file]
     export JOGGER_USER_KEY_FILE=  [Absolute path to the user private key pem file]
 
+  Connections go through the proxy in HTTPS_PROXY, unless the host matches NO_PROXY, e.g.
+    export HTTPS_PROXY=http://[user:password@]proxy[:port] or https://proxy[:port]
+    export NO_PROXY=.internal.example.com,10.0.0.0/8
+  Connections to localhost and loopback addresses never use the proxy.
+
 JOG COMMANDS
     start           start a job -- double dash -- separates the jog command from the remote command
     stop            stop a job
+    update          change the memory or CPU limit of a running job, without restarting it
     status          get the status of a job
     output          stream the output of a job
+    exists          check whether a job exists, exits with 0 if it does and 1 if it doesn't
+    events          print when a job was started, stopped, and exited, to help debug why it stopped
+    watch           print a job's events as they happen, until it exits
+    list            list your jobs, oldest first, with their job ids, statuses, and commands
+    diff            print a unified diff of two jobs' output, line by line, to see where a flaky
+                    job's runs went different ways. Exits with 1 if the output differs
+    config          print the configuration jog would use to connect, and where each value came from
+    doctor          check the configuration, the certificates, and the connection to the host, and
+                    print how to fix each check that fails. Exits with 1 if any check fails
 
 OPTIONS
     -D --host       address[:port] full details: https://github.com/grpc/grpc/blob/master/doc/naming.md
+                    srv://name, e.g. srv://_jogger._tcp.example.com, dials one of the name's SRV
+                    records, by priority and weight, and verifies the certificate against its target.
+                    If the records can't be resolved, name is dialed instead
+    --observer      only allow the commands that don't change jobs: status, output, exists, events,
+                    watch, list, diff, config, and doctor. start, stop, and update fail before anything is sent to the
+                    server. Use it for dashboards, along with a certificate the server only lets observe
+    --authority     the hostname the server's certificate is verified against, and the gRPC
+                    authority, when it differs from the host, e.g. when dialing a load balancer
+                    by IP. Can also be set with JOGGER_AUTHORITY
+    --max-output    start only: keep only the last size bytes of the job's output on the server, e.g.
+                    512, 64K, 10M, 1G. Earlier output is discarded and can't be streamed
+    --tty           start only: run the job in a pseudo-terminal, so tools that check for one print
+                    colors and line buffer. Output uses \r\n line endings
+    --shell         start only: run the command under sh -c on the server, so it can use variables,
+                    pipes, and &&, e.g. jog start --shell -- 'echo $HOME && ls'. Arguments after
+                    the command are quoted, so the shell passes them through as is. The server must
+                    allow shell jobs with JOGGER_ALLOW_SHELL. Never build the command from untrusted
+                    input: the shell runs anything in it
+    -q --quiet      start only: print only the job id, for scripts, e.g. id=$(jog start -q -- make)
+    -f --follow     start and output: keep following the job's output until the job is done. If
+                    the stream ends first, e.g. while the server restarts, it's reopened where it
+                    left off, and a pending job's output is waited for. Without it, jog output
+                    stops when the stream ends. With start, the output is streamed as soon as the
+                    job starts, which saves copying the job id. The job id is printed to stderr
+                    first, and the output options, like --exit-code, apply to the output. Ctrl-C
+                    stops following and leaves the job running
+    --attach        start only: start the job and stream its output in one request, so none of
+                    it is missed, even from a job that's done before a separate request could
+                    ask. The job id is printed to stderr first. The output options, like
+                    --exit-code, apply to the output, and with --follow, a stream that ends
+                    before the job is done is reopened. Ctrl-C stops streaming and leaves the
+                    job running. It can't be used with --after
+    --after         start only: start the job once the job with this id or name is done, e.g. to
+                    run tests after a build. The job is pending until then, with no output, and
+                    jog stop cancels it. With --follow, its output is streamed once it starts
+    --if-completed  start only: with --after, only start the job if the --after job completed.
+                    Otherwise it isn't started, and its status is start_failed
+    --name          start only: name the job, e.g. nightly-build, so other commands can use the
+                    name in place of the job id. Names are unique among your running jobs
+    --capture       start only: keep only the job's stdout or stderr, or both, instead of the
+                    server's default. The other stream is discarded. Ignored with --tty
+    --umask         start only: the job's file mode creation mask in octal, e.g. 022, instead of
+                    the server's
+    --nofile        start only: the most files each of the job's processes can have open, its
+                    RLIMIT_NOFILE, e.g. 4096. It can't be more than the server's own limit
+    --nproc         start only: the most processes the job's user can have, its RLIMIT_NPROC.
+                    It counts all of the user's processes, and isn't enforced for root. --nofile
+                    and --nproc are the only resource limits jobs can be started with
+    --redact        start only: mask this secret with *** in the job's output on the server, e.g.
+                    --redact=$API_TOKEN, can be repeated. It's best-effort: a secret the job
+                    transforms, e.g. base64 encodes, isn't masked. Output after the last newline
+                    may be held back briefly, in case it's the start of a secret
+    --redact-regex  start only: like --redact, but masks each match of a regular expression, e.g.
+                    --redact-regex='ghp_[A-Za-z0-9]+'
+    --grace         stop only: give the job this long to exit after the SIGTERM before it's killed,
+                    instead of the server's default, e.g. 30s, 2m
+    --no-progress   start and stop only: don't show a spinner on stderr while waiting for the
+                    server. It's only shown when stderr is a terminal
+    --memory        update only: the most memory the job can use, e.g. 8G. It can't be more than the
+                    server allows, or less than the job is using
+    --cpus          update only: the number of CPUs the job can use, e.g. 1.5
+    --compress      output only: ask the server to gzip the output stream. This saves bandwidth on
+                    slow links at the cost of CPU time on both the server and the client
+    --exit-code     output only: when the output stream ends, exit with a code for the job's final
+                    status: 0 completed, 1 failed, 2 stopped, 3 killed, 4 not done
+    --strip-ansi    output only: remove ANSI escape sequences, like colors, from the output
+    --save          output only: also write the output to a file, the screen still shows text
+    --gzip          output only: gzip the --save file as the output arrives, e.g. --save=out.gz --gzip,
+                    so huge outputs take little disk. The file is flushed every second, so if jog
+                    is killed, it's readable up to the last flush, e.g. with zcat
+    --json          output only: write the output as NDJSON, one {"offset", "data"} object per chunk.
+                    With --save, the file gets NDJSON and the screen gets text
+    --tag-streams   output only: prefix each line of text with O> for stdout or E> for stderr.
+                    Output from servers that combine the streams isn't prefixed
+    --stderr        output only: show only the job's stderr. --max-bytes and --json offsets count
+                    only its bytes. A job with a --tty has no stderr, its terminal is its stdout
+    --max-bytes     output and diff only: stop after size bytes of output, e.g. 64K, to sample a large
+                    job's output. A note is printed to stderr when the output was cut short. diff
+                    compares the first 4M of each job's output by default
+    --reverse       output only: print the lines of text newest first. Nothing is printed until the
+                    job is done, and only the last 64M of output is reversed
+    --raw           output only: write the output to the screen byte for byte, with no formatting.
+                    Use it for binary output, e.g. jog output --raw 123 > archive.tar.gz
+    -n --number     output only: prefix each line of text with its line number, like cat -n, to
+                    refer to lines in bug reports. Lines are numbered before --tag-streams tags
+    --no-summary    output only: don't print the summary of the job, e.g.
+                    --- job 123 completed in 3.2s, exit 0, 14.0 KiB output ---
+                    to stderr when the output ends because the job is done
+    --chunk         output only: how the server splits the output into messages. line sends each
+                    line as soon as it's complete, for low latency logs. size sends the output as
+                    it's available in messages of up to N bytes, e.g. size:16K, the server's
+                    default size if N isn't given. N is at most 64K. Defaults to size
+    --encoding      output only: how the server encodes each message: raw, base64, or hex. jog
+                    decodes them before printing, so the output is the same. Use base64 or hex
+                    when a proxy between jog and the server only passes text. Defaults to raw
     -h --help       print this usage information
 
 EXAMPLES
@@ -237,4 +989,16 @@ EXAMPLES
     > log lines starting from the beginning and steaming until
     this command is terminated or the job moves to a done state.
 
+    # Starting a job and streaming its output in one step, Ctrl-C leaves it running
+    $ jog start -f -- make test
+    > job started: uuid4
+    > make test output...
+
+    # Starting a job once another one completes
+    $ jog start -q --name=build -- make
+    > uuid5
+    $ jog start --after=build --if-completed -- make test
+    > job started: uuid6
+    > status: PENDING
+
 `
@@ -3,6 +3,7 @@ package command
 import (
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestNewCommand(t *testing.T) {
@@ -119,6 +120,650 @@ func TestNewCommand(t *testing.T) {
 				JobID:      "123",
 			},
 		},
+		{
+			name:  "output command -- compress flag",
+			input: "output --compress 123",
+			want: &Command{
+				SubCommand: Output,
+				JobID:      "123",
+				Compress:   true,
+			},
+		},
+		{
+			name:  "output command -- exit code flag",
+			input: "output --exit-code 123",
+			want: &Command{
+				SubCommand: Output,
+				JobID:      "123",
+				ExitCode:   true,
+			},
+		},
+		{
+			name:  "start command -- max output",
+			input: "start --max-output=10M -- echo hello",
+			want: &Command{
+				SubCommand:    Start,
+				RemoteCommand: "echo",
+				RemoteArgs:    []string{"hello"},
+				MaxOutput:     10 * 1024 * 1024,
+			},
+		},
+		{
+			name:  "start command -- process limits",
+			input: "start --umask=027 --nofile=4096 --nproc=512 -- make",
+			want: &Command{
+				SubCommand:    Start,
+				RemoteCommand: "make",
+				Umask:         "027",
+				NoFile:        4096,
+				NProc:         512,
+			},
+		},
+		{
+			name:  "start command -- umask isn't octal",
+			input: "start --umask=089 -- make",
+			want:  nil,
+			err:   true,
+		},
+		{
+			name:  "start command -- umask too large",
+			input: "start --umask=1777 -- make",
+			want:  nil,
+			err:   true,
+		},
+		{
+			name:  "start command -- zero nofile",
+			input: "start --nofile=0 -- make",
+			want:  nil,
+			err:   true,
+		},
+		{
+			name:  "start command -- tty",
+			input: "start --tty -- top",
+			want: &Command{
+				SubCommand:    Start,
+				RemoteCommand: "top",
+				TTY:           true,
+			},
+		},
+		{
+			name:  "start command -- quiet",
+			input: "start -q -- make",
+			want: &Command{
+				SubCommand:    Start,
+				RemoteCommand: "make",
+				Quiet:         true,
+			},
+		},
+		{
+			name:  "start command -- follow",
+			input: "start -f --exit-code -- make test",
+			want: &Command{
+				SubCommand:    Start,
+				RemoteCommand: "make",
+				RemoteArgs:    []string{"test"},
+				Follow:        true,
+				ExitCode:      true,
+			},
+		},
+		{
+			name:  "start command -- attach",
+			input: "start --attach -f --exit-code -- make test",
+			want: &Command{
+				SubCommand:    Start,
+				RemoteCommand: "make",
+				RemoteArgs:    []string{"test"},
+				Attach:        true,
+				Follow:        true,
+				ExitCode:      true,
+			},
+		},
+		{
+			name:  "start command -- attach with after",
+			input: "start --attach --after=build -- make test",
+			want:  nil,
+			err:   true,
+		},
+		{
+			name:  "start command -- after",
+			input: "start --after=build --if-completed -- make test",
+			want: &Command{
+				SubCommand:    Start,
+				RemoteCommand: "make",
+				RemoteArgs:    []string{"test"},
+				After:         "build",
+				IfCompleted:   true,
+			},
+		},
+		{
+			name:  "start command -- redact",
+			input: "start --redact=hunter2 --redact=s3cr3t --redact-regex=ghp_[A-Za-z0-9]+ -- make deploy",
+			want: &Command{
+				SubCommand:    Start,
+				RemoteCommand: "make",
+				RemoteArgs:    []string{"deploy"},
+				Redact:        []string{"hunter2", "s3cr3t"},
+				RedactRegex:   []string{"ghp_[A-Za-z0-9]+"},
+			},
+		},
+		{
+			name:  "start command -- empty redact",
+			input: "start --redact= -- make deploy",
+			want:  nil,
+			err:   true,
+		},
+		{
+			name:  "start command -- invalid redact regex",
+			input: "start --redact-regex=(a+ -- make deploy",
+			want:  nil,
+			err:   true,
+		},
+		{
+			name:  "start command -- redact regex that matches empty output",
+			input: "start --redact-regex=a* -- make deploy",
+			want:  nil,
+			err:   true,
+		},
+		{
+			name:  "start command -- if completed without after",
+			input: "start --if-completed -- make test",
+			want:  nil,
+			err:   true,
+		},
+		{
+			name:  "start command -- after with follow",
+			input: "start --after=build -f -- make test",
+			want: &Command{
+				SubCommand:    Start,
+				RemoteCommand: "make",
+				RemoteArgs:    []string{"test"},
+				After:         "build",
+				Follow:        true,
+			},
+		},
+		{
+			name:  "output command -- follow",
+			input: "output --follow 123",
+			want: &Command{
+				SubCommand: Output,
+				JobID:      "123",
+				Follow:     true,
+			},
+		},
+		{
+			name:  "output command -- follow with max bytes",
+			input: "output -f --max-bytes=64K 123",
+			want:  nil,
+			err:   true,
+		},
+		{
+			name:  "status command -- observer",
+			input: "status --observer 123",
+			want: &Command{
+				SubCommand: Status,
+				JobID:      "123",
+				Observer:   true,
+			},
+		},
+		{
+			name:  "output command -- observer",
+			input: "output --observer --raw 123",
+			want: &Command{
+				SubCommand: Output,
+				JobID:      "123",
+				Observer:   true,
+				Raw:        true,
+			},
+		},
+		{
+			name:  "start command -- observer",
+			input: "start --observer -- make",
+			want:  nil,
+			err:   true,
+		},
+		{
+			name:  "stop command -- observer",
+			input: "stop --observer 123",
+			want:  nil,
+			err:   true,
+		},
+		{
+			name:  "update command -- observer",
+			input: "update --observer --cpus=1 123",
+			want:  nil,
+			err:   true,
+		},
+		{
+			name:  "update command",
+			input: "update --memory=8G --cpus=1.5 123",
+			want: &Command{
+				SubCommand:  Update,
+				JobID:       "123",
+				MemoryLimit: 8 << 30,
+				CPULimit:    1.5,
+			},
+		},
+		{
+			name:  "update command -- no limits",
+			input: "update 123",
+			want:  nil,
+			err:   true,
+		},
+		{
+			name:  "update command -- invalid cpus",
+			input: "update --cpus=NaN 123",
+			want:  nil,
+			err:   true,
+		},
+		{
+			name:  "stop command -- no progress",
+			input: "stop --no-progress 123",
+			want: &Command{
+				SubCommand: Stop,
+				JobID:      "123",
+				NoProgress: true,
+			},
+		},
+		{
+			name:  "start command -- shell",
+			input: "start --shell -- echo $HOME",
+			want: &Command{
+				SubCommand:    Start,
+				RemoteCommand: "echo",
+				RemoteArgs:    []string{"$HOME"},
+				Shell:         true,
+			},
+		},
+		{
+			name:  "start command -- capture",
+			input: "start --capture=stderr -- make",
+			want: &Command{
+				SubCommand:    Start,
+				RemoteCommand: "make",
+				Capture:       "stderr",
+			},
+		},
+		{
+			name:  "start command -- name",
+			input: "start --name=nightly-build -- make",
+			want: &Command{
+				SubCommand:    Start,
+				RemoteCommand: "make",
+				Name:          "nightly-build",
+			},
+		},
+		{
+			name:  "start command -- empty name",
+			input: "start --name= -- make",
+			want:  nil,
+			err:   true,
+		},
+		{
+			name:  "start command -- invalid capture",
+			input: "start --capture=stdin -- make",
+			want:  nil,
+			err:   true,
+		},
+		{
+			name:  "start command -- invalid max output",
+			input: "start --max-output=10X -- echo hello",
+			want:  nil,
+			err:   true,
+		},
+		{
+			name:  "output command -- strip ansi flag",
+			input: "output --strip-ansi 123",
+			want: &Command{
+				SubCommand: Output,
+				JobID:      "123",
+				StripANSI:  true,
+			},
+		},
+		{
+			name:  "output command -- save and json flags",
+			input: "output --save=out.ndjson --json 123",
+			want: &Command{
+				SubCommand: Output,
+				JobID:      "123",
+				SavePath:   "out.ndjson",
+				JSON:       true,
+			},
+		},
+		{
+			name:  "output command -- gzip the save file",
+			input: "output --save=out.gz --gzip 123",
+			want: &Command{
+				SubCommand: Output,
+				JobID:      "123",
+				SavePath:   "out.gz",
+				Gzip:       true,
+			},
+		},
+		{
+			name:  "output command -- gzip without save",
+			input: "output --gzip 123",
+			want:  nil,
+			err:   true,
+		},
+		{
+			name:  "output command -- save without a file",
+			input: "output --save 123",
+			want:  nil,
+			err:   true,
+		},
+		{
+			name:  "output command -- tag streams",
+			input: "output --tag-streams 123",
+			want: &Command{
+				SubCommand: Output,
+				JobID:      "123",
+				TagStreams: true,
+			},
+		},
+		{
+			name:  "output command -- stderr",
+			input: "output --stderr 123",
+			want: &Command{
+				SubCommand: Output,
+				JobID:      "123",
+				Stderr:     true,
+			},
+		},
+		{
+			name:  "output command -- max bytes",
+			input: "output --max-bytes=64K 123",
+			want: &Command{
+				SubCommand: Output,
+				JobID:      "123",
+				MaxBytes:   64 * 1024,
+			},
+		},
+		{
+			name:  "output command -- invalid max bytes",
+			input: "output --max-bytes=lots 123",
+			want:  nil,
+			err:   true,
+		},
+		{
+			name:  "output command -- raw",
+			input: "output --raw 123",
+			want: &Command{
+				SubCommand: Output,
+				JobID:      "123",
+				Raw:        true,
+			},
+		},
+		{
+			name:  "output command -- raw with a json save file",
+			input: "output --raw --json --save=out.ndjson 123",
+			want: &Command{
+				SubCommand: Output,
+				JobID:      "123",
+				Raw:        true,
+				JSON:       true,
+				SavePath:   "out.ndjson",
+			},
+		},
+		{
+			name:  "output command -- raw with strip ansi",
+			input: "output --raw --strip-ansi 123",
+			want:  nil,
+			err:   true,
+		},
+		{
+			name:  "output command -- raw with json on the screen",
+			input: "output --raw --json 123",
+			want:  nil,
+			err:   true,
+		},
+		{
+			name:  "output command -- number",
+			input: "output -n --tag-streams 123",
+			want: &Command{
+				SubCommand: Output,
+				JobID:      "123",
+				Number:     true,
+				TagStreams: true,
+			},
+		},
+		{
+			name:  "output command -- number with raw",
+			input: "output --number --raw 123",
+			want:  nil,
+			err:   true,
+		},
+		{
+			name:  "output command -- number with json on the screen",
+			input: "output --number --json 123",
+			want:  nil,
+			err:   true,
+		},
+		{
+			name:  "output command -- line chunks",
+			input: "output --chunk=line 123",
+			want: &Command{
+				SubCommand: Output,
+				JobID:      "123",
+				Chunking:   "line",
+			},
+		},
+		{
+			name:  "output command -- size chunks",
+			input: "output --chunk=size:16K 123",
+			want: &Command{
+				SubCommand: Output,
+				JobID:      "123",
+				Chunking:   "size",
+				ChunkSize:  16 * 1024,
+			},
+		},
+		{
+			name:  "output command -- invalid chunk policy",
+			input: "output --chunk=word 123",
+			want:  nil,
+			err:   true,
+		},
+		{
+			name:  "output command -- line chunks don't take a size",
+			input: "output --chunk=line:10 123",
+			want:  nil,
+			err:   true,
+		},
+		{
+			name:  "output command -- zero chunk size",
+			input: "output --chunk=size:0 123",
+			want:  nil,
+			err:   true,
+		},
+		{
+			name:  "output command -- base64 encoding",
+			input: "output --encoding=base64 123",
+			want: &Command{
+				SubCommand: Output,
+				JobID:      "123",
+				Encoding:   "base64",
+			},
+		},
+		{
+			name:  "output command -- invalid encoding",
+			input: "output --encoding=utf8 123",
+			want:  nil,
+			err:   true,
+		},
+		{
+			name:  "output command -- reverse",
+			input: "output --reverse --save=out.ndjson --json 123",
+			want: &Command{
+				SubCommand: Output,
+				JobID:      "123",
+				SavePath:   "out.ndjson",
+				JSON:       true,
+				Reverse:    true,
+			},
+		},
+		{
+			name:  "output command -- reverse with json on the screen",
+			input: "output --reverse --json 123",
+			want:  nil,
+			err:   true,
+		},
+		{
+			name:  "output command -- reverse with follow",
+			input: "output --reverse -f 123",
+			want:  nil,
+			err:   true,
+		},
+		{
+			name:  "stop command -- grace flag",
+			input: "stop --grace=30s 123",
+			want: &Command{
+				SubCommand:  Stop,
+				JobID:       "123",
+				GracePeriod: 30 * time.Second,
+			},
+		},
+		{
+			name:  "stop command -- invalid grace period",
+			input: "stop --grace=soon 123",
+			want:  nil,
+			err:   true,
+		},
+		{
+			name:  "stop command -- negative grace period",
+			input: "stop --grace=-1s 123",
+			want:  nil,
+			err:   true,
+		},
+		{
+			name:  "status command -- authority flag",
+			input: "status --host=10.0.0.5:50051 --authority=jogger.example.com 123",
+			want: &Command{
+				SubCommand: Status,
+				Host:       "10.0.0.5:50051",
+				Authority:  "jogger.example.com",
+				JobID:      "123",
+			},
+		},
+		{
+			name:  "status command -- authority with a port",
+			input: "status --authority=jogger.example.com:50051 123",
+			want:  nil,
+			err:   true,
+		},
+		{
+			name:  "status command -- empty authority",
+			input: "status --authority= 123",
+			want:  nil,
+			err:   true,
+		},
+		{
+			name:  "status command -- authority label starts with a dash",
+			input: "status --authority=-jogger.example.com 123",
+			want:  nil,
+			err:   true,
+		},
+		{
+			name:  "events command",
+			input: "events 123",
+			want: &Command{
+				SubCommand: Events,
+				JobID:      "123",
+			},
+		},
+		{
+			name:  "watch command",
+			input: "watch --observer 123",
+			want: &Command{
+				SubCommand: Watch,
+				JobID:      "123",
+				Observer:   true,
+			},
+		},
+		{
+			name:  "list command",
+			input: "list --observer",
+			want: &Command{
+				SubCommand: List,
+				Observer:   true,
+			},
+		},
+		{
+			name:  "list command -- job id provided",
+			input: "list 123",
+			want:  nil,
+			err:   true,
+		},
+		{
+			name:  "diff command",
+			input: "diff --max-bytes=64K 123 456",
+			want: &Command{
+				SubCommand: Diff,
+				JobID:      "123",
+				OtherJobID: "456",
+				MaxBytes:   64 << 10,
+			},
+		},
+		{
+			name:  "diff command -- one job id provided",
+			input: "diff 123",
+			want:  nil,
+			err:   true,
+		},
+		{
+			name:  "watch command -- no job id provided",
+			input: "watch",
+			want:  nil,
+			err:   true,
+		},
+		{
+			name:  "events command -- no job id provided",
+			input: "events",
+			want:  nil,
+			err:   true,
+		},
+		{
+			name:  "exists command",
+			input: "exists 123",
+			want: &Command{
+				SubCommand: Exists,
+				JobID:      "123",
+			},
+		},
+		{
+			name:  "exists command -- no job id provided",
+			input: "exists",
+			want:  nil,
+			err:   true,
+		},
+		{
+			name:  "config command",
+			input: "config --host=localhost",
+			want: &Command{
+				SubCommand: Config,
+				Host:       "localhost",
+			},
+		},
+		{
+			name:  "config command -- unexpected job id",
+			input: "config 123",
+			want:  nil,
+			err:   true,
+		},
+		{
+			name:  "doctor command",
+			input: "doctor --host=localhost --observer",
+			want: &Command{
+				SubCommand: Doctor,
+				Host:       "localhost",
+				Observer:   true,
+			},
+		},
+		{
+			name:  "doctor command -- unexpected job id",
+			input: "doctor 123",
+			want:  nil,
+			err:   true,
+		},
 		{
 			name:  "output command -- no job id provided",
 			input: "output --host=localhost",
@@ -148,6 +793,72 @@ func TestNewCommand(t *testing.T) {
 			if got.SubCommand != tt.want.SubCommand {
 				t.Fatalf("expected subcommand %v, got %v", tt.want.SubCommand, got.SubCommand)
 			}
+			if strings.Join(got.Redact, " ") != strings.Join(tt.want.Redact, " ") || strings.Join(got.RedactRegex, " ") != strings.Join(tt.want.RedactRegex, " ") {
+				t.Fatalf("expected redact %v and %v, got %v and %v", tt.want.Redact, tt.want.RedactRegex, got.Redact, got.RedactRegex)
+			}
+			if got.Compress != tt.want.Compress {
+				t.Fatalf("expected compress %v, got %v", tt.want.Compress, got.Compress)
+			}
+			if got.ExitCode != tt.want.ExitCode {
+				t.Fatalf("expected exit code %v, got %v", tt.want.ExitCode, got.ExitCode)
+			}
+			if got.TTY != tt.want.TTY {
+				t.Fatalf("expected tty %v, got %v", tt.want.TTY, got.TTY)
+			}
+			if got.StripANSI != tt.want.StripANSI {
+				t.Fatalf("expected strip ansi %v, got %v", tt.want.StripANSI, got.StripANSI)
+			}
+			if got.SavePath != tt.want.SavePath {
+				t.Fatalf("expected save path %q, got %q", tt.want.SavePath, got.SavePath)
+			}
+			if got.TagStreams != tt.want.TagStreams {
+				t.Fatalf("expected tag streams %v, got %v", tt.want.TagStreams, got.TagStreams)
+			}
+			if got.Stderr != tt.want.Stderr {
+				t.Fatalf("expected stderr %v, got %v", tt.want.Stderr, got.Stderr)
+			}
+			if got.Name != tt.want.Name {
+				t.Fatalf("expected name %q, got %q", tt.want.Name, got.Name)
+			}
+			if got.Capture != tt.want.Capture {
+				t.Fatalf("expected capture %q, got %q", tt.want.Capture, got.Capture)
+			}
+			if got.Reverse != tt.want.Reverse {
+				t.Fatalf("expected reverse %v, got %v", tt.want.Reverse, got.Reverse)
+			}
+			if got.JSON != tt.want.JSON {
+				t.Fatalf("expected json %v, got %v", tt.want.JSON, got.JSON)
+			}
+			if got.Authority != tt.want.Authority {
+				t.Fatalf("expected authority %q, got %q", tt.want.Authority, got.Authority)
+			}
+			if got.GracePeriod != tt.want.GracePeriod {
+				t.Fatalf("expected grace period %v, got %v", tt.want.GracePeriod, got.GracePeriod)
+			}
+			if got.MaxBytes != tt.want.MaxBytes {
+				t.Fatalf("expected max bytes %d, got %d", tt.want.MaxBytes, got.MaxBytes)
+			}
+			if got.MaxOutput != tt.want.MaxOutput {
+				t.Fatalf("expected max output %d, got %d", tt.want.MaxOutput, got.MaxOutput)
+			}
+			if got.Follow != tt.want.Follow {
+				t.Fatalf("expected follow %v, got %v", tt.want.Follow, got.Follow)
+			}
+			if got.Attach != tt.want.Attach {
+				t.Fatalf("expected attach %v, got %v", tt.want.Attach, got.Attach)
+			}
+			if got.After != tt.want.After || got.IfCompleted != tt.want.IfCompleted {
+				t.Fatalf("expected after %q, if completed %v, got %q, %v", tt.want.After, tt.want.IfCompleted, got.After, got.IfCompleted)
+			}
+			if got.Gzip != tt.want.Gzip {
+				t.Fatalf("expected gzip %v, got %v", tt.want.Gzip, got.Gzip)
+			}
+			if got.OtherJobID != tt.want.OtherJobID {
+				t.Fatalf("expected other job id %q, got %q", tt.want.OtherJobID, got.OtherJobID)
+			}
+			if got.Umask != tt.want.Umask || got.NoFile != tt.want.NoFile || got.NProc != tt.want.NProc {
+				t.Fatalf("expected umask %q, nofile %d, nproc %d, got %q, %d, %d", tt.want.Umask, tt.want.NoFile, tt.want.NProc, got.Umask, got.NoFile, got.NProc)
+			}
 		})
 	}
 }
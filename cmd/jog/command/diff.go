@@ -0,0 +1,278 @@
+package command
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	jogv1 "github.com/dustinevan/jogger/pkg/gen/jogger/v1"
+)
+
+const (
+	// defaultDiffMaxBytes is the most output of each job diff compares when --max-bytes isn't given
+	defaultDiffMaxBytes = 4 << 20
+	// diffContext is the number of unchanged lines around each change, like diff -u
+	diffContext = 3
+	// maxDiffEdits is the most edits the line diff looks for before it gives up and shows the
+	// changed lines as one replacement, which bounds its time and memory
+	maxDiffEdits = 4096
+)
+
+// runDiff prints a unified diff of two jobs' output, line by line. It exits with 1 when the
+// output differs, like diff(1).
+func runDiff(ctx context.Context, client jogv1.JobServiceClient, cmd *Command, stdout, stderr io.Writer) error {
+	maxBytes := cmd.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = defaultDiffMaxBytes
+	}
+	a, err := fetchOutput(ctx, client, cmd.JobID, maxBytes, stderr)
+	if err != nil {
+		return err
+	}
+	b, err := fetchOutput(ctx, client, cmd.OtherJobID, maxBytes, stderr)
+	if err != nil {
+		return err
+	}
+	differ, err := writeUnifiedDiff(stdout, "job "+cmd.JobID, "job "+cmd.OtherJobID, a, b)
+	if err != nil {
+		return fmt.Errorf("writing diff: %w", err)
+	}
+	if differ {
+		return &ExitError{msg: "job output differs", code: 1}
+	}
+	return nil
+}
+
+// fetchOutput returns the job's output so far, up to maxBytes of it. A running job's output
+// is cut off where it was when its status was read, so the output doesn't change under the
+// diff. A note is printed to stderr when the output is longer than maxBytes.
+func fetchOutput(ctx context.Context, client jogv1.JobServiceClient, jobID string, maxBytes int64, stderr io.Writer) ([]byte, error) {
+	status, err := client.Status(ctx, &jogv1.StatusRequest{JobId: jobID})
+	if err != nil {
+		return nil, fmt.Errorf("getting job %s status: %w", jobID, err)
+	}
+	n := status.OutputBytes
+	if n == 0 {
+		// a max_bytes of 0 would stream the output without a cap
+		return nil, nil
+	}
+	if n > maxBytes {
+		fmt.Fprintf(stderr, "jog: comparing only the first %s of job %s's %s of output, use --max-bytes to compare more\n",
+			formatBytes(maxBytes), jobID, formatBytes(n))
+		n = maxBytes
+	}
+	stream, err := client.Output(ctx, &jogv1.OutputRequest{JobId: jobID, MaxBytes: n})
+	if err != nil {
+		return nil, fmt.Errorf("getting job %s output: %w", jobID, err)
+	}
+	var out []byte
+	for {
+		resp, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return out, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("receiving job %s output: %w", jobID, err)
+		}
+		out = append(out, resp.Data.Data...)
+	}
+}
+
+// diffOp is a line of a diff: kept, ' ', removed from a, '-', or added from b, '+'
+type diffOp struct {
+	kind byte
+	line string
+}
+
+// splitLines splits data into lines that keep their newlines, so a last line without one
+// differs from the same line with one
+func splitLines(data []byte) []string {
+	var lines []string
+	for len(data) > 0 {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			i = len(data) - 1
+		}
+		lines = append(lines, string(data[:i+1]))
+		data = data[i+1:]
+	}
+	return lines
+}
+
+// diffLines returns the edits that turn the lines of a into the lines of b. The lines a and b
+// start and end with are kept without searching, most of the output of two runs of a job
+// usually is.
+func diffLines(a, b []string) []diffOp {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	ops := make([]diffOp, 0, len(a)+len(b))
+	for _, l := range a[:prefix] {
+		ops = append(ops, diffOp{' ', l})
+	}
+	ops = append(ops, myersDiff(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, l := range a[len(a)-suffix:] {
+		ops = append(ops, diffOp{' ', l})
+	}
+	return ops
+}
+
+// myersDiff finds the fewest edits that turn a into b with Myers' O(ND) algorithm. When more
+// than maxDiffEdits are needed, every line of a is removed and every line of b is added.
+func myersDiff(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+	maxD := min(n+m, maxDiffEdits)
+	// v[off+k] is the furthest x reached on diagonal k = x - y
+	off := maxD + 1
+	v := make([]int, 2*off+1)
+	// trace[d] holds v[off-d:off+d+1] after d edits, to walk the path back
+	var trace [][]int
+	for d := 0; d <= maxD; d++ {
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || k != d && v[off+k-1] < v[off+k+1] {
+				x = v[off+k+1]
+			} else {
+				x = v[off+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[off+k] = x
+			if x >= n && y >= m {
+				trace = append(trace, slices.Clone(v[off-d:off+d+1]))
+				return backtrackDiff(a, b, trace)
+			}
+		}
+		trace = append(trace, slices.Clone(v[off-d:off+d+1]))
+	}
+
+	ops := make([]diffOp, 0, n+m)
+	for _, l := range a {
+		ops = append(ops, diffOp{'-', l})
+	}
+	for _, l := range b {
+		ops = append(ops, diffOp{'+', l})
+	}
+	return ops
+}
+
+// backtrackDiff walks the path myersDiff found from the end of a and b back to the start
+func backtrackDiff(a, b []string, trace [][]int) []diffOp {
+	var ops []diffOp
+	x, y := len(a), len(b)
+	for d := len(trace) - 1; d > 0; d-- {
+		prev := trace[d-1]
+		at := func(k int) int { return prev[k+d-1] }
+		k := x - y
+		prevK := k - 1
+		if k == -d || k != d && at(k-1) < at(k+1) {
+			prevK = k + 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			ops = append(ops, diffOp{' ', a[x-1]})
+			x--
+			y--
+		}
+		if x == prevX {
+			ops = append(ops, diffOp{'+', b[y-1]})
+			y--
+		} else {
+			ops = append(ops, diffOp{'-', a[x-1]})
+			x--
+		}
+	}
+	for x > 0 && y > 0 {
+		ops = append(ops, diffOp{' ', a[x-1]})
+		x--
+		y--
+	}
+	slices.Reverse(ops)
+	return ops
+}
+
+// writeUnifiedDiff writes the diff of a and b in the unified format, e.g.
+//
+//	--- job 123
+//	+++ job 456
+//	@@ -1,3 +1,3 @@
+//	 building
+//	-test failed
+//	+test passed
+//	 done
+//
+// Nothing is written when a and b are the same. It returns whether they differ.
+func writeUnifiedDiff(w io.Writer, aName, bName string, a, b []byte) (bool, error) {
+	if bytes.Equal(a, b) {
+		return false, nil
+	}
+	ops := diffLines(splitLines(a), splitLines(b))
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "--- %s\n+++ %s\n", aName, bName)
+	// aLine and bLine count the lines of a and b before each op
+	aLine, bLine := make([]int, len(ops)+1), make([]int, len(ops)+1)
+	for i, op := range ops {
+		aLine[i+1], bLine[i+1] = aLine[i], bLine[i]
+		if op.kind != '+' {
+			aLine[i+1]++
+		}
+		if op.kind != '-' {
+			bLine[i+1]++
+		}
+	}
+	writeHunk := func(start, end int) {
+		fmt.Fprintf(bw, "@@ -%s +%s @@\n", hunkRange(aLine[start], aLine[end]), hunkRange(bLine[start], bLine[end]))
+		for _, op := range ops[start:end] {
+			bw.WriteByte(op.kind)
+			bw.WriteString(op.line)
+			if len(op.line) == 0 || op.line[len(op.line)-1] != '\n' {
+				bw.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+	}
+	// changes closer together than twice the context share a hunk
+	start, end := -1, -1
+	for i, op := range ops {
+		if op.kind == ' ' {
+			continue
+		}
+		if start >= 0 && i-diffContext > end {
+			writeHunk(start, end)
+			start = -1
+		}
+		if start < 0 {
+			start = max(i-diffContext, 0)
+		}
+		end = min(i+1+diffContext, len(ops))
+	}
+	writeHunk(start, end)
+	return true, bw.Flush()
+}
+
+// hunkRange formats the lines from, exclusive, to, inclusive, as a hunk header range: the
+// first line and the number of lines, which is left out when it's 1. An empty range starts
+// at the line before it.
+func hunkRange(from, to int) string {
+	switch to - from {
+	case 0:
+		return fmt.Sprintf("%d,0", from)
+	case 1:
+		return fmt.Sprintf("%d", from+1)
+	default:
+		return fmt.Sprintf("%d,%d", from+1, to-from)
+	}
+}
@@ -0,0 +1,179 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestWriteUnifiedDiff(t *testing.T) {
+	t.Parallel()
+
+	var tenLines strings.Builder
+	for i := 1; i <= 10; i++ {
+		fmt.Fprintf(&tenLines, "%d\n", i)
+	}
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{name: "same output", a: "building\ndone\n", b: "building\ndone\n", want: ""},
+		{
+			name: "removed and added lines",
+			a:    "building\nstep 1\ntest failed\nstep 3\ndone\n",
+			b:    "building\nstep 1\ntest passed\nretrying\nstep 3\ndone\n",
+			want: "--- job 123\n+++ job 456\n" +
+				"@@ -1,5 +1,6 @@\n" +
+				" building\n" +
+				" step 1\n" +
+				"-test failed\n" +
+				"+test passed\n" +
+				"+retrying\n" +
+				" step 3\n" +
+				" done\n",
+		},
+		{
+			name: "changes far apart are separate hunks",
+			a:    tenLines.String(),
+			b:    "0\n" + strings.TrimSuffix(tenLines.String(), "10\n") + "x\n",
+			want: "--- job 123\n+++ job 456\n" +
+				"@@ -1,3 +1,4 @@\n" +
+				"+0\n" +
+				" 1\n" +
+				" 2\n" +
+				" 3\n" +
+				"@@ -7,4 +8,4 @@\n" +
+				" 7\n" +
+				" 8\n" +
+				" 9\n" +
+				"-10\n" +
+				"+x\n",
+		},
+		{
+			name: "no newline at the end",
+			a:    "done\n",
+			b:    "done",
+			want: "--- job 123\n+++ job 456\n" +
+				"@@ -1 +1 @@\n" +
+				"-done\n" +
+				"+done\n" +
+				"\\ No newline at end of file\n",
+		},
+		{
+			name: "empty output",
+			a:    "",
+			b:    "done\n",
+			want: "--- job 123\n+++ job 456\n" +
+				"@@ -0,0 +1 @@\n" +
+				"+done\n",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var out bytes.Buffer
+			differ, err := writeUnifiedDiff(&out, "job 123", "job 456", []byte(tt.a), []byte(tt.b))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if differ != (tt.a != tt.b) {
+				t.Fatalf("expected differ %v, got %v", tt.a != tt.b, differ)
+			}
+			if out.String() != tt.want {
+				t.Fatalf("expected:\n%s\ngot:\n%s", tt.want, out.String())
+			}
+		})
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	t.Parallel()
+
+	a := splitLines([]byte("a\nb\nc\na\nb\nb\na\n"))
+	b := splitLines([]byte("c\nb\na\nb\na\nc\n"))
+	ops := diffLines(a, b)
+
+	// the edits turn a into b, and there are as few as Myers' paper finds for this example
+	var gotA, gotB []string
+	edits := 0
+	for _, op := range ops {
+		if op.kind != '+' {
+			gotA = append(gotA, op.line)
+		}
+		if op.kind != '-' {
+			gotB = append(gotB, op.line)
+		}
+		if op.kind != ' ' {
+			edits++
+		}
+	}
+	if strings.Join(gotA, "") != strings.Join(a, "") || strings.Join(gotB, "") != strings.Join(b, "") {
+		t.Fatalf("expected the edits to turn %q into %q, got %v", a, b, ops)
+	}
+	if edits != 5 {
+		t.Fatalf("expected 5 edits, got %d: %v", edits, ops)
+	}
+}
+
+func TestRunDiff(t *testing.T) {
+	t.Parallel()
+
+	server := &fakeJobServer{outputs: map[string][][]byte{
+		"123": {[]byte("building\ntest "), []byte("failed\ndone\n")},
+		"456": {[]byte("building\ntest passed\ndone\n")},
+		"789": {[]byte("building\ntest passed\ndone\n")},
+	}}
+	client := newTestClient(t, server)
+
+	var stdout, stderr bytes.Buffer
+	err := Run(context.Background(), client, &Command{SubCommand: Diff, JobID: "123", OtherJobID: "456"}, &stdout, &stderr)
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code() != 1 {
+		t.Fatalf("expected exit code 1 for output that differs, got %v", err)
+	}
+	want := "--- job 123\n+++ job 456\n" +
+		"@@ -1,3 +1,3 @@\n" +
+		" building\n" +
+		"-test failed\n" +
+		"+test passed\n" +
+		" done\n"
+	if stdout.String() != want {
+		t.Fatalf("expected:\n%s\ngot:\n%s", want, stdout.String())
+	}
+	if stderr.Len() != 0 {
+		t.Fatalf("expected no notes, got %q", stderr.String())
+	}
+
+	stdout.Reset()
+	if err := Run(context.Background(), client, &Command{SubCommand: Diff, JobID: "456", OtherJobID: "789"}, &stdout, &stderr); err != nil {
+		t.Fatalf("expected no error for the same output, got %v", err)
+	}
+	if stdout.Len() != 0 {
+		t.Fatalf("expected no diff, got %q", stdout.String())
+	}
+}
+
+func TestRunDiffMaxBytes(t *testing.T) {
+	t.Parallel()
+
+	server := &fakeJobServer{output: [][]byte{[]byte("hello\nworld\n")}}
+	client := newTestClient(t, server)
+
+	var stderr bytes.Buffer
+	cmd := &Command{SubCommand: Diff, JobID: "123", OtherJobID: "456", MaxBytes: 6}
+	if err := Run(context.Background(), client, cmd, &bytes.Buffer{}, &stderr); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := server.outputReq.GetMaxBytes(); got != 6 {
+		t.Fatalf("expected max bytes 6 in the request, got %d", got)
+	}
+	if !strings.Contains(stderr.String(), "comparing only the first 6 B of job 123's 12 B of output") {
+		t.Fatalf("expected a note about the cap, got %q", stderr.String())
+	}
+}
@@ -0,0 +1,268 @@
+package command
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+// doctorTimeout bounds each of the network checks
+const doctorTimeout = 5 * time.Second
+
+// Check is the result of one of the checks jog doctor runs
+type Check struct {
+	Name string
+	// Err is why the check failed, nil when it passed or was skipped
+	Err error
+	// Skipped is set when the check can't run, usually because a check it depends on failed
+	Skipped bool
+	// Hint says how to fix the problem when the check failed
+	Hint string
+}
+
+// Diagnose runs the checks needed to connect to the server in order: the environment, the
+// credential files, dialing the host, and the TLS handshake. Checks that depend on one that
+// failed are skipped. now is the time the certificates must be valid at.
+func Diagnose(ctx context.Context, cfg ClientConfig, now time.Time) []Check {
+	var checks []Check
+	pass := func(name string) { checks = append(checks, Check{Name: name}) }
+	fail := func(name string, err error, hint string) {
+		checks = append(checks, Check{Name: name, Err: err, Hint: hint})
+	}
+	skip := func(names ...string) {
+		for _, name := range names {
+			checks = append(checks, Check{Name: name, Skipped: true})
+		}
+	}
+
+	if err := checkEnv(cfg); err != nil {
+		fail("environment variables are set", err, "set them, or run jog config to see where each value comes from. jog --help describes them")
+	} else {
+		pass("environment variables are set")
+	}
+
+	readable := true
+	for _, s := range []Setting{cfg.CACertFile, cfg.UserCertFile, cfg.UserKeyFile} {
+		name := s.Name + " is readable"
+		if s.Source == SourceUnset {
+			skip(name)
+			readable = false
+			continue
+		}
+		if err := checkReadable(s.Value); err != nil {
+			fail(name, err, fmt.Sprintf("check that %s is the absolute path to the file, and that you can read it", s.Name))
+			readable = false
+			continue
+		}
+		pass(name)
+	}
+	if !readable {
+		skip("ca cert parses", "user cert is valid", "host is reachable", "tls handshake succeeds")
+		return checks
+	}
+
+	certPool, err := checkCACert(cfg.CACertFile.Value, now)
+	if err != nil {
+		fail("ca cert parses", err, fmt.Sprintf("%s must be the PEM encoded CA certificate, e.g. certs/ca_tls.crt. Ask your administrator for a new one if it expired", cfg.CACertFile.Name))
+	} else {
+		pass("ca cert parses")
+	}
+	userCert, err := checkUserCert(cfg.UserCertFile.Value, cfg.UserKeyFile.Value, now)
+	if err != nil {
+		fail("user cert is valid", err, "ask your administrator for a new certificate and key, the key must be the one the certificate was issued for")
+	} else {
+		pass("user cert is valid")
+	}
+
+	if cfg.Host.Value == "" {
+		skip("host is reachable", "tls handshake succeeds")
+		return checks
+	}
+	conn, err := checkDial(ctx, cfg)
+	switch {
+	case errors.Is(err, errNotDialable):
+		checks = append(checks, Check{Name: "host is reachable", Err: err, Skipped: true})
+		skip("tls handshake succeeds")
+		return checks
+	case err != nil:
+		fail("host is reachable", err, "check the host and port, and that the server is running. Firewalls and proxies, see HTTPS_PROXY in jog --help, can block it too")
+		skip("tls handshake succeeds")
+		return checks
+	}
+	defer conn.Close()
+	pass("host is reachable")
+
+	if certPool == nil || userCert == nil {
+		skip("tls handshake succeeds")
+		return checks
+	}
+	if err := checkHandshake(ctx, conn, cfg.tlsConfig(*userCert, certPool)); err != nil {
+		hint := "check that the server's certificate was issued by the CA for the host name"
+		if cfg.Authority.Value == "" {
+			hint += ", or use --authority when the server is dialed by another name, e.g. an IP address"
+		}
+		fail("tls handshake succeeds", err, hint)
+	} else {
+		pass("tls handshake succeeds")
+	}
+	return checks
+}
+
+// checkEnv checks that the credentials and the host are set
+func checkEnv(cfg ClientConfig) error {
+	missing := cfg.MissingCredentials()
+	if cfg.Host.Value == "" {
+		missing = append(missing, cfg.Host.Name)
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("not set: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// checkReadable checks that the file at path can be read
+func checkReadable(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := f.Read(make([]byte, 1)); err != nil {
+		if errors.Is(err, io.EOF) {
+			return fmt.Errorf("%s is empty", path)
+		}
+		return err
+	}
+	return nil
+}
+
+// checkCACert returns a pool with the CA certificates in the PEM file at path. There must be
+// at least one, and they must all parse and be valid at now.
+func checkCACert(path string, now time.Time) (*x509.CertPool, error) {
+	rest, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading ca cert file: %w", err)
+	}
+	certPool := x509.NewCertPool()
+	found := false
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("parsing ca cert: %w", err)
+		}
+		if err := checkValidity("ca cert", cert, now); err != nil {
+			return nil, err
+		}
+		certPool.AddCert(cert)
+		found = true
+	}
+	if !found {
+		return nil, fmt.Errorf("no PEM encoded certificates in %s", path)
+	}
+	return certPool, nil
+}
+
+// checkValidity checks that cert is valid at now
+func checkValidity(name string, cert *x509.Certificate, now time.Time) error {
+	if now.After(cert.NotAfter) {
+		return fmt.Errorf("%s expired at %s", name, cert.NotAfter.Format(time.RFC3339))
+	}
+	if now.Before(cert.NotBefore) {
+		return fmt.Errorf("%s isn't valid until %s", name, cert.NotBefore.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// checkUserCert loads the user key pair, and checks that the certificate is valid at now
+func checkUserCert(certFile, keyFile string, now time.Time) (*tls.Certificate, error) {
+	userCert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("loading user key pair: %w", err)
+	}
+	leaf, err := x509.ParseCertificate(userCert.Certificate[0])
+	if err != nil {
+		return nil, fmt.Errorf("parsing user cert: %w", err)
+	}
+	if err := checkValidity("user cert", leaf, now); err != nil {
+		return nil, err
+	}
+	return &userCert, nil
+}
+
+// errNotDialable is returned by checkDial for hosts given as gRPC targets that aren't a
+// network address, e.g. unix:///run/jogger.sock
+var errNotDialable = errors.New("only host[:port] and dns:/// hosts can be checked")
+
+// checkDial opens a TCP connection to the host, through the proxy when one is used for it
+func checkDial(ctx context.Context, cfg ClientConfig) (net.Conn, error) {
+	host := strings.TrimPrefix(cfg.Host.Value, "dns:///")
+	if strings.Contains(host, ":///") || strings.HasPrefix(host, "unix:") {
+		return nil, errNotDialable
+	}
+	addr := hostPort(host)
+	ctx, cancel := context.WithTimeout(ctx, doctorTimeout)
+	defer cancel()
+
+	proxyURL, err := cfg.ProxyURL()
+	if err != nil {
+		return nil, err
+	}
+	if proxyURL != nil {
+		return proxyDialer(proxyURL)(ctx, addr)
+	}
+	var d net.Dialer
+	return d.DialContext(ctx, "tcp", addr)
+}
+
+// checkHandshake runs a TLS handshake with the server on conn. The server verifies the
+// user certificate after the handshake with TLS 1.3, so a rejected certificate doesn't
+// fail this check.
+func checkHandshake(ctx context.Context, conn net.Conn, tlsConfig *tls.Config) error {
+	ctx, cancel := context.WithTimeout(ctx, doctorTimeout)
+	defer cancel()
+	tlsConfig.NextProtos = []string{"h2"}
+	return tls.Client(conn, tlsConfig).HandshakeContext(ctx)
+}
+
+// RunDoctor runs the checks in Diagnose and prints whether each passed, with a hint for the
+// ones that failed. An ExitError is returned when any check failed.
+func RunDoctor(ctx context.Context, cfg ClientConfig, w io.Writer) error {
+	checks := Diagnose(ctx, cfg, time.Now())
+	failed := 0
+	for _, c := range checks {
+		switch {
+		case c.Skipped:
+			fmt.Fprintf(w, "[skip] %s", c.Name)
+			if c.Err != nil {
+				fmt.Fprintf(w, ": %s", c.Err)
+			}
+			fmt.Fprintln(w)
+		case c.Err != nil:
+			failed++
+			fmt.Fprintf(w, "[FAIL] %s: %s\n", c.Name, c.Err)
+			fmt.Fprintf(w, "       %s\n", c.Hint)
+		default:
+			fmt.Fprintf(w, "[ ok ] %s\n", c.Name)
+		}
+	}
+	if failed > 0 {
+		return &ExitError{msg: fmt.Sprintf("%d of %d checks failed", failed, len(checks)), code: 1}
+	}
+	return nil
+}
@@ -0,0 +1,296 @@
+package command
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testCA is a certificate authority that issues certificates for the doctor tests
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T, notAfter time.Time) *testCA {
+	t.Helper()
+	cert, key := issueCert(t, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              notAfter,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, nil, nil)
+	return &testCA{cert: cert, key: key}
+}
+
+// issue returns a certificate signed by the CA, for a client or a server with the DNS name
+func (ca *testCA) issue(t *testing.T, cn string, notAfter time.Time, usage x509.ExtKeyUsage) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	return issueCert(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: cn},
+		DNSNames:    []string{cn},
+		NotBefore:   time.Now().Add(-time.Hour),
+		NotAfter:    notAfter,
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{usage},
+	}, ca.cert, ca.key)
+}
+
+// issueCert creates the certificate from template, self-signed when parent is nil
+func issueCert(t *testing.T, template, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	if err != nil {
+		t.Fatalf("generating serial: %v", err)
+	}
+	template.SerialNumber = serial
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatalf("creating certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("parsing certificate: %v", err)
+	}
+	return cert, key
+}
+
+// writePEM writes the certificate, or the key when cert is nil, to a file in dir
+func writePEM(t *testing.T, dir, name string, cert *x509.Certificate, key *ecdsa.PrivateKey) string {
+	t.Helper()
+	block := &pem.Block{Type: "CERTIFICATE"}
+	if cert != nil {
+		block.Bytes = cert.Raw
+	} else {
+		der, err := x509.MarshalECPrivateKey(key)
+		if err != nil {
+			t.Fatalf("marshaling key: %v", err)
+		}
+		block = &pem.Block{Type: "EC PRIVATE KEY", Bytes: der}
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(block), 0o600); err != nil {
+		t.Fatalf("writing %s: %v", name, err)
+	}
+	return path
+}
+
+// newTLSServer listens for TLS connections with a certificate for jogger.test issued by ca,
+// and requires client certificates issued by ca. It returns the address it listens on.
+func newTLSServer(t *testing.T, ca *testCA) string {
+	t.Helper()
+	cert, key := ca.issue(t, "jogger.test", time.Now().Add(time.Hour), x509.ExtKeyUsageServerAuth)
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	cfg := &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{cert.Raw}, PrivateKey: key}},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		NextProtos:   []string{"h2"},
+	}
+	lis, err := tls.Listen("tcp", "127.0.0.1:0", cfg)
+	if err != nil {
+		t.Fatalf("listening: %v", err)
+	}
+	t.Cleanup(func() { lis.Close() })
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			conn.(*tls.Conn).Handshake()
+			conn.Close()
+		}
+	}()
+	return lis.Addr().String()
+}
+
+// closedAddr returns an address nothing listens on
+func closedAddr(t *testing.T) string {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listening: %v", err)
+	}
+	addr := lis.Addr().String()
+	lis.Close()
+	return addr
+}
+
+func TestDiagnose(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	valid := time.Now().Add(time.Hour)
+	ca := newTestCA(t, valid)
+	caFile := writePEM(t, dir, "ca.crt", ca.cert, nil)
+	userCert, userKey := ca.issue(t, "user1", valid, x509.ExtKeyUsageClientAuth)
+	userCertFile := writePEM(t, dir, "user1.crt", userCert, nil)
+	userKeyFile := writePEM(t, dir, "user1.key", nil, userKey)
+
+	expiredCert, expiredKey := ca.issue(t, "user1", time.Now().Add(-time.Minute), x509.ExtKeyUsageClientAuth)
+	expiredCertFile := writePEM(t, dir, "expired.crt", expiredCert, nil)
+	expiredKeyFile := writePEM(t, dir, "expired.key", nil, expiredKey)
+	expiredCA := newTestCA(t, time.Now().Add(-time.Minute))
+	expiredCAFile := writePEM(t, dir, "expired_ca.crt", expiredCA.cert, nil)
+	otherCAFile := writePEM(t, dir, "other_ca.crt", newTestCA(t, valid).cert, nil)
+	notPEMFile := filepath.Join(dir, "not_pem.crt")
+	if err := os.WriteFile(notPEMFile, []byte("not a certificate\n"), 0o600); err != nil {
+		t.Fatalf("writing not_pem.crt: %v", err)
+	}
+
+	serverAddr := newTLSServer(t, ca)
+	env := func(overrides map[string]string) map[string]string {
+		env := map[string]string{
+			"JOGGER_HOST":           serverAddr,
+			"JOGGER_AUTHORITY":      "jogger.test",
+			"JOGGER_CA_CERT_FILE":   caFile,
+			"JOGGER_USER_CERT_FILE": userCertFile,
+			"JOGGER_USER_KEY_FILE":  userKeyFile,
+		}
+		for k, v := range overrides {
+			env[k] = v
+		}
+		return env
+	}
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		// want is the state of each check: ok, fail, or skip
+		want []string
+		// wantErr is part of the error of the check that failed
+		wantErr string
+	}{
+		{
+			name: "all checks pass",
+			env:  env(nil),
+			want: []string{"ok", "ok", "ok", "ok", "ok", "ok", "ok", "ok"},
+		},
+		{
+			name:    "missing environment variables",
+			env:     env(map[string]string{"JOGGER_USER_KEY_FILE": "", "JOGGER_HOST": ""}),
+			want:    []string{"fail", "ok", "ok", "skip", "skip", "skip", "skip", "skip"},
+			wantErr: "JOGGER_USER_KEY_FILE, JOGGER_HOST",
+		},
+		{
+			name:    "missing file",
+			env:     env(map[string]string{"JOGGER_CA_CERT_FILE": filepath.Join(dir, "missing.crt")}),
+			want:    []string{"ok", "fail", "ok", "ok", "skip", "skip", "skip", "skip"},
+			wantErr: "no such file",
+		},
+		{
+			name:    "ca cert isn't PEM",
+			env:     env(map[string]string{"JOGGER_CA_CERT_FILE": notPEMFile}),
+			want:    []string{"ok", "ok", "ok", "ok", "fail", "ok", "ok", "skip"},
+			wantErr: "no PEM encoded certificates",
+		},
+		{
+			name:    "expired ca cert",
+			env:     env(map[string]string{"JOGGER_CA_CERT_FILE": expiredCAFile}),
+			want:    []string{"ok", "ok", "ok", "ok", "fail", "ok", "ok", "skip"},
+			wantErr: "ca cert expired",
+		},
+		{
+			name:    "expired user cert",
+			env:     env(map[string]string{"JOGGER_USER_CERT_FILE": expiredCertFile, "JOGGER_USER_KEY_FILE": expiredKeyFile}),
+			want:    []string{"ok", "ok", "ok", "ok", "ok", "fail", "ok", "skip"},
+			wantErr: "user cert expired",
+		},
+		{
+			name:    "key doesn't match the cert",
+			env:     env(map[string]string{"JOGGER_USER_KEY_FILE": expiredKeyFile}),
+			want:    []string{"ok", "ok", "ok", "ok", "ok", "fail", "ok", "skip"},
+			wantErr: "loading user key pair",
+		},
+		{
+			name:    "host is unreachable",
+			env:     env(map[string]string{"JOGGER_HOST": closedAddr(t)}),
+			want:    []string{"ok", "ok", "ok", "ok", "ok", "ok", "fail", "skip"},
+			wantErr: "refused",
+		},
+		{
+			name:    "server cert isn't from the ca",
+			env:     env(map[string]string{"JOGGER_CA_CERT_FILE": otherCAFile}),
+			want:    []string{"ok", "ok", "ok", "ok", "ok", "ok", "ok", "fail"},
+			wantErr: "certificate",
+		},
+		{
+			name:    "server cert isn't for the host name",
+			env:     env(map[string]string{"JOGGER_AUTHORITY": "other.test"}),
+			want:    []string{"ok", "ok", "ok", "ok", "ok", "ok", "ok", "fail"},
+			wantErr: "other.test",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := ResolveConfig(&Command{SubCommand: Doctor}, func(k string) string { return tt.env[k] })
+			checks := Diagnose(context.Background(), cfg, time.Now())
+			var got []string
+			var gotErr error
+			for _, c := range checks {
+				switch {
+				case c.Skipped:
+					got = append(got, "skip")
+				case c.Err != nil:
+					got = append(got, "fail")
+					gotErr = c.Err
+					if c.Hint == "" {
+						t.Fatalf("expected a hint for %q", c.Name)
+					}
+				default:
+					got = append(got, "ok")
+				}
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Fatalf("expected checks %v, got %v: %+v", tt.want, got, checks)
+			}
+			if tt.wantErr != "" && (gotErr == nil || !strings.Contains(gotErr.Error(), tt.wantErr)) {
+				t.Fatalf("expected an error containing %q, got %v", tt.wantErr, gotErr)
+			}
+		})
+	}
+}
+
+func TestRunDoctor(t *testing.T) {
+	t.Parallel()
+
+	var out strings.Builder
+	cfg := ResolveConfig(&Command{SubCommand: Doctor}, func(string) string { return "" })
+	err := RunDoctor(context.Background(), cfg, &out)
+	exitErr, ok := err.(*ExitError)
+	if !ok || exitErr.Code() != 1 {
+		t.Fatalf("expected an exit error with code 1, got %v", err)
+	}
+	if !strings.HasPrefix(out.String(), "[FAIL] environment variables are set: not set: ") {
+		t.Fatalf("expected the environment check to fail first, got:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "[skip] tls handshake succeeds\n") {
+		t.Fatalf("expected the handshake to be skipped, got:\n%s", out.String())
+	}
+}
@@ -0,0 +1,281 @@
+package command
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	jogv1 "github.com/dustinevan/jogger/pkg/gen/jogger/v1"
+)
+
+// outputFormatter renders chunks of job output to a writer in some format. Several
+// formatters can render the same chunks, e.g. text to the screen and NDJSON to a file.
+// stream is the output stream the chunk is from, STREAM_UNSPECIFIED if the server
+// combines them.
+type outputFormatter interface {
+	writeChunk(stream jogv1.Stream, chunk []byte) error
+}
+
+// flusher is implemented by formatters that hold back output, it's called when the output ends
+type flusher interface {
+	flush() error
+}
+
+// textFormatter writes output as is
+type textFormatter struct {
+	w io.Writer
+}
+
+func (f *textFormatter) writeChunk(_ jogv1.Stream, chunk []byte) error {
+	_, err := fmt.Fprintf(f.w, "%s", chunk)
+	return err
+}
+
+// rawFormatter writes output byte for byte, for binary output. Unlike text, which may be
+// reformatted, e.g. by --strip-ansi, it's never changed.
+type rawFormatter struct {
+	w io.Writer
+}
+
+func (f *rawFormatter) writeChunk(_ jogv1.Stream, chunk []byte) error {
+	_, err := f.w.Write(chunk)
+	return err
+}
+
+// streamPrefixes are the line prefixes written by the tagged text formatter
+var streamPrefixes = map[jogv1.Stream][]byte{
+	jogv1.Stream_STDOUT: []byte("O> "),
+	jogv1.Stream_STDERR: []byte("E> "),
+}
+
+// taggedTextFormatter writes output as text, prefixing each line with the stream it's from.
+// A chunk can end partway through a line, so the partial line is held back until the rest
+// of it arrives on the same stream. Lines are never mixed between streams. Combined output,
+// from servers that don't separate the streams, isn't prefixed.
+type taggedTextFormatter struct {
+	w io.Writer
+	// partial holds the unfinished last line of each stream
+	partial map[jogv1.Stream][]byte
+	// order is the order streams' partial lines were started in, so they're flushed in order
+	order []jogv1.Stream
+}
+
+func newTaggedTextFormatter(w io.Writer) *taggedTextFormatter {
+	return &taggedTextFormatter{w: w, partial: make(map[jogv1.Stream][]byte)}
+}
+
+func (f *taggedTextFormatter) writeChunk(stream jogv1.Stream, chunk []byte) error {
+	var out []byte
+	for len(chunk) > 0 {
+		i := bytes.IndexByte(chunk, '\n')
+		if i < 0 {
+			if _, ok := f.partial[stream]; !ok {
+				f.order = append(f.order, stream)
+			}
+			f.partial[stream] = append(f.partial[stream], chunk...)
+			break
+		}
+		out = append(out, streamPrefixes[stream]...)
+		out = append(out, f.takePartial(stream)...)
+		out = append(out, chunk[:i+1]...)
+		chunk = chunk[i+1:]
+	}
+	_, err := f.w.Write(out)
+	return err
+}
+
+// takePartial removes and returns the partial line held for stream
+func (f *taggedTextFormatter) takePartial(stream jogv1.Stream) []byte {
+	p, ok := f.partial[stream]
+	if !ok {
+		return nil
+	}
+	delete(f.partial, stream)
+	for i, s := range f.order {
+		if s == stream {
+			f.order = append(f.order[:i], f.order[i+1:]...)
+			break
+		}
+	}
+	return p
+}
+
+// flush writes the partial lines held back, each ended with a newline so that lines from
+// different streams aren't joined
+func (f *taggedTextFormatter) flush() error {
+	var out []byte
+	for len(f.order) > 0 {
+		stream := f.order[0]
+		out = append(out, streamPrefixes[stream]...)
+		out = append(out, f.takePartial(stream)...)
+		out = append(out, '\n')
+	}
+	_, err := f.w.Write(out)
+	return err
+}
+
+// ndjsonRecord is one line of NDJSON output
+type ndjsonRecord struct {
+	// Offset is the position of the chunk in the job's output
+	Offset int64 `json:"offset"`
+	// Data is the chunk of output. Invalid UTF-8 is replaced with U+FFFD
+	Data string `json:"data"`
+}
+
+// ndjsonFormatter writes each chunk of output as a JSON object on its own line
+type ndjsonFormatter struct {
+	enc    *json.Encoder
+	offset int64
+}
+
+func newNDJSONFormatter(w io.Writer) *ndjsonFormatter {
+	return &ndjsonFormatter{enc: json.NewEncoder(w)}
+}
+
+func (f *ndjsonFormatter) writeChunk(_ jogv1.Stream, chunk []byte) error {
+	if err := f.enc.Encode(ndjsonRecord{Offset: f.offset, Data: string(chunk)}); err != nil {
+		return err
+	}
+	f.offset += int64(len(chunk))
+	return nil
+}
+
+// lineNumberer is a writer that prefixes each line written to w with its 1-based line number,
+// like cat -n. A line is numbered when its first byte is written, so a line split across
+// chunks gets one number, and a final line without a newline is numbered too.
+type lineNumberer struct {
+	w    io.Writer
+	line int
+	// midLine is true when the last byte written wasn't a newline
+	midLine bool
+}
+
+func newLineNumberer(w io.Writer) *lineNumberer {
+	return &lineNumberer{w: w}
+}
+
+func (n *lineNumberer) Write(p []byte) (int, error) {
+	out := make([]byte, 0, len(p)+8)
+	for rest := p; len(rest) > 0; {
+		if !n.midLine {
+			n.line++
+			out = fmt.Appendf(out, "%6d\t", n.line)
+			n.midLine = true
+		}
+		i := bytes.IndexByte(rest, '\n')
+		if i < 0 {
+			out = append(out, rest...)
+			break
+		}
+		out = append(out, rest[:i+1]...)
+		rest = rest[i+1:]
+		n.midLine = false
+	}
+	if _, err := n.w.Write(out); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// gzipFlushInterval is how often the --gzip save file is flushed
+const gzipFlushInterval = time.Second
+
+// gzipWriter is a writer that gzips what's written to w as it arrives. It flushes the
+// compressed data when a write comes interval or more after the last flush, so a file that's
+// cut short, e.g. because jog was killed, decompresses up to then. Flushing every write would
+// cost compression when the output comes in small chunks.
+type gzipWriter struct {
+	gz        *gzip.Writer
+	interval  time.Duration
+	now       func() time.Time
+	lastFlush time.Time
+}
+
+func newGzipWriter(w io.Writer, interval time.Duration) *gzipWriter {
+	return &gzipWriter{gz: gzip.NewWriter(w), interval: interval, now: time.Now, lastFlush: time.Now()}
+}
+
+func (g *gzipWriter) Write(p []byte) (int, error) {
+	n, err := g.gz.Write(p)
+	if err != nil {
+		return n, err
+	}
+	if now := g.now(); now.Sub(g.lastFlush) >= g.interval {
+		g.lastFlush = now
+		return n, g.gz.Flush()
+	}
+	return n, nil
+}
+
+// Close writes the rest of the compressed data and the gzip footer. It doesn't close w.
+func (g *gzipWriter) Close() error {
+	return g.gz.Close()
+}
+
+// maxReverseBytes bounds the output held in memory by --reverse
+const maxReverseBytes = 64 << 20
+
+// lineReverser is a writer that holds text until flush, then writes its lines to w in
+// reverse order. A final line without a newline is ended with one. Only the last limit
+// bytes are kept, if more is written the earliest lines are dropped and a warning is
+// written to warn.
+type lineReverser struct {
+	w     io.Writer
+	warn  io.Writer
+	limit int
+	buf   []byte
+	// dropped is true if output was dropped to stay under the limit
+	dropped bool
+}
+
+func newLineReverser(w, warn io.Writer, limit int) *lineReverser {
+	return &lineReverser{w: w, warn: warn, limit: limit}
+}
+
+func (r *lineReverser) Write(p []byte) (int, error) {
+	r.buf = append(r.buf, p...)
+	// trim in batches, copying so the dropped output is released
+	if len(r.buf) > 2*r.limit {
+		r.buf = append([]byte(nil), r.buf[len(r.buf)-r.limit:]...)
+		r.dropped = true
+	}
+	return len(p), nil
+}
+
+func (r *lineReverser) flush() error {
+	buf := r.buf
+	r.buf = nil
+	if len(buf) > r.limit {
+		buf = buf[len(buf)-r.limit:]
+		r.dropped = true
+	}
+	if r.dropped {
+		// the first line was cut partway through, skip the rest of it
+		if i := bytes.IndexByte(buf, '\n'); i >= 0 {
+			buf = buf[i+1:]
+		} else {
+			buf = nil
+		}
+	}
+	if n := len(buf); n > 0 && buf[n-1] != '\n' {
+		buf = append(buf, '\n')
+	}
+
+	out := make([]byte, 0, len(buf))
+	for end := len(buf); end > 0; {
+		start := bytes.LastIndexByte(buf[:end-1], '\n') + 1
+		out = append(out, buf[start:end]...)
+		end = start
+	}
+	if _, err := r.w.Write(out); err != nil {
+		return err
+	}
+	if r.dropped {
+		_, err := fmt.Fprintf(r.warn, "jog: only the last %d bytes of output were reversed, earlier output was skipped\n", r.limit)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package command
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// spinnerFrames are drawn in turn, one per spinnerInterval. The first frame is drawn after
+// one interval, so requests that finish quickly don't flash a spinner.
+var spinnerFrames = [...]string{"|", "/", "-", `\`}
+
+const spinnerInterval = 100 * time.Millisecond
+
+// startProgress draws a spinner and msg on w until the returned func is called, which clears
+// the line. Nothing is drawn unless w is a terminal, so redirected stderr and pipes don't get
+// spinner frames mixed in.
+func startProgress(w io.Writer, msg string) (stop func()) {
+	if !isTerminal(w) {
+		return func() {}
+	}
+	done, stopped := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(spinnerInterval)
+		defer ticker.Stop()
+		drawn := false
+		for i := 0; ; i++ {
+			select {
+			case <-done:
+				if drawn {
+					// return to the start of the line and erase it
+					fmt.Fprint(w, "\r\x1b[K")
+				}
+				return
+			case <-ticker.C:
+				fmt.Fprintf(w, "\r%s %s", spinnerFrames[i%len(spinnerFrames)], msg)
+				drawn = true
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// isTerminal reports whether w is a terminal. Only files can be, and of those, only the ones
+// with terminal attributes: character devices like /dev/null aren't terminals.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	_, err := unix.IoctlGetTermios(int(f.Fd()), unix.TCGETS)
+	return err == nil
+}
@@ -0,0 +1,102 @@
+package command
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/creack/pty"
+)
+
+func TestProgressNotATerminal(t *testing.T) {
+	t.Parallel()
+
+	file, err := os.Create(filepath.Join(t.TempDir(), "stderr"))
+	if err != nil {
+		t.Fatalf("creating file: %v", err)
+	}
+	t.Cleanup(func() { file.Close() })
+	var buf bytes.Buffer
+
+	tests := []struct {
+		name string
+		w    io.Writer
+		read func() string
+	}{
+		{
+			name: "buffer",
+			w:    &buf,
+			read: buf.String,
+		},
+		{
+			name: "file",
+			w:    file,
+			read: func() string {
+				b, err := os.ReadFile(file.Name())
+				if err != nil {
+					t.Fatalf("reading file: %v", err)
+				}
+				return string(b)
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			stop := startProgress(tt.w, "starting job")
+			time.Sleep(3 * spinnerInterval)
+			stop()
+			if got := tt.read(); got != "" {
+				t.Fatalf("expected nothing to be written, got %q", got)
+			}
+		})
+	}
+}
+
+func TestProgressTerminal(t *testing.T) {
+	t.Parallel()
+
+	ptmx, tty, err := pty.Open()
+	if err != nil {
+		t.Skipf("opening a pseudo-terminal: %v", err)
+	}
+	t.Cleanup(func() {
+		ptmx.Close()
+		tty.Close()
+	})
+
+	stop := startProgress(tty, "stopping job")
+	time.Sleep(3 * spinnerInterval)
+	stop()
+	tty.Close()
+
+	// the terminal returns an error once everything written has been read
+	var got bytes.Buffer
+	io.Copy(&got, ptmx)
+	if !strings.Contains(got.String(), "| stopping job") {
+		t.Fatalf("expected a spinner frame, got %q", got.String())
+	}
+	if !strings.HasSuffix(got.String(), "\r\x1b[K") {
+		t.Fatalf("expected the spinner to be cleared, got %q", got.String())
+	}
+}
+
+func TestIsTerminalDevNull(t *testing.T) {
+	t.Parallel()
+
+	// /dev/null is a character device, but not a terminal
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatalf("opening %s: %v", os.DevNull, err)
+	}
+	t.Cleanup(func() { devNull.Close() })
+	if isTerminal(devNull) {
+		t.Fatalf("expected %s not to be a terminal", os.DevNull)
+	}
+}
@@ -0,0 +1,244 @@
+package command
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	jogv1 "github.com/dustinevan/jogger/pkg/gen/jogger/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// connectProxy is an HTTP CONNECT proxy that records the addresses it's asked to connect to,
+// and connects every tunnel to backend
+type connectProxy struct {
+	lis     net.Listener
+	backend string
+
+	mu        sync.Mutex
+	connected []string
+	auth      []string
+}
+
+func newConnectProxy(t *testing.T, backend string) *connectProxy {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listening: %v", err)
+	}
+	p := &connectProxy{lis: lis, backend: backend}
+	t.Cleanup(func() { lis.Close() })
+	go p.serve()
+	return p
+}
+
+func (p *connectProxy) serve() {
+	for {
+		conn, err := p.lis.Accept()
+		if err != nil {
+			return
+		}
+		go p.tunnel(conn)
+	}
+}
+
+func (p *connectProxy) tunnel(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	req, err := http.ReadRequest(r)
+	if err != nil || req.Method != http.MethodConnect {
+		return
+	}
+	p.mu.Lock()
+	p.connected = append(p.connected, req.Host)
+	p.auth = append(p.auth, req.Header.Get("Proxy-Authorization"))
+	p.mu.Unlock()
+
+	backend, err := net.Dial("tcp", p.backend)
+	if err != nil {
+		io.WriteString(conn, "HTTP/1.1 502 Bad Gateway\r\n\r\n")
+		return
+	}
+	defer backend.Close()
+	io.WriteString(conn, "HTTP/1.1 200 Connection established\r\n\r\n")
+	go io.Copy(backend, r)
+	io.Copy(conn, backend)
+}
+
+func (p *connectProxy) requests() ([]string, []string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.connected...), append([]string(nil), p.auth...)
+}
+
+func TestClientConfigProxy(t *testing.T) {
+	t.Parallel()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listening: %v", err)
+	}
+	server := grpc.NewServer()
+	jogv1.RegisterJobServiceServer(server, &authorityRecorder{})
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+	_, port, _ := net.SplitHostPort(lis.Addr().String())
+
+	tests := []struct {
+		name          string
+		host          string
+		noProxy       string
+		userinfo      string
+		wantConnected []string
+		wantAuth      string
+	}{
+		{
+			name:          "host is dialed through the proxy",
+			host:          "jogger.test:" + port,
+			wantConnected: []string{"jogger.test:" + port},
+		},
+		{
+			name:          "proxy credentials are sent",
+			host:          "jogger.test:" + port,
+			userinfo:      "user:pass@",
+			wantConnected: []string{"jogger.test:" + port},
+			wantAuth:      "Basic dXNlcjpwYXNz",
+		},
+		{
+			name:    "NO_PROXY domain bypasses the proxy",
+			host:    "jogger.test:" + port,
+			noProxy: "other.example.com,.test",
+		},
+		{
+			name: "loopback bypasses the proxy",
+			host: "127.0.0.1:" + port,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			proxy := newConnectProxy(t, lis.Addr().String())
+			env := map[string]string{
+				"https_proxy": "http://" + tt.userinfo + proxy.lis.Addr().String(),
+				"NO_PROXY":    tt.noProxy,
+			}
+			cfg := ResolveConfig(&Command{SubCommand: Exists, Host: tt.host}, func(k string) string { return env[k] })
+
+			opts, err := cfg.DialOptions()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			conn, err := grpc.NewClient(cfg.Target(), append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))...)
+			if err != nil {
+				t.Fatalf("connecting: %v", err)
+			}
+			t.Cleanup(func() { conn.Close() })
+
+			// jogger.test doesn't resolve, so the request only succeeds through the proxy
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+			_, err = jogv1.NewJobServiceClient(conn).Exists(ctx, &jogv1.ExistsRequest{JobId: "123"})
+			if len(tt.wantConnected) > 0 && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			connected, auth := proxy.requests()
+			if len(connected) != len(tt.wantConnected) {
+				t.Fatalf("expected proxy connections %v, got %v", tt.wantConnected, connected)
+			}
+			for i := range connected {
+				if connected[i] != tt.wantConnected[i] || auth[i] != tt.wantAuth {
+					t.Fatalf("expected CONNECT %s with auth %q, got %s with %q", tt.wantConnected[i], tt.wantAuth, connected[i], auth[i])
+				}
+			}
+		})
+	}
+}
+
+func TestClientConfigProxyURL(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		host       string
+		env        map[string]string
+		wantProxy  string
+		wantTarget string
+		wantErr    bool
+	}{
+		{
+			name:       "no proxy",
+			host:       "jogger.example.com",
+			wantTarget: "jogger.example.com",
+		},
+		{
+			name:       "upper case takes precedence",
+			host:       "jogger.example.com",
+			env:        map[string]string{"HTTPS_PROXY": "http://upper:3128", "https_proxy": "http://lower:3128"},
+			wantProxy:  "http://upper:3128",
+			wantTarget: "passthrough:///jogger.example.com:443",
+		},
+		{
+			name:       "proxy without a scheme is http",
+			host:       "jogger.example.com:50051",
+			env:        map[string]string{"HTTPS_PROXY": "proxy:3128"},
+			wantProxy:  "http://proxy:3128",
+			wantTarget: "passthrough:///jogger.example.com:50051",
+		},
+		{
+			name:       "NO_PROXY matches the host",
+			host:       "jogger.example.com:50051",
+			env:        map[string]string{"HTTPS_PROXY": "proxy:3128", "no_proxy": "example.com"},
+			wantTarget: "jogger.example.com:50051",
+		},
+		{
+			name:       "targets with a scheme aren't proxied",
+			host:       "dns:///jogger.example.com:50051",
+			env:        map[string]string{"HTTPS_PROXY": "proxy:3128"},
+			wantTarget: "dns:///jogger.example.com:50051",
+		},
+		{
+			name:    "unsupported proxy scheme",
+			host:    "jogger.example.com",
+			env:     map[string]string{"HTTPS_PROXY": "socks5://proxy:1080"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := ResolveConfig(&Command{SubCommand: Config, Host: tt.host}, func(k string) string { return tt.env[k] })
+			proxyURL, err := cfg.ProxyURL()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got proxy %v", proxyURL)
+				}
+				if _, err := cfg.DialOptions(); err == nil {
+					t.Fatal("expected DialOptions to return an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got := ""
+			if proxyURL != nil {
+				got = proxyURL.String()
+			}
+			if got != tt.wantProxy {
+				t.Fatalf("expected proxy %q, got %q", tt.wantProxy, got)
+			}
+			if target := cfg.Target(); target != tt.wantTarget {
+				t.Fatalf("expected target %q, got %q", tt.wantTarget, target)
+			}
+		})
+	}
+}
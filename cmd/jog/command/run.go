@@ -2,68 +2,586 @@ package command
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	jogv1 "github.com/dustinevan/jogger/pkg/gen/jogger/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
 )
 
-func Run(ctx context.Context, client jogv1.JobServiceClient, cmd *Command) error {
+// ExitError is returned by Run when jog should exit with a specific, non-zero code
+type ExitError struct {
+	msg  string
+	code int
+}
+
+func (e *ExitError) Error() string {
+	return e.msg
+}
+
+// Code returns the process exit code
+func (e *ExitError) Code() int {
+	return e.code
+}
+
+// statusExitError returns an ExitError with the exit code for a job's final status
+func statusExitError(s jogv1.Status) *ExitError {
+	e := &ExitError{msg: fmt.Sprintf("job finished with status: %s", s)}
+	switch s {
+	case jogv1.Status_COMPLETED:
+		e.code = 0
+	case jogv1.Status_FAILED:
+		e.code = 1
+	case jogv1.Status_STOPPED:
+		e.code = 2
+	case jogv1.Status_KILLED:
+		e.code = 3
+	default:
+		// the job isn't done, the stream ended for some other reason
+		e.code = 4
+	}
+	return e
+}
+
+// Run runs the command using the client, printing results to stdout. Notes that aren't part of
+// the results, like the summary after a job's output, are printed to stderr.
+func Run(ctx context.Context, client jogv1.JobServiceClient, cmd *Command, stdout, stderr io.Writer) error {
+	// NewCommand rejects these too, this also covers commands that are built directly
+	if cmd.Observer && cmd.SubCommand.mutating() {
+		return errObserver(cmd.SubCommand)
+	}
 	switch cmd.SubCommand {
 	case Start:
-		return runStart(ctx, client, cmd)
+		if cmd.Attach {
+			return runAttach(ctx, client, cmd, stdout, stderr)
+		}
+		if cmd.Follow {
+			return runFollow(ctx, client, cmd, stdout, stderr)
+		}
+		return runStart(ctx, client, cmd, stdout, stderr)
 	case Stop:
-		return runStop(ctx, client, cmd)
+		return runStop(ctx, client, cmd, stdout, stderr)
 	case Status:
-		return runStatus(ctx, client, cmd)
+		return runStatus(ctx, client, cmd, stdout)
 	case Output:
-		return runOutput(ctx, client, cmd)
+		return runOutput(ctx, client, cmd, stdout, stderr)
+	case Exists:
+		return runExists(ctx, client, cmd, stdout)
+	case Events:
+		return runEvents(ctx, client, cmd, stdout)
+	case Watch:
+		return runWatch(ctx, client, cmd, stdout)
+	case List:
+		return runList(ctx, client, stdout)
+	case Diff:
+		return runDiff(ctx, client, cmd, stdout, stderr)
+	case Update:
+		return runUpdate(ctx, client, cmd, stdout)
 	default:
 		return fmt.Errorf("unsupported subcommand: %v", cmd.SubCommand)
 	}
 }
 
-func runStart(ctx context.Context, client jogv1.JobServiceClient, cmd *Command) error {
-	resp, err := client.Start(ctx, &jogv1.StartRequest{Job: &jogv1.Job{Cmd: cmd.RemoteCommand, Args: cmd.RemoteArgs}})
+// captures maps --capture values to the proto enum. No flag is CAPTURE_UNSPECIFIED, the server's default.
+var captures = map[string]jogv1.Capture{
+	"both":   jogv1.Capture_CAPTURE_BOTH,
+	"stdout": jogv1.Capture_CAPTURE_STDOUT,
+	"stderr": jogv1.Capture_CAPTURE_STDERR,
+}
+
+// chunkings maps --chunk policies to the proto enum. No flag is CHUNKING_UNSPECIFIED, which is size.
+var chunkings = map[string]jogv1.Chunking{
+	"line": jogv1.Chunking_CHUNKING_LINE,
+	"size": jogv1.Chunking_CHUNKING_SIZE,
+}
+
+// encodings maps --encoding values to the proto enum. No flag is ENCODING_UNSPECIFIED, which is raw.
+var encodings = map[string]jogv1.Encoding{
+	"raw":    jogv1.Encoding_ENCODING_RAW,
+	"base64": jogv1.Encoding_ENCODING_BASE64,
+	"hex":    jogv1.Encoding_ENCODING_HEX,
+}
+
+// decodeOutput decodes a chunk of output the server sent with encoding. The server encodes
+// each chunk on its own, so every chunk decodes without the ones around it.
+func decodeOutput(encoding jogv1.Encoding, data []byte) ([]byte, error) {
+	switch encoding {
+	case jogv1.Encoding_ENCODING_BASE64:
+		return base64.StdEncoding.AppendDecode(nil, data)
+	case jogv1.Encoding_ENCODING_HEX:
+		return hex.AppendDecode(nil, data)
+	default:
+		return data, nil
+	}
+}
+
+// progress shows a spinner with msg on stderr until the returned func is called, unless it's
+// turned off with --no-progress
+func progress(cmd *Command, stderr io.Writer, msg string) (stop func()) {
+	if cmd.NoProgress {
+		return func() {}
+	}
+	return startProgress(stderr, msg)
+}
+
+func runStart(ctx context.Context, client jogv1.JobServiceClient, cmd *Command, stdout, stderr io.Writer) error {
+	resp, err := startJob(ctx, client, cmd, stderr)
 	if err != nil {
-		return fmt.Errorf("starting job: %w", err)
+		return err
+	}
+	if cmd.Quiet {
+		fmt.Fprintln(stdout, resp.JobId)
+		return nil
+	}
+	fmt.Fprintf(stdout, "job started: %s\n", resp.JobId)
+	if resp.Status != jogv1.Status_STATUS_UNSPECIFIED {
+		fmt.Fprintf(stdout, "status: %s\n", resp.Status)
+	}
+	if resp.StartedAtUnixNano > 0 {
+		fmt.Fprintf(stdout, "started at: %s\n", time.Unix(0, resp.StartedAtUnixNano).UTC().Format(time.RFC3339Nano))
+	}
+	if resp.CommandPath != "" {
+		fmt.Fprintf(stdout, "command: %s\n", resp.CommandPath)
 	}
-	fmt.Printf("job started: %s\n", resp.JobId)
 	return nil
 }
 
-func runStop(ctx context.Context, client jogv1.JobServiceClient, cmd *Command) error {
-	_, err := client.Stop(ctx, &jogv1.StopRequest{JobId: cmd.JobID})
+// runFollow starts the job, then streams its output like jog output. The job id is printed
+// to stderr first, so it isn't mixed into the output. When ctx is canceled, e.g. by Ctrl-C,
+// jog stops following and the job keeps running.
+func runFollow(ctx context.Context, client jogv1.JobServiceClient, cmd *Command, stdout, stderr io.Writer) error {
+	resp, err := startJob(ctx, client, cmd, stderr)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stderr, "job started: %s\n", resp.JobId)
+	follow := *cmd
+	follow.JobID = resp.JobId
+	err = runOutput(ctx, client, &follow, stdout, stderr)
+	if err != nil && ctx.Err() != nil {
+		fmt.Fprintf(stderr, "jog: stopped following job %s, it wasn't stopped\n", resp.JobId)
+		return nil
+	}
+	return err
+}
+
+// runAttach starts the job and streams its output in one call, so none of its output is missed,
+// however fast it is. Otherwise it's like runFollow, and with --follow, a stream that ends
+// before the job is done is reopened with jog output's request.
+func runAttach(ctx context.Context, client jogv1.JobServiceClient, cmd *Command, stdout, stderr io.Writer) error {
+	opts, trailer := outputCallOptions(cmd)
+	stopProgress := progress(cmd, stderr, "starting job")
+	stream, err := client.StartAndStream(ctx, &jogv1.StartAndStreamRequest{
+		Start:  startRequest(cmd),
+		Output: outputRequest(cmd, "", 0),
+	}, opts...)
+	// the first message is the started job
+	var resp *jogv1.StartAndStreamResponse
+	if err == nil {
+		resp, err = stream.Recv()
+	}
+	stopProgress()
+	if err != nil {
+		return fmt.Errorf("starting job: %w", err)
+	}
+	jobID := resp.GetStart().GetJobId()
+	if jobID == "" {
+		return fmt.Errorf("starting job: the server didn't send the job id")
+	}
+	fmt.Fprintf(stderr, "job started: %s\n", jobID)
+	attach := *cmd
+	attach.JobID = jobID
+	err = writeOutput(ctx, client, &attach, attachedOutput{stream}, outputOpener(ctx, client, &attach, opts), trailer, stdout, stderr)
+	if err != nil && ctx.Err() != nil {
+		fmt.Fprintf(stderr, "jog: stopped following job %s, it wasn't stopped\n", jobID)
+		return nil
+	}
+	return err
+}
+
+// attachedOutput reads the output messages of a StartAndStream stream, after the first one,
+// like an Output stream
+type attachedOutput struct {
+	jogv1.JobService_StartAndStreamClient
+}
+
+func (a attachedOutput) Recv() (*jogv1.OutputResponse, error) {
+	resp, err := a.JobService_StartAndStreamClient.Recv()
+	if err != nil {
+		return nil, err
+	}
+	if resp.GetData() == nil {
+		return nil, fmt.Errorf("expected output, got %v", resp)
+	}
+	return &jogv1.OutputResponse{Data: resp.GetData()}, nil
+}
+
+// startJob starts the job described by the command
+func startJob(ctx context.Context, client jogv1.JobServiceClient, cmd *Command, stderr io.Writer) (*jogv1.StartResponse, error) {
+	stopProgress := progress(cmd, stderr, "starting job")
+	resp, err := client.Start(ctx, startRequest(cmd))
+	stopProgress()
+	if err != nil {
+		return nil, fmt.Errorf("starting job: %w", err)
+	}
+	return resp, nil
+}
+
+// startRequest returns the request to start the job described by the command
+func startRequest(cmd *Command) *jogv1.StartRequest {
+	return &jogv1.StartRequest{Spec: &jogv1.JobSpec{
+		Cmd:              cmd.RemoteCommand,
+		Args:             cmd.RemoteArgs,
+		Tty:              cmd.TTY,
+		Shell:            cmd.Shell,
+		Name:             cmd.Name,
+		Capture:          captures[cmd.Capture],
+		OutputLimitBytes: cmd.MaxOutput,
+		Umask:            cmd.Umask,
+		RlimitNofile:     cmd.NoFile,
+		RlimitNproc:      cmd.NProc,
+		AfterJobId:       cmd.After,
+		AfterCompleted:   cmd.IfCompleted,
+		Redact:           cmd.Redact,
+		RedactPatterns:   cmd.RedactRegex,
+	}}
+}
+
+func runStop(ctx context.Context, client jogv1.JobServiceClient, cmd *Command, stdout, stderr io.Writer) error {
+	stopProgress := progress(cmd, stderr, "stopping job")
+	resp, err := client.Stop(ctx, &jogv1.StopRequest{JobId: cmd.JobID, GracePeriodMs: cmd.GracePeriod.Milliseconds()})
+	stopProgress()
 	if err != nil {
 		return fmt.Errorf("stopping job: %w", err)
 	}
-	fmt.Printf("job stopped: %s\n", cmd.JobID)
+	// the job may still be exiting, e.g. while it waits for the SIGKILL after ignoring a SIGTERM
+	if resp.Status == jogv1.Status_STOPPING {
+		fmt.Fprintf(stdout, "job stopping: %s\n", cmd.JobID)
+		return nil
+	}
+	fmt.Fprintf(stdout, "job stopped: %s\n", cmd.JobID)
+	return nil
+}
+
+func runUpdate(ctx context.Context, client jogv1.JobServiceClient, cmd *Command, stdout io.Writer) error {
+	_, err := client.UpdateLimits(ctx, &jogv1.UpdateLimitsRequest{JobId: cmd.JobID, MemoryMaxBytes: cmd.MemoryLimit, Cpus: cmd.CPULimit})
+	if err != nil {
+		return fmt.Errorf("updating job limits: %w", err)
+	}
+	fmt.Fprintf(stdout, "job updated: %s\n", cmd.JobID)
 	return nil
 }
 
-func runStatus(ctx context.Context, client jogv1.JobServiceClient, cmd *Command) error {
+func runStatus(ctx context.Context, client jogv1.JobServiceClient, cmd *Command, stdout io.Writer) error {
 	resp, err := client.Status(ctx, &jogv1.StatusRequest{JobId: cmd.JobID})
 	if err != nil {
 		return fmt.Errorf("getting job status: %w", err)
 	}
-	fmt.Printf("job status: %s\n", resp.Status)
+	// the exit code is -1 while the job is running, and when it was killed by a signal
+	if resp.ExitCode >= 0 {
+		fmt.Fprintf(stdout, "job status: %s (exit %d)\n", resp.Status, resp.ExitCode)
+	} else {
+		fmt.Fprintf(stdout, "job status: %s\n", resp.Status)
+	}
+	if resp.Name != "" {
+		fmt.Fprintf(stdout, "name: %s\n", resp.Name)
+	}
+	if resp.SourceAddr != "" {
+		fmt.Fprintf(stdout, "source address: %s\n", resp.SourceAddr)
+	}
+	if resp.StartError != "" {
+		fmt.Fprintf(stdout, "start error: %s\n", resp.StartError)
+	}
+	fmt.Fprintf(stdout, "output: %s\n", formatBytes(resp.OutputBytes))
+	if len(resp.OrphanedPids) > 0 {
+		fmt.Fprintf(stdout, "orphaned pids: %v\n", resp.OrphanedPids)
+	}
+	if resp.PeakMemoryBytes > 0 {
+		fmt.Fprintf(stdout, "peak memory: %s\n", formatBytes(resp.PeakMemoryBytes))
+	}
+	if resp.StartLatencyUsec > 0 {
+		fmt.Fprintf(stdout, "start latency: %s\n", time.Duration(resp.StartLatencyUsec)*time.Microsecond)
+	}
+	if resp.TotalCpuUsec > 0 {
+		fmt.Fprintf(stdout, "cpu time: %s\n", time.Duration(resp.TotalCpuUsec)*time.Microsecond)
+	}
 	return nil
 }
 
-func runOutput(ctx context.Context, client jogv1.JobServiceClient, cmd *Command) error {
-	stream, err := client.Output(ctx, &jogv1.OutputRequest{JobId: cmd.JobID})
+func runExists(ctx context.Context, client jogv1.JobServiceClient, cmd *Command, stdout io.Writer) error {
+	resp, err := client.Exists(ctx, &jogv1.ExistsRequest{JobId: cmd.JobID})
 	if err != nil {
-		return fmt.Errorf("getting job output: %w", err)
+		return fmt.Errorf("checking job exists: %w", err)
+	}
+	if !resp.Exists {
+		return &ExitError{msg: fmt.Sprintf("job not found: %s", cmd.JobID), code: 1}
+	}
+	fmt.Fprintf(stdout, "job exists: %s\n", cmd.JobID)
+	return nil
+}
+
+func runEvents(ctx context.Context, client jogv1.JobServiceClient, cmd *Command, stdout io.Writer) error {
+	resp, err := client.Events(ctx, &jogv1.EventsRequest{JobId: cmd.JobID})
+	if err != nil {
+		return fmt.Errorf("getting job events: %w", err)
+	}
+	for _, e := range resp.Events {
+		printEvent(stdout, e)
+	}
+	return nil
+}
+
+func runWatch(ctx context.Context, client jogv1.JobServiceClient, cmd *Command, stdout io.Writer) error {
+	stream, err := client.WatchEvents(ctx, &jogv1.EventsRequest{JobId: cmd.JobID})
+	if err != nil {
+		return fmt.Errorf("watching job events: %w", err)
 	}
 	for {
-		resp, err := stream.Recv()
+		e, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("watching job events: %w", err)
+		}
+		printEvent(stdout, e)
+	}
+}
+
+// runList prints a table of the user's jobs: their job ids, statuses, and commands
+func runList(ctx context.Context, client jogv1.JobServiceClient, stdout io.Writer) error {
+	resp, err := client.ListJobs(ctx, &jogv1.ListJobsRequest{})
+	if err != nil {
+		return fmt.Errorf("listing jobs: %w", err)
+	}
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "JOB ID\tSTATUS\tCOMMAND")
+	for _, j := range resp.Jobs {
+		command := strings.Join(append([]string{j.Cmd}, j.Args...), " ")
+		if j.Name != "" {
+			command += " (" + j.Name + ")"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", j.JobId, j.Status, command)
+	}
+	return w.Flush()
+}
+
+// printEvent prints an event on a line: when it happened, its type, and its detail
+func printEvent(w io.Writer, e *jogv1.Event) {
+	at := time.Unix(0, e.TimeUnixNano).UTC().Format(time.RFC3339Nano)
+	fmt.Fprintf(w, "%-35s %-15s %s\n", at, e.Type, e.Detail)
+}
+
+// newFormatter returns an NDJSON formatter if json is true, and a text formatter otherwise
+func newFormatter(json bool, w io.Writer) outputFormatter {
+	if json {
+		return newNDJSONFormatter(w)
+	}
+	return &textFormatter{w: w}
+}
+
+// formatBytes formats a byte count using binary units, e.g. 1.2 MiB
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// sentBytesTrailer is the trailer the server reports the number of bytes of output it sent in
+const sentBytesTrailer = "jogger-sent-bytes"
+
+// sentBytes returns the number of bytes of output the server reported sending, -1 if it didn't
+func sentBytes(trailer metadata.MD) int64 {
+	v := trailer.Get(sentBytesTrailer)
+	if len(v) != 1 {
+		return -1
+	}
+	n, err := strconv.ParseInt(v[0], 10, 64)
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// outputCallOptions returns the call options for the command's output streams, and the
+// trailer the server reports how much output it sent in, to tell if --max-bytes cut it short
+func outputCallOptions(cmd *Command) ([]grpc.CallOption, *metadata.MD) {
+	var opts []grpc.CallOption
+	if cmd.Compress {
+		// The server responds using the compressor the client requests with
+		opts = append(opts, grpc.UseCompressor(gzip.Name))
+	}
+	trailer := new(metadata.MD)
+	opts = append(opts, grpc.Trailer(trailer))
+	return opts, trailer
+}
+
+// outputRequest returns the request for the command's output of the job at offset
+func outputRequest(cmd *Command, jobID string, offset int64) *jogv1.OutputRequest {
+	// --tag-streams needs each chunk to be from one stream, and to say which
+	var selector jogv1.StreamSelector
+	switch {
+	case cmd.Stderr:
+		selector = jogv1.StreamSelector_STREAM_SELECTOR_STDERR
+	case cmd.TagStreams:
+		selector = jogv1.StreamSelector_STREAM_SELECTOR_MERGED
+	}
+	return &jogv1.OutputRequest{
+		JobId:     jobID,
+		MaxBytes:  cmd.MaxBytes,
+		Chunking:  chunkings[cmd.Chunking],
+		ChunkSize: cmd.ChunkSize,
+		Encoding:  encodings[cmd.Encoding],
+		Offset:    offset,
+		Stream:    selector,
+	}
+}
+
+// outputOpener returns a function that opens the command's job's output stream at offset,
+// --follow reopens the stream where the last one ended
+func outputOpener(ctx context.Context, client jogv1.JobServiceClient, cmd *Command, opts []grpc.CallOption) func(int64) (jogv1.JobService_OutputClient, error) {
+	return func(offset int64) (jogv1.JobService_OutputClient, error) {
+		return client.Output(ctx, outputRequest(cmd, cmd.JobID, offset), opts...)
+	}
+}
+
+func runOutput(ctx context.Context, client jogv1.JobServiceClient, cmd *Command, stdout, stderr io.Writer) error {
+	opts, trailer := outputCallOptions(cmd)
+	open := outputOpener(ctx, client, cmd, opts)
+	stream, err := open(0)
+	if err != nil && cmd.Follow {
+		// e.g. the job is pending, it has no output until it's started
+		stream, err = followOutput(ctx, client, cmd.JobID, open, 0, false)
+	}
+	if err != nil {
+		return fmt.Errorf("getting job output: %w", err)
+	}
+	return writeOutput(ctx, client, cmd, stream, open, trailer, stdout, stderr)
+}
+
+// writeOutput writes the output from stream, reopening it with open when --follow is set, then
+// the summary. trailer is the stream's trailer.
+func writeOutput(ctx context.Context, client jogv1.JobServiceClient, cmd *Command, stream jogv1.JobService_OutputClient, open func(int64) (jogv1.JobService_OutputClient, error), trailer *metadata.MD, stdout, stderr io.Writer) error {
+	var reverser *lineReverser
+	if cmd.Reverse {
+		reverser = newLineReverser(stdout, stderr, maxReverseBytes)
+		stdout = reverser
+	}
+	// ANSI escapes are stripped in output order, before the lines are reversed
+	if cmd.StripANSI {
+		stdout = newANSIStripper(stdout)
+	}
+	// lines are numbered in output order too, and before stream tags are added
+	if cmd.Number {
+		stdout = newLineNumberer(stdout)
+	}
+
+	// The format applies to the save file when there is one, the screen always gets text then.
+	// Stream tags only apply to text on the screen.
+	var text outputFormatter = &textFormatter{w: stdout}
+	if cmd.TagStreams {
+		text = newTaggedTextFormatter(stdout)
+	} else if cmd.Raw {
+		text = &rawFormatter{w: stdout}
+	}
+	var formatters []outputFormatter
+	// gz compresses the save file with --gzip. It's closed when the output ends, or when jog is
+	// interrupted, so the file is a complete gzip of the output received.
+	var gz *gzipWriter
+	if cmd.SavePath != "" {
+		f, err := os.Create(cmd.SavePath)
+		if err != nil {
+			return fmt.Errorf("creating save file: %w", err)
+		}
+		defer f.Close()
+		var save io.Writer = f
+		if cmd.Gzip {
+			gz = newGzipWriter(f, gzipFlushInterval)
+			defer gz.Close()
+			save = gz
+		}
+		formatters = append(formatters, text, newFormatter(cmd.JSON, save))
+	} else if cmd.JSON {
+		formatters = append(formatters, newNDJSONFormatter(stdout))
+	} else {
+		formatters = append(formatters, text)
+	}
+
+	var err, writeErr error
+	// received is the offset in the job's output of the next byte
+	var received int64
+	for writeErr == nil {
+		var resp *jogv1.OutputResponse
+		resp, err = stream.Recv()
+		if err != nil && cmd.Follow && ctx.Err() == nil {
+			next, followErr := followOutput(ctx, client, cmd.JobID, open, received, errors.Is(err, io.EOF))
+			if followErr != nil {
+				err = followErr
+			} else if next != nil {
+				stream.CloseSend()
+				stream = next
+				continue
+			}
+		}
 		if err != nil {
 			if errors.Is(err, io.EOF) {
+				err = nil
+			} else {
+				err = fmt.Errorf("receiving output: %w", err)
+			}
+			break
+		}
+		data, decodeErr := decodeOutput(encodings[cmd.Encoding], resp.Data.Data)
+		if decodeErr != nil {
+			writeErr = fmt.Errorf("decoding output: %w", decodeErr)
+			break
+		}
+		received += int64(len(data))
+		for _, f := range formatters {
+			if writeErr = f.writeChunk(resp.Data.Stream, data); writeErr != nil {
+				writeErr = fmt.Errorf("writing output: %w", writeErr)
 				break
 			}
-			err = fmt.Errorf("receiving output: %w", err)
 		}
-		fmt.Printf("%s", resp.Data.Data)
+	}
+	if writeErr == nil {
+		// write the output the formatters held back, like the end of a partial line
+		for _, f := range formatters {
+			if fl, ok := f.(flusher); ok {
+				if writeErr = fl.flush(); writeErr != nil {
+					writeErr = fmt.Errorf("writing output: %w", writeErr)
+					break
+				}
+			}
+		}
+	}
+	if writeErr == nil && gz != nil {
+		if writeErr = gz.Close(); writeErr != nil {
+			writeErr = fmt.Errorf("writing save file: %w", writeErr)
+		}
+	}
+	if writeErr == nil && reverser != nil {
+		if writeErr = reverser.flush(); writeErr != nil {
+			writeErr = fmt.Errorf("writing output: %w", writeErr)
+		}
 	}
 
 	closeErr := stream.CloseSend()
@@ -73,6 +591,119 @@ func runOutput(ctx context.Context, client jogv1.JobServiceClient, cmd *Command)
 		}
 		return fmt.Errorf("closing output stream: %w", closeErr)
 	}
-	// if there was an error while receiving output, return that error, this will be nil otherwise
-	return err
+	// if there was an error while receiving output, return that error
+	if err != nil {
+		return err
+	}
+	if writeErr != nil {
+		return writeErr
+	}
+	capped := cmd.MaxBytes > 0 && sentBytes(*trailer) >= cmd.MaxBytes
+	if capped {
+		fmt.Fprintf(stderr, "jog: output stopped at the --max-bytes cap of %d bytes\n", cmd.MaxBytes)
+	}
+
+	// the summary is only for output that ended because the job is done
+	summary := !cmd.NoSummary && !capped
+	if !cmd.ExitCode && !summary {
+		return nil
+	}
+	final, err := client.Status(ctx, &jogv1.StatusRequest{JobId: cmd.JobID})
+	if err != nil {
+		if !cmd.ExitCode {
+			// the summary is best effort, the output was streamed
+			return nil
+		}
+		return fmt.Errorf("getting final job status: %w", err)
+	}
+	if summary {
+		writeSummary(ctx, client, cmd.JobID, final, stderr)
+	}
+	if cmd.ExitCode && final.Status != jogv1.Status_COMPLETED {
+		return statusExitError(final.Status)
+	}
+	return nil
+}
+
+// followRetryInterval is how often --follow checks on a job whose output stream can't be
+// opened, e.g. while it's pending or the server is restarting
+const followRetryInterval = time.Second
+
+// followOutput returns the next output stream of a job followed with --follow, opened with
+// open at offset, the length of the output received so far. It returns a nil stream when
+// there's no more output: the job is done and the last stream ended cleanly. Otherwise it
+// retries until the stream opens, e.g. once a pending job is started, or ctx is done. Only
+// an unavailable server is retried when the job's status can't be read, e.g. a job that
+// doesn't exist isn't.
+func followOutput(ctx context.Context, client jogv1.JobServiceClient, jobID string, open func(int64) (jogv1.JobService_OutputClient, error), offset int64, ended bool) (jogv1.JobService_OutputClient, error) {
+	for {
+		resp, err := client.Status(ctx, &jogv1.StatusRequest{JobId: jobID})
+		if err != nil && status.Code(err) != codes.Unavailable {
+			return nil, fmt.Errorf("getting job status: %w", err)
+		}
+		if err == nil {
+			// a stream that broke may not have sent all of a done job's output
+			if jobDone(resp.Status) && ended {
+				return nil, nil
+			}
+			if resp.Status != jogv1.Status_PENDING {
+				if stream, err := open(offset); err == nil {
+					return stream, nil
+				}
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(followRetryInterval):
+		}
+	}
+}
+
+// jobDone reports whether a job with the status is done, it won't have more output
+func jobDone(s jogv1.Status) bool {
+	switch s {
+	case jogv1.Status_STATUS_UNSPECIFIED, jogv1.Status_RUNNING, jogv1.Status_STOPPING, jogv1.Status_PENDING:
+		return false
+	}
+	return true
+}
+
+// writeSummary writes a footer for a done job's output, e.g.
+// --- job 123 completed in 3.2s, exit 0, 14.0 KiB output ---
+// Nothing is written if the job isn't done, e.g. when the output ended because the server
+// is shutting down. The run time is left out if the job's events can't be read.
+func writeSummary(ctx context.Context, client jogv1.JobServiceClient, jobID string, final *jogv1.StatusResponse, w io.Writer) {
+	if !jobDone(final.Status) {
+		return
+	}
+	done := fmt.Sprintf("job %s %s", jobID, strings.ToLower(final.Status.String()))
+	if events, err := client.Events(ctx, &jogv1.EventsRequest{JobId: jobID}); err == nil {
+		if d, ok := runTime(events.Events); ok {
+			done += " in " + d.Round(time.Millisecond).String()
+		}
+	}
+	parts := []string{done}
+	if final.ExitCode >= 0 {
+		parts = append(parts, fmt.Sprintf("exit %d", final.ExitCode))
+	}
+	parts = append(parts, formatBytes(final.OutputBytes)+" output")
+	fmt.Fprintf(w, "--- %s ---\n", strings.Join(parts, ", "))
+}
+
+// runTime returns how long a job ran, from its started event to its exited event
+func runTime(events []*jogv1.Event) (time.Duration, bool) {
+	var started, exited int64
+	for _, e := range events {
+		switch e.Type {
+		case "started":
+			started = e.TimeUnixNano
+		case "exited":
+			exited = e.TimeUnixNano
+		}
+	}
+	if started == 0 || exited < started {
+		return 0, false
+	}
+	return time.Duration(exited - started), true
 }
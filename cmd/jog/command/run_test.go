@@ -0,0 +1,1300 @@
+package command
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	jogv1 "github.com/dustinevan/jogger/pkg/gen/jogger/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/stats"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+)
+
+// fakeJobServer is a JobServiceServer that streams fixed output chunks and reports a fixed status
+type fakeJobServer struct {
+	jogv1.UnimplementedJobServiceServer
+	output [][]byte
+	status jogv1.Status
+	// exitCode is reported with status
+	exitCode int32
+	// outputs is the output of each job id, output is used for the jobs without one
+	outputs map[string][][]byte
+	// jobs is the set of job ids that exist
+	jobs map[string]bool
+	// events are returned for every job
+	events []*jogv1.Event
+	// stopStatus is the status in the response to every stop request
+	stopStatus jogv1.Status
+	// list is returned for every list request
+	list []*jogv1.JobSummary
+	// tagged is streamed instead of output when it's set
+	tagged []*jogv1.OutputData
+	// started is the last start request, and startResp is the response to it when it's set
+	started   *jogv1.StartRequest
+	startResp *jogv1.StartResponse
+	// outputReq is the last output request
+	outputReq *jogv1.OutputRequest
+	// attached is the last start and stream request
+	attached *jogv1.StartAndStreamRequest
+	// updated is the last update limits request
+	updated *jogv1.UpdateLimitsRequest
+}
+
+func (f *fakeJobServer) Start(_ context.Context, req *jogv1.StartRequest) (*jogv1.StartResponse, error) {
+	f.started = req
+	if f.startResp != nil {
+		return f.startResp, nil
+	}
+	return &jogv1.StartResponse{JobId: "123"}, nil
+}
+
+func (f *fakeJobServer) StartAndStream(req *jogv1.StartAndStreamRequest, srv jogv1.JobService_StartAndStreamServer) error {
+	f.attached = req
+	resp, err := f.Start(srv.Context(), req.GetStart())
+	if err != nil {
+		return err
+	}
+	if err := srv.Send(&jogv1.StartAndStreamResponse{Response: &jogv1.StartAndStreamResponse_Start{Start: resp}}); err != nil {
+		return err
+	}
+	for _, chunk := range f.jobOutput(resp.JobId) {
+		if err := srv.Send(&jogv1.StartAndStreamResponse{Response: &jogv1.StartAndStreamResponse_Data{Data: &jogv1.OutputData{Data: chunk}}}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *fakeJobServer) UpdateLimits(_ context.Context, req *jogv1.UpdateLimitsRequest) (*jogv1.UpdateLimitsResponse, error) {
+	f.updated = req
+	return &jogv1.UpdateLimitsResponse{}, nil
+}
+
+func (f *fakeJobServer) Events(context.Context, *jogv1.EventsRequest) (*jogv1.EventsResponse, error) {
+	return &jogv1.EventsResponse{Events: f.events}, nil
+}
+
+func (f *fakeJobServer) WatchEvents(_ *jogv1.EventsRequest, srv jogv1.JobService_WatchEventsServer) error {
+	for _, e := range f.events {
+		if err := srv.Send(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *fakeJobServer) Stop(context.Context, *jogv1.StopRequest) (*jogv1.StopResponse, error) {
+	return &jogv1.StopResponse{Status: f.stopStatus}, nil
+}
+
+func (f *fakeJobServer) ListJobs(context.Context, *jogv1.ListJobsRequest) (*jogv1.ListJobsResponse, error) {
+	return &jogv1.ListJobsResponse{Jobs: f.list}, nil
+}
+
+func (f *fakeJobServer) Exists(_ context.Context, req *jogv1.ExistsRequest) (*jogv1.ExistsResponse, error) {
+	return &jogv1.ExistsResponse{Exists: f.jobs[req.JobId]}, nil
+}
+
+// jobOutput returns the output chunks of the job
+func (f *fakeJobServer) jobOutput(jobID string) [][]byte {
+	if output, ok := f.outputs[jobID]; ok {
+		return output
+	}
+	return f.output
+}
+
+func (f *fakeJobServer) Status(_ context.Context, req *jogv1.StatusRequest) (*jogv1.StatusResponse, error) {
+	var outputBytes int64
+	for _, chunk := range f.jobOutput(req.JobId) {
+		outputBytes += int64(len(chunk))
+	}
+	return &jogv1.StatusResponse{Status: f.status, ExitCode: f.exitCode, OutputBytes: outputBytes}, nil
+}
+
+func (f *fakeJobServer) Output(req *jogv1.OutputRequest, srv jogv1.JobService_OutputServer) error {
+	f.outputReq = req
+	for _, data := range f.tagged {
+		if err := srv.Send(&jogv1.OutputResponse{Data: data}); err != nil {
+			return err
+		}
+	}
+	var sent int
+	for _, chunk := range f.jobOutput(req.JobId) {
+		data := chunk
+		switch req.GetEncoding() {
+		case jogv1.Encoding_ENCODING_BASE64:
+			data = []byte(base64.StdEncoding.EncodeToString(chunk))
+		case jogv1.Encoding_ENCODING_HEX:
+			data = []byte(hex.EncodeToString(chunk))
+		}
+		if err := srv.Send(&jogv1.OutputResponse{Data: &jogv1.OutputData{Data: data}}); err != nil {
+			return err
+		}
+		sent += len(chunk)
+	}
+	srv.SetTrailer(metadata.Pairs(sentBytesTrailer, strconv.Itoa(sent)))
+	return nil
+}
+
+// compressionRecorder is a stats.Handler that records the compression of incoming requests
+type compressionRecorder struct {
+	mu          sync.Mutex
+	compression []string
+}
+
+func (r *compressionRecorder) TagRPC(ctx context.Context, _ *stats.RPCTagInfo) context.Context {
+	return ctx
+}
+
+func (r *compressionRecorder) HandleRPC(_ context.Context, s stats.RPCStats) {
+	if h, ok := s.(*stats.InHeader); ok {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.compression = append(r.compression, h.Compression)
+	}
+}
+
+func (r *compressionRecorder) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+func (r *compressionRecorder) HandleConn(context.Context, stats.ConnStats) {}
+
+// newTestClient serves srv over an in-memory connection and returns a client connected to it
+func newTestClient(t *testing.T, srv jogv1.JobServiceServer, opts ...grpc.ServerOption) jogv1.JobServiceClient {
+	t.Helper()
+	lis := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer(opts...)
+	jogv1.RegisterJobServiceServer(server, srv)
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("connecting to test server: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return jogv1.NewJobServiceClient(conn)
+}
+
+func TestRunOutputCompressed(t *testing.T) {
+	t.Parallel()
+
+	// compressible and incompressible chunks
+	chunks := [][]byte{
+		bytes.Repeat([]byte("hello world\n"), 100),
+		{0x00, 0xff, 0x1f, 0x8b, 0x08},
+		[]byte("the end"),
+	}
+	var want []byte
+	for _, c := range chunks {
+		want = append(want, c...)
+	}
+
+	tests := []struct {
+		name            string
+		compress        bool
+		wantCompression string
+	}{
+		{name: "uncompressed", compress: false, wantCompression: ""},
+		{name: "compressed", compress: true, wantCompression: "gzip"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			recorder := &compressionRecorder{}
+			client := newTestClient(t, &fakeJobServer{output: chunks}, grpc.StatsHandler(recorder))
+
+			var stdout bytes.Buffer
+			// without the summary, the output request is the only request
+			cmd := &Command{SubCommand: Output, JobID: "123", Compress: tt.compress, NoSummary: true}
+			if err := Run(context.Background(), client, cmd, &stdout, io.Discard); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !bytes.Equal(stdout.Bytes(), want) {
+				t.Fatalf("output did not round trip: expected %d bytes, got %d bytes", len(want), stdout.Len())
+			}
+
+			recorder.mu.Lock()
+			defer recorder.mu.Unlock()
+			if len(recorder.compression) != 1 {
+				t.Fatalf("expected 1 request header, got %d", len(recorder.compression))
+			}
+			if recorder.compression[0] != tt.wantCompression {
+				t.Fatalf("expected request compression %q, got %q", tt.wantCompression, recorder.compression[0])
+			}
+		})
+	}
+}
+
+func TestRunOutputExitCode(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		exitCode bool
+		status   jogv1.Status
+		wantCode int
+	}{
+		{name: "completed job", exitCode: true, status: jogv1.Status_COMPLETED, wantCode: 0},
+		{name: "failed job", exitCode: true, status: jogv1.Status_FAILED, wantCode: 1},
+		{name: "killed job", exitCode: true, status: jogv1.Status_KILLED, wantCode: 3},
+		{name: "failed job without the flag", exitCode: false, status: jogv1.Status_FAILED, wantCode: 0},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			client := newTestClient(t, &fakeJobServer{output: [][]byte{[]byte("output\n")}, status: tt.status})
+
+			var stdout bytes.Buffer
+			cmd := &Command{SubCommand: Output, JobID: "123", ExitCode: tt.exitCode}
+			err := Run(context.Background(), client, cmd, &stdout, io.Discard)
+			if stdout.String() != "output\n" {
+				t.Fatalf("expected the output to be printed, got %q", stdout.String())
+			}
+			if tt.wantCode == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			var exitErr *ExitError
+			if !errors.As(err, &exitErr) {
+				t.Fatalf("expected an *ExitError, got %v", err)
+			}
+			if code := exitErr.Code(); code != tt.wantCode {
+				t.Fatalf("expected exit code %d, got %d", tt.wantCode, code)
+			}
+		})
+	}
+}
+
+func TestRunOutputSaveJSON(t *testing.T) {
+	t.Parallel()
+
+	chunks := [][]byte{[]byte("hello\n"), []byte("world\n")}
+	client := newTestClient(t, &fakeJobServer{output: chunks})
+
+	path := filepath.Join(t.TempDir(), "out.ndjson")
+	var stdout bytes.Buffer
+	cmd := &Command{SubCommand: Output, JobID: "123", SavePath: path, JSON: true}
+	if err := Run(context.Background(), client, cmd, &stdout, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stdout.String() != "hello\nworld\n" {
+		t.Fatalf("expected plain text on stdout, got %q", stdout.String())
+	}
+
+	saved, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading save file: %v", err)
+	}
+	want := `{"offset":0,"data":"hello\n"}` + "\n" + `{"offset":6,"data":"world\n"}` + "\n"
+	if string(saved) != want {
+		t.Fatalf("expected NDJSON in the save file\nwant: %q\ngot:  %q", want, saved)
+	}
+}
+
+func TestRunOutputSaveGzip(t *testing.T) {
+	t.Parallel()
+
+	chunks := [][]byte{[]byte("hello\n"), []byte("world\n")}
+	client := newTestClient(t, &fakeJobServer{output: chunks})
+
+	path := filepath.Join(t.TempDir(), "out.gz")
+	var stdout bytes.Buffer
+	cmd := &Command{SubCommand: Output, JobID: "123", SavePath: path, Gzip: true}
+	if err := Run(context.Background(), client, cmd, &stdout, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stdout.String() != "hello\nworld\n" {
+		t.Fatalf("expected plain text on stdout, got %q", stdout.String())
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("opening save file: %v", err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("reading gzip header: %v", err)
+	}
+	saved, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatalf("decompressing save file: %v", err)
+	}
+	if string(saved) != "hello\nworld\n" {
+		t.Fatalf("expected the output in the save file, got %q", saved)
+	}
+}
+
+func TestGzipWriterFlush(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	now := time.Unix(0, 0)
+	w := newGzipWriter(&buf, time.Second)
+	w.now = func() time.Time { return now }
+	w.lastFlush = now
+
+	if _, err := w.Write([]byte("hello\n")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	now = now.Add(time.Second)
+	if _, err := w.Write([]byte("world\n")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// not flushed, the last flush was less than a second ago
+	if _, err := w.Write([]byte("lost\n")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the file isn't closed, as if jog was killed, but it decompresses up to the last flush
+	gz, err := gzip.NewReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("reading gzip header: %v", err)
+	}
+	got, err := io.ReadAll(gz)
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("expected an unexpected EOF for the unclosed file, got %v", err)
+	}
+	if string(got) != "hello\nworld\n" {
+		t.Fatalf("expected the output up to the flush, got %q", got)
+	}
+}
+
+func TestRunOutputTagStreams(t *testing.T) {
+	t.Parallel()
+
+	stdout, stderr := jogv1.Stream_STDOUT, jogv1.Stream_STDERR
+	tagged := []*jogv1.OutputData{
+		{Stream: stdout, Data: []byte("line one\nline tw")},
+		// stderr interrupts stdout's partial line
+		{Stream: stderr, Data: []byte("warning: ")},
+		{Stream: stderr, Data: []byte("disk full\n")},
+		{Stream: stdout, Data: []byte("o\n")},
+		{Stream: stderr, Data: []byte("a\nb\nno newline")},
+		{Stream: stdout, Data: []byte("unfinished")},
+	}
+
+	tests := []struct {
+		name       string
+		tagStreams bool
+		want       string
+	}{
+		{
+			name:       "tagged",
+			tagStreams: true,
+			want: "O> line one\n" +
+				"E> warning: disk full\n" +
+				"O> line two\n" +
+				"E> a\n" +
+				"E> b\n" +
+				"E> no newline\n" +
+				"O> unfinished\n",
+		},
+		{
+			name: "interleaved by default",
+			want: "line one\nline twwarning: disk full\no\na\nb\nno newlineunfinished",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			client := newTestClient(t, &fakeJobServer{tagged: tagged})
+			var out bytes.Buffer
+			cmd := &Command{SubCommand: Output, JobID: "123", TagStreams: tt.tagStreams}
+			if err := Run(context.Background(), client, cmd, &out, io.Discard); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if out.String() != tt.want {
+				t.Fatalf("expected:\n%q\ngot:\n%q", tt.want, out.String())
+			}
+		})
+	}
+}
+
+func TestRunOutputStreamSelector(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		cmd  *Command
+		want jogv1.StreamSelector
+	}{
+		{name: "default", cmd: &Command{SubCommand: Output, JobID: "123"}, want: jogv1.StreamSelector_STREAM_SELECTOR_UNSPECIFIED},
+		{name: "tag streams", cmd: &Command{SubCommand: Output, JobID: "123", TagStreams: true}, want: jogv1.StreamSelector_STREAM_SELECTOR_MERGED},
+		{name: "stderr", cmd: &Command{SubCommand: Output, JobID: "123", Stderr: true}, want: jogv1.StreamSelector_STREAM_SELECTOR_STDERR},
+		{name: "stderr with tags", cmd: &Command{SubCommand: Output, JobID: "123", Stderr: true, TagStreams: true}, want: jogv1.StreamSelector_STREAM_SELECTOR_STDERR},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			server := &fakeJobServer{output: [][]byte{[]byte("hello\n")}}
+			client := newTestClient(t, server)
+			if err := Run(context.Background(), client, tt.cmd, io.Discard, io.Discard); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := server.outputReq.GetStream(); got != tt.want {
+				t.Fatalf("expected stream %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRunOutputMaxBytes(t *testing.T) {
+	t.Parallel()
+
+	server := &fakeJobServer{output: [][]byte{[]byte("hello\n"), []byte("world\n")}}
+	client := newTestClient(t, server)
+	var stdout bytes.Buffer
+	cmd := &Command{SubCommand: Output, JobID: "123", MaxBytes: 64 * 1024}
+	if err := Run(context.Background(), client, cmd, &stdout, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := server.outputReq.GetMaxBytes(); got != 64*1024 {
+		t.Fatalf("expected max bytes %d in the request, got %d", 64*1024, got)
+	}
+	if stdout.String() != "hello\nworld\n" {
+		t.Fatalf("expected %q, got %q", "hello\nworld\n", stdout.String())
+	}
+}
+
+func TestRunOutputRaw(t *testing.T) {
+	t.Parallel()
+
+	// every byte value, including NUL, invalid UTF-8, and ANSI escape bytes
+	binary := make([]byte, 256)
+	for i := range binary {
+		binary[i] = byte(i)
+	}
+	server := &fakeJobServer{output: [][]byte{binary[:100], binary[100:], {0xff, 0xfe, '\r', '\n'}}}
+	client := newTestClient(t, server)
+	var stdout bytes.Buffer
+	cmd := &Command{SubCommand: Output, JobID: "123", Raw: true}
+	if err := Run(context.Background(), client, cmd, &stdout, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := append(append([]byte(nil), binary...), 0xff, 0xfe, '\r', '\n')
+	if !bytes.Equal(stdout.Bytes(), want) {
+		t.Fatalf("expected the output to be unchanged, got %q", stdout.Bytes())
+	}
+}
+
+func TestRunOutputSummary(t *testing.T) {
+	t.Parallel()
+
+	started := time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)
+	events := []*jogv1.Event{
+		{TimeUnixNano: started.UnixNano(), Type: "started", Detail: "pid 42"},
+		{TimeUnixNano: started.Add(3200 * time.Millisecond).UnixNano(), Type: "exited", Detail: "COMPLETED"},
+	}
+
+	tests := []struct {
+		name       string
+		status     jogv1.Status
+		noSummary  bool
+		maxBytes   int64
+		wantStderr string
+	}{
+		{
+			name:       "completed job",
+			status:     jogv1.Status_COMPLETED,
+			wantStderr: "--- job 123 completed in 3.2s, exit 0, 12 B output ---\n",
+		},
+		{
+			name:      "no summary",
+			status:    jogv1.Status_COMPLETED,
+			noSummary: true,
+		},
+		{
+			name:   "job still running",
+			status: jogv1.Status_RUNNING,
+		},
+		{
+			name:       "output cut short by max bytes",
+			status:     jogv1.Status_COMPLETED,
+			maxBytes:   6,
+			wantStderr: "jog: output stopped at the --max-bytes cap of 6 bytes\n",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			server := &fakeJobServer{
+				output: [][]byte{[]byte("hello\n"), []byte("world\n")},
+				status: tt.status,
+				events: events,
+			}
+			client := newTestClient(t, server)
+			var stdout, stderr bytes.Buffer
+			cmd := &Command{SubCommand: Output, JobID: "123", NoSummary: tt.noSummary, MaxBytes: tt.maxBytes}
+			if err := Run(context.Background(), client, cmd, &stdout, &stderr); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if stderr.String() != tt.wantStderr {
+				t.Fatalf("expected stderr %q, got %q", tt.wantStderr, stderr.String())
+			}
+			// the summary never goes to stdout, so the output can be piped
+			if strings.Contains(stdout.String(), "---") {
+				t.Fatalf("expected only output on stdout, got %q", stdout.String())
+			}
+		})
+	}
+}
+
+// resumingOutputServer serves a job that's pending for its first status request, and whose
+// first output stream ends partway through while the job is running, like a server that's
+// restarting. Later streams send the rest of the output from the request's offset, and the
+// job is done once the output has all been sent.
+type resumingOutputServer struct {
+	jogv1.UnimplementedJobServiceServer
+	output []byte
+	mu     sync.Mutex
+	// statuses counts the status requests, offsets are the offsets of the output requests
+	statuses int
+	offsets  []int64
+}
+
+func (f *resumingOutputServer) Status(context.Context, *jogv1.StatusRequest) (*jogv1.StatusResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.statuses++
+	switch {
+	case f.statuses == 1:
+		return &jogv1.StatusResponse{Status: jogv1.Status_PENDING, ExitCode: -1}, nil
+	case len(f.offsets) < 3:
+		return &jogv1.StatusResponse{Status: jogv1.Status_RUNNING, ExitCode: -1}, nil
+	default:
+		return &jogv1.StatusResponse{Status: jogv1.Status_COMPLETED, OutputBytes: int64(len(f.output))}, nil
+	}
+}
+
+func (f *resumingOutputServer) Output(req *jogv1.OutputRequest, srv jogv1.JobService_OutputServer) error {
+	f.mu.Lock()
+	f.offsets = append(f.offsets, req.Offset)
+	n := len(f.offsets)
+	f.mu.Unlock()
+	switch n {
+	case 1:
+		return status.Error(codes.Unknown, "streaming output: job not found")
+	case 2:
+		return srv.Send(&jogv1.OutputResponse{Data: &jogv1.OutputData{Data: f.output[:6]}})
+	default:
+		return srv.Send(&jogv1.OutputResponse{Data: &jogv1.OutputData{Data: f.output[req.Offset:]}})
+	}
+}
+
+func TestRunOutputFollow(t *testing.T) {
+	t.Parallel()
+
+	server := &resumingOutputServer{output: []byte("hello\nworld\n")}
+	client := newTestClient(t, server)
+
+	var stdout, stderr bytes.Buffer
+	cmd := &Command{SubCommand: Output, JobID: "123", Follow: true, ExitCode: true}
+	if err := Run(context.Background(), client, cmd, &stdout, &stderr); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stdout.String() != "hello\nworld\n" {
+		t.Fatalf("expected all of the output once, got %q", stdout.String())
+	}
+	// the pending job's stream was retried, then reopened where the first one ended
+	if !slices.Equal(server.offsets, []int64{0, 0, 6}) {
+		t.Fatalf("expected output requests at offsets 0, 0, and 6, got %v", server.offsets)
+	}
+	if !strings.Contains(stderr.String(), "completed") {
+		t.Fatalf("expected a summary of the done job, got %q", stderr.String())
+	}
+
+	// without --follow, the output stops when the stream ends
+	server = &resumingOutputServer{output: []byte("hello\nworld\n"), statuses: 1, offsets: []int64{0}}
+	client = newTestClient(t, server)
+	stdout.Reset()
+	cmd = &Command{SubCommand: Output, JobID: "123", NoSummary: true}
+	if err := Run(context.Background(), client, cmd, &stdout, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stdout.String() != "hello\n" {
+		t.Fatalf("expected the output of the first stream, got %q", stdout.String())
+	}
+}
+
+func TestRunOutputChunking(t *testing.T) {
+	t.Parallel()
+
+	server := &fakeJobServer{output: [][]byte{[]byte("hello\n")}}
+	client := newTestClient(t, server)
+	cmd := &Command{SubCommand: Output, JobID: "123", Chunking: "size", ChunkSize: 16 * 1024}
+	if err := Run(context.Background(), client, cmd, &bytes.Buffer{}, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := server.outputReq.GetChunking(); got != jogv1.Chunking_CHUNKING_SIZE {
+		t.Fatalf("expected chunking %v in the request, got %v", jogv1.Chunking_CHUNKING_SIZE, got)
+	}
+	if got := server.outputReq.GetChunkSize(); got != 16*1024 {
+		t.Fatalf("expected chunk size %d in the request, got %d", 16*1024, got)
+	}
+}
+
+func TestSentBytes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		trailer metadata.MD
+		want    int64
+	}{
+		{trailer: metadata.Pairs(sentBytesTrailer, "1024"), want: 1024},
+		{trailer: metadata.Pairs(sentBytesTrailer, "0"), want: 0},
+		{trailer: nil, want: -1},
+		{trailer: metadata.Pairs(sentBytesTrailer, "lots"), want: -1},
+		{trailer: metadata.Pairs(sentBytesTrailer, "1", sentBytesTrailer, "2"), want: -1},
+	}
+
+	for _, tt := range tests {
+		if got := sentBytes(tt.trailer); got != tt.want {
+			t.Fatalf("sentBytes(%v): expected %d, got %d", tt.trailer, tt.want, got)
+		}
+	}
+}
+
+func TestRunOutputReverse(t *testing.T) {
+	t.Parallel()
+
+	// lines are split across chunks and the last line has no newline
+	client := newTestClient(t, &fakeJobServer{output: [][]byte{
+		[]byte("one\ntw"),
+		[]byte("o\n\nthr"),
+		[]byte("ee\nfour"),
+	}})
+	var stdout bytes.Buffer
+	cmd := &Command{SubCommand: Output, JobID: "123", Reverse: true}
+	if err := Run(context.Background(), client, cmd, &stdout, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "four\nthree\n\ntwo\none\n"
+	if stdout.String() != want {
+		t.Fatalf("expected %q, got %q", want, stdout.String())
+	}
+}
+
+func TestRunOutputNumber(t *testing.T) {
+	t.Parallel()
+
+	// lines are split across chunks and the last line has no newline
+	client := newTestClient(t, &fakeJobServer{output: [][]byte{
+		[]byte("one\ntw"),
+		[]byte("o\n\nthr"),
+		[]byte("ee\nfour"),
+	}})
+
+	tests := []struct {
+		name    string
+		reverse bool
+		want    string
+	}{
+		{
+			name: "in order",
+			want: "     1\tone\n     2\ttwo\n     3\t\n     4\tthree\n     5\tfour",
+		},
+		{
+			name:    "reversed lines keep their numbers",
+			reverse: true,
+			want:    "     5\tfour\n     4\tthree\n     3\t\n     2\ttwo\n     1\tone\n",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var stdout bytes.Buffer
+			cmd := &Command{SubCommand: Output, JobID: "123", Number: true, Reverse: tt.reverse}
+			if err := Run(context.Background(), client, cmd, &stdout, io.Discard); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if stdout.String() != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, stdout.String())
+			}
+		})
+	}
+}
+
+func TestRunOutputEncoding(t *testing.T) {
+	t.Parallel()
+
+	// binary output, with a multi-byte rune split across chunks
+	output := [][]byte{{0x00, 0xff, 0x1b, '\n'}, []byte("caf\xc3"), []byte("\xa9\r\n"), {0x80}}
+	want := string(bytes.Join(output, nil))
+
+	for _, encoding := range []string{"", "raw", "base64", "hex"} {
+		encoding := encoding
+		t.Run(encoding, func(t *testing.T) {
+			t.Parallel()
+
+			server := &fakeJobServer{output: output}
+			client := newTestClient(t, server)
+			var stdout bytes.Buffer
+			cmd := &Command{SubCommand: Output, JobID: "123", Raw: true, Encoding: encoding}
+			if err := Run(context.Background(), client, cmd, &stdout, io.Discard); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := server.outputReq.GetEncoding(); got != encodings[encoding] {
+				t.Fatalf("expected encoding %v, got %v", encodings[encoding], got)
+			}
+			if stdout.String() != want {
+				t.Fatalf("expected %q, got %q", want, stdout.String())
+			}
+		})
+	}
+}
+
+func TestLineReverserLimit(t *testing.T) {
+	t.Parallel()
+
+	var out, warn bytes.Buffer
+	r := newLineReverser(&out, &warn, 10)
+	for _, chunk := range []string{"first line\n", "second\n", "3rd\n", "4th\n"} {
+		if _, err := r.Write([]byte(chunk)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := r.flush(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// the last 10 bytes are "d\n3rd\n4th\n", the partial first line is skipped
+	want := "4th\n3rd\n"
+	if out.String() != want {
+		t.Fatalf("expected %q, got %q", want, out.String())
+	}
+	if !strings.Contains(warn.String(), "only the last 10 bytes") {
+		t.Fatalf("expected a warning, got %q", warn.String())
+	}
+}
+
+func TestRunStart(t *testing.T) {
+	t.Parallel()
+
+	startedAt := time.Date(2024, 7, 1, 12, 30, 0, 500, time.UTC)
+	server := &fakeJobServer{startResp: &jogv1.StartResponse{
+		JobId:             "123",
+		Status:            jogv1.Status_RUNNING,
+		StartedAtUnixNano: startedAt.UnixNano(),
+		CommandPath:       "/usr/bin/make",
+	}}
+	client := newTestClient(t, server)
+
+	tests := []struct {
+		name  string
+		quiet bool
+		want  string
+	}{
+		{
+			name: "details",
+			want: "job started: 123\nstatus: RUNNING\nstarted at: 2024-07-01T12:30:00.0000005Z\ncommand: /usr/bin/make\n",
+		},
+		{
+			name:  "quiet",
+			quiet: true,
+			want:  "123\n",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var stdout bytes.Buffer
+			cmd := &Command{SubCommand: Start, RemoteCommand: "make", Quiet: tt.quiet}
+			if err := Run(context.Background(), client, cmd, &stdout, io.Discard); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if stdout.String() != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, stdout.String())
+			}
+		})
+	}
+}
+
+func TestRunStartSpec(t *testing.T) {
+	t.Parallel()
+
+	server := &fakeJobServer{}
+	client := newTestClient(t, server)
+	cmd := &Command{
+		SubCommand:    Start,
+		RemoteCommand: "make",
+		RemoteArgs:    []string{"-j", "4", "all"},
+		TTY:           true,
+		Shell:         true,
+		Name:          "build",
+		Capture:       "stdout",
+		MaxOutput:     1 << 20,
+		Umask:         "027",
+		NoFile:        4096,
+		NProc:         512,
+	}
+	if err := Run(context.Background(), client, cmd, io.Discard, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := &jogv1.StartRequest{Spec: &jogv1.JobSpec{
+		Cmd:              "make",
+		Args:             []string{"-j", "4", "all"},
+		Tty:              true,
+		Shell:            true,
+		Name:             "build",
+		Capture:          jogv1.Capture_CAPTURE_STDOUT,
+		OutputLimitBytes: 1 << 20,
+		Umask:            "027",
+		RlimitNofile:     4096,
+		RlimitNproc:      512,
+	}}
+	if !proto.Equal(server.started, want) {
+		t.Fatalf("expected start request %v, got %v", want, server.started)
+	}
+}
+
+func TestRunStartCapture(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		capture string
+		want    jogv1.Capture
+	}{
+		{capture: "", want: jogv1.Capture_CAPTURE_UNSPECIFIED},
+		{capture: "both", want: jogv1.Capture_CAPTURE_BOTH},
+		{capture: "stdout", want: jogv1.Capture_CAPTURE_STDOUT},
+		{capture: "stderr", want: jogv1.Capture_CAPTURE_STDERR},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.capture, func(t *testing.T) {
+			t.Parallel()
+
+			server := &fakeJobServer{}
+			client := newTestClient(t, server)
+			var stdout bytes.Buffer
+			cmd := &Command{SubCommand: Start, RemoteCommand: "make", Capture: tt.capture}
+			if err := Run(context.Background(), client, cmd, &stdout, io.Discard); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := server.started.GetSpec().GetCapture(); got != tt.want {
+				t.Fatalf("expected capture %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRunStartFollow(t *testing.T) {
+	t.Parallel()
+
+	server := &fakeJobServer{
+		startResp: &jogv1.StartResponse{JobId: "456"},
+		outputs:   map[string][][]byte{"456": {[]byte("building\n"), []byte("done\n")}},
+		status:    jogv1.Status_FAILED,
+	}
+	client := newTestClient(t, server)
+
+	var stdout, stderr bytes.Buffer
+	cmd := &Command{SubCommand: Start, RemoteCommand: "make", Follow: true, ExitCode: true, NoSummary: true}
+	err := Run(context.Background(), client, cmd, &stdout, &stderr)
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code() != 1 {
+		t.Fatalf("expected exit code 1 for the failed job, got %v", err)
+	}
+	if got := server.started.GetSpec().GetCmd(); got != "make" {
+		t.Fatalf("expected the job to be started with make, got %q", got)
+	}
+	if got := server.outputReq.GetJobId(); got != "456" {
+		t.Fatalf("expected the started job's output, got job %q's", got)
+	}
+	if stdout.String() != "building\ndone\n" {
+		t.Fatalf("expected only the job's output on stdout, got %q", stdout.String())
+	}
+	if stderr.String() != "job started: 456\n" {
+		t.Fatalf("expected the job id on stderr, got %q", stderr.String())
+	}
+}
+
+func TestRunStartAttach(t *testing.T) {
+	t.Parallel()
+
+	server := &fakeJobServer{
+		startResp: &jogv1.StartResponse{JobId: "456"},
+		outputs:   map[string][][]byte{"456": {[]byte("building\n"), []byte("done\n")}},
+		status:    jogv1.Status_FAILED,
+	}
+	client := newTestClient(t, server)
+
+	var stdout, stderr bytes.Buffer
+	cmd := &Command{SubCommand: Start, RemoteCommand: "make", Attach: true, ExitCode: true, NoSummary: true, Chunking: "line"}
+	err := Run(context.Background(), client, cmd, &stdout, &stderr)
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code() != 1 {
+		t.Fatalf("expected exit code 1 for the failed job, got %v", err)
+	}
+	if got := server.attached.GetStart().GetSpec().GetCmd(); got != "make" {
+		t.Fatalf("expected the job to be started with make, got %q", got)
+	}
+	if got := server.attached.GetOutput().GetChunking(); got != jogv1.Chunking_CHUNKING_LINE {
+		t.Fatalf("expected the output options to be sent with the start, got chunking %v", got)
+	}
+	// the output comes with the start, it isn't asked for separately
+	if server.started != server.attached.GetStart() || server.outputReq != nil {
+		t.Fatalf("expected one start and stream request, got output request %v", server.outputReq)
+	}
+	if stdout.String() != "building\ndone\n" {
+		t.Fatalf("expected only the job's output on stdout, got %q", stdout.String())
+	}
+	if stderr.String() != "job started: 456\n" {
+		t.Fatalf("expected the job id on stderr, got %q", stderr.String())
+	}
+}
+
+// blockingOutputServer streams the output, then holds the stream open like a running job
+type blockingOutputServer struct {
+	*fakeJobServer
+}
+
+func (s blockingOutputServer) Output(req *jogv1.OutputRequest, srv jogv1.JobService_OutputServer) error {
+	for _, chunk := range s.jobOutput(req.JobId) {
+		if err := srv.Send(&jogv1.OutputResponse{Data: &jogv1.OutputData{Data: chunk}}); err != nil {
+			return err
+		}
+	}
+	<-srv.Context().Done()
+	return srv.Context().Err()
+}
+
+// signalWriter closes written after its first write
+type signalWriter struct {
+	bytes.Buffer
+	written chan struct{}
+	once    sync.Once
+}
+
+func (w *signalWriter) Write(p []byte) (int, error) {
+	n, err := w.Buffer.Write(p)
+	w.once.Do(func() { close(w.written) })
+	return n, err
+}
+
+func TestRunStartFollowDetach(t *testing.T) {
+	t.Parallel()
+
+	client := newTestClient(t, blockingOutputServer{&fakeJobServer{output: [][]byte{[]byte("building\n")}}})
+
+	// Ctrl-C cancels the context once the output is streaming
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stdout := &signalWriter{written: make(chan struct{})}
+	go func() {
+		<-stdout.written
+		cancel()
+	}()
+	var stderr bytes.Buffer
+	cmd := &Command{SubCommand: Start, RemoteCommand: "make", Follow: true}
+	if err := Run(ctx, client, cmd, stdout, &stderr); err != nil {
+		t.Fatalf("expected detaching to succeed, got %v", err)
+	}
+	if stdout.String() != "building\n" {
+		t.Fatalf("expected the output before detaching, got %q", stdout.String())
+	}
+	if !strings.Contains(stderr.String(), "stopped following job 123, it wasn't stopped") {
+		t.Fatalf("expected a note that the job is still running, got %q", stderr.String())
+	}
+}
+
+func TestRunExists(t *testing.T) {
+	t.Parallel()
+
+	client := newTestClient(t, &fakeJobServer{jobs: map[string]bool{"123": true}})
+
+	var stdout bytes.Buffer
+	if err := Run(context.Background(), client, &Command{SubCommand: Exists, JobID: "123"}, &stdout, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stdout.String() != "job exists: 123\n" {
+		t.Fatalf("unexpected output: %q", stdout.String())
+	}
+
+	stdout.Reset()
+	err := Run(context.Background(), client, &Command{SubCommand: Exists, JobID: "456"}, &stdout, io.Discard)
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code() != 1 {
+		t.Fatalf("expected an *ExitError with code 1, got %v", err)
+	}
+	if stdout.Len() != 0 {
+		t.Fatalf("expected no output for a missing job, got %q", stdout.String())
+	}
+}
+
+func TestRunUpdate(t *testing.T) {
+	t.Parallel()
+
+	server := &fakeJobServer{}
+	client := newTestClient(t, server)
+
+	var stdout bytes.Buffer
+	cmd := &Command{SubCommand: Update, JobID: "123", MemoryLimit: 8 << 30, CPULimit: 1.5}
+	if err := Run(context.Background(), client, cmd, &stdout, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stdout.String() != "job updated: 123\n" {
+		t.Fatalf("unexpected output: %q", stdout.String())
+	}
+	want := &jogv1.UpdateLimitsRequest{JobId: "123", MemoryMaxBytes: 8 << 30, Cpus: 1.5}
+	if !proto.Equal(server.updated, want) {
+		t.Fatalf("expected update request %v, got %v", want, server.updated)
+	}
+}
+
+func TestRunObserver(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		cmd     *Command
+		wantErr bool
+	}{
+		{
+			name:    "start",
+			cmd:     &Command{SubCommand: Start, RemoteCommand: "make", Observer: true},
+			wantErr: true,
+		},
+		{
+			name:    "stop",
+			cmd:     &Command{SubCommand: Stop, JobID: "123", Observer: true},
+			wantErr: true,
+		},
+		{
+			name: "status",
+			cmd:  &Command{SubCommand: Status, JobID: "123", Observer: true},
+		},
+		{
+			name: "output",
+			cmd:  &Command{SubCommand: Output, JobID: "123", Observer: true, NoSummary: true},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			server := &fakeJobServer{output: [][]byte{[]byte("hello\n")}, status: jogv1.Status_RUNNING}
+			client := newTestClient(t, server)
+			err := Run(context.Background(), client, tt.cmd, io.Discard, io.Discard)
+			if !tt.wantErr {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), "--observer") {
+				t.Fatalf("expected an observer error, got %v", err)
+			}
+			if server.started != nil {
+				t.Fatalf("expected no start request to be sent")
+			}
+		})
+	}
+}
+
+func TestRunEvents(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)
+	client := newTestClient(t, &fakeJobServer{events: []*jogv1.Event{
+		{TimeUnixNano: start.UnixNano(), Type: "started", Detail: "pid 42"},
+		{TimeUnixNano: start.Add(1500 * time.Millisecond).UnixNano(), Type: "exited", Detail: "COMPLETED"},
+	}})
+
+	var stdout bytes.Buffer
+	if err := Run(context.Background(), client, &Command{SubCommand: Events, JobID: "123"}, &stdout, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %q", stdout.String())
+	}
+	for i, want := range [][]string{
+		{"2024-07-01T12:00:00Z", "started", "pid 42"},
+		{"2024-07-01T12:00:01.5Z", "exited", "COMPLETED"},
+	} {
+		if got := strings.Fields(lines[i]); strings.Join(got, " ") != strings.Join(want, " ") {
+			t.Fatalf("line %d: expected %v, got %q", i, want, lines[i])
+		}
+	}
+}
+
+func TestRunWatch(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)
+	client := newTestClient(t, &fakeJobServer{events: []*jogv1.Event{
+		{TimeUnixNano: start.UnixNano(), Type: "started", Detail: "pid 42"},
+		{TimeUnixNano: start.Add(time.Second).UnixNano(), Type: "stop_requested", Detail: "SIGKILL"},
+		{TimeUnixNano: start.Add(1500 * time.Millisecond).UnixNano(), Type: "exited", Detail: "KILLED"},
+	}})
+
+	var stdout bytes.Buffer
+	if err := Run(context.Background(), client, &Command{SubCommand: Watch, JobID: "123"}, &stdout, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	want := [][]string{
+		{"2024-07-01T12:00:00Z", "started", "pid 42"},
+		{"2024-07-01T12:00:01Z", "stop_requested", "SIGKILL"},
+		{"2024-07-01T12:00:01.5Z", "exited", "KILLED"},
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("expected %d lines, got %q", len(want), stdout.String())
+	}
+	for i, w := range want {
+		if got := strings.Fields(lines[i]); strings.Join(got, " ") != strings.Join(w, " ") {
+			t.Fatalf("line %d: expected %v, got %q", i, w, lines[i])
+		}
+	}
+}
+
+func TestRunStatus(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		status   jogv1.Status
+		exitCode int32
+		want     string
+	}{
+		{name: "failed job", status: jogv1.Status_FAILED, exitCode: 2, want: "job status: FAILED (exit 2)\n"},
+		{name: "completed job", status: jogv1.Status_COMPLETED, exitCode: 0, want: "job status: COMPLETED (exit 0)\n"},
+		{name: "running job", status: jogv1.Status_RUNNING, exitCode: -1, want: "job status: RUNNING\n"},
+		{name: "killed job", status: jogv1.Status_KILLED, exitCode: -1, want: "job status: KILLED\n"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			client := newTestClient(t, &fakeJobServer{status: tt.status, exitCode: tt.exitCode})
+			var stdout bytes.Buffer
+			if err := Run(context.Background(), client, &Command{SubCommand: Status, JobID: "123"}, &stdout, io.Discard); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if first, _, _ := strings.Cut(stdout.String(), "output:"); first != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, stdout.String())
+			}
+		})
+	}
+}
+
+func TestRunStop(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		status jogv1.Status
+		want   string
+	}{
+		{name: "job exited", status: jogv1.Status_STOPPED, want: "job stopped: 123\n"},
+		{name: "job is still exiting", status: jogv1.Status_STOPPING, want: "job stopping: 123\n"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			client := newTestClient(t, &fakeJobServer{stopStatus: tt.status})
+			var stdout bytes.Buffer
+			cmd := &Command{SubCommand: Stop, JobID: "123", NoProgress: true}
+			if err := Run(context.Background(), client, cmd, &stdout, io.Discard); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if stdout.String() != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, stdout.String())
+			}
+		})
+	}
+}
+
+func TestRunList(t *testing.T) {
+	t.Parallel()
+
+	client := newTestClient(t, &fakeJobServer{list: []*jogv1.JobSummary{
+		{JobId: "1d3b", Cmd: "make", Args: []string{"test"}, Status: jogv1.Status_COMPLETED},
+		{JobId: "9f0a", Name: "nap", Cmd: "sleep", Args: []string{"10"}, Status: jogv1.Status_RUNNING},
+	}})
+
+	var stdout bytes.Buffer
+	if err := Run(context.Background(), client, &Command{SubCommand: List}, &stdout, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "JOB ID  STATUS     COMMAND\n" +
+		"1d3b    COMPLETED  make test\n" +
+		"9f0a    RUNNING    sleep 10 (nap)\n"
+	if stdout.String() != want {
+		t.Fatalf("expected:\n%s\ngot:\n%s", want, stdout.String())
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		n    int64
+		want string
+	}{
+		{n: 0, want: "0 B"},
+		{n: 1023, want: "1023 B"},
+		{n: 1024, want: "1.0 KiB"},
+		{n: 1536, want: "1.5 KiB"},
+		{n: 1258291, want: "1.2 MiB"},
+		{n: 5 * 1024 * 1024 * 1024, want: "5.0 GiB"},
+	}
+
+	for _, tt := range tests {
+		if got := formatBytes(tt.n); got != tt.want {
+			t.Fatalf("formatBytes(%d): expected %s, got %s", tt.n, tt.want, got)
+		}
+	}
+}
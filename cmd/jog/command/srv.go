@@ -0,0 +1,77 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// srvScheme marks a host as an SRV record name, e.g. srv://_jogger._tcp.example.com
+const srvScheme = "srv://"
+
+// SRVLookup looks up the SRV records for name, like net.Resolver.LookupSRV with an empty
+// service and proto
+type SRVLookup func(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+
+// ResolveSRV resolves a host given as srv://name to the host:port of one of the name's SRV
+// records, picked by priority and weight. The certificate is verified against the record's
+// target, see TLSServerName. Hosts without the srv:// scheme are returned as is.
+//
+// When the records can't be resolved, the configuration returned dials name itself, on the
+// default port, and the error says why, so callers can warn and carry on.
+func (c ClientConfig) ResolveSRV(ctx context.Context, lookup SRVLookup) (ClientConfig, error) {
+	return c.resolveSRV(ctx, lookup, rand.IntN)
+}
+
+// resolveSRV is ResolveSRV with the random source used to pick among records of the same
+// priority. intN returns a number in [0, n).
+func (c ClientConfig) resolveSRV(ctx context.Context, lookup SRVLookup, intN func(n int) int) (ClientConfig, error) {
+	name, ok := strings.CutPrefix(c.Host.Value, srvScheme)
+	if !ok {
+		return c, nil
+	}
+	_, records, err := lookup(ctx, "", "", name)
+	if err == nil && len(records) == 0 {
+		err = fmt.Errorf("no records")
+	}
+	if err != nil {
+		c.Host.Value = name
+		return c, fmt.Errorf("resolving SRV record %s: %w", name, err)
+	}
+	record := pickSRV(records, intN)
+	target := strings.TrimSuffix(record.Target, ".")
+	c.Host.Value = net.JoinHostPort(target, strconv.Itoa(int(record.Port)))
+	c.srvTarget = target
+	return c, nil
+}
+
+// pickSRV picks a record as RFC 2782 describes: one of those with the lowest priority, at
+// random in proportion to their weights. Records with a weight of 0 are only picked when
+// every record of the priority has a weight of 0.
+func pickSRV(records []*net.SRV, intN func(n int) int) *net.SRV {
+	var candidates []*net.SRV
+	total := 0
+	for _, r := range records {
+		switch {
+		case len(candidates) == 0 || r.Priority < candidates[0].Priority:
+			candidates, total = []*net.SRV{r}, int(r.Weight)
+		case r.Priority == candidates[0].Priority:
+			candidates = append(candidates, r)
+			total += int(r.Weight)
+		}
+	}
+	if total == 0 {
+		return candidates[intN(len(candidates))]
+	}
+	n := intN(total)
+	for _, r := range candidates {
+		if n < int(r.Weight) {
+			return r
+		}
+		n -= int(r.Weight)
+	}
+	return candidates[len(candidates)-1]
+}
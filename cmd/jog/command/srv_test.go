@@ -0,0 +1,123 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestResolveSRV(t *testing.T) {
+	t.Parallel()
+
+	records := []*net.SRV{
+		{Target: "backup.example.com.", Port: 50051, Priority: 20, Weight: 100},
+		{Target: "jogger1.example.com.", Port: 50051, Priority: 10, Weight: 10},
+		{Target: "jogger2.example.com.", Port: 50052, Priority: 10, Weight: 30},
+	}
+	tests := []struct {
+		name           string
+		host           string
+		authority      string
+		records        []*net.SRV
+		lookupErr      error
+		random         int
+		wantHost       string
+		wantServerName string
+		wantErr        bool
+	}{
+		{
+			name:           "hosts without the scheme aren't resolved",
+			host:           "jogger.example.com:50051",
+			wantHost:       "jogger.example.com:50051",
+			wantServerName: "jogger.example.com:50051",
+		},
+		{
+			name:           "lowest priority, first by weight",
+			host:           "srv://_jogger._tcp.example.com",
+			records:        records,
+			random:         9,
+			wantHost:       "jogger1.example.com:50051",
+			wantServerName: "jogger1.example.com",
+		},
+		{
+			name:           "lowest priority, second by weight",
+			host:           "srv://_jogger._tcp.example.com",
+			records:        records,
+			random:         10,
+			wantHost:       "jogger2.example.com:50052",
+			wantServerName: "jogger2.example.com",
+		},
+		{
+			name:           "records without weights are picked at random",
+			host:           "srv://_jogger._tcp.example.com",
+			records:        []*net.SRV{{Target: "a.example.com.", Port: 1}, {Target: "b.example.com.", Port: 2}},
+			random:         1,
+			wantHost:       "b.example.com:2",
+			wantServerName: "b.example.com",
+		},
+		{
+			name:           "authority takes precedence over the target",
+			host:           "srv://_jogger._tcp.example.com",
+			authority:      "jogger.example.com",
+			records:        records,
+			wantHost:       "jogger1.example.com:50051",
+			wantServerName: "jogger.example.com",
+		},
+		{
+			name:           "lookup failure falls back to the name",
+			host:           "srv://jogger.example.com",
+			lookupErr:      &net.DNSError{Err: "no such host", Name: "jogger.example.com", IsNotFound: true},
+			wantHost:       "jogger.example.com",
+			wantServerName: "jogger.example.com",
+			wantErr:        true,
+		},
+		{
+			name:           "no records falls back to the name",
+			host:           "srv://jogger.example.com",
+			wantHost:       "jogger.example.com",
+			wantServerName: "jogger.example.com",
+			wantErr:        true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var looked string
+			lookup := func(_ context.Context, service, proto, name string) (string, []*net.SRV, error) {
+				if service != "" || proto != "" {
+					t.Errorf("expected the name to be looked up as is, got service %q and proto %q", service, proto)
+				}
+				looked = name
+				return name, tt.records, tt.lookupErr
+			}
+			intN := func(n int) int {
+				if tt.random >= n {
+					t.Fatalf("random number %d out of range [0, %d)", tt.random, n)
+				}
+				return tt.random
+			}
+
+			cfg := ResolveConfig(&Command{Host: tt.host, Authority: tt.authority}, func(string) string { return "" })
+			cfg, err := cfg.resolveSRV(context.Background(), lookup, intN)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if tt.lookupErr != nil && !errors.Is(err, tt.lookupErr) {
+				t.Fatalf("expected the lookup error to be wrapped, got %v", err)
+			}
+			if name, ok := strings.CutPrefix(tt.host, srvScheme); ok && looked != name {
+				t.Fatalf("expected %s to be looked up, got %q", name, looked)
+			}
+			if cfg.Host.Value != tt.wantHost {
+				t.Fatalf("expected host %q, got %q", tt.wantHost, cfg.Host.Value)
+			}
+			if got := cfg.TLSServerName(); got != tt.wantServerName {
+				t.Fatalf("expected tls server name %q, got %q", tt.wantServerName, got)
+			}
+		})
+	}
+}
@@ -2,10 +2,9 @@ package main
 
 import (
 	"context"
-	"crypto/tls"
-	"crypto/x509"
 	"errors"
 	"fmt"
+	"net"
 	"os"
 	"os/signal"
 	"strings"
@@ -20,6 +19,11 @@ import (
 
 func main() {
 	if err := run(); err != nil {
+		var exitErr *command.ExitError
+		if errors.As(err, &exitErr) {
+			fmt.Fprintln(os.Stderr, exitErr)
+			os.Exit(exitErr.Code())
+		}
 		fmt.Printf("error: %s\n", err)
 	}
 }
@@ -41,58 +45,54 @@ func run() error {
 	// ===============================================================================
 	// Check for required environment variables
 
-	var caCertFile, userCertFile, userPrivateKeyFile string
-	var missingVars []string
-	if caCertFile = os.Getenv("JOGGER_CA_CERT_FILE"); caCertFile == "" {
-		missingVars = append(missingVars, "JOGGER_CA_CERT_FILE")
+	cfg := command.ResolveConfig(cmd, os.Getenv)
+	if cmd.SubCommand == command.Config {
+		fmt.Print(cfg.String())
+		return nil
 	}
-	if userCertFile = os.Getenv("JOGGER_USER_CERT_FILE"); userCertFile == "" {
-		missingVars = append(missingVars, "JOGGER_USER_CERT_FILE")
+	// srv:// hosts are resolved to one of their records, or dialed as is when that fails
+	cfg, err = cfg.ResolveSRV(context.Background(), net.DefaultResolver.LookupSRV)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %s: dialing %s instead\n", err, cfg.Host.Value)
 	}
-	if userPrivateKeyFile = os.Getenv("JOGGER_USER_KEY_FILE"); userPrivateKeyFile == "" {
-		missingVars = append(missingVars, "JOGGER_USER_KEY_FILE")
+
+	// doctor checks the configuration itself, so it runs before the checks below
+	if cmd.SubCommand == command.Doctor {
+		return command.RunDoctor(context.Background(), cfg, os.Stdout)
 	}
-	if len(missingVars) > 0 {
+
+	if missingVars := cfg.MissingCredentials(); len(missingVars) > 0 {
 		return fmt.Errorf("missing environment variables: \n\n\t%s\n\nfor more information see: jog --help", strings.Join(missingVars, "\n\t"))
 	}
 
-	var host string
-	if cmd.Host != "" {
-		host = cmd.Host
-	} else {
-		host = os.Getenv("JOGGER_HOST")
-	}
+	host := cfg.Host.Value
 	if host == "" {
 		return errors.New("no host provided: use -D --host or set the JOGGER_HOST environment variable")
 	}
+	// the flag is validated when it's parsed, this checks the environment variable
+	if cfg.Authority.Source == command.SourceEnv {
+		if err := command.ValidateAuthority(cfg.Authority.Value); err != nil {
+			return fmt.Errorf("invalid JOGGER_AUTHORITY: %s: %w", cfg.Authority.Value, err)
+		}
+	}
 
 	// ===============================================================================
 	// Setup mTLS configuration
 
-	userCert, err := tls.LoadX509KeyPair(userCertFile, userPrivateKeyFile)
-	if err != nil {
-		return fmt.Errorf("loading user key pair: %w", err)
-	}
-
-	certPool := x509.NewCertPool()
-	caCertBytes, err := os.ReadFile(caCertFile)
+	tlsConfig, err := cfg.TLSConfig()
 	if err != nil {
-		return fmt.Errorf("reading ca cert file: %w", err)
-	}
-	if ok := certPool.AppendCertsFromPEM(caCertBytes); !ok {
-		return fmt.Errorf("loading cert pool: failed to append ca cert")
-	}
-
-	tlsConfig := &tls.Config{
-		ServerName:   host,
-		Certificates: []tls.Certificate{userCert},
-		RootCAs:      certPool,
+		return err
 	}
 
 	// ===============================================================================
 	// Connect to the server
 
-	conn, err := grpc.NewClient(host, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
+	dialOptions, err := cfg.DialOptions()
+	if err != nil {
+		return err
+	}
+	dialOptions = append(dialOptions, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
+	conn, err := grpc.NewClient(cfg.Target(), dialOptions...)
 	if err != nil {
 		return fmt.Errorf("connecting to server: %w", err)
 	}
@@ -109,7 +109,7 @@ func run() error {
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		clientErr <- command.Run(ctx, client, cmd)
+		clientErr <- command.Run(ctx, client, cmd, os.Stdout, os.Stderr)
 	}()
 
 	// ===============================================================================
@@ -2,40 +2,201 @@ package api
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dustinevan/jogger/lib/cgroup"
 	"github.com/dustinevan/jogger/lib/job"
 	"go.uber.org/zap"
 
 	jogv1 "github.com/dustinevan/jogger/pkg/gen/jogger/v1"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
 )
 
 // Server is the implementation of the grpc JobServiceServer
 type Server struct {
 	jogv1.UnimplementedJobServiceServer
-	manager *job.Manager
-	log     *zap.SugaredLogger
+	manager  *job.Manager
+	log      *zap.SugaredLogger
+	redactor logRedactor
+	// admins are the users that source addresses are reported to, nil if there are none
+	admins *Admins
+}
+
+type ServerOption func(*Server)
+
+// LogRedaction controls how job requests are logged: which fields are masked, and how many
+// args are logged before the list is truncated
+type LogRedaction struct {
+	// MaskedFields lists the job fields to mask in logs: cmd and/or args
+	MaskedFields []string
+	// MaxArgs is the number of args logged before the list is truncated. 0 means no limit.
+	MaxArgs int
+}
+
+// WithLogRedaction sets how job requests are logged
+func WithLogRedaction(r LogRedaction) ServerOption {
+	return func(s *Server) {
+		s.redactor = newLogRedactor(r)
+	}
+}
+
+// WithAdmins sets the admins that job source addresses are reported to in Status
+func WithAdmins(admins *Admins) ServerOption {
+	return func(s *Server) {
+		s.admins = admins
+	}
 }
 
-func NewServer(manager *job.Manager, log *zap.SugaredLogger) *Server {
-	return &Server{manager: manager, log: log}
+func NewServer(manager *job.Manager, log *zap.SugaredLogger, options ...ServerOption) *Server {
+	s := &Server{manager: manager, log: log, redactor: newLogRedactor(LogRedaction{})}
+	for _, opt := range options {
+		opt(s)
+	}
+	return s
 }
 
 // Start starts a new job
 func (s Server) Start(ctx context.Context, req *jogv1.StartRequest) (*jogv1.StartResponse, error) {
-	s.log.Infow("starting job", "cmd", req.Job.GetCmd(), "args", req.Job.GetArgs())
+	resp, _, err := s.start(ctx, req)
+	return resp, err
+}
+
+// start starts a new job, and returns the response and the username of the user who started it
+func (s Server) start(ctx context.Context, req *jogv1.StartRequest) (*jogv1.StartResponse, string, error) {
+	spec, err := startSpec(req)
+	if err != nil {
+		return nil, "", err
+	}
+	s.log.Infow("starting job", s.redactor.jobFields(spec)...)
 	username, err := CommonNameFromContext(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("starting job: %w", err)
+		return nil, "", fmt.Errorf("starting job: %w", err)
 	}
 	//
-	jobID, err := s.manager.Start(ctx, username, req.Job.GetCmd(), req.Job.GetArgs()...)
+	if spec.GetOutputLimitBytes() < 0 {
+		return nil, "", status.Error(codes.InvalidArgument, "starting job: output_limit_bytes must not be negative")
+	}
+	sourceAddr := SourceAddrFromContext(ctx)
+	options := []job.JobOption{
+		job.WithMaxOutputBytes(spec.GetOutputLimitBytes()),
+		job.WithSourceAddr(sourceAddr),
+	}
+	if spec.GetTty() {
+		options = append(options, job.WithTTY())
+	}
+	if spec.GetName() != "" {
+		options = append(options, job.WithName(spec.GetName()))
+	}
+	if spec.GetShell() {
+		options = append(options, job.WithShell())
+	}
+	if spec.GetUmask() != "" {
+		mask, err := strconv.ParseUint(spec.GetUmask(), 8, 32)
+		if err != nil {
+			return nil, "", status.Error(codes.InvalidArgument, fmt.Sprintf("starting job: invalid umask: %q: use octal, e.g. 022", spec.GetUmask()))
+		}
+		options = append(options, job.WithUmask(int(mask)))
+	}
+	if n := spec.GetRlimitNofile(); n > 0 {
+		options = append(options, job.WithRlimit(job.RlimitNoFile, n))
+	}
+	if n := spec.GetRlimitNproc(); n > 0 {
+		options = append(options, job.WithRlimit(job.RlimitNProc, n))
+	}
+	if len(spec.GetRedact()) > 0 || len(spec.GetRedactPatterns()) > 0 {
+		options = append(options, job.WithOutputRedaction(spec.GetRedact(), spec.GetRedactPatterns()))
+	}
+	if spec.GetAfterJobId() != "" {
+		options = append(options, job.WithAfter(spec.GetAfterJobId(), spec.GetAfterCompleted()))
+	} else if spec.GetAfterCompleted() {
+		return nil, "", status.Error(codes.InvalidArgument, "starting job: after_completed needs after_job_id")
+	}
+	switch spec.GetCapture() {
+	case jogv1.Capture_CAPTURE_UNSPECIFIED:
+		// the manager's default is used
+	case jogv1.Capture_CAPTURE_BOTH:
+		options = append(options, job.WithCapture(job.CaptureBoth))
+	case jogv1.Capture_CAPTURE_STDOUT:
+		options = append(options, job.WithCapture(job.CaptureStdout))
+	case jogv1.Capture_CAPTURE_STDERR:
+		options = append(options, job.WithCapture(job.CaptureStderr))
+	default:
+		return nil, "", status.Error(codes.InvalidArgument, fmt.Sprintf("starting job: unsupported capture: %v", spec.GetCapture()))
+	}
+	jobID, err := s.manager.Start(ctx, username, spec.GetCmd(), spec.GetArgs(), options...)
 	if err != nil {
-		return nil, fmt.Errorf("starting job: %w", err)
+		if errors.Is(err, job.ErrInvalidCommand) || errors.Is(err, job.ErrInvalidName) || errors.Is(err, job.ErrInvalidProcessLimit) ||
+			errors.Is(err, job.ErrInvalidRedaction) {
+			return nil, "", status.Error(codes.InvalidArgument, fmt.Sprintf("starting job: %s", err))
+		}
+		if errors.Is(err, job.ErrShellNotAllowed) {
+			return nil, "", status.Error(codes.PermissionDenied, fmt.Sprintf("starting job: %s", err))
+		}
+		if errors.Is(err, job.ErrNameInUse) {
+			return nil, "", status.Error(codes.AlreadyExists, fmt.Sprintf("starting job: %s", err))
+		}
+		if errors.Is(err, job.ErrJobNotFound) {
+			// the job to start it after doesn't exist
+			return nil, "", status.Error(codes.NotFound, fmt.Sprintf("starting job: %s", err))
+		}
+		if errors.Is(err, job.ErrTooManyJobs) || errors.Is(err, job.ErrHostOverloaded) {
+			return nil, "", status.Error(codes.ResourceExhausted, fmt.Sprintf("starting job: %s", err))
+		}
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			// the client canceled the request or its deadline passed before the job was launched
+			return nil, "", status.FromContextError(err).Err()
+		}
+		if jobID != "" {
+			// the failed start was recorded, its status can be looked up by the job id
+			return nil, "", fmt.Errorf("starting job %s: %w", jobID, err)
+		}
+		return nil, "", fmt.Errorf("starting job: %w", err)
+	}
+	resp := &jogv1.StartResponse{JobId: jobID, Status: jogv1.Status_RUNNING}
+	var startLatency time.Duration
+	// the job may already have been removed, e.g. a fast job evicted by the retained job limit,
+	// the job id is still returned then
+	if info, err := s.manager.Status(ctx, username, jobID); err == nil {
+		resp.Status = info.Status
+		// a pending job hasn't started
+		if !info.StartedAt.IsZero() {
+			resp.StartedAtUnixNano = info.StartedAt.UnixNano()
+		}
+		resp.CommandPath = info.CommandPath
+		startLatency = info.StartLatency
+	}
+	s.log.Infow("job started", "jobID", jobID, "name", spec.GetName(), "username", username, "sourceAddr", sourceAddr, "runningJobs", s.manager.RunningJobs(), "startLatency", startLatency)
+	return resp, username, nil
+}
+
+// startSpec returns the spec of the job to start. Requests from older clients give the job
+// with the job field and the other StartRequest fields, they're copied into a spec.
+func startSpec(req *jogv1.StartRequest) (*jogv1.JobSpec, error) {
+	if req.GetSpec() == nil {
+		return &jogv1.JobSpec{
+			Cmd:              req.GetJob().GetCmd(),
+			Args:             req.GetJob().GetArgs(),
+			Tty:              req.GetJob().GetTty(),
+			Shell:            req.GetJob().GetShell(),
+			Name:             req.GetName(),
+			Capture:          req.GetCapture(),
+			OutputLimitBytes: req.GetOutputLimitBytes(),
+		}, nil
+	}
+	if req.GetJob() != nil || req.GetName() != "" || req.GetCapture() != jogv1.Capture_CAPTURE_UNSPECIFIED || req.GetOutputLimitBytes() != 0 {
+		return nil, status.Error(codes.InvalidArgument, "starting job: spec can't be set with job, name, capture, or output_limit_bytes")
 	}
-	s.log.Infow("job started", "jobID", jobID, "username", username)
-	return &jogv1.StartResponse{JobId: jobID}, nil
+	return req.GetSpec(), nil
 }
 
 // Stop stops a job
@@ -45,12 +206,46 @@ func (s Server) Stop(ctx context.Context, req *jogv1.StopRequest) (*jogv1.StopRe
 	if err != nil {
 		return nil, fmt.Errorf("stopping job: %w", err)
 	}
-	err = s.manager.Stop(ctx, username, req.JobId)
+	if req.GetGracePeriodMs() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "stopping job: grace_period_ms must not be negative")
+	}
+	err = s.manager.Stop(ctx, username, req.JobId, job.WithGracePeriod(time.Duration(req.GetGracePeriodMs())*time.Millisecond))
 	if err != nil {
 		return nil, fmt.Errorf("stopping job: %w", err)
 	}
 	s.log.Infow("job stopped", "jobID", req.JobId, "username", username)
-	return &jogv1.StopResponse{}, nil
+	resp := &jogv1.StopResponse{}
+	if info, err := s.manager.Status(ctx, username, req.JobId); err == nil {
+		resp.Status = info.Status
+	}
+	return resp, nil
+}
+
+// UpdateLimits changes the resource limits of a running job
+func (s Server) UpdateLimits(ctx context.Context, req *jogv1.UpdateLimitsRequest) (*jogv1.UpdateLimitsResponse, error) {
+	s.log.Infow("updating job limits", "jobID", req.JobId, "memoryMaxBytes", req.GetMemoryMaxBytes(), "cpus", req.GetCpus())
+	username, err := CommonNameFromContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("updating job limits: %w", err)
+	}
+	if req.GetMemoryMaxBytes() < 0 || req.GetCpus() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "updating job limits: limits must not be negative")
+	}
+	if req.GetMemoryMaxBytes() == 0 && req.GetCpus() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "updating job limits: set memory_max_bytes or cpus")
+	}
+	limits := cgroup.Limits{MemoryMaxBytes: req.GetMemoryMaxBytes(), CPUs: req.GetCpus()}
+	if err := s.manager.UpdateLimits(ctx, username, req.JobId, limits); err != nil {
+		if errors.Is(err, cgroup.ErrLimitTooHigh) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		if errors.Is(err, cgroup.ErrBelowUsage) || errors.Is(err, job.ErrJobNotRunning) {
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		}
+		return nil, err
+	}
+	s.log.Infow("job limits updated", "jobID", req.JobId, "username", username)
+	return &jogv1.UpdateLimitsResponse{}, nil
 }
 
 // Status gets the status of a job
@@ -60,12 +255,117 @@ func (s Server) Status(ctx context.Context, req *jogv1.StatusRequest) (*jogv1.St
 	if err != nil {
 		return nil, fmt.Errorf("getting job status: %w", err)
 	}
-	status, err := s.manager.Status(ctx, username, req.JobId)
+	info, err := s.manager.Status(ctx, username, req.JobId)
 	if err != nil {
 		return nil, fmt.Errorf("getting job status: %w", err)
 	}
-	s.log.Infow("job status", "jobID", req.JobId, "status", status, "username", username)
-	return &jogv1.StatusResponse{Status: status}, nil
+	s.log.Infow("job status", "jobID", req.JobId, "status", info.Status, "username", username)
+	orphaned := make([]int32, 0, len(info.OrphanedPIDs))
+	for _, pid := range info.OrphanedPIDs {
+		orphaned = append(orphaned, int32(pid))
+	}
+	resp := &jogv1.StatusResponse{
+		Status:           info.Status,
+		OutputBytes:      info.OutputBytes,
+		OrphanedPids:     orphaned,
+		StartError:       info.StartError,
+		Name:             info.Name,
+		PeakMemoryBytes:  info.PeakMemoryBytes,
+		TotalCpuUsec:     info.TotalCPUUsec,
+		StartLatencyUsec: info.StartLatency.Microseconds(),
+		ExitCode:         int32(info.ExitCode),
+	}
+	if s.admins.Contains(username) {
+		resp.SourceAddr = info.SourceAddr
+	}
+	return resp, nil
+}
+
+// Exists reports whether the caller has a job with the job_id
+func (s Server) Exists(ctx context.Context, req *jogv1.ExistsRequest) (*jogv1.ExistsResponse, error) {
+	username, err := CommonNameFromContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("checking job exists: %w", err)
+	}
+	exists := s.manager.Exists(ctx, username, req.JobId)
+	s.log.Infow("job exists", "jobID", req.JobId, "exists", exists, "username", username)
+	return &jogv1.ExistsResponse{Exists: exists}, nil
+}
+
+// Events returns the lifecycle events of one of the caller's jobs
+func (s Server) Events(ctx context.Context, req *jogv1.EventsRequest) (*jogv1.EventsResponse, error) {
+	s.log.Infow("getting job events", "jobID", req.JobId)
+	username, err := CommonNameFromContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting job events: %w", err)
+	}
+	events, err := s.manager.Events(ctx, username, req.JobId)
+	if err != nil {
+		return nil, fmt.Errorf("getting job events: %w", err)
+	}
+	resp := &jogv1.EventsResponse{Events: make([]*jogv1.Event, 0, len(events))}
+	for _, e := range events {
+		resp.Events = append(resp.Events, eventMessage(e))
+	}
+	s.log.Infow("job events", "jobID", req.JobId, "events", len(events), "username", username)
+	return resp, nil
+}
+
+// ListJobs lists the caller's jobs
+func (s Server) ListJobs(ctx context.Context, req *jogv1.ListJobsRequest) (*jogv1.ListJobsResponse, error) {
+	s.log.Infow("listing jobs")
+	username, err := CommonNameFromContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing jobs: %w", err)
+	}
+	jobs := s.manager.List(ctx, username)
+	resp := &jogv1.ListJobsResponse{Jobs: make([]*jogv1.JobSummary, 0, len(jobs))}
+	for _, j := range jobs {
+		summary := &jogv1.JobSummary{
+			JobId:  j.JobID,
+			Name:   j.Name,
+			Cmd:    j.Command,
+			Args:   j.Args,
+			Status: j.Status,
+		}
+		if !j.StartedAt.IsZero() {
+			summary.StartedAtUnixNano = j.StartedAt.UnixNano()
+		}
+		resp.Jobs = append(resp.Jobs, summary)
+	}
+	s.log.Infow("jobs listed", "jobs", len(jobs), "username", username)
+	return resp, nil
+}
+
+// WatchEvents streams the lifecycle events of one of the caller's jobs as they happen
+func (s Server) WatchEvents(req *jogv1.EventsRequest, srv jogv1.JobService_WatchEventsServer) error {
+	s.log.Infow("watching job events", "jobID", req.JobId)
+	username, err := CommonNameFromContext(srv.Context())
+	if err != nil {
+		return fmt.Errorf("watching job events: %w", err)
+	}
+	events, err := s.manager.WatchEvents(srv.Context(), username, req.JobId)
+	if err != nil {
+		return fmt.Errorf("watching job events: %w", err)
+	}
+	sent := 0
+	for e := range events {
+		if err := srv.Send(eventMessage(e)); err != nil {
+			return fmt.Errorf("watching job events: %w", err)
+		}
+		sent++
+	}
+	s.log.Infow("job events watched", "jobID", req.JobId, "events", sent, "username", username)
+	return nil
+}
+
+// eventMessage converts a job event to its message
+func eventMessage(e job.Event) *jogv1.Event {
+	return &jogv1.Event{
+		TimeUnixNano: e.Time.UnixNano(),
+		Type:         string(e.Type),
+		Detail:       e.Detail,
+	}
 }
 
 // Output streams the output of a job
@@ -76,51 +376,264 @@ func (s Server) Output(req *jogv1.OutputRequest, srv jogv1.JobService_OutputServ
 		return fmt.Errorf("streaming output: %w", err)
 	}
 	defer s.log.Infow("streaming output complete", "jobID", req.JobId, "username", username)
+	streamOptions, encode, err := outputOptions(req)
+	if err != nil {
+		return err
+	}
 
-	stream, err := s.manager.OutputStream(srv.Context(), username, req.JobId)
+	stream, err := s.manager.OutputStream(srv.Context(), username, req.JobId, streamOptions...)
 	if err != nil {
-		return fmt.Errorf("streaming output: %w", err)
+		return outputStreamError(err)
+	}
+	s.log.Infow("output stream opened", "jobID", req.JobId, "username", username, "activeStreams", s.manager.ActiveStreams())
+
+	return sendOutput(srv, stream, req.GetMaxBytes(), encode)
+}
+
+// StartAndStream starts a job, then streams its output from the beginning. The output
+// request is checked before the job is started, so a bad one doesn't leave a job running.
+func (s Server) StartAndStream(req *jogv1.StartAndStreamRequest, srv jogv1.JobService_StartAndStreamServer) error {
+	if req.GetOutput().GetJobId() != "" || req.GetOutput().GetOffset() != 0 {
+		return status.Error(codes.InvalidArgument, "starting job: output job_id and offset can't be set, the output is the started job's")
+	}
+	// a pending job has no output to stream until it's started
+	if req.GetStart().GetSpec().GetAfterJobId() != "" {
+		return status.Error(codes.InvalidArgument, "starting job: after_job_id can't be used when streaming the job's output, use Start then Output")
+	}
+	streamOptions, encode, err := outputOptions(req.GetOutput())
+	if err != nil {
+		return err
+	}
+	resp, username, err := s.start(srv.Context(), req.GetStart())
+	if err != nil {
+		return err
+	}
+	if err := srv.Send(&jogv1.StartAndStreamResponse{Response: &jogv1.StartAndStreamResponse_Start{Start: resp}}); err != nil {
+		return fmt.Errorf("sending start response: %w", err)
+	}
+	defer s.log.Infow("streaming output complete", "jobID", resp.JobId, "username", username)
+
+	// the output is streamed from the beginning, so it has everything the job wrote since it started.
+	// It fails if the job was already removed, e.g. a fast job evicted by the retained job limit.
+	stream, err := s.manager.OutputStream(srv.Context(), username, resp.JobId, streamOptions...)
+	if err != nil {
+		return outputStreamError(err)
+	}
+	s.log.Infow("output stream opened", "jobID", resp.JobId, "username", username, "activeStreams", s.manager.ActiveStreams())
+
+	return sendOutput(startAndStreamOutput{srv}, stream, req.GetOutput().GetMaxBytes(), encode)
+}
+
+// startAndStreamOutput sends output on a StartAndStream stream, for sendOutput
+type startAndStreamOutput struct {
+	jogv1.JobService_StartAndStreamServer
+}
+
+func (s startAndStreamOutput) Send(resp *jogv1.OutputResponse) error {
+	return s.JobService_StartAndStreamServer.Send(&jogv1.StartAndStreamResponse{Response: &jogv1.StartAndStreamResponse_Data{Data: resp.GetData()}})
+}
+
+// outputOptions checks an output request, and returns the stream options and the encoder for it.
+// The errors have the codes.InvalidArgument gRPC status code.
+func outputOptions(req *jogv1.OutputRequest) ([]job.StreamOption, func([]byte) []byte, error) {
+	if req.GetMaxBytes() < 0 {
+		return nil, nil, status.Error(codes.InvalidArgument, "streaming output: max_bytes must not be negative")
+	}
+	if req.GetOffset() < 0 {
+		return nil, nil, status.Error(codes.InvalidArgument, "streaming output: offset must not be negative")
+	}
+	streamOptions, err := chunkOptions(req)
+	if err != nil {
+		return nil, nil, status.Error(codes.InvalidArgument, fmt.Sprintf("streaming output: %s", err))
+	}
+	switch req.GetStream() {
+	case jogv1.StreamSelector_STREAM_SELECTOR_UNSPECIFIED, jogv1.StreamSelector_STREAM_SELECTOR_BOTH,
+		jogv1.StreamSelector_STREAM_SELECTOR_STDOUT, jogv1.StreamSelector_STREAM_SELECTOR_STDERR,
+		jogv1.StreamSelector_STREAM_SELECTOR_MERGED:
+	default:
+		return nil, nil, status.Error(codes.InvalidArgument, fmt.Sprintf("streaming output: unknown stream: %s", req.GetStream()))
+	}
+	streamOptions = append(streamOptions, job.WithStartOffset(req.GetOffset()), job.WithSelector(req.GetStream()))
+	encode, err := outputEncoder(req.GetEncoding())
+	if err != nil {
+		return nil, nil, status.Error(codes.InvalidArgument, fmt.Sprintf("streaming output: %s", err))
 	}
+	return streamOptions, encode, nil
+}
+
+// outputStreamError returns the error for a job's output stream that couldn't be opened
+func outputStreamError(err error) error {
+	if errors.Is(err, job.ErrTooManyStreams) {
+		return status.Error(codes.ResourceExhausted, fmt.Sprintf("streaming output: %s", err))
+	}
+	if errors.Is(err, job.ErrOffsetOutOfRange) {
+		return status.Error(codes.InvalidArgument, fmt.Sprintf("streaming output: %s", err))
+	}
+	return fmt.Errorf("streaming output: %w", err)
+}
+
+// maxChunkSize is the largest chunk_size a client can ask for
+const maxChunkSize = 64 << 10
+
+// chunkOptions returns the stream options for the chunking the request asks for
+func chunkOptions(req *jogv1.OutputRequest) ([]job.StreamOption, error) {
+	var options []job.StreamOption
+	switch size := req.GetChunkSize(); {
+	case size < 0 || size > maxChunkSize:
+		return nil, fmt.Errorf("chunk_size must be between 0 and %d", maxChunkSize)
+	case size > 0:
+		options = append(options, job.WithChunkSize(int(size)))
+	}
+	switch req.GetChunking() {
+	case jogv1.Chunking_CHUNKING_UNSPECIFIED, jogv1.Chunking_CHUNKING_SIZE:
+	case jogv1.Chunking_CHUNKING_LINE:
+		options = append(options, job.WithLineChunks())
+	default:
+		return nil, fmt.Errorf("unknown chunking: %s", req.GetChunking())
+	}
+	return options, nil
+}
+
+// outputEncoder returns the function that encodes each chunk of output for an encoding, nil
+// when the chunks are sent as is
+func outputEncoder(e jogv1.Encoding) (func([]byte) []byte, error) {
+	switch e {
+	case jogv1.Encoding_ENCODING_UNSPECIFIED, jogv1.Encoding_ENCODING_RAW:
+		return nil, nil
+	case jogv1.Encoding_ENCODING_BASE64:
+		return func(b []byte) []byte { return base64.StdEncoding.AppendEncode(nil, b) }, nil
+	case jogv1.Encoding_ENCODING_HEX:
+		return func(b []byte) []byte { return hex.AppendEncode(nil, b) }, nil
+	default:
+		return nil, fmt.Errorf("unknown encoding: %s", e)
+	}
+}
+
+// SentBytesTrailer is the trailer the Output handler reports the number of bytes of output it sent in
+const SentBytesTrailer = "jogger-sent-bytes"
+
+// sendOutput sends the output from stream until it's closed, the client goes away, or
+// maxBytes have been sent, when maxBytes > 0. An error is returned if the stream ended
+// because the output couldn't be read. The chunk that reaches maxBytes is cut
+// short. The number of bytes sent is set in the SentBytesTrailer. Each chunk is encoded
+// with encode when it's set, maxBytes and the trailer count the bytes before encoding.
+func sendOutput(srv jogv1.JobService_OutputServer, stream <-chan job.Chunk, maxBytes int64, encode func([]byte) []byte) error {
+	var sent int64
+	defer func() {
+		srv.SetTrailer(metadata.Pairs(SentBytesTrailer, strconv.FormatInt(sent, 10)))
+	}()
 
 	// Instead of ranging over the channel, we loop here tp listen for context cancellation.
 	for {
 		select {
 		case <-srv.Context().Done():
 			return nil
-		case output, ok := <-stream:
+		case chunk, ok := <-stream:
 			if !ok {
 				// The stream has been closed
 				return nil
 			}
-			if err := srv.Send(&jogv1.OutputResponse{Data: &jogv1.OutputData{Data: output}}); err != nil {
+			if chunk.Err != nil {
+				return fmt.Errorf("streaming output: %w", chunk.Err)
+			}
+			output := chunk.Data
+			if maxBytes > 0 && sent+int64(len(output)) > maxBytes {
+				output = output[:maxBytes-sent]
+			}
+			data := output
+			if encode != nil {
+				data = encode(output)
+			}
+			if err := srv.Send(&jogv1.OutputResponse{Data: &jogv1.OutputData{Data: data, Stream: chunk.Stream}}); err != nil {
 				return fmt.Errorf("sending output chunk: %w", err)
 			}
+			sent += int64(len(output))
+			if maxBytes > 0 && sent >= maxBytes {
+				return nil
+			}
 		}
 	}
 }
 
+// maskedValue replaces masked values in logs
+const maskedValue = "***"
+
+// logRedactor builds the log fields for a job, masking values that may contain secrets
+type logRedactor struct {
+	masked  map[string]bool
+	maxArgs int
+}
+
+func newLogRedactor(r LogRedaction) logRedactor {
+	masked := make(map[string]bool)
+	for _, f := range r.MaskedFields {
+		masked[strings.TrimSpace(f)] = true
+	}
+	return logRedactor{masked: masked, maxArgs: r.MaxArgs}
+}
+
+// jobFields returns the cmd and args of a job as zap key value pairs
+func (r logRedactor) jobFields(j *jogv1.JobSpec) []any {
+	cmd := j.GetCmd()
+	if r.masked["cmd"] {
+		cmd = maskedValue
+	}
+
+	args := j.GetArgs()
+	truncated := 0
+	if r.maxArgs > 0 && len(args) > r.maxArgs {
+		truncated = len(args) - r.maxArgs
+		args = args[:r.maxArgs]
+	}
+	loggedArgs := make([]string, 0, len(args)+1)
+	for _, a := range args {
+		if r.masked["args"] {
+			a = maskedValue
+		}
+		loggedArgs = append(loggedArgs, a)
+	}
+	if truncated > 0 {
+		loggedArgs = append(loggedArgs, fmt.Sprintf("...(%d more)", truncated))
+	}
+
+	return []any{"cmd", cmd, "args", loggedArgs, "shell", j.GetShell()}
+}
+
+// SourceAddrFromContext gets the network address of the peer in the context, e.g.
+// "203.0.113.7:51234". It's empty if the context has no peer address.
+func SourceAddrFromContext(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	return p.Addr.String()
+}
+
 // CommonNameFromContext gets the common name from peer certificates in the context -- this is the username
 // Note that for local development, this is set in the gencerts binary.
+//
+// All errors returned have the codes.Unauthenticated gRPC status code, so that clients
+// receive an actionable error rather than codes.Unknown.
 func CommonNameFromContext(ctx context.Context) (string, error) {
 	p, ok := peer.FromContext(ctx)
 	if !ok {
-		return "", fmt.Errorf("getting common name from context: failed to get peer")
+		return "", status.Error(codes.Unauthenticated, "getting common name from context: failed to get peer")
 	}
 	if p.AuthInfo == nil {
-		return "", fmt.Errorf("getting common name from context: no AuthInfo available")
+		return "", status.Error(codes.Unauthenticated, "getting common name from context: no AuthInfo available")
 	}
 	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
 	if !ok {
-		return "", fmt.Errorf("getting common name from context: no TLSInfo available")
+		return "", status.Error(codes.Unauthenticated, "getting common name from context: no TLSInfo available")
 	}
 	if len(tlsInfo.State.PeerCertificates) == 0 {
-		return "", fmt.Errorf("getting common name from context: there are no peer certificates")
+		return "", status.Error(codes.Unauthenticated, "getting common name from context: there are no peer certificates")
 	}
 	if len(tlsInfo.State.PeerCertificates) > 1 {
-		return "", fmt.Errorf("getting common name from context: there are multiple peer certificates")
+		return "", status.Error(codes.Unauthenticated, "getting common name from context: there are multiple peer certificates")
 	}
 	if tlsInfo.State.PeerCertificates[0].Subject.CommonName == "" {
-		return "", fmt.Errorf("getting common name from context: peer certificate has no common name")
+		return "", status.Error(codes.Unauthenticated, "getting common name from context: peer certificate has no common name")
 	}
 	return tlsInfo.State.PeerCertificates[0].Subject.CommonName, nil
 }
@@ -0,0 +1,763 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/dustinevan/jogger/lib/cgroup"
+	"github.com/dustinevan/jogger/lib/job"
+	jogv1 "github.com/dustinevan/jogger/pkg/gen/jogger/v1"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// nonTLSAuthInfo is a credentials.AuthInfo that isn't credentials.TLSInfo
+type nonTLSAuthInfo struct{}
+
+func (nonTLSAuthInfo) AuthType() string { return "insecure" }
+
+func certWithCN(cn string) *x509.Certificate {
+	return &x509.Certificate{Subject: pkix.Name{CommonName: cn}}
+}
+
+func tlsPeerContext(certs ...*x509.Certificate) context.Context {
+	return peer.NewContext(context.Background(), &peer.Peer{
+		Addr:     &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 50051},
+		AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{PeerCertificates: certs}},
+	})
+}
+
+func TestCommonNameFromContext(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		want    string
+		wantMsg string
+	}{
+		{
+			name:    "no peer",
+			ctx:     context.Background(),
+			wantMsg: "failed to get peer",
+		},
+		{
+			name:    "nil AuthInfo",
+			ctx:     peer.NewContext(context.Background(), &peer.Peer{}),
+			wantMsg: "no AuthInfo available",
+		},
+		{
+			name:    "non-TLS AuthInfo",
+			ctx:     peer.NewContext(context.Background(), &peer.Peer{AuthInfo: nonTLSAuthInfo{}}),
+			wantMsg: "no TLSInfo available",
+		},
+		{
+			name:    "zero certs",
+			ctx:     tlsPeerContext(),
+			wantMsg: "there are no peer certificates",
+		},
+		{
+			name:    "multiple certs",
+			ctx:     tlsPeerContext(certWithCN("user1"), certWithCN("user2")),
+			wantMsg: "there are multiple peer certificates",
+		},
+		{
+			name:    "empty CN",
+			ctx:     tlsPeerContext(certWithCN("")),
+			wantMsg: "peer certificate has no common name",
+		},
+		{
+			name: "valid CN",
+			ctx:  tlsPeerContext(certWithCN("user1")),
+			want: "user1",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := CommonNameFromContext(tt.ctx)
+			if tt.wantMsg == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if got != tt.want {
+					t.Fatalf("expected common name %q, got %q", tt.want, got)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected error, got nil")
+			}
+			if code := status.Code(err); code != codes.Unauthenticated {
+				t.Fatalf("expected code %v, got %v", codes.Unauthenticated, code)
+			}
+			if !strings.Contains(err.Error(), tt.wantMsg) {
+				t.Fatalf("expected error containing %q, got %q", tt.wantMsg, err.Error())
+			}
+		})
+	}
+}
+
+func TestSourceAddrFromContext(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want string
+	}{
+		{
+			name: "no peer",
+			ctx:  context.Background(),
+		},
+		{
+			name: "no address",
+			ctx:  peer.NewContext(context.Background(), &peer.Peer{}),
+		},
+		{
+			name: "tcp address",
+			ctx: peer.NewContext(context.Background(), &peer.Peer{
+				Addr: &net.TCPAddr{IP: net.IPv4(203, 0, 113, 7), Port: 51234},
+			}),
+			want: "203.0.113.7:51234",
+		},
+		{
+			name: "ipv6 address",
+			ctx: peer.NewContext(context.Background(), &peer.Peer{
+				Addr: &net.TCPAddr{IP: net.ParseIP("2001:db8::1"), Port: 51234},
+			}),
+			want: "[2001:db8::1]:51234",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := SourceAddrFromContext(tt.ctx); got != tt.want {
+				t.Fatalf("expected source address %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestHandlersReturnUnauthenticated(t *testing.T) {
+	t.Parallel()
+
+	// CommonNameFromContext is checked before the manager is used, so a nil manager is fine here
+	s := NewServer(nil, zap.NewNop().Sugar())
+	ctx := tlsPeerContext()
+
+	_, err := s.Start(ctx, &jogv1.StartRequest{Job: &jogv1.Job{Cmd: "echo"}})
+	if code := status.Code(err); code != codes.Unauthenticated {
+		t.Fatalf("start: expected code %v, got %v", codes.Unauthenticated, code)
+	}
+	_, err = s.Stop(ctx, &jogv1.StopRequest{JobId: "123"})
+	if code := status.Code(err); code != codes.Unauthenticated {
+		t.Fatalf("stop: expected code %v, got %v", codes.Unauthenticated, code)
+	}
+	_, err = s.UpdateLimits(ctx, &jogv1.UpdateLimitsRequest{JobId: "123", Cpus: 1})
+	if code := status.Code(err); code != codes.Unauthenticated {
+		t.Fatalf("update limits: expected code %v, got %v", codes.Unauthenticated, code)
+	}
+	_, err = s.Status(ctx, &jogv1.StatusRequest{JobId: "123"})
+	if code := status.Code(err); code != codes.Unauthenticated {
+		t.Fatalf("status: expected code %v, got %v", codes.Unauthenticated, code)
+	}
+	_, err = s.Exists(ctx, &jogv1.ExistsRequest{JobId: "123"})
+	if code := status.Code(err); code != codes.Unauthenticated {
+		t.Fatalf("exists: expected code %v, got %v", codes.Unauthenticated, code)
+	}
+	_, err = s.Events(ctx, &jogv1.EventsRequest{JobId: "123"})
+	if code := status.Code(err); code != codes.Unauthenticated {
+		t.Fatalf("events: expected code %v, got %v", codes.Unauthenticated, code)
+	}
+	_, err = s.ListJobs(ctx, &jogv1.ListJobsRequest{})
+	if code := status.Code(err); code != codes.Unauthenticated {
+		t.Fatalf("list jobs: expected code %v, got %v", codes.Unauthenticated, code)
+	}
+	err = s.WatchEvents(&jogv1.EventsRequest{JobId: "123"}, &fakeWatchEventsServer{ctx: ctx})
+	if code := status.Code(err); code != codes.Unauthenticated {
+		t.Fatalf("watch events: expected code %v, got %v", codes.Unauthenticated, code)
+	}
+	err = s.StartAndStream(&jogv1.StartAndStreamRequest{Start: &jogv1.StartRequest{Job: &jogv1.Job{Cmd: "echo"}}}, &fakeStartAndStreamServer{ctx: ctx})
+	if code := status.Code(err); code != codes.Unauthenticated {
+		t.Fatalf("start and stream: expected code %v, got %v", codes.Unauthenticated, code)
+	}
+}
+
+// fakeWatchEventsServer is a JobService_WatchEventsServer with the given context
+type fakeWatchEventsServer struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (f *fakeWatchEventsServer) Context() context.Context { return f.ctx }
+
+func (f *fakeWatchEventsServer) Send(*jogv1.Event) error { return nil }
+
+func TestStartLogRedaction(t *testing.T) {
+	t.Parallel()
+
+	req := &jogv1.StartRequest{Job: &jogv1.Job{
+		Cmd:  "deploy",
+		Args: []string{"--verbose", "--token=abc", "one", "two", "three"},
+	}}
+
+	tests := []struct {
+		name      string
+		redaction LogRedaction
+		wantCmd   string
+		wantArgs  []string
+	}{
+		{
+			name:     "defaults",
+			wantCmd:  "deploy",
+			wantArgs: []string{"--verbose", "--token=abc", "one", "two", "three"},
+		},
+		{
+			name:      "truncated args",
+			redaction: LogRedaction{MaxArgs: 2},
+			wantCmd:   "deploy",
+			wantArgs:  []string{"--verbose", "--token=abc", "...(3 more)"},
+		},
+		{
+			name:      "masked cmd and args",
+			redaction: LogRedaction{MaskedFields: []string{"cmd", "args"}, MaxArgs: 4},
+			wantCmd:   maskedValue,
+			wantArgs:  []string{maskedValue, maskedValue, maskedValue, maskedValue, "...(1 more)"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			core, logs := observer.New(zap.InfoLevel)
+			s := NewServer(nil, zap.New(core).Sugar(), WithLogRedaction(tt.redaction))
+
+			// the request fails authentication after the request is logged, so no manager is needed
+			s.Start(tlsPeerContext(), req)
+
+			entries := logs.FilterMessage("starting job").All()
+			if len(entries) != 1 {
+				t.Fatalf("expected 1 starting job log entry, got %d", len(entries))
+			}
+			fields := entries[0].ContextMap()
+			if fields["cmd"] != tt.wantCmd {
+				t.Fatalf("expected cmd %q, got %v", tt.wantCmd, fields["cmd"])
+			}
+			if got := fmt.Sprintf("%v", fields["args"]); got != fmt.Sprintf("%v", tt.wantArgs) {
+				t.Fatalf("expected args %v, got %s", tt.wantArgs, got)
+			}
+		})
+	}
+}
+
+func TestStartSpec(t *testing.T) {
+	t.Parallel()
+
+	spec := &jogv1.JobSpec{
+		Cmd:              "make",
+		Args:             []string{"-j", "4", "all"},
+		Tty:              true,
+		Shell:            true,
+		Name:             "build",
+		Capture:          jogv1.Capture_CAPTURE_STDOUT,
+		OutputLimitBytes: 1 << 20,
+	}
+	tests := []struct {
+		name    string
+		req     *jogv1.StartRequest
+		want    *jogv1.JobSpec
+		wantErr bool
+	}{
+		{
+			name: "spec",
+			req:  &jogv1.StartRequest{Spec: spec},
+			want: spec,
+		},
+		{
+			name: "job and options from older clients",
+			req: &jogv1.StartRequest{
+				Job:              &jogv1.Job{Cmd: "make", Args: []string{"-j", "4", "all"}, Tty: true, Shell: true},
+				Name:             "build",
+				Capture:          jogv1.Capture_CAPTURE_STDOUT,
+				OutputLimitBytes: 1 << 20,
+			},
+			want: spec,
+		},
+		{
+			name: "empty request",
+			req:  &jogv1.StartRequest{},
+			want: &jogv1.JobSpec{},
+		},
+		{
+			name:    "spec and job",
+			req:     &jogv1.StartRequest{Spec: spec, Job: &jogv1.Job{Cmd: "make"}},
+			wantErr: true,
+		},
+		{
+			name:    "spec and name",
+			req:     &jogv1.StartRequest{Spec: spec, Name: "build"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := startSpec(tt.req)
+			if tt.wantErr {
+				if code := status.Code(err); code != codes.InvalidArgument {
+					t.Fatalf("expected code %v, got %v", codes.InvalidArgument, code)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !proto.Equal(got, tt.want) {
+				t.Fatalf("expected spec %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestStartRejectsNegativeOutputLimit(t *testing.T) {
+	t.Parallel()
+
+	// the request is rejected before the manager is used
+	s := NewServer(nil, zap.NewNop().Sugar())
+	_, err := s.Start(tlsPeerContext(certWithCN("user1")), &jogv1.StartRequest{
+		Job:              &jogv1.Job{Cmd: "echo"},
+		OutputLimitBytes: -1,
+	})
+	if code := status.Code(err); code != codes.InvalidArgument {
+		t.Fatalf("expected code %v, got %v", codes.InvalidArgument, code)
+	}
+}
+
+func TestStartRejectsInvalidUmask(t *testing.T) {
+	t.Parallel()
+
+	// the request is rejected before the manager is used
+	s := NewServer(nil, zap.NewNop().Sugar())
+	_, err := s.Start(tlsPeerContext(certWithCN("user1")), &jogv1.StartRequest{
+		Spec: &jogv1.JobSpec{Cmd: "echo", Umask: "u=rwx"},
+	})
+	if code := status.Code(err); code != codes.InvalidArgument {
+		t.Fatalf("expected code %v, got %v", codes.InvalidArgument, code)
+	}
+}
+
+func TestStartRejectsInvalidRedaction(t *testing.T) {
+	t.Parallel()
+
+	// the patterns are checked before a cgroup is created, so no cgroup manager is needed
+	s := NewServer(job.NewManager(context.Background()), zap.NewNop().Sugar())
+	_, err := s.Start(tlsPeerContext(certWithCN("user1")), &jogv1.StartRequest{
+		Spec: &jogv1.JobSpec{Cmd: "echo", Redact: []string{"hunter2"}, RedactPatterns: []string{"(a+"}},
+	})
+	if code := status.Code(err); code != codes.InvalidArgument {
+		t.Fatalf("expected code %v, got %v", codes.InvalidArgument, code)
+	}
+}
+
+func TestStartAfter(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		spec     *jogv1.JobSpec
+		wantCode codes.Code
+	}{
+		{name: "after_completed without after_job_id", spec: &jogv1.JobSpec{Cmd: "echo", AfterCompleted: true}, wantCode: codes.InvalidArgument},
+		{name: "missing job", spec: &jogv1.JobSpec{Cmd: "echo", AfterJobId: "123"}, wantCode: codes.NotFound},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			// the job to start after is looked up before a cgroup is created, so no cgroup manager is needed
+			s := NewServer(job.NewManager(context.Background()), zap.NewNop().Sugar())
+			_, err := s.Start(tlsPeerContext(certWithCN("user1")), &jogv1.StartRequest{Spec: tt.spec})
+			if code := status.Code(err); code != tt.wantCode {
+				t.Fatalf("expected code %v, got %v", tt.wantCode, code)
+			}
+		})
+	}
+}
+
+func TestStopRejectsNegativeGracePeriod(t *testing.T) {
+	t.Parallel()
+
+	// the request is rejected before the manager is used
+	s := NewServer(nil, zap.NewNop().Sugar())
+	_, err := s.Stop(tlsPeerContext(certWithCN("user1")), &jogv1.StopRequest{JobId: "123", GracePeriodMs: -1})
+	if code := status.Code(err); code != codes.InvalidArgument {
+		t.Fatalf("expected code %v, got %v", codes.InvalidArgument, code)
+	}
+}
+
+func TestUpdateLimitsRejectsInvalidLimits(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		req  *jogv1.UpdateLimitsRequest
+	}{
+		{name: "no limits", req: &jogv1.UpdateLimitsRequest{JobId: "123"}},
+		{name: "negative memory", req: &jogv1.UpdateLimitsRequest{JobId: "123", MemoryMaxBytes: -1}},
+		{name: "negative cpus", req: &jogv1.UpdateLimitsRequest{JobId: "123", Cpus: -0.5}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			// the request is rejected before the manager is used
+			s := NewServer(nil, zap.NewNop().Sugar())
+			_, err := s.UpdateLimits(tlsPeerContext(certWithCN("user1")), tt.req)
+			if code := status.Code(err); code != codes.InvalidArgument {
+				t.Fatalf("expected code %v, got %v", codes.InvalidArgument, code)
+			}
+		})
+	}
+}
+
+func TestStartCanceled(t *testing.T) {
+	t.Parallel()
+
+	// the context is checked before a cgroup is created, so no cgroup manager is needed
+	s := NewServer(job.NewManager(context.Background()), zap.NewNop().Sugar())
+	ctx, cancel := context.WithCancel(tlsPeerContext(certWithCN("user1")))
+	cancel()
+	_, err := s.Start(ctx, &jogv1.StartRequest{Job: &jogv1.Job{Cmd: "echo"}})
+	if code := status.Code(err); code != codes.Canceled {
+		t.Fatalf("expected code %v, got %v", codes.Canceled, code)
+	}
+}
+
+// fakeOutputServer is an output stream that records what's sent to the client
+type fakeOutputServer struct {
+	grpc.ServerStream
+	ctx     context.Context
+	sent    []string
+	streams []jogv1.Stream
+	trailer metadata.MD
+}
+
+func (f *fakeOutputServer) Context() context.Context {
+	return f.ctx
+}
+
+func (f *fakeOutputServer) Send(resp *jogv1.OutputResponse) error {
+	f.sent = append(f.sent, string(resp.GetData().GetData()))
+	f.streams = append(f.streams, resp.GetData().GetStream())
+	return nil
+}
+
+func (f *fakeOutputServer) SetTrailer(md metadata.MD) {
+	f.trailer = metadata.Join(f.trailer, md)
+}
+
+func TestSendOutputMaxBytes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		maxBytes int64
+		want     []string
+	}{
+		{name: "no cap", want: []string{"hello\n", "world\n", "again\n"}},
+		{name: "cap inside a chunk", maxBytes: 8, want: []string{"hello\n", "wo"}},
+		{name: "cap at the end of a chunk", maxBytes: 12, want: []string{"hello\n", "world\n"}},
+		{name: "cap past the end of the output", maxBytes: 100, want: []string{"hello\n", "world\n", "again\n"}},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			stream := make(chan job.Chunk, 3)
+			stream <- job.Chunk{Data: []byte("hello\n")}
+			stream <- job.Chunk{Data: []byte("world\n")}
+			stream <- job.Chunk{Data: []byte("again\n")}
+			close(stream)
+
+			srv := &fakeOutputServer{ctx: context.Background()}
+			if err := sendOutput(srv, stream, tt.maxBytes, nil); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if fmt.Sprintf("%q", srv.sent) != fmt.Sprintf("%q", tt.want) {
+				t.Fatalf("expected %q, got %q", tt.want, srv.sent)
+			}
+			wantSent := 0
+			for _, chunk := range tt.want {
+				wantSent += len(chunk)
+			}
+			if got := srv.trailer.Get(SentBytesTrailer); len(got) != 1 || got[0] != fmt.Sprint(wantSent) {
+				t.Fatalf("expected %s trailer %d, got %v", SentBytesTrailer, wantSent, got)
+			}
+		})
+	}
+}
+
+func TestSendOutputStreams(t *testing.T) {
+	t.Parallel()
+
+	stream := make(chan job.Chunk, 3)
+	stream <- job.Chunk{Data: []byte("building\n"), Stream: jogv1.Stream_STDOUT}
+	stream <- job.Chunk{Data: []byte("warning\n"), Stream: jogv1.Stream_STDERR}
+	stream <- job.Chunk{Data: []byte("done\n")}
+	close(stream)
+
+	srv := &fakeOutputServer{ctx: context.Background()}
+	if err := sendOutput(srv, stream, 0, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []jogv1.Stream{jogv1.Stream_STDOUT, jogv1.Stream_STDERR, jogv1.Stream_STREAM_UNSPECIFIED}
+	if fmt.Sprint(srv.streams) != fmt.Sprint(want) {
+		t.Fatalf("expected the chunks to be tagged %v, got %v", want, srv.streams)
+	}
+}
+
+func TestSendOutputReadError(t *testing.T) {
+	t.Parallel()
+
+	readErr := errors.New("reading spilled output")
+	stream := make(chan job.Chunk, 2)
+	stream <- job.Chunk{Data: []byte("hello\n")}
+	stream <- job.Chunk{Err: readErr}
+	close(stream)
+
+	srv := &fakeOutputServer{ctx: context.Background()}
+	if err := sendOutput(srv, stream, 0, nil); !errors.Is(err, readErr) {
+		t.Fatalf("expected the stream's error, got %v", err)
+	}
+	if fmt.Sprintf("%q", srv.sent) != fmt.Sprintf("%q", []string{"hello\n"}) {
+		t.Fatalf("expected the output before the error to be sent, got %q", srv.sent)
+	}
+}
+
+func TestSendOutputEncoding(t *testing.T) {
+	t.Parallel()
+
+	chunks := [][]byte{{0x00, 0xff, 0x1b, '\n'}, []byte("héllo\r\n"), {0x80, 0x81}}
+	tests := []struct {
+		name     string
+		encoding jogv1.Encoding
+		decode   func(string) ([]byte, error)
+	}{
+		{name: "unspecified", encoding: jogv1.Encoding_ENCODING_UNSPECIFIED, decode: func(s string) ([]byte, error) { return []byte(s), nil }},
+		{name: "raw", encoding: jogv1.Encoding_ENCODING_RAW, decode: func(s string) ([]byte, error) { return []byte(s), nil }},
+		{name: "base64", encoding: jogv1.Encoding_ENCODING_BASE64, decode: base64.StdEncoding.DecodeString},
+		{name: "hex", encoding: jogv1.Encoding_ENCODING_HEX, decode: hex.DecodeString},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			stream := make(chan job.Chunk, len(chunks))
+			for _, c := range chunks {
+				stream <- job.Chunk{Data: c}
+			}
+			close(stream)
+
+			encode, err := outputEncoder(tt.encoding)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			srv := &fakeOutputServer{ctx: context.Background()}
+			if err := sendOutput(srv, stream, 0, encode); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(srv.sent) != len(chunks) {
+				t.Fatalf("expected %d chunks, got %d", len(chunks), len(srv.sent))
+			}
+			wantSent := 0
+			for i, sent := range srv.sent {
+				got, err := tt.decode(sent)
+				if err != nil {
+					t.Fatalf("decoding chunk %d: %v", i, err)
+				}
+				if !bytes.Equal(got, chunks[i]) {
+					t.Fatalf("expected chunk %d to be %q, got %q", i, chunks[i], got)
+				}
+				wantSent += len(chunks[i])
+			}
+			// the trailer counts the bytes before they're encoded
+			if got := srv.trailer.Get(SentBytesTrailer); len(got) != 1 || got[0] != fmt.Sprint(wantSent) {
+				t.Fatalf("expected %s trailer %d, got %v", SentBytesTrailer, wantSent, got)
+			}
+		})
+	}
+}
+
+func TestOutputRejectsNegativeMaxBytes(t *testing.T) {
+	t.Parallel()
+
+	// the request is rejected before the manager is used
+	s := NewServer(nil, zap.NewNop().Sugar())
+	srv := &fakeOutputServer{ctx: tlsPeerContext(certWithCN("user1"))}
+	err := s.Output(&jogv1.OutputRequest{JobId: "123", MaxBytes: -1}, srv)
+	if code := status.Code(err); code != codes.InvalidArgument {
+		t.Fatalf("expected code %v, got %v", codes.InvalidArgument, code)
+	}
+}
+
+func TestOutputRejectsInvalidChunking(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		req  *jogv1.OutputRequest
+	}{
+		{name: "negative chunk size", req: &jogv1.OutputRequest{JobId: "123", ChunkSize: -1}},
+		{name: "chunk size too large", req: &jogv1.OutputRequest{JobId: "123", ChunkSize: maxChunkSize + 1}},
+		{name: "unknown chunking", req: &jogv1.OutputRequest{JobId: "123", Chunking: jogv1.Chunking(99)}},
+		{name: "unknown encoding", req: &jogv1.OutputRequest{JobId: "123", Encoding: jogv1.Encoding(99)}},
+		{name: "negative offset", req: &jogv1.OutputRequest{JobId: "123", Offset: -1}},
+		{name: "unknown stream", req: &jogv1.OutputRequest{JobId: "123", Stream: jogv1.StreamSelector(99)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			// the request is rejected before the manager is used
+			s := NewServer(nil, zap.NewNop().Sugar())
+			srv := &fakeOutputServer{ctx: tlsPeerContext(certWithCN("user1"))}
+			err := s.Output(tt.req, srv)
+			if code := status.Code(err); code != codes.InvalidArgument {
+				t.Fatalf("expected code %v, got %v", codes.InvalidArgument, code)
+			}
+		})
+	}
+}
+
+// fakeStartAndStreamServer is a StartAndStream stream that records what's sent to the client
+type fakeStartAndStreamServer struct {
+	grpc.ServerStream
+	ctx     context.Context
+	sent    []*jogv1.StartAndStreamResponse
+	trailer metadata.MD
+}
+
+func (f *fakeStartAndStreamServer) Context() context.Context {
+	return f.ctx
+}
+
+func (f *fakeStartAndStreamServer) Send(resp *jogv1.StartAndStreamResponse) error {
+	f.sent = append(f.sent, resp)
+	return nil
+}
+
+func (f *fakeStartAndStreamServer) SetTrailer(md metadata.MD) {
+	f.trailer = metadata.Join(f.trailer, md)
+}
+
+// noopCgroups is a job.CgroupManager that doesn't create cgroups, so jobs can be started
+// without a cgroup filesystem
+type noopCgroups struct{}
+
+func (noopCgroups) AddGroupWithLimits(string, string, cgroup.ResourceLimits, ...cgroup.GroupOption) (int, error) {
+	return -1, nil
+}
+func (noopCgroups) Procs(string) ([]int, error)             { return nil, nil }
+func (noopCgroups) Kill(string) error                       { return nil }
+func (noopCgroups) Usage(string) (cgroup.Usage, error)      { return cgroup.Usage{}, nil }
+func (noopCgroups) UpdateGroup(string, cgroup.Limits) error { return nil }
+func (noopCgroups) RemoveGroup(string) error                { return nil }
+
+func TestStartAndStream(t *testing.T) {
+	t.Parallel()
+
+	s := NewServer(job.NewManager(context.Background(), job.WithCgroupManager(noopCgroups{})), zap.NewNop().Sugar())
+	srv := &fakeStartAndStreamServer{ctx: tlsPeerContext(certWithCN("user1"))}
+	err := s.StartAndStream(&jogv1.StartAndStreamRequest{
+		Start:  &jogv1.StartRequest{Spec: &jogv1.JobSpec{Cmd: "sh", Args: []string{"-c", "echo first; echo second"}}},
+		Output: &jogv1.OutputRequest{Chunking: jogv1.Chunking_CHUNKING_LINE},
+	}, srv)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(srv.sent) == 0 || srv.sent[0].GetStart().GetJobId() == "" {
+		t.Fatalf("expected the first message to have the job id, got %v", srv.sent)
+	}
+	// the output is streamed from the job's beginning, so the first line isn't missed
+	var output []string
+	for _, resp := range srv.sent[1:] {
+		if resp.GetData() == nil {
+			t.Fatalf("expected only output after the first message, got %v", resp)
+		}
+		output = append(output, string(resp.GetData().GetData()))
+	}
+	if want := []string{"first\n", "second\n"}; fmt.Sprintf("%q", output) != fmt.Sprintf("%q", want) {
+		t.Fatalf("expected %q, got %q", want, output)
+	}
+	if got := srv.trailer.Get(SentBytesTrailer); len(got) != 1 || got[0] != "13" {
+		t.Fatalf("expected %s trailer 13, got %v", SentBytesTrailer, got)
+	}
+}
+
+func TestStartAndStreamRejectsInvalidRequest(t *testing.T) {
+	t.Parallel()
+
+	start := &jogv1.StartRequest{Spec: &jogv1.JobSpec{Cmd: "echo"}}
+	tests := []struct {
+		name string
+		req  *jogv1.StartAndStreamRequest
+	}{
+		{name: "output job id", req: &jogv1.StartAndStreamRequest{Start: start, Output: &jogv1.OutputRequest{JobId: "123"}}},
+		{name: "output offset", req: &jogv1.StartAndStreamRequest{Start: start, Output: &jogv1.OutputRequest{Offset: 10}}},
+		{name: "after another job", req: &jogv1.StartAndStreamRequest{Start: &jogv1.StartRequest{Spec: &jogv1.JobSpec{Cmd: "echo", AfterJobId: "123"}}}},
+		{name: "negative max bytes", req: &jogv1.StartAndStreamRequest{Start: start, Output: &jogv1.OutputRequest{MaxBytes: -1}}},
+		{name: "unknown encoding", req: &jogv1.StartAndStreamRequest{Start: start, Output: &jogv1.OutputRequest{Encoding: jogv1.Encoding(99)}}},
+		{name: "negative output limit", req: &jogv1.StartAndStreamRequest{Start: &jogv1.StartRequest{Spec: &jogv1.JobSpec{Cmd: "echo", OutputLimitBytes: -1}}}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			// the request is rejected before the manager is used, so no job is started
+			s := NewServer(nil, zap.NewNop().Sugar())
+			srv := &fakeStartAndStreamServer{ctx: tlsPeerContext(certWithCN("user1"))}
+			err := s.StartAndStream(tt.req, srv)
+			if code := status.Code(err); code != codes.InvalidArgument {
+				t.Fatalf("expected code %v, got %v", codes.InvalidArgument, code)
+			}
+			if len(srv.sent) > 0 {
+				t.Fatalf("expected nothing to be sent, got %v", srv.sent)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	jogv1 "github.com/dustinevan/jogger/pkg/gen/jogger/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// MaxStartRequestSize returns a unary interceptor that rejects Start requests whose serialized
+// size is over maxBytes with InvalidArgument, before they reach the handler. It guards against
+// oversized commands, arguments, and other job options, whatever field they're in. Other methods
+// are passed through, as are all requests when maxBytes <= 0.
+func MaxStartRequestSize(maxBytes int) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if maxBytes <= 0 || info.FullMethod != jogv1.JobService_Start_FullMethodName {
+			return handler(ctx, req)
+		}
+		if msg, ok := req.(proto.Message); ok {
+			if size := proto.Size(msg); size > maxBytes {
+				return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("starting job: the request is %d bytes, over the limit of %d", size, maxBytes))
+			}
+		}
+		return handler(ctx, req)
+	}
+}
+
+// MaxStartStreamRequestSize returns a stream interceptor that applies the same limit as
+// MaxStartRequestSize to StartAndStream requests, which start jobs too. The request is
+// rejected when the handler reads it, before the job is started.
+func MaxStartStreamRequestSize(maxBytes int) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if maxBytes <= 0 || info.FullMethod != jogv1.JobService_StartAndStream_FullMethodName {
+			return handler(srv, ss)
+		}
+		return handler(srv, &maxSizeStream{ServerStream: ss, maxBytes: maxBytes})
+	}
+}
+
+// maxSizeStream is a server stream that rejects received messages over maxBytes
+type maxSizeStream struct {
+	grpc.ServerStream
+	maxBytes int
+}
+
+func (s *maxSizeStream) RecvMsg(m any) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	if msg, ok := m.(proto.Message); ok {
+		if size := proto.Size(msg); size > s.maxBytes {
+			return status.Error(codes.InvalidArgument, fmt.Sprintf("starting job: the request is %d bytes, over the limit of %d", size, s.maxBytes))
+		}
+	}
+	return nil
+}
+
+// DefaultDeadline returns a unary interceptor that gives requests without a deadline one of
+// timeout, so a client that never times out can't tie up the server. Requests with their own
+// deadline keep it, shorter or longer. It's a unary interceptor, so streams, like output,
+// which follow a job for as long as it runs, aren't bounded. A timeout <= 0 disables it.
+func DefaultDeadline(timeout time.Duration) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if _, ok := ctx.Deadline(); ok || timeout <= 0 {
+			return handler(ctx, req)
+		}
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		return handler(ctx, req)
+	}
+}
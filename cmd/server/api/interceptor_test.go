@@ -0,0 +1,215 @@
+package api
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	jogv1 "github.com/dustinevan/jogger/pkg/gen/jogger/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestMaxStartRequestSize(t *testing.T) {
+	t.Parallel()
+
+	const maxBytes = 1024
+	oversized := &jogv1.StartRequest{Job: &jogv1.Job{Cmd: "echo", Args: []string{strings.Repeat("a", maxBytes)}}}
+	tests := []struct {
+		name        string
+		maxBytes    int
+		method      string
+		req         any
+		wantCode    codes.Code
+		wantHandled bool
+	}{
+		{
+			name:        "small start request",
+			maxBytes:    maxBytes,
+			method:      jogv1.JobService_Start_FullMethodName,
+			req:         &jogv1.StartRequest{Job: &jogv1.Job{Cmd: "echo", Args: []string{"hello"}}},
+			wantHandled: true,
+		},
+		{
+			name:     "oversized start request",
+			maxBytes: maxBytes,
+			method:   jogv1.JobService_Start_FullMethodName,
+			req:      oversized,
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "oversized spec",
+			maxBytes: maxBytes,
+			method:   jogv1.JobService_Start_FullMethodName,
+			req:      &jogv1.StartRequest{Spec: &jogv1.JobSpec{Cmd: "echo", Redact: []string{strings.Repeat("a", maxBytes)}}},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:        "other methods aren't limited",
+			maxBytes:    maxBytes,
+			method:      jogv1.JobService_Status_FullMethodName,
+			req:         &jogv1.StatusRequest{JobId: strings.Repeat("a", 2*maxBytes)},
+			wantHandled: true,
+		},
+		{
+			name:        "no limit",
+			method:      jogv1.JobService_Start_FullMethodName,
+			req:         oversized,
+			wantHandled: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			// the handler spies on whether the request reached the server, and so Manager.Start
+			handled := false
+			handler := func(ctx context.Context, req any) (any, error) {
+				handled = true
+				return &jogv1.StartResponse{}, nil
+			}
+			interceptor := MaxStartRequestSize(tt.maxBytes)
+			_, err := interceptor(context.Background(), tt.req, &grpc.UnaryServerInfo{FullMethod: tt.method}, handler)
+			if code := status.Code(err); code != tt.wantCode {
+				t.Fatalf("expected code %v, got %v", tt.wantCode, err)
+			}
+			if handled != tt.wantHandled {
+				t.Fatalf("expected the handler to be called: %v, got %v", tt.wantHandled, handled)
+			}
+		})
+	}
+}
+
+// fakeRecvStream is a server stream that receives req
+type fakeRecvStream struct {
+	grpc.ServerStream
+	req proto.Message
+}
+
+func (f fakeRecvStream) RecvMsg(m any) error {
+	proto.Merge(m.(proto.Message), f.req)
+	return nil
+}
+
+func TestMaxStartStreamRequestSize(t *testing.T) {
+	t.Parallel()
+
+	const maxBytes = 1024
+	oversized := &jogv1.StartAndStreamRequest{Start: &jogv1.StartRequest{Spec: &jogv1.JobSpec{Cmd: "echo", Args: []string{strings.Repeat("a", maxBytes)}}}}
+	tests := []struct {
+		name        string
+		maxBytes    int
+		method      string
+		req         proto.Message
+		wantCode    codes.Code
+		wantHandled bool
+	}{
+		{
+			name:        "small request",
+			maxBytes:    maxBytes,
+			method:      jogv1.JobService_StartAndStream_FullMethodName,
+			req:         &jogv1.StartAndStreamRequest{Start: &jogv1.StartRequest{Spec: &jogv1.JobSpec{Cmd: "echo", Args: []string{"hello"}}}},
+			wantHandled: true,
+		},
+		{
+			name:     "oversized request",
+			maxBytes: maxBytes,
+			method:   jogv1.JobService_StartAndStream_FullMethodName,
+			req:      oversized,
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:        "other methods aren't limited",
+			maxBytes:    maxBytes,
+			method:      jogv1.JobService_Output_FullMethodName,
+			req:         &jogv1.OutputRequest{JobId: strings.Repeat("a", 2*maxBytes)},
+			wantHandled: true,
+		},
+		{
+			name:        "no limit",
+			method:      jogv1.JobService_StartAndStream_FullMethodName,
+			req:         oversized,
+			wantHandled: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			// the handler spies on whether it got the request, and so would start the job
+			handled := false
+			handler := func(srv any, ss grpc.ServerStream) error {
+				req := tt.req.ProtoReflect().New().Interface()
+				if err := ss.RecvMsg(req); err != nil {
+					return err
+				}
+				handled = true
+				return nil
+			}
+			interceptor := MaxStartStreamRequestSize(tt.maxBytes)
+			err := interceptor(nil, fakeRecvStream{req: tt.req}, &grpc.StreamServerInfo{FullMethod: tt.method, IsServerStream: true}, handler)
+			if code := status.Code(err); code != tt.wantCode {
+				t.Fatalf("expected code %v, got %v", tt.wantCode, err)
+			}
+			if handled != tt.wantHandled {
+				t.Fatalf("expected the handler to be called: %v, got %v", tt.wantHandled, handled)
+			}
+		})
+	}
+}
+
+func TestDefaultDeadline(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		// clientTimeout is the deadline the client set, none when 0
+		clientTimeout time.Duration
+		wantTimeout   time.Duration
+	}{
+		{name: "no deadline", timeout: time.Minute, wantTimeout: time.Minute},
+		{name: "shorter client deadline", timeout: time.Minute, clientTimeout: time.Second, wantTimeout: time.Second},
+		{name: "longer client deadline", timeout: time.Minute, clientTimeout: time.Hour, wantTimeout: time.Hour},
+		{name: "disabled", timeout: 0},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			before := time.Now()
+			ctx := context.Background()
+			if tt.clientTimeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.clientTimeout)
+				defer cancel()
+			}
+			var deadline time.Time
+			var hasDeadline bool
+			handler := func(ctx context.Context, req any) (any, error) {
+				deadline, hasDeadline = ctx.Deadline()
+				return &jogv1.StatusResponse{}, nil
+			}
+			interceptor := DefaultDeadline(tt.timeout)
+			if _, err := interceptor(ctx, &jogv1.StatusRequest{}, &grpc.UnaryServerInfo{FullMethod: jogv1.JobService_Status_FullMethodName}, handler); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if hasDeadline != (tt.wantTimeout > 0) {
+				t.Fatalf("expected a deadline: %v, got %v", tt.wantTimeout > 0, hasDeadline)
+			}
+			if !hasDeadline {
+				return
+			}
+			// the deadline is set between before and now, plus the timeout
+			if deadline.Before(before.Add(tt.wantTimeout)) || deadline.After(time.Now().Add(tt.wantTimeout)) {
+				t.Fatalf("expected a deadline %v from now, got one %v from now", tt.wantTimeout, time.Until(deadline))
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+	"unicode"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/reflection"
+	reflectionv1 "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	reflectionv1alpha "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+)
+
+// RegisterAdminReflection registers the gRPC reflection service on s, so admins can explore
+// the API with tools like grpcurl. Each call is checked against the caller's certificate,
+// callers whose common name isn't in admins get a PermissionDenied error. Both the v1 and
+// v1alpha versions are registered, like reflection.Register.
+func RegisterAdminReflection(s *grpc.Server, admins *Admins) {
+	svr := reflection.NewServerV1(reflection.ServerOptions{Services: s})
+	reflectionv1.RegisterServerReflectionServer(s, adminReflectionServer{ServerReflectionServer: svr, admins: admins})
+	reflectionv1alpha.RegisterServerReflectionServer(s, adminReflectionServerV1Alpha{
+		ServerReflectionServer: reflection.NewServer(reflection.ServerOptions{Services: s}),
+		admins:                 admins,
+	})
+}
+
+// Admins is the set of common names of admin client certificates. It's safe for concurrent
+// use, and can be replaced while the server is running, e.g. when the admins file is reloaded.
+type Admins struct {
+	set atomic.Pointer[map[string]bool]
+}
+
+// NewAdmins returns the set of admins with the given common names
+func NewAdmins(cns []string) *Admins {
+	a := &Admins{}
+	a.Set(cns)
+	return a
+}
+
+// Set replaces the admins with the given common names
+func (a *Admins) Set(cns []string) {
+	set := make(map[string]bool, len(cns))
+	for _, cn := range cns {
+		set[cn] = true
+	}
+	a.set.Store(&set)
+}
+
+// Load replaces the admins with the common names in the file at path, see ReadAdminsFile.
+// If the file can't be read or is invalid, the admins are left as they were.
+func (a *Admins) Load(path string) error {
+	cns, err := ReadAdminsFile(path)
+	if err != nil {
+		return err
+	}
+	a.Set(cns)
+	return nil
+}
+
+// Contains reports whether cn is an admin's common name. A nil Admins has no admins.
+func (a *Admins) Contains(cn string) bool {
+	if a == nil {
+		return false
+	}
+	return (*a.set.Load())[cn]
+}
+
+// ReadAdminsFile reads the common names of admins from a file, one per line. Blank lines
+// and lines starting with # are skipped, and surrounding whitespace is trimmed.
+func ReadAdminsFile(path string) ([]string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading admins file: %w", err)
+	}
+	var cns []string
+	for i, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		// X.509 limits common names to 64 characters
+		if len(line) > 64 || strings.IndexFunc(line, unicode.IsControl) >= 0 {
+			return nil, fmt.Errorf("reading admins file: line %d: invalid common name", i+1)
+		}
+		cns = append(cns, line)
+	}
+	return cns, nil
+}
+
+// authorize returns a PermissionDenied error unless the caller is an admin
+func (a *Admins) authorize(ctx context.Context) error {
+	username, err := CommonNameFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	if !a.Contains(username) {
+		return status.Error(codes.PermissionDenied, "server reflection is only available to admins")
+	}
+	return nil
+}
+
+// adminReflectionServer is the v1 reflection service, for admins only
+type adminReflectionServer struct {
+	reflectionv1.ServerReflectionServer
+	admins *Admins
+}
+
+func (s adminReflectionServer) ServerReflectionInfo(stream reflectionv1.ServerReflection_ServerReflectionInfoServer) error {
+	if err := s.admins.authorize(stream.Context()); err != nil {
+		return err
+	}
+	return s.ServerReflectionServer.ServerReflectionInfo(stream)
+}
+
+// adminReflectionServerV1Alpha is the v1alpha reflection service, for admins only.
+// Many clients, including grpcurl, try v1alpha first.
+type adminReflectionServerV1Alpha struct {
+	reflectionv1alpha.ServerReflectionServer
+	admins *Admins
+}
+
+func (s adminReflectionServerV1Alpha) ServerReflectionInfo(stream reflectionv1alpha.ServerReflection_ServerReflectionInfoServer) error {
+	if err := s.admins.authorize(stream.Context()); err != nil {
+		return err
+	}
+	return s.ServerReflectionServer.ServerReflectionInfo(stream)
+}
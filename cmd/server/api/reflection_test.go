@@ -0,0 +1,157 @@
+package api
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	jogv1 "github.com/dustinevan/jogger/pkg/gen/jogger/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/reflection"
+	reflectionv1 "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+)
+
+// fakeReflectionStream is a reflection stream that sends one list services request
+type fakeReflectionStream struct {
+	grpc.ServerStream
+	ctx       context.Context
+	sent      bool
+	responses []*reflectionv1.ServerReflectionResponse
+}
+
+func (f *fakeReflectionStream) Context() context.Context {
+	return f.ctx
+}
+
+func (f *fakeReflectionStream) Recv() (*reflectionv1.ServerReflectionRequest, error) {
+	if f.sent {
+		return nil, io.EOF
+	}
+	f.sent = true
+	return &reflectionv1.ServerReflectionRequest{
+		MessageRequest: &reflectionv1.ServerReflectionRequest_ListServices{},
+	}, nil
+}
+
+func (f *fakeReflectionStream) Send(resp *reflectionv1.ServerReflectionResponse) error {
+	f.responses = append(f.responses, resp)
+	return nil
+}
+
+func TestAdminReflection(t *testing.T) {
+	t.Parallel()
+
+	server := grpc.NewServer()
+	jogv1.RegisterJobServiceServer(server, &Server{})
+	RegisterAdminReflection(server, NewAdmins([]string{"admin1"}))
+	svr := server.GetServiceInfo()
+	if _, ok := svr["grpc.reflection.v1.ServerReflection"]; !ok {
+		t.Fatalf("expected the v1 reflection service to be registered")
+	}
+	if _, ok := svr["grpc.reflection.v1alpha.ServerReflection"]; !ok {
+		t.Fatalf("expected the v1alpha reflection service to be registered")
+	}
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		wantCode codes.Code
+	}{
+		{name: "admin", ctx: tlsPeerContext(certWithCN("admin1")), wantCode: codes.OK},
+		{name: "not an admin", ctx: tlsPeerContext(certWithCN("user1")), wantCode: codes.PermissionDenied},
+		{name: "no certificate", ctx: tlsPeerContext(), wantCode: codes.Unauthenticated},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := adminReflectionServer{
+				ServerReflectionServer: reflection.NewServerV1(reflection.ServerOptions{Services: server}),
+				admins:                 NewAdmins([]string{"admin1"}),
+			}
+			stream := &fakeReflectionStream{ctx: tt.ctx}
+			err := r.ServerReflectionInfo(stream)
+			if code := status.Code(err); code != tt.wantCode {
+				t.Fatalf("expected code %v, got %v: %v", tt.wantCode, code, err)
+			}
+			if tt.wantCode != codes.OK {
+				if len(stream.responses) != 0 {
+					t.Fatalf("expected no responses, got %d", len(stream.responses))
+				}
+				return
+			}
+
+			if len(stream.responses) != 1 {
+				t.Fatalf("expected 1 response, got %d", len(stream.responses))
+			}
+			var names []string
+			for _, svc := range stream.responses[0].GetListServicesResponse().GetService() {
+				names = append(names, svc.GetName())
+			}
+			found := false
+			for _, n := range names {
+				found = found || n == "jogger.v1.JobService"
+			}
+			if !found {
+				t.Fatalf("expected jogger.v1.JobService to be listed, got %v", names)
+			}
+		})
+	}
+}
+
+func TestAdminsLoad(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "admins")
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("writing admins file: %v", err)
+		}
+	}
+	user2 := tlsPeerContext(certWithCN("user2"))
+
+	write("admin1\n")
+	admins := NewAdmins(nil)
+	if err := admins.Load(path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if code := status.Code(admins.authorize(user2)); code != codes.PermissionDenied {
+		t.Fatalf("expected user2 to be denied, got %v", code)
+	}
+
+	// reload while callers are being authorized
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			admins.authorize(user2)
+		}
+	}()
+	write("# admins can use server reflection\nadmin1\n\n  user2  \n")
+	if err := admins.Load(path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	<-done
+	if err := admins.authorize(user2); err != nil {
+		t.Fatalf("expected user2 to be allowed after the reload, got %v", err)
+	}
+
+	// an invalid file is rejected, and the admins are kept
+	write("admin1\nbad\x01name\n")
+	if err := admins.Load(path); err == nil {
+		t.Fatalf("expected an error for an invalid admins file")
+	}
+	if err := admins.Load(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Fatalf("expected an error for a missing admins file")
+	}
+	if err := admins.authorize(user2); err != nil {
+		t.Fatalf("expected user2 to still be allowed, got %v", err)
+	}
+}
@@ -10,20 +10,33 @@ import (
 	"net"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
 	"time"
 
 	"github.com/ardanlabs/conf/v3"
 	"github.com/dustinevan/jogger/cmd/server/api"
+	"github.com/dustinevan/jogger/lib/cgroup"
 	"github.com/dustinevan/jogger/lib/job"
+	"github.com/dustinevan/jogger/lib/objstore"
 	joggerv1 "github.com/dustinevan/jogger/pkg/gen/jogger/v1"
+	"github.com/dustinevan/jogger/pkg/humanize"
 	"github.com/dustinevan/jogger/pkg/logger"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
+
+	// Registering the gzip compressor lets clients request compressed output streams with
+	// jog output --compress. Compression is only used when a client asks for it, because
+	// it costs CPU time on the server for every message sent. Output messages are at
+	// most streamMessageSize bytes, which is large enough to compress reasonably well.
+	_ "google.golang.org/grpc/encoding/gzip"
 )
 
 func main() {
+	// jobs with a umask or resource limits are started by re-running this binary as a helper
+	job.RunExecHelper()
+
 	// Set up the zap logger
 	log, err := logger.New("JOGGER-SERVER")
 	if err != nil {
@@ -54,6 +67,103 @@ func run(log *zap.SugaredLogger) error {
 		}
 		Server struct {
 			Port int `conf:"env:JOGGER_SERVER_PORT,default:50051"`
+			// MaxStreams caps the number of open output streams across all jobs, 0 is no limit
+			MaxStreams int64 `conf:"env:JOGGER_MAX_STREAMS,default:1024"`
+			// AdminCNs lists the common names of admin client certificates, separated by ;
+			// Admins can use gRPC server reflection, e.g. with grpcurl, and see the address jobs
+			// were started from in status. Reflection is disabled when empty.
+			AdminCNs []string `conf:"env:JOGGER_ADMIN_CNS"`
+			// AdminsFile lists admin common names, one per line, instead of AdminCNs. It's reloaded
+			// on SIGHUP, so admins can be changed without a restart. An invalid file is ignored.
+			AdminsFile string `conf:"env:JOGGER_ADMINS_FILE"`
+			// MaxRunningJobs caps the number of running jobs across all users, 0 is no limit
+			MaxRunningJobs int64 `conf:"env:JOGGER_MAX_RUNNING_JOBS,default:0"`
+			// MaxLoad rejects new jobs while the host's 1 minute load average is over it, 0 is no limit
+			MaxLoad float64 `conf:"env:JOGGER_MAX_LOAD,default:0"`
+			// MinAvailableMemory rejects new jobs while the host has less memory available, e.g.
+			// 512M. Disabled when empty.
+			MinAvailableMemory string `conf:"env:JOGGER_MIN_AVAILABLE_MEMORY"`
+			// MaxStartRequestSize rejects start requests whose serialized size is over it, e.g. 1M
+			MaxStartRequestSize string `conf:"env:JOGGER_MAX_START_REQUEST_SIZE,default:1M"`
+			// DefaultDeadline bounds requests from clients that don't set a deadline, 0 disables it.
+			// Streams, like output, aren't bounded.
+			DefaultDeadline time.Duration `conf:"env:JOGGER_DEFAULT_DEADLINE,default:30s"`
+		}
+		Log struct {
+			// MaskedFields lists job fields to mask in logs, cmd and/or args, separated by ;
+			MaskedFields []string `conf:"env:JOGGER_LOG_MASKED_FIELDS"`
+			MaxArgs      int      `conf:"env:JOGGER_LOG_MAX_ARGS,default:32"`
+		}
+		Job struct {
+			// Path is used to resolve job commands, and is set as the PATH in each job's environment.
+			// job.DefaultPath is used when it's empty.
+			Path string `conf:"env:JOGGER_JOB_PATH"`
+			// StopPolicy is graceful: SIGTERM then SIGKILL after a delay, or immediate: SIGKILL only
+			StopPolicy string `conf:"env:JOGGER_STOP_POLICY,default:graceful"`
+			// Capture is which output streams are kept for jobs that don't choose: both, stdout, or stderr
+			Capture string `conf:"env:JOGGER_CAPTURE,default:both"`
+			// OutputFIFODir enables per-job named pipes, <dir>/<jobID>.fifo, that job output is copied to
+			OutputFIFODir string `conf:"env:JOGGER_OUTPUT_FIFO_DIR"`
+			// OutputBuffer caps each job's output kept in memory, e.g. 64M. Older output is spilled to a
+			// temporary file in OutputSpillDir, the system's temporary directory when empty. Output is
+			// kept in memory when OutputBuffer is empty.
+			OutputBuffer   string `conf:"env:JOGGER_OUTPUT_BUFFER"`
+			OutputSpillDir string `conf:"env:JOGGER_OUTPUT_SPILL_DIR"`
+			// OutputInitialCapacity is preallocated for each job's output in memory, so chatty jobs
+			// reallocate it less as it grows. Every job allocates it, 0 starts with an empty buffer.
+			OutputInitialCapacity string `conf:"env:JOGGER_OUTPUT_INITIAL_CAPACITY,default:64K"`
+			// OutputRedact lists secrets to mask with *** in every job's output, separated by ;, and
+			// OutputRedactPatterns lists regular expressions to mask. Clients can add their own when
+			// they start a job. Masking is best-effort, e.g. secrets a job encodes get through.
+			OutputRedact         []string `conf:"env:JOGGER_OUTPUT_REDACT,mask"`
+			OutputRedactPatterns []string `conf:"env:JOGGER_OUTPUT_REDACT_PATTERNS,mask"`
+			// FailedStartTTL is how long jobs that couldn't be started are reported as START_FAILED, 0 disables it
+			FailedStartTTL time.Duration `conf:"env:JOGGER_FAILED_START_TTL,default:0s"`
+			// CleanupWorkers bounds how many done jobs' cgroups are cleaned up at once, 0 uses
+			// job.DefaultCleanupWorkers
+			CleanupWorkers int `conf:"env:JOGGER_CLEANUP_WORKERS,default:0"`
+			// MaxRetainedJobs caps the number of done jobs kept, with their output, 0 is no limit.
+			// The least recently accessed are removed first.
+			MaxRetainedJobs int `conf:"env:JOGGER_MAX_RETAINED_JOBS,default:0"`
+			// AllowShell lets clients run jobs under sh -c with jog start --shell. It's off by default:
+			// the shell interprets the command line, so it's open to injection when clients build it
+			// from untrusted input.
+			AllowShell bool `conf:"env:JOGGER_ALLOW_SHELL,default:false"`
+		}
+		Cgroup struct {
+			// RootPath is where the cgroup v2 filesystem is mounted. Jobs run in cgroups under
+			// <RootPath>/<ServerName>, which the server creates at startup.
+			RootPath   string `conf:"env:JOGGER_CGROUP_ROOT,default:/sys/fs/cgroup"`
+			ServerName string `conf:"env:JOGGER_CGROUP_SERVER_NAME,default:jogger"`
+			// StrictLimits fails job starts when their cgroup limits don't read back as written,
+			// e.g. because the cpu or memory controllers aren't delegated to the server's cgroup
+			StrictLimits bool `conf:"env:JOGGER_STRICT_LIMITS,default:false"`
+			// UserMemoryQuota caps the memory all of a user's jobs use together, e.g. 8G. No limit when empty.
+			UserMemoryQuota string `conf:"env:JOGGER_USER_MEMORY_QUOTA"`
+			// UserCPUQuota caps the CPUs all of a user's jobs use together, e.g. 1.5, 0 is no limit
+			UserCPUQuota float64 `conf:"env:JOGGER_USER_CPU_QUOTA,default:0"`
+			// InitTimeout bounds how long setting up the server's cgroup at startup can take, 0 uses
+			// the cgroup package's default
+			InitTimeout time.Duration `conf:"env:JOGGER_CGROUP_INIT_TIMEOUT,default:0s"`
+		}
+		Snapshot struct {
+			// Endpoint enables uploading each job's output to an S3-compatible bucket, while it runs
+			// and when it's done, e.g. https://s3.us-east-1.amazonaws.com. Disabled when empty.
+			Endpoint string `conf:"env:JOGGER_SNAPSHOT_ENDPOINT"`
+			Bucket   string `conf:"env:JOGGER_SNAPSHOT_BUCKET"`
+			// Prefix starts each object's name, objects are named <prefix><username>/<jobID>/<offset>
+			Prefix          string `conf:"env:JOGGER_SNAPSHOT_PREFIX,default:jogger/"`
+			Region          string `conf:"env:JOGGER_SNAPSHOT_REGION,default:us-east-1"`
+			AccessKeyID     string `conf:"env:JOGGER_SNAPSHOT_ACCESS_KEY_ID"`
+			SecretAccessKey string `conf:"env:JOGGER_SNAPSHOT_SECRET_ACCESS_KEY,mask"`
+			// Interval is how often running jobs' output is uploaded, 0 only uploads it when they're done
+			Interval time.Duration `conf:"env:JOGGER_SNAPSHOT_INTERVAL,default:1m"`
+		}
+		Metrics struct {
+			// TextfileDir enables writing job metrics to <dir>/jogger.prom every TextfileInterval,
+			// for node_exporter's textfile collector. Disabled when empty.
+			TextfileDir      string        `conf:"env:JOGGER_METRICS_TEXTFILE_DIR"`
+			TextfileInterval time.Duration `conf:"env:JOGGER_METRICS_TEXTFILE_INTERVAL,default:15s"`
 		}
 	}{}
 
@@ -74,6 +184,67 @@ func run(log *zap.SugaredLogger) error {
 
 	log.Infow("starting service", "configuration\n", cfgString)
 
+	stopPolicy, err := job.ParseStopPolicy(cfg.Job.StopPolicy)
+	if err != nil {
+		return fmt.Errorf("parsing config: %w", err)
+	}
+	capture, err := job.ParseCapture(cfg.Job.Capture)
+	if err != nil {
+		return fmt.Errorf("parsing config: %w", err)
+	}
+	// admins can use server reflection, it's disabled when there are none
+	var admins *api.Admins
+	if cfg.Server.AdminsFile != "" {
+		admins = api.NewAdmins(nil)
+		if err := admins.Load(cfg.Server.AdminsFile); err != nil {
+			return fmt.Errorf("parsing config: %w", err)
+		}
+	} else if len(cfg.Server.AdminCNs) > 0 {
+		admins = api.NewAdmins(cfg.Server.AdminCNs)
+	}
+	admission := job.AdmissionLimits{MaxLoad1: cfg.Server.MaxLoad}
+	if cfg.Server.MinAvailableMemory != "" {
+		admission.MinAvailableMemoryBytes, err = humanize.ParseBytes(cfg.Server.MinAvailableMemory)
+		if err != nil {
+			return fmt.Errorf("parsing config: min available memory: %w", err)
+		}
+	}
+	maxStartRequestSize, err := humanize.ParseBytes(cfg.Server.MaxStartRequestSize)
+	if err != nil {
+		return fmt.Errorf("parsing config: max start request size: %w", err)
+	}
+	var outputBuffer int64
+	if cfg.Job.OutputBuffer != "" {
+		outputBuffer, err = humanize.ParseBytes(cfg.Job.OutputBuffer)
+		if err != nil {
+			return fmt.Errorf("parsing config: output buffer: %w", err)
+		}
+	}
+	userQuota := cgroup.UserQuota{CPUs: cfg.Cgroup.UserCPUQuota}
+	if cfg.Cgroup.UserMemoryQuota != "" {
+		userQuota.MemoryMaxBytes, err = humanize.ParseBytes(cfg.Cgroup.UserMemoryQuota)
+		if err != nil {
+			return fmt.Errorf("parsing config: user memory quota: %w", err)
+		}
+	}
+	outputCapacity, err := humanize.ParseBytes(cfg.Job.OutputInitialCapacity)
+	if err != nil {
+		return fmt.Errorf("parsing config: output initial capacity: %w", err)
+	}
+	if _, err := job.CompileRedaction(cfg.Job.OutputRedact, cfg.Job.OutputRedactPatterns); err != nil {
+		return fmt.Errorf("parsing config: output redaction: %w", err)
+	}
+	// job output is uploaded to the bucket when an endpoint is set
+	var snapshots *objstore.S3
+	if cfg.Snapshot.Endpoint != "" {
+		snapshots, err = objstore.NewS3(cfg.Snapshot.Endpoint, cfg.Snapshot.Bucket,
+			objstore.WithRegion(cfg.Snapshot.Region),
+			objstore.WithCredentials(cfg.Snapshot.AccessKeyID, cfg.Snapshot.SecretAccessKey))
+		if err != nil {
+			return fmt.Errorf("parsing config: snapshot: %w", err)
+		}
+	}
+
 	// ===============================================================================
 	// mTLS Configuration
 
@@ -109,14 +280,72 @@ func run(log *zap.SugaredLogger) error {
 	// ===============================================================================
 	// Start Server
 
+	log.Infow("starting service", "initializing", "cgroups")
+
+	cgroups, err := cgroup.NewFSManager(shutdownCtx,
+		cgroup.WithRootPath(cfg.Cgroup.RootPath),
+		cgroup.WithServerCGroupName(cfg.Cgroup.ServerName),
+		cgroup.WithStrictLimits(cfg.Cgroup.StrictLimits),
+		cgroup.WithUserQuota(userQuota),
+		cgroup.WithInitTimeout(cfg.Cgroup.InitTimeout))
+	if err != nil {
+		shutdown()
+		return fmt.Errorf("setting up cgroups: %w", err)
+	}
+
 	log.Infow("starting service", "initializing", "grpc server")
 
-	jobManager := job.NewManager(shutdownCtx)
+	managerOptions := []job.ManagerOption{
+		job.WithCgroupManager(cgroups),
+		job.WithJobPath(cfg.Job.Path),
+		job.WithDefaultStopPolicy(stopPolicy),
+		job.WithDefaultCapture(capture),
+		job.WithMaxStreams(cfg.Server.MaxStreams),
+		job.WithMaxRunningJobs(cfg.Server.MaxRunningJobs),
+		job.WithAdmissionLimits(admission),
+		job.WithFailedStartTTL(cfg.Job.FailedStartTTL),
+		job.WithCleanupWorkers(cfg.Job.CleanupWorkers),
+		job.WithMaxRetainedJobs(cfg.Job.MaxRetainedJobs),
+		job.WithAllowShell(cfg.Job.AllowShell),
+		job.WithJobOutputBuffer(outputBuffer, cfg.Job.OutputSpillDir),
+		job.WithJobOutputCapacity(int(outputCapacity)),
+		job.WithJobOutputRedaction(cfg.Job.OutputRedact, cfg.Job.OutputRedactPatterns),
+		job.WithLogger(log),
+	}
+	if cfg.Job.OutputFIFODir != "" {
+		managerOptions = append(managerOptions, job.WithOutputFIFODir(cfg.Job.OutputFIFODir))
+	}
+	if snapshots != nil {
+		managerOptions = append(managerOptions, job.WithOutputSnapshots(snapshots, cfg.Snapshot.Prefix, cfg.Snapshot.Interval))
+	}
+	jobManager := job.NewManager(shutdownCtx, managerOptions...)
 
-	joggerServer := api.NewServer(jobManager, log)
+	joggerServer := api.NewServer(jobManager, log, api.WithLogRedaction(api.LogRedaction{
+		MaskedFields: cfg.Log.MaskedFields,
+		MaxArgs:      cfg.Log.MaxArgs,
+	}), api.WithAdmins(admins))
 
-	server := grpc.NewServer(grpc.Creds(credentials.NewTLS(tlsConfig)))
+	server := grpc.NewServer(
+		grpc.Creds(credentials.NewTLS(tlsConfig)),
+		grpc.ChainUnaryInterceptor(
+			api.DefaultDeadline(cfg.Server.DefaultDeadline),
+			api.MaxStartRequestSize(int(maxStartRequestSize)),
+		),
+		grpc.ChainStreamInterceptor(
+			api.MaxStartStreamRequestSize(int(maxStartRequestSize)),
+		),
+	)
 	joggerv1.RegisterJobServiceServer(server, joggerServer)
+	if admins != nil {
+		api.RegisterAdminReflection(server, admins)
+		if cfg.Server.AdminsFile != "" {
+			go reloadAdminsOnHangup(shutdownCtx, log, admins, cfg.Server.AdminsFile)
+		}
+	}
+	if cfg.Metrics.TextfileDir != "" {
+		path := filepath.Join(cfg.Metrics.TextfileDir, "jogger.prom")
+		go writeMetricsTextfile(shutdownCtx, log, jobManager, path, cfg.Metrics.TextfileInterval)
+	}
 
 	lis, err := net.Listen("tcp", fmt.Sprintf("localhost:%d", cfg.Server.Port))
 	if err != nil {
@@ -141,34 +370,61 @@ func run(log *zap.SugaredLogger) error {
 	case err = <-serverErr:
 		log.Infow("stopping service", "error", err)
 	}
-	// Canceling the shutdownCtx cancels the context for each job. Each running job will
-	// receive a SIGTERM and be given a chance to gracefully shutdown. After the WaitDelay
-	// period, the jobs will be sent a SIGKILL.
-	shutdown()
+	// A second signal forces the shutdown. It's watched on a channel of its own, registered
+	// before the first is released, so it's observed no matter how the first wait ended.
+	force := make(chan os.Signal, 1)
+	signal.Notify(force, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(force)
+	signal.Stop(terminate)
 
 	// this shutdown is set to be 5 seconds longer than the wait delay for the jobs
 	// this should be configurable in the future
-	shutdownTimeout := 15 * time.Second
+	status := gracefulShutdown(server, shutdown, shutdownConfig{
+		drain:   jobManager.DrainStreams,
+		force:   force,
+		timeout: 15 * time.Second,
+	})
+	log.Infow("stopping service", "status", status)
+	return nil
+}
 
-	// shutdown the server in a goroutine so we can time out
-	done := make(chan struct{})
-	defer close(done)
-	go func() {
-		server.GracefulStop()
-		done <- struct{}{}
-	}()
+// reloadAdminsOnHangup reloads the admins from path each time the server gets a SIGHUP,
+// until ctx is done. If the file is invalid, the admins are left as they were.
+func reloadAdminsOnHangup(ctx context.Context, log *zap.SugaredLogger, admins *api.Admins, path string) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hangup:
+			if err := admins.Load(path); err != nil {
+				log.Errorw("reloading admins", "error", err, "status", "keeping the current admins")
+				continue
+			}
+			log.Infow("reloading admins", "status", "done")
+		}
+	}
+}
 
-	// wait for one of the shutdown conditions to be met
-	select {
-	case <-terminate:
-		// the user has sent another terminate signal -- force shutdown
-		server.Stop()
-		log.Infow("stopping service", "status", "forced shutdown")
-	case <-done:
-		log.Infow("stopping service", "status", "graceful shutdown complete")
-	case <-time.After(shutdownTimeout):
-		server.Stop()
-		log.Infow("stopping service", "status", "forced shutdown")
+// writeMetricsTextfile writes the job metrics to path every interval, until ctx is done.
+// Failed writes are logged, and the file is left as it was until the next one.
+func writeMetricsTextfile(ctx context.Context, log *zap.SugaredLogger, m *job.Manager, path string, interval time.Duration) {
+	if interval <= 0 {
+		log.Errorw("writing metrics textfile", "error", "interval must be positive", "interval", interval)
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := m.WriteMetricsFile(path); err != nil {
+			log.Errorw("writing metrics textfile", "error", err, "path", path)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
 	}
-	return nil
 }
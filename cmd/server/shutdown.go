@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+)
+
+// grpcStopper is the part of *grpc.Server used to shut it down
+type grpcStopper interface {
+	GracefulStop()
+	Stop()
+}
+
+// shutdownConfig configures gracefulShutdown
+type shutdownConfig struct {
+	// drain is called after the jobs are canceled, before the server is stopped. It's used to
+	// flush and close output streams, so they don't hold up the graceful stop. It may be nil.
+	drain func()
+	// force receives the signal that forces the shutdown, e.g. a second SIGTERM
+	force <-chan os.Signal
+	// timeout is how long the server has to stop gracefully before it's forced to stop
+	timeout time.Duration
+	// after is the clock used for the timeout, it defaults to time.After
+	after func(time.Duration) <-chan time.Time
+}
+
+// gracefulShutdown cancels the jobs with shutdown, drains output streams, and gracefully
+// stops server, waiting for in-flight RPCs to finish. The server is forced to stop, closing
+// connections and canceling RPCs, when a signal is received on cfg.force or after
+// cfg.timeout. gracefulShutdown returns once the server has stopped, with how it stopped.
+func gracefulShutdown(server grpcStopper, shutdown context.CancelFunc, cfg shutdownConfig) string {
+	after := cfg.after
+	if after == nil {
+		after = time.After
+	}
+	// Canceling the shutdownCtx cancels the context for each job. Each running job will
+	// receive a SIGTERM and be given a chance to gracefully shutdown. After the WaitDelay
+	// period, the jobs will be sent a SIGKILL.
+	shutdown()
+
+	// Flush the output buffered so far to all clients streaming output, and close their streams.
+	// Otherwise, GracefulStop waits for streams to end on their own, until the shutdown timeout
+	// forces them closed.
+	if cfg.drain != nil {
+		cfg.drain()
+	}
+
+	timeout := after(cfg.timeout)
+	done := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return "graceful shutdown complete"
+	case sig := <-cfg.force:
+		// the user has sent another terminate signal
+		server.Stop()
+		<-done
+		return fmt.Sprintf("forced shutdown: received %s", sig)
+	case <-timeout:
+		server.Stop()
+		<-done
+		return fmt.Sprintf("forced shutdown: not done after %s", cfg.timeout)
+	}
+}
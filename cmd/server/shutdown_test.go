@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// fakeServer is a grpcStopper whose GracefulStop blocks until finish is closed, or Stop is called
+type fakeServer struct {
+	finish   chan struct{}
+	stopOnce sync.Once
+	stopped  chan struct{}
+}
+
+func newFakeServer() *fakeServer {
+	return &fakeServer{finish: make(chan struct{}), stopped: make(chan struct{})}
+}
+
+func (f *fakeServer) GracefulStop() {
+	select {
+	case <-f.finish:
+	case <-f.stopped:
+	}
+}
+
+func (f *fakeServer) Stop() {
+	f.stopOnce.Do(func() { close(f.stopped) })
+}
+
+func (f *fakeServer) wasStopped() bool {
+	select {
+	case <-f.stopped:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestGracefulShutdown(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		// act is called once gracefulShutdown is waiting for the server to stop
+		act        func(server *fakeServer, force chan os.Signal, timeout chan time.Time)
+		wantStatus string
+		wantStop   bool
+	}{
+		{
+			name:       "graceful",
+			act:        func(server *fakeServer, _ chan os.Signal, _ chan time.Time) { close(server.finish) },
+			wantStatus: "graceful shutdown complete",
+		},
+		{
+			name:       "second signal",
+			act:        func(_ *fakeServer, force chan os.Signal, _ chan time.Time) { force <- syscall.SIGTERM },
+			wantStatus: "forced shutdown: received terminated",
+			wantStop:   true,
+		},
+		{
+			name:       "timeout",
+			act:        func(_ *fakeServer, _ chan os.Signal, timeout chan time.Time) { timeout <- time.Now() },
+			wantStatus: "forced shutdown: not done after 15s",
+			wantStop:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			server := newFakeServer()
+			force := make(chan os.Signal, 1)
+			timeout := make(chan time.Time, 1)
+			// waiting is closed when the timeout is started, after the jobs are canceled and drained
+			waiting := make(chan struct{})
+			var steps []string
+			cfg := shutdownConfig{
+				drain:   func() { steps = append(steps, "drain") },
+				force:   force,
+				timeout: 15 * time.Second,
+				after: func(d time.Duration) <-chan time.Time {
+					steps = append(steps, "wait "+d.String())
+					close(waiting)
+					return timeout
+				},
+			}
+			result := make(chan string, 1)
+			go func() {
+				result <- gracefulShutdown(server, func() { steps = append(steps, "shutdown") }, cfg)
+			}()
+
+			<-waiting
+			tt.act(server, force, timeout)
+			select {
+			case status := <-result:
+				if status != tt.wantStatus {
+					t.Fatalf("expected status %q, got %q", tt.wantStatus, status)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("gracefulShutdown didn't return")
+			}
+			if server.wasStopped() != tt.wantStop {
+				t.Fatalf("expected Stop called to be %v", tt.wantStop)
+			}
+			if got, want := fmt.Sprint(steps), "[shutdown drain wait 15s]"; got != want {
+				t.Fatalf("expected steps %s, got %s", want, got)
+			}
+		})
+	}
+}
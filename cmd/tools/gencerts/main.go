@@ -6,27 +6,69 @@ import (
 	"crypto/rand"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/json"
 	"encoding/pem"
+	"flag"
 	"fmt"
+	"io"
 	"math/big"
 	"os"
 	"path/filepath"
+	"strconv"
 	"time"
+
+	"software.sslmate.com/src/go-pkcs12"
 )
 
 var certDir = "certs/"
 
+var (
+	p12         bool
+	p12Password string
+	jsonOutput  bool
+)
+
+func init() {
+	flag.BoolVar(&p12, "p12", false, "also write the client cert, key, and CA cert as a PKCS#12 bundle")
+	flag.StringVar(&p12Password, "p12-password", pkcs12.DefaultPassword, "the password used to encrypt the PKCS#12 bundle")
+	flag.BoolVar(&jsonOutput, "json", false, "print the generated file paths and environment variables as JSON")
+}
+
+const serverPort = 50051
+
+// generatedFiles holds the absolute paths of the generated files
+type generatedFiles struct {
+	CACert     string `json:"ca_cert"`
+	ServerCert string `json:"server_cert"`
+	ServerKey  string `json:"server_key"`
+	UserCert   string `json:"user_cert"`
+	UserKey    string `json:"user_key"`
+	UserP12    string `json:"user_p12,omitempty"`
+}
+
+// output is the machine-readable output printed with --json
+type output struct {
+	Files     generatedFiles    `json:"files"`
+	ServerEnv map[string]string `json:"server_env"`
+	ClientEnv map[string]string `json:"client_env"`
+}
+
 func main() {
+	flag.Parse()
 
-	if _, err := os.Stat(certDir); os.IsNotExist(err) {
-		os.Mkdir(certDir, 0755)
+	files := generate()
+	if jsonOutput {
+		if err := writeJSON(os.Stdout, files); err != nil {
+			fmt.Printf("failed to write JSON output: %v\n", err)
+			os.Exit(1)
+		}
+		return
 	}
 
-	crt, key, certAbsPath := caCert()
-	serverCertAbsPath, serverKeyAbsPath := serverCert(crt, key)
-	clientCertAbsPath, clientKeyAbsPath := clientCert(crt, key)
-
 	fmt.Println("Certificates generated successfully.")
+	if files.UserP12 != "" {
+		fmt.Printf("\n    The client PKCS#12 bundle was written to: %s\n", files.UserP12)
+	}
 	// Print exports needed for client and server
 	fmt.Printf(`
     To use the generated certificates, set the following environment variables:
@@ -42,9 +84,60 @@ func main() {
         export JOGGER_CA_CERT_FILE=%s
         export JOGGER_USER_CERT_FILE=%s
         export JOGGER_USER_KEY_FILE=%s
-        export JOGGER_HOST=localhost:50051
+        export JOGGER_HOST=localhost:%d
 
-`, certAbsPath, 50051, serverCertAbsPath, serverKeyAbsPath, certAbsPath, clientCertAbsPath, clientKeyAbsPath)
+`, files.CACert, serverPort, files.ServerCert, files.ServerKey, files.CACert, files.UserCert, files.UserKey, serverPort)
+}
+
+// generate writes the certificates and keys to certDir, and returns their absolute paths
+func generate() generatedFiles {
+	if _, err := os.Stat(certDir); os.IsNotExist(err) {
+		os.Mkdir(certDir, 0755)
+	}
+
+	crt, key, certAbsPath := caCert()
+	serverCertAbsPath, serverKeyAbsPath := serverCert(crt, key)
+	clientCertAbsPath, clientKeyAbsPath, clientCrt, clientKey := clientCert(crt, key)
+	files := generatedFiles{
+		CACert:     certAbsPath,
+		ServerCert: serverCertAbsPath,
+		ServerKey:  serverKeyAbsPath,
+		UserCert:   clientCertAbsPath,
+		UserKey:    clientKeyAbsPath,
+	}
+
+	if p12 {
+		p12AbsPath, err := writeP12("certs/user1_tls.p12", clientCrt, clientKey, crt, p12Password)
+		if err != nil {
+			fmt.Printf("failed to write PKCS#12 bundle: %v\n", err)
+			os.Exit(1)
+		}
+		files.UserP12 = p12AbsPath
+	}
+	return files
+}
+
+// writeJSON writes the file paths and the environment variables for the server and
+// client as a JSON object, so provisioning scripts don't have to parse the text output
+func writeJSON(w io.Writer, files generatedFiles) error {
+	out := output{
+		Files: files,
+		ServerEnv: map[string]string{
+			"JOGGER_CA_CERT_FILE":     files.CACert,
+			"JOGGER_SERVER_PORT":      strconv.Itoa(serverPort),
+			"JOGGER_SERVER_CERT_FILE": files.ServerCert,
+			"JOGGER_SERVER_KEY_FILE":  files.ServerKey,
+		},
+		ClientEnv: map[string]string{
+			"JOGGER_CA_CERT_FILE":   files.CACert,
+			"JOGGER_USER_CERT_FILE": files.UserCert,
+			"JOGGER_USER_KEY_FILE":  files.UserKey,
+			"JOGGER_HOST":           fmt.Sprintf("localhost:%d", serverPort),
+		},
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
 }
 
 var maxInt128 = new(big.Int).Lsh(big.NewInt(1), 128)
@@ -204,7 +297,7 @@ func serverCert(caCert *x509.Certificate, caKey *ecdsa.PrivateKey) (keyAbsPath s
 	return certAbsPath, keyAbsPath
 }
 
-func clientCert(caCert *x509.Certificate, caKey *ecdsa.PrivateKey) (certAbsPath string, keyAbsPath string) {
+func clientCert(caCert *x509.Certificate, caKey *ecdsa.PrivateKey) (certAbsPath string, keyAbsPath string, cert *x509.Certificate, key *ecdsa.PrivateKey) {
 	// Generate a ECDSA P256 key pair
 	private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
 	if err != nil {
@@ -238,6 +331,13 @@ func clientCert(caCert *x509.Certificate, caKey *ecdsa.PrivateKey) (certAbsPath
 		os.Exit(1)
 	}
 
+	// The certificate and key are returned in case they're needed for a PKCS#12 bundle
+	cert, err = x509.ParseCertificate(certBytes)
+	if err != nil {
+		fmt.Printf("failed to parse client certificate: %v\n", err)
+		os.Exit(1)
+	}
+
 	// Write the certificate and private key to files
 	certFile, err := os.Create("certs/user1_tls.crt")
 	if err != nil {
@@ -276,5 +376,23 @@ func clientCert(caCert *x509.Certificate, caKey *ecdsa.PrivateKey) (certAbsPath
 		fmt.Printf("failed to get absolute path of key file: %v\n", err)
 		os.Exit(1)
 	}
-	return certAbsPath, keyAbsPath
+	return certAbsPath, keyAbsPath, cert, private
+}
+
+// writeP12 writes the certificate, private key, and CA certificate to a password protected
+// PKCS#12 file, and returns the absolute path of the file. Some clients, like GUI tools,
+// prefer a single bundle to separate PEM files.
+func writeP12(path string, cert *x509.Certificate, key *ecdsa.PrivateKey, caCert *x509.Certificate, password string) (string, error) {
+	pfxData, err := pkcs12.Modern.Encode(key, cert, []*x509.Certificate{caCert}, password)
+	if err != nil {
+		return "", fmt.Errorf("encoding PKCS#12 bundle: %w", err)
+	}
+	if err := os.WriteFile(path, pfxData, 0600); err != nil {
+		return "", fmt.Errorf("writing PKCS#12 file: %w", err)
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("getting absolute path of PKCS#12 file: %w", err)
+	}
+	return absPath, nil
 }
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// testCert creates a certificate signed by parent, or a self-signed CA certificate if parent is nil
+func testCert(t *testing.T, cn string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+	template := &x509.Certificate{
+		Subject:               pkix.Name{Organization: []string{"Jogger"}, CommonName: cn},
+		SerialNumber:          big.NewInt(1),
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+	}
+	if parent == nil {
+		template.IsCA = true
+		template.KeyUsage |= x509.KeyUsageCertSign
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatalf("creating certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("parsing certificate: %v", err)
+	}
+	return cert, key
+}
+
+func TestWriteP12(t *testing.T) {
+	t.Parallel()
+
+	caCrt, caKey := testCert(t, "localhost", nil, nil)
+	clientCrt, clientKey := testCert(t, "user1", caCrt, caKey)
+
+	path := filepath.Join(t.TempDir(), "user1_tls.p12")
+	absPath, err := writeP12(path, clientCrt, clientKey, caCrt, "secret")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !filepath.IsAbs(absPath) {
+		t.Fatalf("expected an absolute path, got %s", absPath)
+	}
+
+	data, err := os.ReadFile(absPath)
+	if err != nil {
+		t.Fatalf("reading PKCS#12 file: %v", err)
+	}
+
+	if _, _, _, err := pkcs12.DecodeChain(data, "wrong"); err == nil {
+		t.Fatalf("expected an error decoding with the wrong password")
+	}
+
+	key, cert, caCerts, err := pkcs12.DecodeChain(data, "secret")
+	if err != nil {
+		t.Fatalf("decoding PKCS#12 bundle: %v", err)
+	}
+	if !cert.Equal(clientCrt) {
+		t.Fatalf("decoded certificate does not match the client certificate")
+	}
+	ecKey, ok := key.(*ecdsa.PrivateKey)
+	if !ok {
+		t.Fatalf("expected an *ecdsa.PrivateKey, got %T", key)
+	}
+	if !ecKey.Equal(clientKey) {
+		t.Fatalf("decoded key does not match the client key")
+	}
+	if len(caCerts) != 1 || !caCerts[0].Equal(caCrt) {
+		t.Fatalf("expected the CA certificate in the bundle, got %d certificates", len(caCerts))
+	}
+}
+
+func TestGenerateJSON(t *testing.T) {
+	// generate writes to the relative certs directory, so this test changes the working
+	// directory and can't run in parallel
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("getting working directory: %v", err)
+	}
+	dir := t.TempDir()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("changing working directory: %v", err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	var buf bytes.Buffer
+	if err := writeJSON(&buf, generate()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var out output
+	if err := json.Unmarshal(buf.Bytes(), &out); err != nil {
+		t.Fatalf("decoding JSON output: %v\n%s", err, buf.String())
+	}
+
+	for name, path := range map[string]string{
+		"ca_cert":     out.Files.CACert,
+		"server_cert": out.Files.ServerCert,
+		"server_key":  out.Files.ServerKey,
+		"user_cert":   out.Files.UserCert,
+		"user_key":    out.Files.UserKey,
+	} {
+		if !filepath.IsAbs(path) {
+			t.Fatalf("%s: expected an absolute path, got %q", name, path)
+		}
+		if _, err := os.Stat(path); err != nil {
+			t.Fatalf("%s: expected the file to exist: %v", name, err)
+		}
+	}
+	if out.Files.UserP12 != "" {
+		t.Fatalf("expected no PKCS#12 bundle without --p12, got %q", out.Files.UserP12)
+	}
+	if !strings.Contains(buf.String(), `"ca_cert"`) || strings.Contains(buf.String(), "user_p12") {
+		t.Fatalf("unexpected JSON keys: %s", buf.String())
+	}
+
+	if got := out.ServerEnv["JOGGER_SERVER_CERT_FILE"]; got != out.Files.ServerCert {
+		t.Fatalf("expected JOGGER_SERVER_CERT_FILE=%s, got %s", out.Files.ServerCert, got)
+	}
+	if got := out.ServerEnv["JOGGER_SERVER_PORT"]; got != "50051" {
+		t.Fatalf("expected JOGGER_SERVER_PORT=50051, got %s", got)
+	}
+	if got := out.ClientEnv["JOGGER_USER_KEY_FILE"]; got != out.Files.UserKey {
+		t.Fatalf("expected JOGGER_USER_KEY_FILE=%s, got %s", out.Files.UserKey, got)
+	}
+	if got := out.ClientEnv["JOGGER_HOST"]; got != "localhost:50051" {
+		t.Fatalf("expected JOGGER_HOST=localhost:50051, got %s", got)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"sort"
@@ -12,18 +13,57 @@ import (
 )
 
 // This copied from a previous project
-var service string
+var service, exclude string
 
 func init() {
-	flag.StringVar(&service, "service", "", "filter which service to see")
+	flag.StringVar(&service, "service", "", "comma-separated list of services to see")
+	flag.StringVar(&exclude, "exclude", "", "comma-separated list of services to hide")
 }
 
 func main() {
 	flag.Parse()
+	if err := format(os.Stdin, os.Stdout, newServiceFilter(service, exclude)); err != nil {
+		log.Println(err)
+	}
+}
+
+// serviceFilter decides which services' log lines are shown
+type serviceFilter struct {
+	include map[string]bool
+	exclude map[string]bool
+}
+
+// newServiceFilter creates a serviceFilter from comma-separated lists of services.
+// An empty include list shows every service that isn't excluded.
+func newServiceFilter(include, exclude string) serviceFilter {
+	return serviceFilter{include: serviceSet(include), exclude: serviceSet(exclude)}
+}
+
+func serviceSet(list string) map[string]bool {
+	set := make(map[string]bool)
+	for _, s := range strings.Split(list, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			set[s] = true
+		}
+	}
+	return set
+}
+
+// match reports whether lines from service should be shown
+func (f serviceFilter) match(service any) bool {
+	s, _ := service.(string)
+	if f.exclude[s] {
+		return false
+	}
+	return len(f.include) == 0 || f.include[s]
+}
+
+// format reads JSON log lines from r and writes them to w in a readable format
+func format(r io.Reader, w io.Writer, filter serviceFilter) error {
 	var b strings.Builder
 
-	// Scan standard input for log data per line.
-	scanner := bufio.NewScanner(os.Stdin)
+	// Scan the input for log data per line.
+	scanner := bufio.NewScanner(r)
 	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024*1024)
 	for scanner.Scan() {
 		s := scanner.Text()
@@ -32,8 +72,8 @@ func main() {
 		m := make(map[string]any)
 		err := json.Unmarshal([]byte(s), &m)
 		if err != nil {
-			if service == "" {
-				fmt.Println(s)
+			if len(filter.include) == 0 {
+				fmt.Fprintln(w, s)
 			}
 			continue
 		}
@@ -57,7 +97,7 @@ func main() {
 		}
 
 		// If a service filter was provided, check.
-		if service != "" && m["service"] != service {
+		if !filter.match(m["service"]) {
 			continue
 		}
 
@@ -87,10 +127,8 @@ func main() {
 
 		// Write the new log format, removing the last :
 		out := b.String()
-		fmt.Println(out[:len(out)-2])
+		fmt.Fprintln(w, out[:len(out)-2])
 	}
 
-	if err := scanner.Err(); err != nil {
-		log.Println(err)
-	}
+	return scanner.Err()
 }
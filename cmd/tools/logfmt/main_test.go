@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestFormatServiceFilter(t *testing.T) {
+	t.Parallel()
+
+	input := strings.Join([]string{
+		`{"service":"jogger","ts":"1","level":"info","caller":"main.go:1","msg":"server"}`,
+		`{"service":"cleanup","ts":"2","level":"debug","caller":"cg.go:1","msg":"noisy"}`,
+		`{"service":"metrics","ts":"3","level":"info","caller":"m.go:1","msg":"scrape"}`,
+		`{"ts":"4","level":"info","caller":"x.go:1","msg":"no service"}`,
+		`not json`,
+	}, "\n")
+
+	tests := []struct {
+		name    string
+		service string
+		exclude string
+		want    []string
+	}{
+		{
+			name: "no filter",
+			want: []string{"server", "noisy", "scrape", "no service", "not json"},
+		},
+		{
+			name:    "single service",
+			service: "jogger",
+			want:    []string{"server"},
+		},
+		{
+			name:    "multiple services",
+			service: "jogger, metrics",
+			want:    []string{"server", "scrape"},
+		},
+		{
+			name:    "exclude",
+			exclude: "cleanup",
+			want:    []string{"server", "scrape", "no service", "not json"},
+		},
+		{
+			name:    "exclude wins over service",
+			service: "jogger,cleanup",
+			exclude: "cleanup",
+			want:    []string{"server"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var out bytes.Buffer
+			if err := format(strings.NewReader(input), &out, newServiceFilter(tt.service, tt.exclude)); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var got []string
+			for _, msg := range []string{"server", "noisy", "scrape", "no service", "not json"} {
+				if strings.Contains(out.String(), msg) {
+					got = append(got, msg)
+				}
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Fatalf("expected lines %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -4,18 +4,21 @@ go 1.22.5
 
 require (
 	github.com/ardanlabs/conf/v3 v3.1.7
+	github.com/creack/pty v1.1.24
 	github.com/dustinevan/chron v1.0.0
 	github.com/google/uuid v1.6.0
 	go.uber.org/zap v1.27.0
+	golang.org/x/net v0.25.0
 	golang.org/x/sys v0.22.0
 	google.golang.org/grpc v1.65.0
 	google.golang.org/protobuf v1.34.2
+	software.sslmate.com/src/go-pkcs12 v0.4.0
 )
 
 require (
 	github.com/stretchr/testify v1.8.4 // indirect
 	go.uber.org/multierr v1.10.0 // indirect
-	golang.org/x/net v0.25.0 // indirect
+	golang.org/x/crypto v0.23.0 // indirect
 	golang.org/x/text v0.15.0 // indirect
 	google.golang.org/genproto/googleapis/rpc v0.0.0-20240528184218-531527333157 // indirect
 )
@@ -2,16 +2,49 @@ package cgroup
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
+	"syscall"
+	"time"
 )
 
 const gb = 1024 * 1024 * 1024
 
+// cpuPeriodMicros is the period written to cpu.max, cpu quotas are a fraction of this period
+const cpuPeriodMicros = 100000
+
+// ErrLimitNotEnforced is returned with WithStrictLimits when a limit written to a cgroup
+// doesn't read back as written, e.g. because its controller isn't delegated to the cgroup
+var ErrLimitNotEnforced = errors.New("cgroup limit is not enforced")
+
+// ErrLimitTooHigh is returned by UpdateGroup for limits over the server's maxima
+var ErrLimitTooHigh = errors.New("limit is over the server's maximum")
+
+// ErrInvalidLimit is returned by AddGroupWithLimits for limits that can't be written, e.g.
+// negative ones
+var ErrInvalidLimit = errors.New("invalid cgroup limit")
+
+// ErrBelowUsage is returned by UpdateGroup for a memory limit below what the cgroup is
+// using. The kernel would reclaim memory to meet it, or OOM kill the job if it can't.
+var ErrBelowUsage = errors.New("memory limit is below the current usage")
+
+// UserQuota is the aggregate limit for all of a user's jobs. It's applied to the user's
+// parent cgroup, so the kernel enforces it across every job cgroup nested under it.
+// Zero values mean the resource is unlimited.
+type UserQuota struct {
+	// MemoryMaxBytes is written to the user cgroup's memory.max
+	MemoryMaxBytes int64
+	// CPUs is the number of CPUs the user's jobs can use in total, e.g. 1.5, and is
+	// written to the user cgroup's cpu.max
+	CPUs float64
+}
+
 type FSManager struct {
 	// controllers is a list of cgroup controllers to enable for job cgroups
 	controllers []string
@@ -20,10 +53,22 @@ type FSManager struct {
 	rootPath          string
 	memoryTargetBytes int
 	serverCGroupName  string
+	userQuota         UserQuota
+	initTimeout       time.Duration
+	strictLimits      bool
+
+	// writeFile writes cgroup interface files, it's os.WriteFile outside of tests
+	writeFile func(name string, data []byte, perm fs.FileMode) error
+	// removePollInterval is how often RemoveGroup checks whether a cgroup's processes have
+	// exited, and removeTimeout is how long it waits for them
+	removePollInterval time.Duration
+	removeTimeout      time.Duration
 
 	// groups is a map of cgroup names to their directories
 	groups map[string]*CGroup
-	mu     sync.Mutex
+	// users is the set of users whose parent cgroup has been created
+	users map[string]struct{}
+	mu    sync.Mutex
 
 	// shutdownCtx is a context that is closed when the server is shutting down
 	shutdownCtx context.Context
@@ -31,6 +76,7 @@ type FSManager struct {
 
 type CGroup struct {
 	dir          *os.File
+	path         string
 	cgEventsFile string
 }
 
@@ -45,12 +91,19 @@ func NewFSManager(shutdownCtx context.Context, options ...FSManagerOption) (*FSM
 		opt(&cfg)
 	}
 	fsm := &FSManager{
-		controllers:       []string{"cpu", "memory", "io"},
-		rootPath:          cfg.rootPath,
-		memoryTargetBytes: cfg.targetMaxMemoryBytes,
-		serverCGroupName:  cfg.serverCGroupName,
-		groups:            make(map[string]*CGroup),
-		shutdownCtx:       shutdownCtx,
+		controllers:        []string{"cpu", "memory", "io"},
+		rootPath:           cfg.rootPath,
+		memoryTargetBytes:  cfg.targetMaxMemoryBytes,
+		serverCGroupName:   cfg.serverCGroupName,
+		userQuota:          cfg.userQuota,
+		initTimeout:        cfg.initTimeout,
+		strictLimits:       cfg.strictLimits,
+		writeFile:          os.WriteFile,
+		removePollInterval: defaultRemovePollInterval,
+		removeTimeout:      defaultRemoveTimeout,
+		groups:             make(map[string]*CGroup),
+		users:              make(map[string]struct{}),
+		shutdownCtx:        shutdownCtx,
 	}
 
 	if err := fsm.init(); err != nil {
@@ -60,80 +113,570 @@ func NewFSManager(shutdownCtx context.Context, options ...FSManagerOption) (*FSM
 	return fsm, nil
 }
 
-func (m *FSManager) AddGroup(name string) (int, error) {
-	dirPath := filepath.Join(m.rootPath, m.serverCGroupName, name)
+// GroupOption configures a job cgroup created by AddGroup
+type GroupOption func(*groupConfig)
+
+type groupConfig struct {
+	label string
+}
+
+// WithLabel prefixes the cgroup's directory name with label, e.g. echo-<name>, so the job's
+// cgroup can be recognized in tools like systemd-cgtop. The label is sanitized to characters
+// that are safe in a directory name, and shortened. The cgroup is still referred to by name.
+func WithLabel(label string) GroupOption {
+	return func(cfg *groupConfig) {
+		cfg.label = label
+	}
+}
+
+// maxLabelLength is the most characters of a label kept in a cgroup directory name
+const maxLabelLength = 32
+
+// GroupDirName returns the name of the directory of the cgroup name, with label as its
+// prefix, see WithLabel. Runs of characters other than letters, digits, '_' and '.' are
+// replaced with '-'. The name is kept whole as the suffix, so the directory name stays unique
+// and maps back to the name.
+func GroupDirName(label, name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range label {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '.' {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			dash = false
+			b.WriteRune(r)
+			continue
+		}
+		dash = true
+	}
+	prefix := strings.TrimLeft(b.String(), ".-")
+	if len(prefix) > maxLabelLength {
+		prefix = strings.TrimRight(prefix[:maxLabelLength], "-")
+	}
+	if prefix == "" {
+		return name
+	}
+	return prefix + "-" + name
+}
+
+// ResourceLimits are the limits a job's cgroup is created with, see AddGroupWithLimits. Zero
+// values keep the defaults: a fifth of the target max memory, and no cpu or io limit.
+type ResourceLimits struct {
+	// MemoryBytes is written to the cgroup's memory.max
+	MemoryBytes int64
+	// CPUMaxPercent is the CPU time the job can use as a percentage of one CPU, e.g. 150 for
+	// one and a half CPUs, and is written to cpu.max
+	CPUMaxPercent int
+	// IOWeight is the job's share of IO when a device is contended, relative to other cgroups'
+	// weights, 1 to 10000. The kernel's default is 100. It's written to io.weight.
+	IOWeight int
+}
+
+// maxIOWeight is the highest weight io.weight accepts
+const maxIOWeight = 10000
+
+// validateLimits checks the limits against the server's target max memory and the user quota
+func (m *FSManager) validateLimits(limits ResourceLimits) error {
+	if limits.MemoryBytes < 0 || limits.CPUMaxPercent < 0 || limits.IOWeight < 0 {
+		return fmt.Errorf("%w: limits must not be negative", ErrInvalidLimit)
+	}
+	if limits.IOWeight > maxIOWeight {
+		return fmt.Errorf("%w: io weight %d isn't 1 to %d", ErrInvalidLimit, limits.IOWeight, maxIOWeight)
+	}
+	if limits.MemoryBytes > int64(m.memoryTargetBytes) ||
+		m.userQuota.MemoryMaxBytes > 0 && limits.MemoryBytes > m.userQuota.MemoryMaxBytes {
+		return fmt.Errorf("memory limit %d: %w", limits.MemoryBytes, ErrLimitTooHigh)
+	}
+	if m.userQuota.CPUs > 0 && float64(limits.CPUMaxPercent) > m.userQuota.CPUs*100 {
+		return fmt.Errorf("cpu limit %d%%: %w", limits.CPUMaxPercent, ErrLimitTooHigh)
+	}
+	return nil
+}
+
+// AddGroup creates a cgroup for the job at jogger/<username>/<name> and returns the file
+// descriptor of its directory. The user's parent cgroup is created on first use. If the job's
+// cgroup can't be set up, its directory is removed. The user's cgroup is kept for the next job.
+// With WithLabel, the directory is jogger/<username>/<label>-<name>.
+func (m *FSManager) AddGroup(username, name string, options ...GroupOption) (int, error) {
+	return m.AddGroupWithLimits(username, name, ResourceLimits{}, options...)
+}
+
+// AddGroupWithLimits is AddGroup for a cgroup created with limits. The memory limit can't be
+// more than the server's target max memory, and neither it nor the cpu limit can be more than
+// the user quota, see ErrLimitTooHigh.
+func (m *FSManager) AddGroupWithLimits(username, name string, limits ResourceLimits, options ...GroupOption) (int, error) {
+	var cfg groupConfig
+	for _, opt := range options {
+		opt(&cfg)
+	}
+	if err := m.validateLimits(limits); err != nil {
+		return -1, err
+	}
+	if err := validGroupName(username); err != nil {
+		return -1, fmt.Errorf("invalid username: %w", err)
+	}
+	if err := validGroupName(name); err != nil {
+		return -1, fmt.Errorf("invalid cgroup name: %w", err)
+	}
+	if err := m.initUserGroup(username); err != nil {
+		return -1, fmt.Errorf("failed to initialize user cgroup: %w", err)
+	}
+
+	dirPath := filepath.Join(m.rootPath, m.serverCGroupName, username, GroupDirName(cfg.label, name))
 	if err := os.Mkdir(dirPath, 0755); err != nil {
 		return -1, fmt.Errorf("failed to create cgroup directory: %w", err)
 	}
 	dir, err := os.Open(dirPath)
 	if err != nil {
-		return -1, fmt.Errorf("failed to open cgroup directory: %w", err)
+		return -1, abortGroup(dirPath, nil, fmt.Errorf("failed to open cgroup directory: %w", err))
 	}
-	memMax, err := os.Open(filepath.Join(dirPath, "memory.max"))
-	if err != nil {
-		rErr := os.Remove(dirPath)
-		if rErr != nil {
-			err = fmt.Errorf("failed to remove cgroup directory: %w", rErr)
+	memory := limits.MemoryBytes
+	if memory == 0 {
+		memory = int64(m.memoryTargetBytes / 5)
+	}
+	if err := m.writeLimit(dirPath, "memory.max", strconv.FormatInt(memory, 10)); err != nil {
+		return -1, abortGroup(dirPath, dir, err)
+	}
+	if limits.CPUMaxPercent > 0 {
+		if err := m.writeLimit(dirPath, "cpu.max", fmt.Sprintf("%d %d", limits.CPUMaxPercent*cpuPeriodMicros/100, cpuPeriodMicros)); err != nil {
+			return -1, abortGroup(dirPath, dir, err)
 		}
-		return -1, fmt.Errorf("failed to open memory.max file: %w", err)
 	}
-	_, err = memMax.WriteString(fmt.Sprintf("%d", m.memoryTargetBytes/5))
-	if err != nil {
-		rErr := os.Remove(dirPath)
-		if rErr != nil {
-			err = fmt.Errorf("failed to remove cgroup directory: %w", rErr)
+	if limits.IOWeight > 0 {
+		// the kernel reads io.weight back with the default prefix
+		if err := m.writeLimit(dirPath, "io.weight", fmt.Sprintf("default %d", limits.IOWeight)); err != nil {
+			return -1, abortGroup(dirPath, dir, err)
 		}
-		return -1, fmt.Errorf("failed to write to memory.max file: %w", err)
 	}
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.groups[name] = &CGroup{
 		dir:          dir,
+		path:         dirPath,
 		cgEventsFile: filepath.Join(dirPath, "cgroup.events"),
 	}
 	return int(dir.Fd()), nil
 }
 
-func (m *FSManager) RemoveGroup(name string) error {
+// abortGroup removes the directory of a job cgroup that couldn't be set up, so it isn't leaked.
+// dir is closed first, if it was opened. err is returned, along with why the directory
+// couldn't be removed, if it couldn't be.
+func abortGroup(dirPath string, dir *os.File, err error) error {
+	if dir != nil {
+		dir.Close()
+	}
+	if rErr := removeGroupDir(dirPath); rErr != nil {
+		return fmt.Errorf("%w, and failed to remove cgroup directory: %w", err, rErr)
+	}
+	return err
+}
+
+// removeGroupDir removes a cgroup directory that has no processes or child cgroups. On a
+// cgroup filesystem, the interface files go with the directory. Elsewhere, e.g. in tests,
+// they're regular files, and are removed first.
+func removeGroupDir(dirPath string) error {
+	err := os.Remove(dirPath)
+	if err == nil || errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if !errors.Is(err, syscall.ENOTEMPTY) {
+		return err
+	}
+	return os.RemoveAll(dirPath)
+}
+
+// initUserGroup creates the parent cgroup for a user's jobs, enables the controllers
+// for its children, and writes the aggregate user quota. It's a no-op after the
+// user cgroup has been initialized.
+func (m *FSManager) initUserGroup(username string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.users[username]; ok {
+		return nil
+	}
+
+	dirPath := filepath.Join(m.rootPath, m.serverCGroupName, username)
+	// the directory may be left over from a previous run of the server
+	if err := os.Mkdir(dirPath, 0755); err != nil && !errors.Is(err, fs.ErrExist) {
+		return fmt.Errorf("failed to create user cgroup directory: %w", err)
+	}
+	if err := m.writeControlFile(dirPath, "cgroup.subtree_control", "+"+strings.Join(m.controllers, " +")); err != nil {
+		return err
+	}
+	if m.strictLimits {
+		if err := verifyControllers(dirPath, m.controllers); err != nil {
+			return err
+		}
+	}
+	if err := m.writeLimit(dirPath, "memory.max", m.userQuota.memoryMax()); err != nil {
+		return err
+	}
+	if err := m.writeLimit(dirPath, "cpu.max", m.userQuota.cpuMax()); err != nil {
+		return err
+	}
+
+	m.users[username] = struct{}{}
+	return nil
+}
+
+// memoryMax returns the memory.max value for the quota
+func (q UserQuota) memoryMax() string {
+	if q.MemoryMaxBytes <= 0 {
+		return "max"
+	}
+	return fmt.Sprintf("%d", q.MemoryMaxBytes)
+}
+
+// cpuMax returns the cpu.max value for the quota
+func (q UserQuota) cpuMax() string {
+	return cpuMax(q.CPUs)
+}
+
+// cpuMax returns the cpu.max value for a number of CPUs, formatted as "$MAX $PERIOD".
+// 0 or less is unlimited.
+func cpuMax(cpus float64) string {
+	if cpus <= 0 {
+		return fmt.Sprintf("max %d", cpuPeriodMicros)
+	}
+	return fmt.Sprintf("%d %d", int64(cpus*cpuPeriodMicros), cpuPeriodMicros)
+}
+
+// writeControlFile writes value to the cgroup interface file named name in dirPath
+func (m *FSManager) writeControlFile(dirPath, name, value string) error {
+	if err := m.writeFile(filepath.Join(dirPath, name), []byte(value), 0644); err != nil {
+		return fmt.Errorf("failed to write to %s file: %w", name, err)
+	}
+	return nil
+}
+
+// writeLimit writes a limit to the cgroup interface file named name in dirPath. With
+// WithStrictLimits, the file is read back to check the kernel accepted the limit.
+func (m *FSManager) writeLimit(dirPath, name, value string) error {
+	if err := m.writeControlFile(dirPath, name, value); err != nil {
+		return err
+	}
+	if !m.strictLimits {
+		return nil
+	}
+	b, err := os.ReadFile(filepath.Join(dirPath, name))
+	if err != nil {
+		return fmt.Errorf("failed to read %s file: %w: %w", name, ErrLimitNotEnforced, err)
+	}
+	if got := strings.TrimSpace(string(b)); !limitApplied(name, got, value) {
+		return fmt.Errorf("%s is %q after writing %q: %w", name, got, value, ErrLimitNotEnforced)
+	}
+	return nil
+}
+
+// limitApplied reports whether got, read back from the interface file name, is the limit
+// want that was written. The kernel rounds memory limits down to a multiple of the page size.
+func limitApplied(name, got, want string) bool {
+	if got == want {
+		return true
+	}
+	if name != "memory.max" {
+		return false
+	}
+	g, gErr := strconv.ParseInt(got, 10, 64)
+	w, wErr := strconv.ParseInt(want, 10, 64)
+	return gErr == nil && wErr == nil && g <= w && w-g < int64(os.Getpagesize())
+}
+
+// verifyControllers returns ErrLimitNotEnforced if any of controllers isn't enabled in the
+// cgroup.subtree_control file in dirPath. Without them, the child cgroups' limits do nothing.
+func verifyControllers(dirPath string, controllers []string) error {
+	b, err := os.ReadFile(filepath.Join(dirPath, "cgroup.subtree_control"))
+	if err != nil {
+		return fmt.Errorf("failed to read cgroup.subtree_control file: %w: %w", ErrLimitNotEnforced, err)
+	}
+	enabled := make(map[string]bool)
+	for _, field := range strings.Fields(string(b)) {
+		enabled[strings.TrimPrefix(field, "+")] = true
+	}
+	for _, c := range controllers {
+		if !enabled[c] {
+			return fmt.Errorf("the %s controller isn't enabled: %w", c, ErrLimitNotEnforced)
+		}
+	}
+	return nil
+}
+
+// validGroupName returns an error if name can't be used as a single cgroup directory name
+func validGroupName(name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsRune(name, filepath.Separator) {
+		return fmt.Errorf("%q is not a valid cgroup directory name", name)
+	}
+	return nil
+}
+
+// Procs returns the PIDs of the processes in the cgroup, read from its cgroup.procs file.
+// Once a job has exited, any PIDs left are processes it started that outlived it.
+func (m *FSManager) Procs(name string) ([]int, error) {
+	cg, err := m.group(name)
+	if err != nil {
+		return nil, err
+	}
+	b, err := os.ReadFile(filepath.Join(cg.path, "cgroup.procs"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cgroup.procs file: %w", err)
+	}
+	var pids []int
+	for _, field := range strings.Fields(string(b)) {
+		pid, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse cgroup.procs file: %w", err)
+		}
+		pids = append(pids, pid)
+	}
+	return pids, nil
+}
+
+// Limits are new resource limits for a job's cgroup. Zero values leave a limit as it is.
+type Limits struct {
+	// MemoryMaxBytes is written to the cgroup's memory.max
+	MemoryMaxBytes int64
+	// CPUs is the number of CPUs the job can use, e.g. 1.5, and is written to cpu.max
+	CPUs float64
+}
+
+// UpdateGroup changes the limits of a cgroup while its processes run. The memory limit can't
+// be more than the server's target max memory, and neither limit can be more than the user
+// quota, see ErrLimitTooHigh. Lowering the memory limit below what the cgroup is using is
+// rejected with ErrBelowUsage.
+func (m *FSManager) UpdateGroup(name string, limits Limits) error {
+	cg, err := m.group(name)
+	if err != nil {
+		return err
+	}
+	if limits.MemoryMaxBytes > int64(m.memoryTargetBytes) ||
+		m.userQuota.MemoryMaxBytes > 0 && limits.MemoryMaxBytes > m.userQuota.MemoryMaxBytes {
+		return fmt.Errorf("memory limit %d: %w", limits.MemoryMaxBytes, ErrLimitTooHigh)
+	}
+	if m.userQuota.CPUs > 0 && limits.CPUs > m.userQuota.CPUs {
+		return fmt.Errorf("cpu limit %g: %w", limits.CPUs, ErrLimitTooHigh)
+	}
+	if limits.MemoryMaxBytes > 0 {
+		b, err := os.ReadFile(filepath.Join(cg.path, "memory.current"))
+		if err != nil {
+			return fmt.Errorf("failed to read memory.current file: %w", err)
+		}
+		current, err := strconv.ParseInt(strings.TrimSpace(string(b)), 10, 64)
+		if err != nil {
+			return fmt.Errorf("failed to parse memory.current file: %w", err)
+		}
+		if limits.MemoryMaxBytes < current {
+			return fmt.Errorf("memory limit %d, using %d: %w", limits.MemoryMaxBytes, current, ErrBelowUsage)
+		}
+		if err := m.writeLimit(cg.path, "memory.max", strconv.FormatInt(limits.MemoryMaxBytes, 10)); err != nil {
+			return err
+		}
+	}
+	if limits.CPUs > 0 {
+		if err := m.writeLimit(cg.path, "cpu.max", cpuMax(limits.CPUs)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Kill sends a SIGKILL to every process in the cgroup by writing to its cgroup.kill file.
+// The killed processes have been reparented away from the job, so they're reaped by init.
+func (m *FSManager) Kill(name string) error {
+	cg, err := m.group(name)
+	if err != nil {
+		return err
+	}
+	return m.writeControlFile(cg.path, "cgroup.kill", "1")
+}
+
+// Usage is the resources used by the processes in a cgroup
+type Usage struct {
+	// PeakMemoryBytes is the most memory the processes used at once, read from memory.peak.
+	// It's 0 on kernels older than 5.19, which don't have memory.peak.
+	PeakMemoryBytes int64
+	// CPUUsageUsec is the total CPU time the processes used, read from cpu.stat's usage_usec
+	CPUUsageUsec int64
+}
+
+// Usage reads the resources used by the processes in the cgroup. The interface files are
+// gone once the cgroup is removed, so a job's usage must be read before RemoveGroup.
+func (m *FSManager) Usage(name string) (Usage, error) {
+	cg, err := m.group(name)
+	if err != nil {
+		return Usage{}, err
+	}
+	var usage Usage
+	b, err := os.ReadFile(filepath.Join(cg.path, "memory.peak"))
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return Usage{}, fmt.Errorf("failed to read memory.peak file: %w", err)
+	default:
+		if usage.PeakMemoryBytes, err = strconv.ParseInt(strings.TrimSpace(string(b)), 10, 64); err != nil {
+			return Usage{}, fmt.Errorf("failed to parse memory.peak file: %w", err)
+		}
+	}
+	b, err = os.ReadFile(filepath.Join(cg.path, "cpu.stat"))
+	if err != nil {
+		return Usage{}, fmt.Errorf("failed to read cpu.stat file: %w", err)
+	}
+	// cpu.stat is a "key value" pair per line
+	for _, line := range strings.Split(string(b), "\n") {
+		key, value, _ := strings.Cut(line, " ")
+		if key != "usage_usec" {
+			continue
+		}
+		if usage.CPUUsageUsec, err = strconv.ParseInt(strings.TrimSpace(value), 10, 64); err != nil {
+			return Usage{}, fmt.Errorf("failed to parse cpu.stat file: %w", err)
+		}
+		return usage, nil
+	}
+	return Usage{}, fmt.Errorf("failed to parse cpu.stat file: no usage_usec")
+}
+
+func (m *FSManager) group(name string) (*CGroup, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	cg, ok := m.groups[name]
 	if !ok {
-		return fmt.Errorf("cgroup %s not found", name)
+		return nil, fmt.Errorf("cgroup %s not found", name)
 	}
-	if err := cg.dir.Close(); err != nil {
-		return fmt.Errorf("failed to close cgroup directory: %w", err)
+	return cg, nil
+}
+
+// RemoveGroup closes the cgroup's directory and forgets it, then waits for its processes to
+// exit and removes the directory, see waitUnpopulated. Processes left in the cgroup should be
+// killed first. If they don't exit in time, the directory is left behind and an error is
+// returned, the cgroup is forgotten either way.
+func (m *FSManager) RemoveGroup(name string) error {
+	m.mu.Lock()
+	cg, ok := m.groups[name]
+	if !ok {
+		m.mu.Unlock()
+		return fmt.Errorf("cgroup %s not found", name)
 	}
 	delete(m.groups, name)
+	m.mu.Unlock()
+
+	closeErr := cg.dir.Close()
+	if err := m.waitUnpopulated(cg.cgEventsFile); err != nil {
+		return fmt.Errorf("failed to remove cgroup directory: %w", err)
+	}
+	if err := removeGroupDir(cg.path); err != nil {
+		return fmt.Errorf("failed to remove cgroup directory: %w", err)
+	}
+	if closeErr != nil {
+		return fmt.Errorf("failed to close cgroup directory: %w", closeErr)
+	}
 	return nil
 }
 
-// Add the default controllers to the root cgroup subtree_control file like this:
+// waitUnpopulated polls the cgroup.events file eventsFile until it reads populated 0, which
+// means the cgroup's processes have exited and its directory can be removed. It gives up
+// after the remove timeout. It doesn't stop at shutdown: the jobs are killed then, and their
+// cgroups are still removed.
+func (m *FSManager) waitUnpopulated(eventsFile string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), m.removeTimeout)
+	defer cancel()
+	ticker := time.NewTicker(m.removePollInterval)
+	defer ticker.Stop()
+	for {
+		busy, err := populated(eventsFile)
+		if err != nil {
+			return err
+		}
+		if !busy {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("processes are still in the cgroup after %s: %w", m.removeTimeout, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// populated reports whether the cgroup.events file eventsFile says the cgroup or its
+// descendants have processes. A missing file means the cgroup is already gone.
+func populated(eventsFile string) (bool, error) {
+	b, err := os.ReadFile(eventsFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read cgroup.events file: %w", err)
+	}
+	for _, line := range strings.Split(string(b), "\n") {
+		if v, ok := strings.CutPrefix(line, "populated "); ok {
+			return strings.TrimSpace(v) != "0", nil
+		}
+	}
+	return false, fmt.Errorf("no populated field in cgroup.events file: %q", b)
+}
+
+// init enables the default controllers for the root cgroup's children, and creates the
+// server cgroup with them enabled for its children too, like this:
 // `echo "+cpu +memory +io" > /sys/fs/cgroup/cgroup.subtree_control`
 // `mkdir /sys/fs/cgroup/jogger`
 // `echo "+cpu +memory +io" > /sys/fs/cgroup/jogger/cgroup.subtree_control`
+// The control files are written directly, each write is one line to the kernel.
+//
+// The setup is bounded by the init timeout, so a hung cgroup filesystem fails server
+// startup with an error naming the step that stalled, instead of blocking it forever.
 func (m *FSManager) init() error {
-	// enable the controllers in the root cgroup
-	cmdString := fmt.Sprintf("echo \"+%s\" > %s", strings.Join(m.controllers, " +"), filepath.Join(m.rootPath, "cgroup.subtree_control"))
-	cmd := exec.CommandContext(m.shutdownCtx, cmdString)
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to enable controllers in root cgroup: cmdString=[%s]: %w", cmdString, err)
+	ctx, cancel := context.WithTimeout(m.shutdownCtx, m.initTimeout)
+	defer cancel()
+
+	controllers := "+" + strings.Join(m.controllers, " +")
+	err := initStep(ctx, "enabling controllers in root cgroup", func(context.Context) error {
+		if err := m.writeControlFile(m.rootPath, "cgroup.subtree_control", controllers); err != nil {
+			return fmt.Errorf("failed to enable controllers in root cgroup: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
-	// create the server cgroup
-	cmdString = fmt.Sprintf("mkdir %s", filepath.Join(m.rootPath, m.serverCGroupName))
-	cmd = exec.CommandContext(m.shutdownCtx, cmdString)
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to create server cgroup: cmdString=[%s]: %w", cmdString, err)
+	serverPath := filepath.Join(m.rootPath, m.serverCGroupName)
+	err = initStep(ctx, "creating server cgroup", func(context.Context) error {
+		// the directory may be left over from a previous run of the server
+		if err := os.Mkdir(serverPath, 0755); err != nil && !errors.Is(err, fs.ErrExist) {
+			return fmt.Errorf("failed to create server cgroup: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
-	// enable the controllers in the server cgroup
-	cmdString = fmt.Sprintf("echo \"+%s\" > %s", strings.Join(m.controllers, " +"), filepath.Join(m.rootPath, m.serverCGroupName, "cgroup.subtree_control"))
-	cmd = exec.CommandContext(m.shutdownCtx, cmdString)
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to enable controllers in server cgroup: cmdString=[%s]: %w", cmdString, err)
+	return initStep(ctx, "enabling controllers in server cgroup", func(context.Context) error {
+		if err := m.writeControlFile(serverPath, "cgroup.subtree_control", controllers); err != nil {
+			return fmt.Errorf("failed to enable controllers in server cgroup: %w", err)
+		}
+		return nil
+	})
+}
+
+// initStep runs one step of the cgroup setup. If ctx is done before the step returns, a
+// timeout error naming the step is returned. The step keeps running in the background in
+// that case, operations on a hung filesystem can't always be interrupted.
+func initStep(ctx context.Context, step string, run func(ctx context.Context) error) error {
+	result := make(chan error, 1)
+	go func() {
+		result <- run(ctx)
+	}()
+	select {
+	case err := <-result:
+		// the step may have failed because it was interrupted by the deadline
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return fmt.Errorf("timed out %s: %w", step, ctxErr)
+		}
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("timed out %s: %w", step, ctx.Err())
 	}
-	return nil
 }
 
 type FSManagerOption func(*fSManagerConfig)
@@ -142,12 +685,18 @@ var (
 	defaultCgroupRootPath       = "/sys/fs/cgroup"
 	defaultServerCGroupName     = "jogger"
 	defaultTargetMaxMemoryBytes = 4 * gb
+	defaultInitTimeout          = 10 * time.Second
+	defaultRemovePollInterval   = 100 * time.Millisecond
+	defaultRemoveTimeout        = 30 * time.Second
 )
 
 type fSManagerConfig struct {
 	rootPath             string
 	serverCGroupName     string
 	targetMaxMemoryBytes int
+	userQuota            UserQuota
+	initTimeout          time.Duration
+	strictLimits         bool
 }
 
 func defaultFSManagerConfig() fSManagerConfig {
@@ -155,6 +704,7 @@ func defaultFSManagerConfig() fSManagerConfig {
 		rootPath:             defaultCgroupRootPath,
 		serverCGroupName:     defaultServerCGroupName,
 		targetMaxMemoryBytes: defaultTargetMaxMemoryBytes,
+		initTimeout:          defaultInitTimeout,
 	}
 }
 
@@ -175,3 +725,29 @@ func WithTargetMaxMemoryBytes(targetMaxMemoryBytes int) FSManagerOption {
 		cfg.targetMaxMemoryBytes = targetMaxMemoryBytes
 	}
 }
+
+// WithUserQuota sets the aggregate limits applied to each user's parent cgroup
+func WithUserQuota(quota UserQuota) FSManagerOption {
+	return func(cfg *fSManagerConfig) {
+		cfg.userQuota = quota
+	}
+}
+
+// WithInitTimeout bounds how long setting up the server cgroup can take. A timeout <= 0 keeps
+// the default.
+func WithInitTimeout(timeout time.Duration) FSManagerOption {
+	return func(cfg *fSManagerConfig) {
+		if timeout > 0 {
+			cfg.initTimeout = timeout
+		}
+	}
+}
+
+// WithStrictLimits makes AddGroup fail with ErrLimitNotEnforced when the limits it writes
+// don't read back as written, instead of starting jobs in cgroups that may not enforce them.
+// This happens when the cpu or memory controllers aren't delegated to the server's cgroup.
+func WithStrictLimits(strict bool) FSManagerOption {
+	return func(cfg *fSManagerConfig) {
+		cfg.strictLimits = strict
+	}
+}
@@ -0,0 +1,788 @@
+package cgroup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestFSManager returns an FSManager rooted in a temporary directory. init is skipped
+// because it needs a real cgroup2 filesystem, so the server cgroup directory is created here.
+func newTestFSManager(t *testing.T, quota UserQuota) *FSManager {
+	t.Helper()
+	cfg := defaultFSManagerConfig()
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, cfg.serverCGroupName), 0755); err != nil {
+		t.Fatalf("creating server cgroup directory: %v", err)
+	}
+	return &FSManager{
+		controllers:        []string{"cpu", "memory", "io"},
+		rootPath:           root,
+		memoryTargetBytes:  cfg.targetMaxMemoryBytes,
+		serverCGroupName:   cfg.serverCGroupName,
+		userQuota:          quota,
+		writeFile:          os.WriteFile,
+		removePollInterval: time.Millisecond,
+		removeTimeout:      time.Second,
+		groups:             make(map[string]*CGroup),
+		users:              make(map[string]struct{}),
+		shutdownCtx:        context.Background(),
+	}
+}
+
+// readControlFile returns the contents of a cgroup interface file or fails the test
+func readControlFile(t *testing.T, path ...string) string {
+	t.Helper()
+	b, err := os.ReadFile(filepath.Join(path...))
+	if err != nil {
+		t.Fatalf("reading control file: %v", err)
+	}
+	return string(b)
+}
+
+func TestAddGroupCreatesNestedUserGroup(t *testing.T) {
+	t.Parallel()
+
+	m := newTestFSManager(t, UserQuota{})
+	userDir := filepath.Join(m.rootPath, m.serverCGroupName, "user1")
+
+	for _, jobID := range []string{"job1", "job2"} {
+		fd, err := m.AddGroup("user1", jobID)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if fd < 0 {
+			t.Fatalf("expected a valid file descriptor, got %d", fd)
+		}
+		if info, err := os.Stat(filepath.Join(userDir, jobID)); err != nil || !info.IsDir() {
+			t.Fatalf("expected a job cgroup directory under the user cgroup: %v", err)
+		}
+		if got := readControlFile(t, userDir, jobID, "memory.max"); got != "858993459" {
+			t.Fatalf("expected job memory.max 858993459, got %s", got)
+		}
+		if err := m.RemoveGroup(jobID); err != nil {
+			t.Fatalf("unexpected error removing group: %v", err)
+		}
+	}
+
+	if got := readControlFile(t, userDir, "cgroup.subtree_control"); got != "+cpu +memory +io" {
+		t.Fatalf("expected the controllers to be enabled in the user cgroup, got %q", got)
+	}
+	if len(m.users) != 1 {
+		t.Fatalf("expected 1 initialized user cgroup, got %d", len(m.users))
+	}
+}
+
+func TestAddGroupWritesUserQuota(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		quota   UserQuota
+		wantMem string
+		wantCPU string
+	}{
+		{
+			name:    "unlimited",
+			wantMem: "max",
+			wantCPU: "max 100000",
+		},
+		{
+			name:    "memory and cpu",
+			quota:   UserQuota{MemoryMaxBytes: 2 * gb, CPUs: 1.5},
+			wantMem: "2147483648",
+			wantCPU: "150000 100000",
+		},
+		{
+			name:    "fractional cpu",
+			quota:   UserQuota{CPUs: 0.25},
+			wantMem: "max",
+			wantCPU: "25000 100000",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			m := newTestFSManager(t, tt.quota)
+			if _, err := m.AddGroup("user1", "job1"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			userDir := filepath.Join(m.rootPath, m.serverCGroupName, "user1")
+			if got := readControlFile(t, userDir, "memory.max"); got != tt.wantMem {
+				t.Fatalf("expected user memory.max %q, got %q", tt.wantMem, got)
+			}
+			if got := readControlFile(t, userDir, "cpu.max"); got != tt.wantCPU {
+				t.Fatalf("expected user cpu.max %q, got %q", tt.wantCPU, got)
+			}
+		})
+	}
+}
+
+func TestAddGroupWithLimits(t *testing.T) {
+	t.Parallel()
+
+	quota := UserQuota{MemoryMaxBytes: 2 * gb, CPUs: 2}
+	tests := []struct {
+		name   string
+		limits ResourceLimits
+		// want maps the job cgroup's control files to their contents, "" for a file not written
+		want    map[string]string
+		wantErr error
+	}{
+		{
+			name: "defaults",
+			want: map[string]string{"memory.max": "858993459", "cpu.max": "", "io.weight": ""},
+		},
+		{
+			name:   "all limits",
+			limits: ResourceLimits{MemoryBytes: gb, CPUMaxPercent: 150, IOWeight: 200},
+			want:   map[string]string{"memory.max": "1073741824", "cpu.max": "150000 100000", "io.weight": "default 200"},
+		},
+		{
+			name:   "cpu only",
+			limits: ResourceLimits{CPUMaxPercent: 25},
+			want:   map[string]string{"memory.max": "858993459", "cpu.max": "25000 100000", "io.weight": ""},
+		},
+		{name: "memory over the user quota", limits: ResourceLimits{MemoryBytes: 3 * gb}, wantErr: ErrLimitTooHigh},
+		{name: "cpu over the user quota", limits: ResourceLimits{CPUMaxPercent: 201}, wantErr: ErrLimitTooHigh},
+		{name: "io weight too high", limits: ResourceLimits{IOWeight: 10001}, wantErr: ErrInvalidLimit},
+		{name: "negative memory", limits: ResourceLimits{MemoryBytes: -1}, wantErr: ErrInvalidLimit},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			m := newTestFSManager(t, quota)
+			_, err := m.AddGroupWithLimits("user1", "job1", tt.limits)
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil) != (err == nil) {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			jobDir := filepath.Join(m.rootPath, m.serverCGroupName, "user1", "job1")
+			if err != nil {
+				// invalid limits are rejected before anything is created
+				if _, statErr := os.Stat(jobDir); !os.IsNotExist(statErr) {
+					t.Fatalf("expected no job cgroup directory, got %v", statErr)
+				}
+				return
+			}
+			for name, want := range tt.want {
+				b, err := os.ReadFile(filepath.Join(jobDir, name))
+				if want == "" {
+					if !os.IsNotExist(err) {
+						t.Fatalf("expected %s not to be written, got %q, %v", name, b, err)
+					}
+					continue
+				}
+				if string(b) != want {
+					t.Fatalf("expected %s %q, got %q, %v", name, want, b, err)
+				}
+			}
+		})
+	}
+}
+
+func TestAddGroupWithLabel(t *testing.T) {
+	t.Parallel()
+
+	const jobID = "0b5e7c1a-3f0e-4c55-9a8e-2d1f6b7c8d9e"
+	tests := []struct {
+		name    string
+		label   string
+		wantDir string
+	}{
+		{name: "command", label: "echo", wantDir: "echo-" + jobID},
+		{name: "job name", label: "nightly_backup.v2", wantDir: "nightly_backup.v2-" + jobID},
+		{name: "unsafe characters", label: "../echo hi && ls /", wantDir: "echo-hi-ls-" + jobID},
+		{name: "long label", label: strings.Repeat("a", 30) + "--" + strings.Repeat("b", 10), wantDir: strings.Repeat("a", 30) + "-b-" + jobID},
+		{name: "only unsafe characters", label: "/ &", wantDir: jobID},
+		{name: "no label", wantDir: jobID},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			m := newTestFSManager(t, UserQuota{})
+			if _, err := m.AddGroup("user1", jobID, WithLabel(tt.label)); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			jobDir := filepath.Join(m.rootPath, m.serverCGroupName, "user1", tt.wantDir)
+			if info, err := os.Stat(jobDir); err != nil || !info.IsDir() {
+				t.Fatalf("expected the cgroup directory %s: %v", tt.wantDir, err)
+			}
+			if !strings.HasSuffix(tt.wantDir, jobID) {
+				t.Fatalf("expected the directory name to end with the jobID")
+			}
+
+			// the cgroup is still referred to by the jobID
+			if err := os.WriteFile(filepath.Join(jobDir, "cgroup.procs"), []byte("1234\n"), 0644); err != nil {
+				t.Fatalf("writing cgroup.procs: %v", err)
+			}
+			if pids, err := m.Procs(jobID); err != nil || len(pids) != 1 || pids[0] != 1234 {
+				t.Fatalf("expected pids [1234], got %v, %v", pids, err)
+			}
+			if err := m.RemoveGroup(jobID); err != nil {
+				t.Fatalf("unexpected error removing group: %v", err)
+			}
+		})
+	}
+}
+
+func TestAddGroupRejectsInvalidNames(t *testing.T) {
+	t.Parallel()
+
+	m := newTestFSManager(t, UserQuota{})
+	tests := []struct {
+		username string
+		jobID    string
+	}{
+		{username: "", jobID: "job1"},
+		{username: "..", jobID: "job1"},
+		{username: "user1/../user2", jobID: "job1"},
+		{username: "user1", jobID: "../job1"},
+	}
+	for _, tt := range tests {
+		if _, err := m.AddGroup(tt.username, tt.jobID); err == nil {
+			t.Fatalf("expected an error for username %q and job %q", tt.username, tt.jobID)
+		}
+	}
+}
+
+func TestAddGroupStrictLimits(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		strict   bool
+		noopFile string
+		wantErr  bool
+	}{
+		{
+			name:   "limits are accepted",
+			strict: true,
+		},
+		{
+			name:     "memory.max write is a no-op",
+			strict:   true,
+			noopFile: "memory.max",
+			wantErr:  true,
+		},
+		{
+			name:     "cpu.max write is a no-op",
+			strict:   true,
+			noopFile: "cpu.max",
+			wantErr:  true,
+		},
+		{
+			name:     "controllers aren't enabled",
+			strict:   true,
+			noopFile: "cgroup.subtree_control",
+			wantErr:  true,
+		},
+		{
+			name:     "limits aren't checked without strict limits",
+			noopFile: "memory.max",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			m := newTestFSManager(t, UserQuota{MemoryMaxBytes: 2 * gb, CPUs: 1})
+			m.strictLimits = tt.strict
+			userDir := filepath.Join(m.rootPath, m.serverCGroupName, "user1")
+			if tt.noopFile != "" {
+				// writes to /dev/null succeed and are discarded, like writes to an interface
+				// file whose controller isn't delegated
+				if err := os.Mkdir(userDir, 0755); err != nil {
+					t.Fatalf("creating user cgroup directory: %v", err)
+				}
+				if err := os.Symlink(os.DevNull, filepath.Join(userDir, tt.noopFile)); err != nil {
+					t.Fatalf("creating %s: %v", tt.noopFile, err)
+				}
+			}
+
+			_, err := m.AddGroup("user1", "job1")
+			if !tt.wantErr {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrLimitNotEnforced) {
+				t.Fatalf("expected ErrLimitNotEnforced, got %v", err)
+			}
+			if _, err := m.group("job1"); err == nil {
+				t.Fatalf("expected the job cgroup not to be added")
+			}
+		})
+	}
+}
+
+func TestAddGroupCleansUpFailedWrites(t *testing.T) {
+	t.Parallel()
+
+	errWrite := errors.New("write failed")
+	tests := []struct {
+		name string
+		// failPath is the interface file whose write fails, relative to the server cgroup
+		failPath string
+		// partial writes the file before failing, so the job cgroup directory isn't empty
+		partial bool
+	}{
+		{name: "user subtree_control", failPath: "user1/cgroup.subtree_control"},
+		{name: "user memory.max", failPath: "user1/memory.max"},
+		{name: "user cpu.max", failPath: "user1/cpu.max"},
+		{name: "job memory.max", failPath: "user1/job1/memory.max"},
+		{name: "job memory.max partially written", failPath: "user1/job1/memory.max", partial: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			m := newTestFSManager(t, UserQuota{})
+			failPath := filepath.Join(m.rootPath, m.serverCGroupName, tt.failPath)
+			m.writeFile = func(name string, data []byte, perm os.FileMode) error {
+				if name != failPath {
+					return os.WriteFile(name, data, perm)
+				}
+				if tt.partial {
+					os.WriteFile(name, data[:1], perm)
+				}
+				return errWrite
+			}
+
+			if _, err := m.AddGroup("user1", "job1"); !errors.Is(err, errWrite) {
+				t.Fatalf("expected the write error, got %v", err)
+			}
+			jobDir := filepath.Join(m.rootPath, m.serverCGroupName, "user1", "job1")
+			if _, err := os.Stat(jobDir); !errors.Is(err, os.ErrNotExist) {
+				t.Fatalf("expected the job cgroup directory to be removed, got %v", err)
+			}
+			if len(m.groups) != 0 {
+				t.Fatalf("expected no cgroups to be added, got %d", len(m.groups))
+			}
+
+			// the next job can still be started once writes succeed
+			m.writeFile = os.WriteFile
+			if _, err := m.AddGroup("user1", "job1"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestUpdateGroup(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		quota   UserQuota
+		limits  Limits
+		wantMem string
+		wantCPU string
+		wantErr error
+	}{
+		{
+			name:    "memory and cpu",
+			limits:  Limits{MemoryMaxBytes: 2 * gb, CPUs: 1.5},
+			wantMem: "2147483648",
+			wantCPU: "150000 100000",
+		},
+		{
+			name:    "zero limits are unchanged",
+			limits:  Limits{CPUs: 0.5},
+			wantMem: "858993459",
+			wantCPU: "50000 100000",
+		},
+		{
+			name:    "memory over the server's target",
+			limits:  Limits{MemoryMaxBytes: 5 * gb},
+			wantErr: ErrLimitTooHigh,
+		},
+		{
+			name:    "memory over the user quota",
+			quota:   UserQuota{MemoryMaxBytes: gb},
+			limits:  Limits{MemoryMaxBytes: 2 * gb},
+			wantErr: ErrLimitTooHigh,
+		},
+		{
+			name:    "cpu over the user quota",
+			quota:   UserQuota{CPUs: 1},
+			limits:  Limits{CPUs: 2},
+			wantErr: ErrLimitTooHigh,
+		},
+		{
+			name:    "memory below the current usage",
+			limits:  Limits{MemoryMaxBytes: 1 << 20},
+			wantErr: ErrBelowUsage,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			m := newTestFSManager(t, tt.quota)
+			if _, err := m.AddGroup("user1", "job1"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			jobDir := filepath.Join(m.rootPath, m.serverCGroupName, "user1", "job1")
+			// the kernel creates memory.current, so it's written here. The job is using 64M.
+			if err := os.WriteFile(filepath.Join(jobDir, "memory.current"), []byte("67108864\n"), 0644); err != nil {
+				t.Fatalf("writing memory.current: %v", err)
+			}
+
+			err := m.UpdateGroup("job1", tt.limits)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got %v", tt.wantErr, err)
+				}
+				if got := readControlFile(t, jobDir, "memory.max"); got != "858993459" {
+					t.Fatalf("expected memory.max to be unchanged, got %s", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := readControlFile(t, jobDir, "memory.max"); got != tt.wantMem {
+				t.Fatalf("expected memory.max %q, got %q", tt.wantMem, got)
+			}
+			if got := readControlFile(t, jobDir, "cpu.max"); got != tt.wantCPU {
+				t.Fatalf("expected cpu.max %q, got %q", tt.wantCPU, got)
+			}
+		})
+	}
+
+	m := newTestFSManager(t, UserQuota{})
+	if err := m.UpdateGroup("job2", Limits{CPUs: 1}); err == nil {
+		t.Fatalf("expected an error for a missing cgroup")
+	}
+}
+
+func TestLimitApplied(t *testing.T) {
+	t.Parallel()
+
+	page := int64(os.Getpagesize())
+	tests := []struct {
+		name      string
+		file      string
+		got, want string
+		applied   bool
+	}{
+		{name: "same value", file: "cpu.max", got: "150000 100000", want: "150000 100000", applied: true},
+		{name: "different value", file: "cpu.max", got: "max 100000", want: "150000 100000"},
+		{name: "memory rounded to a page", file: "memory.max", got: fmt.Sprint(10 * page), want: fmt.Sprint(10*page + 1), applied: true},
+		{name: "memory off by a page", file: "memory.max", got: fmt.Sprint(9 * page), want: fmt.Sprint(10 * page)},
+		{name: "memory unlimited", file: "memory.max", got: "max", want: "2147483648"},
+		{name: "empty", file: "memory.max", got: "", want: "max"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if applied := limitApplied(tt.file, tt.got, tt.want); applied != tt.applied {
+				t.Fatalf("expected limitApplied(%q, %q, %q) to be %v", tt.file, tt.got, tt.want, tt.applied)
+			}
+		})
+	}
+}
+
+func TestProcsAndKill(t *testing.T) {
+	t.Parallel()
+
+	m := newTestFSManager(t, UserQuota{})
+	if _, err := m.AddGroup("user1", "job1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	jobDir := filepath.Join(m.rootPath, m.serverCGroupName, "user1", "job1")
+
+	// the kernel creates cgroup.procs, so it's written here
+	if err := os.WriteFile(filepath.Join(jobDir, "cgroup.procs"), []byte("1234\n5678\n"), 0644); err != nil {
+		t.Fatalf("writing cgroup.procs: %v", err)
+	}
+	pids, err := m.Procs("job1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(pids) != 2 || pids[0] != 1234 || pids[1] != 5678 {
+		t.Fatalf("expected pids [1234 5678], got %v", pids)
+	}
+
+	if err := m.Kill("job1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := readControlFile(t, jobDir, "cgroup.kill"); got != "1" {
+		t.Fatalf("expected cgroup.kill 1, got %q", got)
+	}
+
+	if _, err := m.Procs("job2"); err == nil {
+		t.Fatalf("expected an error for a missing cgroup")
+	}
+}
+
+func TestUsage(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		files   map[string]string
+		want    Usage
+		wantErr bool
+	}{
+		{
+			name: "peak memory and cpu",
+			files: map[string]string{
+				"memory.peak": "73400320\n",
+				"cpu.stat":    "usage_usec 1520000\nuser_usec 1000000\nsystem_usec 520000\n",
+			},
+			want: Usage{PeakMemoryBytes: 73400320, CPUUsageUsec: 1520000},
+		},
+		{
+			name:  "kernel without memory.peak",
+			files: map[string]string{"cpu.stat": "usage_usec 42\n"},
+			want:  Usage{CPUUsageUsec: 42},
+		},
+		{
+			name:    "missing cpu.stat",
+			files:   map[string]string{"memory.peak": "4096\n"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid memory.peak",
+			files:   map[string]string{"memory.peak": "lots\n", "cpu.stat": "usage_usec 42\n"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			m := newTestFSManager(t, UserQuota{})
+			if _, err := m.AddGroup("user1", "job1"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			jobDir := filepath.Join(m.rootPath, m.serverCGroupName, "user1", "job1")
+			// the kernel creates these files, so they're written here
+			for name, content := range tt.files {
+				if err := os.WriteFile(filepath.Join(jobDir, name), []byte(content), 0644); err != nil {
+					t.Fatalf("writing %s: %v", name, err)
+				}
+			}
+
+			usage, err := m.Usage("job1")
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", usage)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if usage != tt.want {
+				t.Fatalf("expected %+v, got %+v", tt.want, usage)
+			}
+
+			// the usage can't be read once the cgroup is removed
+			if err := m.RemoveGroup("job1"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if _, err := m.Usage("job1"); err == nil {
+				t.Fatal("expected an error after the cgroup was removed")
+			}
+		})
+	}
+}
+
+func TestInitStepTimeout(t *testing.T) {
+	t.Parallel()
+
+	// a step that hangs until the test ends, like a write to a hung filesystem
+	hung := make(chan struct{})
+	defer close(hung)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := initStep(ctx, "creating server cgroup", func(context.Context) error {
+		<-hung
+		return nil
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "creating server cgroup") {
+		t.Fatalf("expected the error to name the stalled step, got %q", err)
+	}
+
+	// a step that fails because it was interrupted by the deadline is also a timeout
+	err = initStep(ctx, "enabling controllers", func(ctx context.Context) error {
+		return errors.New("interrupted")
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+
+	// steps that finish in time return their own result
+	want := errors.New("step failed")
+	if err := initStep(context.Background(), "step", func(context.Context) error { return want }); err != want {
+		t.Fatalf("expected %v, got %v", want, err)
+	}
+	if err := initStep(context.Background(), "step", func(context.Context) error { return nil }); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestInitTimeout(t *testing.T) {
+	t.Parallel()
+
+	// an init timeout that has already passed fails on the first step
+	m := newTestFSManager(t, UserQuota{})
+	m.initTimeout = -time.Second
+	err := m.init()
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "enabling controllers in root cgroup") {
+		t.Fatalf("expected the error to name the first step, got %q", err)
+	}
+}
+
+func TestInit(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		// leftover leaves the server cgroup from a previous run of the server
+		leftover bool
+	}{
+		{name: "new server cgroup"},
+		{name: "leftover server cgroup", leftover: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			m := newTestFSManager(t, UserQuota{})
+			m.initTimeout = 5 * time.Second
+			if !tt.leftover {
+				if err := os.Remove(filepath.Join(m.rootPath, m.serverCGroupName)); err != nil {
+					t.Fatalf("removing server cgroup directory: %v", err)
+				}
+			}
+			if err := m.init(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := readControlFile(t, m.rootPath, "cgroup.subtree_control"); got != "+cpu +memory +io" {
+				t.Fatalf("expected the root cgroup's controllers to be enabled, got %q", got)
+			}
+			if got := readControlFile(t, m.rootPath, m.serverCGroupName, "cgroup.subtree_control"); got != "+cpu +memory +io" {
+				t.Fatalf("expected the server cgroup's controllers to be enabled, got %q", got)
+			}
+		})
+	}
+}
+
+func TestInitFailedWrite(t *testing.T) {
+	t.Parallel()
+
+	// the root cgroup's controllers can't be enabled, e.g. without privileges
+	m := newTestFSManager(t, UserQuota{})
+	m.initTimeout = 5 * time.Second
+	m.writeFile = func(string, []byte, os.FileMode) error { return os.ErrPermission }
+	err := m.init()
+	if !errors.Is(err, os.ErrPermission) {
+		t.Fatalf("expected os.ErrPermission, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "root cgroup") {
+		t.Fatalf("expected the error to name the root cgroup, got %q", err)
+	}
+}
+
+func TestRemoveGroupWaitsForProcesses(t *testing.T) {
+	t.Parallel()
+
+	m := newTestFSManager(t, UserQuota{})
+	if _, err := m.AddGroup("user1", "job1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	jobDir := filepath.Join(m.rootPath, m.serverCGroupName, "user1", "job1")
+	events := filepath.Join(jobDir, "cgroup.events")
+	if err := os.WriteFile(events, []byte("populated 1\nfrozen 0\n"), 0644); err != nil {
+		t.Fatalf("writing cgroup.events: %v", err)
+	}
+
+	removed := make(chan error, 1)
+	go func() {
+		removed <- m.RemoveGroup("job1")
+	}()
+	// the directory is kept while the cgroup has processes, but the cgroup is forgotten
+	select {
+	case err := <-removed:
+		t.Fatalf("expected RemoveGroup to wait for the processes to exit, got %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	if _, err := os.Stat(jobDir); err != nil {
+		t.Fatalf("expected the cgroup directory to be kept, got %v", err)
+	}
+	if _, err := m.Procs("job1"); err == nil {
+		t.Fatal("expected the removed cgroup to be forgotten")
+	}
+
+	// the processes exit
+	if err := os.WriteFile(events, []byte("populated 0\nfrozen 0\n"), 0644); err != nil {
+		t.Fatalf("writing cgroup.events: %v", err)
+	}
+	select {
+	case err := <-removed:
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected RemoveGroup to return once the cgroup is unpopulated")
+	}
+	if _, err := os.Stat(jobDir); !os.IsNotExist(err) {
+		t.Fatalf("expected the cgroup directory to be removed, got %v", err)
+	}
+}
+
+func TestRemoveGroupTimeout(t *testing.T) {
+	t.Parallel()
+
+	// processes that never exit leave the directory behind
+	m := newTestFSManager(t, UserQuota{})
+	m.removeTimeout = 20 * time.Millisecond
+	if _, err := m.AddGroup("user1", "job1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	jobDir := filepath.Join(m.rootPath, m.serverCGroupName, "user1", "job1")
+	if err := os.WriteFile(filepath.Join(jobDir, "cgroup.events"), []byte("populated 1\nfrozen 0\n"), 0644); err != nil {
+		t.Fatalf("writing cgroup.events: %v", err)
+	}
+	if err := m.RemoveGroup("job1"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if _, err := os.Stat(jobDir); err != nil {
+		t.Fatalf("expected the cgroup directory to be kept, got %v", err)
+	}
+	if err := m.RemoveGroup("job1"); err == nil {
+		t.Fatal("expected the cgroup to be forgotten")
+	}
+}
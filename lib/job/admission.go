@@ -0,0 +1,95 @@
+package job
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ErrHostOverloaded is returned by Start when the host's load is over the manager's
+// admission limits, see WithAdmissionLimits
+var ErrHostOverloaded = errors.New("host is overloaded")
+
+// AdmissionLimits are the host load limits new jobs are admitted under. Zero values mean
+// the limit isn't checked.
+type AdmissionLimits struct {
+	// MaxLoad1 is the highest 1 minute load average new jobs are started at
+	MaxLoad1 float64
+	// MinAvailableMemoryBytes is the least available memory new jobs are started with
+	MinAvailableMemoryBytes int64
+}
+
+// HostLoad is a reading of the host's load
+type HostLoad struct {
+	// Load1 is the 1 minute load average, from /proc/loadavg
+	Load1 float64
+	// AvailableMemoryBytes is MemAvailable from /proc/meminfo, the kernel's estimate of the
+	// memory available for new processes without swapping
+	AvailableMemoryBytes int64
+}
+
+// ReadHostLoad reads the host's load from /proc
+func ReadHostLoad() (HostLoad, error) {
+	loadavg, err := os.ReadFile("/proc/loadavg")
+	if err != nil {
+		return HostLoad{}, fmt.Errorf("reading host load: %w", err)
+	}
+	meminfo, err := os.ReadFile("/proc/meminfo")
+	if err != nil {
+		return HostLoad{}, fmt.Errorf("reading host load: %w", err)
+	}
+	return parseHostLoad(loadavg, meminfo)
+}
+
+// parseHostLoad parses the contents of /proc/loadavg and /proc/meminfo
+func parseHostLoad(loadavg, meminfo []byte) (HostLoad, error) {
+	fields := strings.Fields(string(loadavg))
+	if len(fields) == 0 {
+		return HostLoad{}, fmt.Errorf("parsing /proc/loadavg: no load averages")
+	}
+	load1, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return HostLoad{}, fmt.Errorf("parsing /proc/loadavg: %w", err)
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(meminfo))
+	for scanner.Scan() {
+		// the line looks like "MemAvailable:    1234567 kB"
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 3 || fields[0] != "MemAvailable:" || fields[2] != "kB" {
+			continue
+		}
+		kb, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return HostLoad{}, fmt.Errorf("parsing /proc/meminfo: %w", err)
+		}
+		return HostLoad{Load1: load1, AvailableMemoryBytes: kb << 10}, nil
+	}
+	return HostLoad{}, fmt.Errorf("parsing /proc/meminfo: no MemAvailable")
+}
+
+// admit returns ErrHostOverloaded if the host's load is over the admission limits. If the
+// load can't be read, the job is admitted, so a missing /proc doesn't stop every job, and
+// the error is logged.
+func (m *Manager) admit() error {
+	limits := m.admissionLimits
+	if limits.MaxLoad1 <= 0 && limits.MinAvailableMemoryBytes <= 0 {
+		return nil
+	}
+	load, err := m.readHostLoad()
+	if err != nil {
+		m.log.Warnw("admitting job without checking the host load", "error", err)
+		return nil
+	}
+	if limits.MaxLoad1 > 0 && load.Load1 > limits.MaxLoad1 {
+		return fmt.Errorf("%w: load average %.2f is over %.2f", ErrHostOverloaded, load.Load1, limits.MaxLoad1)
+	}
+	if limits.MinAvailableMemoryBytes > 0 && load.AvailableMemoryBytes < limits.MinAvailableMemoryBytes {
+		return fmt.Errorf("%w: %d bytes of memory available, under %d", ErrHostOverloaded, load.AvailableMemoryBytes, limits.MinAvailableMemoryBytes)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestParseHostLoad(t *testing.T) {
+	t.Parallel()
+
+	const meminfo = "MemTotal:       16310120 kB\nMemFree:         1048576 kB\nMemAvailable:    8155060 kB\n"
+	tests := []struct {
+		name    string
+		loadavg string
+		meminfo string
+		want    HostLoad
+		wantErr bool
+	}{
+		{
+			name:    "load and memory",
+			loadavg: "2.50 1.75 1.10 3/1234 56789\n",
+			meminfo: meminfo,
+			want:    HostLoad{Load1: 2.5, AvailableMemoryBytes: 8155060 << 10},
+		},
+		{name: "empty loadavg", loadavg: "", meminfo: meminfo, wantErr: true},
+		{name: "invalid loadavg", loadavg: "high 1.75 1.10", meminfo: meminfo, wantErr: true},
+		{name: "no MemAvailable", loadavg: "0.10 0.10 0.10", meminfo: "MemTotal: 16310120 kB\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := parseHostLoad([]byte(tt.loadavg), []byte(tt.meminfo))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if got != tt.want {
+				t.Fatalf("expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestManagerAdmissionLimits(t *testing.T) {
+	t.Parallel()
+
+	limits := AdmissionLimits{MaxLoad1: 4, MinAvailableMemoryBytes: 512 << 20}
+	tests := []struct {
+		name    string
+		limits  AdmissionLimits
+		load    HostLoad
+		loadErr error
+		wantErr error
+	}{
+		{name: "below the limits", limits: limits, load: HostLoad{Load1: 3.9, AvailableMemoryBytes: 1 << 30}},
+		{name: "load too high", limits: limits, load: HostLoad{Load1: 4.1, AvailableMemoryBytes: 1 << 30}, wantErr: ErrHostOverloaded},
+		{name: "memory too low", limits: limits, load: HostLoad{Load1: 0.5, AvailableMemoryBytes: 256 << 20}, wantErr: ErrHostOverloaded},
+		{name: "load can't be read", limits: limits, loadErr: errors.New("no /proc")},
+		{name: "no limits", load: HostLoad{Load1: 100}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			m := NewManager(context.Background(), WithAdmissionLimits(tt.limits))
+			m.cgroupFSManager = noopCgroups{}
+			m.readHostLoad = func() (HostLoad, error) { return tt.load, tt.loadErr }
+
+			_, err := m.Start(context.Background(), "user1", "true", nil)
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil) != (err == nil) {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			// a rejected job doesn't count as running
+			if err != nil && m.RunningJobs() != 0 {
+				t.Fatalf("expected no running jobs, got %d", m.RunningJobs())
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	jogv1 "github.com/dustinevan/jogger/pkg/gen/jogger/v1"
+	"github.com/google/uuid"
+)
+
+// ErrDependencyNotCompleted is the start error of a job started with WithAfter that wasn't
+// started, because the job it was started after didn't complete
+var ErrDependencyNotCompleted = errors.New("the job it was started after didn't complete")
+
+// ErrNotStarted is the start error of a job started with WithAfter that was stopped, or
+// whose server shut down, while it was pending
+var ErrNotStarted = errors.New("the job was stopped before it was started")
+
+// pendingFailureTTL is how long a job started with WithAfter that wasn't started is
+// recorded, when failed starts aren't otherwise recorded, see WithFailedStartTTL. It's the
+// only way its user can tell why it wasn't started.
+const pendingFailureTTL = 10 * time.Minute
+
+// WithAfter starts the job once the user's job with jobID, or name, is done, e.g. to run a
+// test job after a build job. The job is PENDING until then. It has no process, output, or
+// events yet, and it doesn't count against the running job limit. With onlyIfCompleted,
+// it's only started if that job COMPLETED. A job that isn't started is recorded as
+// START_FAILED. It's applied by Manager.Start.
+func WithAfter(jobID string, onlyIfCompleted bool) JobOption {
+	return func(cfg *jobConfig) {
+		cfg.after, cfg.afterCompleted = jobID, onlyIfCompleted
+	}
+}
+
+// pendingJob is a job started with WithAfter that's waiting for the job it was started after
+type pendingJob struct {
+	username string
+	jobID    string
+	name     string
+	// command and args are what the job will be started with, see Job.Command
+	command string
+	args    []string
+	// cancel stops the job from being started, see Manager.Stop
+	cancel context.CancelFunc
+	// done is closed once the job is started, or won't be. job is set before then if it was
+	// started.
+	done chan struct{}
+	job  *Job
+}
+
+// wait blocks until the pending job is started and done, and returns its status.
+// START_FAILED is returned if it isn't started.
+func (p *pendingJob) wait(ctx context.Context) (jogv1.Status, error) {
+	select {
+	case <-p.done:
+	case <-ctx.Done():
+		return jogv1.Status_STATUS_UNSPECIFIED, ctx.Err()
+	}
+	if p.job == nil {
+		return jogv1.Status_START_FAILED, nil
+	}
+	return waitJob(ctx, p.job)
+}
+
+// waitJob blocks until the job is done, and returns its status
+func waitJob(ctx context.Context, j *Job) (jogv1.Status, error) {
+	select {
+	case <-j.doneCtx.Done():
+		return j.Status(), nil
+	case <-ctx.Done():
+		return jogv1.Status_STATUS_UNSPECIFIED, ctx.Err()
+	}
+}
+
+// dependency returns a function that waits for the user's job with jobID, or name, to be
+// done, and returns its status. The job may be pending itself. Pending jobs are looked up
+// first: a pending job is added to the job map before it's removed from the pending jobs,
+// so it's found in one or the other.
+func (m *Manager) dependency(username, jobID string) (func(context.Context) (jogv1.Status, error), error) {
+	if p := m.getPending(username, jobID); p != nil {
+		return p.wait, nil
+	}
+	j, err := m.getJob(username, jobID)
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context) (jogv1.Status, error) { return waitJob(ctx, j) }, nil
+}
+
+// startAfter registers a job to start once the job it's started after is done, see WithAfter.
+// The job's name is reserved right away, so it can be referred to while it's pending.
+func (m *Manager) startAfter(username, cmd string, args []string, cfg jobConfig, options []JobOption) (string, error) {
+	wait, err := m.dependency(username, cfg.after)
+	if err != nil {
+		return "", fmt.Errorf("starting job after %s: %w", cfg.after, err)
+	}
+	jobID := uuid.NewString()
+	if err := m.reserveName(username, cfg.name, jobID); err != nil {
+		return "", err
+	}
+	ctx, cancel := context.WithCancel(m.shutdownCtx)
+	p := &pendingJob{
+		username: username,
+		jobID:    jobID,
+		name:     cfg.name,
+		command:  cmd,
+		args:     append([]string(nil), args...),
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+	m.mu.Lock()
+	m.pending[keyString(username, jobID)] = p
+	m.mu.Unlock()
+
+	go func() {
+		defer cancel()
+		j, err := m.startPending(ctx, wait, username, jobID, cmd, args, cfg, options)
+		if err != nil {
+			m.recordFailedStart(username, jobID, err, max(m.failedStartTTL, pendingFailureTTL))
+		}
+		p.job = j
+		m.mu.Lock()
+		delete(m.pending, keyString(username, jobID))
+		m.mu.Unlock()
+		close(p.done)
+	}()
+	return jobID, nil
+}
+
+// startPending waits for the job a pending job was started after, then starts it
+func (m *Manager) startPending(ctx context.Context, wait func(context.Context) (jogv1.Status, error), username, jobID, cmd string, args []string, cfg jobConfig, options []JobOption) (*Job, error) {
+	status, err := wait(ctx)
+	if err != nil {
+		m.releaseName(username, cfg.name, jobID)
+		return nil, fmt.Errorf("%w: it was pending on job %s", ErrNotStarted, cfg.after)
+	}
+	if cfg.afterCompleted && status != jogv1.Status_COMPLETED {
+		m.releaseName(username, cfg.name, jobID)
+		return nil, fmt.Errorf("%w: job %s is %s", ErrDependencyNotCompleted, cfg.after, status)
+	}
+	return m.launch(ctx, m.now(), username, jobID, cmd, args, cfg, options)
+}
+
+// getPending returns the user's pending job with jobID, which can also be its name, or nil
+func (m *Manager) getPending(username, jobID string) *pendingJob {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if p, ok := m.pending[keyString(username, jobID)]; ok {
+		return p
+	}
+	if named, ok := m.names[keyString(username, jobID)]; ok {
+		return m.pending[keyString(username, named)]
+	}
+	return nil
+}
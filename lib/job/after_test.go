@@ -0,0 +1,198 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	jogv1 "github.com/dustinevan/jogger/pkg/gen/jogger/v1"
+)
+
+// waitForStatus polls the job's status until it's no longer PENDING, and returns it
+func waitForStatus(t *testing.T, m *Manager, username, jobID string, timeout time.Duration) StatusInfo {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for {
+		info, err := m.Status(context.Background(), username, jobID)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if info.Status != jogv1.Status_PENDING {
+			return info
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job %s was still pending after %v", jobID, timeout)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestManagerStartAfter(t *testing.T) {
+	t.Parallel()
+
+	m := NewManager(context.Background())
+	m.cgroupFSManager = noopCgroups{}
+
+	a, err := m.Start(context.Background(), "user1", "sleep", []string{"0.3"}, WithName("build"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b, err := m.Start(context.Background(), "user1", "true", nil, WithAfter("build", true), WithName("test"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// b is pending while a runs, and can be referred to by its name
+	for i := 0; i < 5; i++ {
+		info, err := m.Status(context.Background(), "user1", "test")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if info.Status != jogv1.Status_PENDING {
+			t.Fatalf("expected b to be pending while a runs, got %v", info.Status)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if !m.Exists(context.Background(), "user1", b) {
+		t.Fatalf("expected a pending job to exist")
+	}
+	if n := m.RunningJobs(); n != 1 {
+		t.Fatalf("expected a pending job not to count as running, got %d running", n)
+	}
+
+	info := waitForStatus(t, m, "user1", b, 5*time.Second)
+	aj, err := m.getJob("user1", a)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if aj.Status() != jogv1.Status_COMPLETED {
+		t.Fatalf("expected a to have completed before b started, got %v", aj.Status())
+	}
+	if info.StartedAt.Before(aj.ExitedAt()) {
+		t.Fatalf("expected b to start after a exited at %v, it started at %v", aj.ExitedAt(), info.StartedAt)
+	}
+	bj, err := m.getJob("user1", b)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	waitForJob(t, bj, 5*time.Second)
+	if bj.Status() != jogv1.Status_COMPLETED {
+		t.Fatalf("expected b to complete, got %v", bj.Status())
+	}
+}
+
+func TestManagerStartAfterNotStarted(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		// start starts the job the pending job is started after, and returns its id
+		start           func(t *testing.T, m *Manager) string
+		onlyIfCompleted bool
+		// stop stops the pending job before the job it's started after is done
+		stop    bool
+		wantErr error
+	}{
+		{
+			name: "job failed",
+			start: func(t *testing.T, m *Manager) string {
+				id, err := m.Start(context.Background(), "user1", "false", nil)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return id
+			},
+			onlyIfCompleted: true,
+			wantErr:         ErrDependencyNotCompleted,
+		},
+		{
+			name: "pending job wasn't started",
+			start: func(t *testing.T, m *Manager) string {
+				a, err := m.Start(context.Background(), "user1", "false", nil)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				b, err := m.Start(context.Background(), "user1", "true", nil, WithAfter(a, true))
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return b
+			},
+			onlyIfCompleted: true,
+			wantErr:         ErrDependencyNotCompleted,
+		},
+		{
+			name: "stopped while pending",
+			start: func(t *testing.T, m *Manager) string {
+				id, err := m.Start(context.Background(), "user1", "sleep", []string{"10"})
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				t.Cleanup(func() { m.Stop(context.Background(), "user1", id) })
+				return id
+			},
+			stop:    true,
+			wantErr: ErrNotStarted,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			m := NewManager(context.Background())
+			m.cgroupFSManager = noopCgroups{}
+
+			after := tt.start(t, m)
+			jobID, err := m.Start(context.Background(), "user1", "true", nil, WithAfter(after, tt.onlyIfCompleted), WithName("next"))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.stop {
+				if err := m.Stop(context.Background(), "user1", jobID); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+
+			info := waitForStatus(t, m, "user1", jobID, 5*time.Second)
+			if info.Status != jogv1.Status_START_FAILED {
+				t.Fatalf("expected status %v, got %v", jogv1.Status_START_FAILED, info.Status)
+			}
+			if !strings.Contains(info.StartError, tt.wantErr.Error()) {
+				t.Fatalf("expected the start error to contain %q, got %q", tt.wantErr, info.StartError)
+			}
+			// the job that wasn't started doesn't keep its name
+			if _, err := m.Start(context.Background(), "user1", "true", nil, WithName("next")); err != nil {
+				t.Fatalf("expected the name to be released, got %v", err)
+			}
+		})
+	}
+}
+
+func TestManagerStartAfterMissingJob(t *testing.T) {
+	t.Parallel()
+
+	m := NewManager(context.Background())
+	m.cgroupFSManager = noopCgroups{}
+	a, err := m.Start(context.Background(), "user1", "true", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// jobs are scoped per user, so another user's job is missing too
+	for _, username := range []string{"user1", "user2"} {
+		after := a
+		if username == "user1" {
+			after = "no-such-job"
+		}
+		jobID, err := m.Start(context.Background(), username, "true", nil, WithAfter(after, false))
+		if !errors.Is(err, ErrJobNotFound) {
+			t.Fatalf("expected ErrJobNotFound, got %v", err)
+		}
+		if jobID != "" {
+			t.Fatalf("expected no job id, got %q", jobID)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package job
+
+import (
+	"context"
+	"time"
+)
+
+// EventType is a kind of job lifecycle event
+type EventType string
+
+const (
+	// EventStarted is recorded when the job's process is started
+	EventStarted EventType = "started"
+	// EventStopRequested is recorded each time Stop is called on the job
+	EventStopRequested EventType = "stop_requested"
+	// EventExited is recorded when the job's process has exited and its final status is set
+	EventExited EventType = "exited"
+)
+
+// Event is a job lifecycle event. Events are kept with the job, so they're available for as
+// long as the job is, and help explain why a job ended the way it did.
+type Event struct {
+	Time time.Time
+	Type EventType
+	// Detail describes the event, e.g. the signal sent to stop the job
+	Detail string
+}
+
+// recordEvent appends an event to the job's event log
+func (j *Job) recordEvent(t EventType, detail string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.events = append(j.events, Event{Time: time.Now(), Type: t, Detail: detail})
+	if j.eventsChanged != nil {
+		close(j.eventsChanged)
+		j.eventsChanged = nil
+	}
+}
+
+// StartedAt returns when the job's process was started, the zero time if it wasn't
+func (j *Job) StartedAt() time.Time {
+	return j.eventTime(EventStarted)
+}
+
+// ExitedAt returns when the job's process exited, the zero time if it hasn't
+func (j *Job) ExitedAt() time.Time {
+	return j.eventTime(EventExited)
+}
+
+// eventTime returns the time of the job's first event of type t, the zero time if there isn't one
+func (j *Job) eventTime(t EventType) time.Time {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for _, e := range j.events {
+		if e.Type == t {
+			return e.Time
+		}
+	}
+	return time.Time{}
+}
+
+// Events returns the job's lifecycle events, oldest first
+func (j *Job) Events() []Event {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return append([]Event(nil), j.events...)
+}
+
+// WatchEvents returns a channel that streams the job's lifecycle events, starting with the
+// ones already recorded, and then each one as it's recorded. The channel is closed once the
+// job is done and its exited event has been sent, or when ctx is done. Each stream keeps its
+// own position in the event log, so any number of streams can watch the same job.
+func (j *Job) WatchEvents(ctx context.Context) <-chan Event {
+	events := make(chan Event)
+	go func() {
+		defer close(events)
+		next := 0
+		for {
+			recorded, changed := j.eventsSince(next)
+			for _, e := range recorded {
+				select {
+				case events <- e:
+				case <-ctx.Done():
+					return
+				}
+				next++
+				if e.Type == EventExited {
+					return
+				}
+			}
+			if len(recorded) > 0 {
+				continue
+			}
+			select {
+			case <-changed:
+			case <-j.doneCtx.Done():
+				// the job may have been done without exiting, e.g. if it couldn't be started, so
+				// the events left are sent before the stream is closed
+				if recorded, _ := j.eventsSince(next); len(recorded) == 0 {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return events
+}
+
+// eventsSince returns the events recorded after the first n. When there are none, it returns
+// a channel that's closed when the next event is recorded.
+func (j *Job) eventsSince(n int) ([]Event, <-chan struct{}) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if n < len(j.events) {
+		return append([]Event(nil), j.events[n:]...), nil
+	}
+	if j.eventsChanged == nil {
+		j.eventsChanged = make(chan struct{})
+	}
+	return nil, j.eventsChanged
+}
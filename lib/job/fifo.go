@@ -0,0 +1,69 @@
+package job
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"golang.org/x/sys/unix"
+)
+
+// fifoSink is an io.Writer that copies job output to a named pipe for external consumers,
+// like log shippers. It never blocks the job: the pipe is opened and written in
+// non-blocking mode, so output is dropped while no reader is present, or when the
+// reader falls behind and the pipe is full.
+type fifoSink struct {
+	path string
+
+	mu sync.Mutex
+	// fd is the write end of the pipe, -1 until a reader is present
+	fd int
+}
+
+// newFIFOSink creates a named pipe at path
+func newFIFOSink(path string) (*fifoSink, error) {
+	if err := unix.Mkfifo(path, 0600); err != nil {
+		return nil, fmt.Errorf("creating fifo %s: %w", path, err)
+	}
+	return &fifoSink{path: path, fd: -1}, nil
+}
+
+// Write writes b to the pipe. os.File isn't used because it would wait on the
+// runtime poller for the pipe to be writable, which is the blocking we need to avoid.
+func (s *fifoSink) Write(b []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fd < 0 {
+		// opening the write end fails with ENXIO when there is no reader
+		fd, err := unix.Open(s.path, unix.O_WRONLY|unix.O_NONBLOCK|unix.O_CLOEXEC, 0)
+		if err != nil {
+			return 0, fmt.Errorf("opening fifo %s: %w", s.path, err)
+		}
+		s.fd = fd
+	}
+	n, err := unix.Write(s.fd, b)
+	if err != nil {
+		if errors.Is(err, unix.EPIPE) {
+			// the reader went away, reopen when the next reader arrives
+			unix.Close(s.fd)
+			s.fd = -1
+		}
+		return max(n, 0), fmt.Errorf("writing to fifo %s: %w", s.path, err)
+	}
+	return n, nil
+}
+
+// Close closes the pipe and removes it from the filesystem. Readers see EOF.
+func (s *fifoSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fd >= 0 {
+		unix.Close(s.fd)
+		s.fd = -1
+	}
+	if err := os.Remove(s.path); err != nil {
+		return fmt.Errorf("removing fifo %s: %w", s.path, err)
+	}
+	return nil
+}
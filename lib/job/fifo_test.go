@@ -0,0 +1,71 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestFIFOSink(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "job.fifo")
+	s, err := newFIFOSink(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// without a reader the output is dropped instead of blocking
+	if _, err := s.Write([]byte("dropped")); !errors.Is(err, unix.ENXIO) {
+		t.Fatalf("expected ENXIO without a reader, got %v", err)
+	}
+
+	r, err := os.OpenFile(path, os.O_RDONLY|unix.O_NONBLOCK, 0)
+	if err != nil {
+		t.Fatalf("opening fifo for reading: %v", err)
+	}
+	defer r.Close()
+
+	if n, err := s.Write([]byte("hello fifo")); err != nil || n != len("hello fifo") {
+		t.Fatalf("unexpected write result: n=%d err=%v", n, err)
+	}
+	buf := make([]byte, 64)
+	n, err := r.Read(buf)
+	if err != nil {
+		t.Fatalf("reading fifo: %v", err)
+	}
+	if got := string(buf[:n]); got != "hello fifo" {
+		t.Fatalf("expected %q, got %q", "hello fifo", got)
+	}
+
+	if err := s.Close(); err != nil {
+		t.Fatalf("unexpected close error: %v", err)
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected the fifo to be removed, got %v", err)
+	}
+}
+
+func TestNewJobOutputFIFO(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "job.fifo")
+	j := newJob(context.Background(), -1, "echo", nil, WithOutputFIFO(path))
+	if j.fifo == nil || j.cmd.Err != nil {
+		t.Fatalf("expected an output fifo, got err=%v", j.cmd.Err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode()&os.ModeNamedPipe == 0 {
+		t.Fatalf("expected a named pipe at %s: %v", path, err)
+	}
+	j.closeFIFO()
+
+	// a fifo that can't be created fails the job at start
+	j = newJob(context.Background(), -1, "echo", nil, WithOutputFIFO(filepath.Join(t.TempDir(), "missing", "job.fifo")))
+	if j.cmd.Err == nil {
+		t.Fatalf("expected an error creating the fifo")
+	}
+}
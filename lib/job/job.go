@@ -4,9 +4,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"github.com/dustinevan/jogger/lib/cgroup"
 	jogv1 "github.com/dustinevan/jogger/pkg/gen/jogger/v1"
 	"golang.org/x/sys/unix"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"syscall"
 	"time"
@@ -15,13 +20,289 @@ import (
 // CommandWaitDelay is the amount of time to wait for a canceled Job to shut down before sending a SIGKILL
 const CommandWaitDelay = 10 * time.Second
 
+// ImmediateWaitDelay is the wait delay used by the StopImmediate policy. Jobs are sent a SIGKILL
+// right away, so this only bounds how long we wait for the job's output pipes to close.
+const ImmediateWaitDelay = 100 * time.Millisecond
+
+// StopPolicy controls how a job is signaled when it is stopped, or when the server shuts down
+type StopPolicy string
+
+const (
+	// StopGraceful sends a SIGTERM, then a SIGKILL if the job hasn't exited after CommandWaitDelay.
+	// Stopped jobs end with the STOPPED status, or KILLED if they didn't exit in time.
+	StopGraceful StopPolicy = "graceful"
+	// StopImmediate sends a SIGKILL, skipping the SIGTERM. Stopped jobs end with the KILLED status.
+	// This is meant for untrusted or known-unresponsive workloads.
+	StopImmediate StopPolicy = "immediate"
+)
+
+// ParseStopPolicy parses a StopPolicy from a string
+func ParseStopPolicy(s string) (StopPolicy, error) {
+	switch p := StopPolicy(s); p {
+	case StopGraceful, StopImmediate:
+		return p, nil
+	default:
+		return "", fmt.Errorf("unsupported stop policy: %q: use %q or %q", s, StopGraceful, StopImmediate)
+	}
+}
+
+// Capture controls which of a job's output streams are kept. Output that isn't captured
+// is discarded, which saves memory for jobs that are chatty on a stream nobody reads.
+type Capture string
+
+const (
+	// CaptureBoth keeps stdout and stderr
+	CaptureBoth Capture = "both"
+	// CaptureStdout keeps stdout, stderr is discarded
+	CaptureStdout Capture = "stdout"
+	// CaptureStderr keeps stderr, stdout is discarded
+	CaptureStderr Capture = "stderr"
+)
+
+// ParseCapture parses a Capture from a string
+func ParseCapture(s string) (Capture, error) {
+	switch c := Capture(s); c {
+	case CaptureBoth, CaptureStdout, CaptureStderr:
+		return c, nil
+	default:
+		return "", fmt.Errorf("unsupported capture: %q: use %q, %q, or %q", s, CaptureBoth, CaptureStdout, CaptureStderr)
+	}
+}
+
+// DefaultPath is the PATH used to resolve job commands when one isn't configured. The server
+// process's own PATH isn't used because it may be empty or unexpected, e.g. under systemd.
+const DefaultPath = "/usr/local/bin:/usr/bin:/bin"
+
+type JobOption func(*jobConfig)
+
+type jobConfig struct {
+	path       string
+	stopPolicy StopPolicy
+	fifoPath   string
+	snapshot   snapshotConfig
+	maxOutput  int64
+	maxBuffer  int64
+	spillDir   string
+	spillErr   func(error)
+	tty        bool
+	capture    Capture
+	name       string
+	shell      bool
+	sourceAddr string
+	// umask is only set when umaskSet is, 0 is a valid mask
+	umask    int
+	umaskSet bool
+	rlimits  map[Rlimit]uint64
+	// resourceLimits are the limits of the job's cgroup, see Manager.Start
+	resourceLimits cgroup.ResourceLimits
+	// outputCapacity is preallocated for the job's output, see WithOutputCapacity
+	outputCapacity int
+	// redact are the patterns masked in the job's output, see WithOutputRedaction
+	redact []string
+	// after is the job this one is started after, see WithAfter
+	after          string
+	afterCompleted bool
+}
+
+func defaultJobConfig() jobConfig {
+	return jobConfig{
+		path:       DefaultPath,
+		stopPolicy: StopGraceful,
+		capture:    CaptureBoth,
+	}
+}
+
+// WithPath sets the PATH used to resolve the job's command. It is also set in the job's environment.
+// An empty path keeps the default, DefaultPath.
+func WithPath(path string) JobOption {
+	return func(cfg *jobConfig) {
+		if path != "" {
+			cfg.path = path
+		}
+	}
+}
+
+// WithStopPolicy sets how the job is signaled when it is stopped
+func WithStopPolicy(p StopPolicy) JobOption {
+	return func(cfg *jobConfig) {
+		cfg.stopPolicy = p
+	}
+}
+
+// WithOutputFIFO creates a named pipe at path that the job's output is copied to, in
+// addition to the in-memory buffer. Output is dropped while no reader has the pipe open,
+// so a missing consumer never blocks the job. The pipe is removed when the job is done.
+func WithOutputFIFO(path string) JobOption {
+	return func(cfg *jobConfig) {
+		cfg.fifoPath = path
+	}
+}
+
+// snapshotConfig is where and how often the job's output is uploaded, see WithOutputSnapshot
+type snapshotConfig struct {
+	uploader Uploader
+	key      string
+	interval time.Duration
+	onErr    func(error)
+}
+
+// WithOutputSnapshot uploads the job's output to objects under key, every interval while the
+// job runs and once more when it's done. The output is uploaded in parts, each named
+// key/<offset> after the offset of its first byte, zero-padded to 20 digits, so listing the
+// objects lists the output in order. Uploads never block the job. Failed uploads are retried,
+// then passed to onErr, which may be nil, and tried again at the next interval.
+func WithOutputSnapshot(uploader Uploader, key string, interval time.Duration, onErr func(error)) JobOption {
+	return func(cfg *jobConfig) {
+		if onErr == nil {
+			onErr = func(error) {}
+		}
+		cfg.snapshot = snapshotConfig{uploader: uploader, key: key, interval: interval, onErr: onErr}
+	}
+}
+
+// WithMaxOutputBytes keeps only the last limit bytes of the job's output in memory.
+// Earlier output is discarded, see WithOutputLimit. A limit <= 0 keeps all output.
+func WithMaxOutputBytes(limit int64) JobOption {
+	return func(cfg *jobConfig) {
+		cfg.maxOutput = limit
+	}
+}
+
+// WithOutputBuffer caps the job's output kept in memory at size bytes. Older output is
+// spilled to a temporary file in dir, or os.TempDir when it's empty, see WithMaxBufferBytes.
+// If it can't be spilled, it's discarded, and the error is passed to onErr, which may be nil.
+// A size <= 0 keeps all output in memory.
+func WithOutputBuffer(size int64, dir string, onErr func(error)) JobOption {
+	return func(cfg *jobConfig) {
+		if onErr == nil {
+			onErr = func(error) {}
+		}
+		cfg.maxBuffer, cfg.spillDir, cfg.spillErr = size, dir, onErr
+	}
+}
+
+// WithOutputCapacity preallocates size bytes for the job's output in memory, see
+// WithInitialBufferCapacity
+func WithOutputCapacity(size int) JobOption {
+	return func(cfg *jobConfig) {
+		cfg.outputCapacity = size
+	}
+}
+
+// WithResourceLimits sets the memory, cpu, and io limits of the job's cgroup. Zero limits
+// keep the server's defaults. It's applied by Manager.Start, which creates the cgroup.
+func WithResourceLimits(limits cgroup.ResourceLimits) JobOption {
+	return func(cfg *jobConfig) {
+		cfg.resourceLimits = limits
+	}
+}
+
+// WithTTY runs the job in a pseudo-terminal. Tools that check for a terminal behave
+// interactively, e.g. they line buffer and color their output. The terminal changes the
+// output too: line endings are written as \r\n, and stdout and stderr can't be told apart.
+func WithTTY() JobOption {
+	return func(cfg *jobConfig) {
+		cfg.tty = true
+	}
+}
+
+// WithCapture sets which of the job's output streams are kept. It has no effect with
+// WithTTY, the terminal combines the streams.
+func WithCapture(c Capture) JobOption {
+	return func(cfg *jobConfig) {
+		cfg.capture = c
+	}
+}
+
+// WithName gives the job a name its user can refer to it by, in place of its job id.
+// See ValidateName. The Manager keeps names unique among each user's running jobs.
+func WithName(name string) JobOption {
+	return func(cfg *jobConfig) {
+		cfg.name = name
+	}
+}
+
+// WithShell runs the job's command under a shell, with sh -c, instead of executing it
+// directly. The command is a shell command line, e.g. "echo $HOME && ls", and the arguments
+// are quoted and appended to it, so they're passed to the command literally.
+//
+// The shell interprets anything in the command, so a command built from untrusted input can
+// run anything the job's user can. The Manager only allows it when it's configured to, see
+// WithAllowShell.
+func WithShell() JobOption {
+	return func(cfg *jobConfig) {
+		cfg.shell = true
+	}
+}
+
+// WithSourceAddr records the network address of the client that started the job, e.g. for
+// tracing a job back to the host it came from. It's informational only.
+func WithSourceAddr(addr string) JobOption {
+	return func(cfg *jobConfig) {
+		cfg.sourceAddr = addr
+	}
+}
+
+// StopOption configures a single call to Stop
+type StopOption func(*stopConfig)
+
+type stopConfig struct {
+	gracePeriod time.Duration
+}
+
+// WithGracePeriod gives the job gracePeriod to exit after the SIGTERM, rather than the
+// CommandWaitDelay it was started with. It has no effect on jobs with the StopImmediate policy.
+func WithGracePeriod(gracePeriod time.Duration) StopOption {
+	return func(cfg *stopConfig) {
+		cfg.gracePeriod = gracePeriod
+	}
+}
+
 type Job struct {
-	cmd      *exec.Cmd
-	streamer *OutputStreamer
+	cmd *exec.Cmd
+	// command and args are what the job was started with, before the command was resolved
+	// or wrapped in a shell
+	command string
+	args    []string
+	// commandPath is the executable the job runs, the command resolved against the job's PATH
+	commandPath string
+	name        string
+	sourceAddr  string
+	stopPolicy  StopPolicy
+	streamer    *OutputStreamer
+	// fifo is the job's output pipe, nil unless WithOutputFIFO is used
+	fifo *fifoSink
+	// snapshot uploads the job's output, nil unless WithOutputSnapshot is used
+	snapshot *snapshotter
+	// tty is the job's terminal, nil unless WithTTY is used
+	tty *jobTTY
 
 	cancel context.CancelFunc
 	status *atomic.Value
 
+	// lastAccess orders the job's last access by the Manager, for removing old jobs
+	lastAccess atomic.Int64
+	// startLatency is how long the Manager took to start the job. It's set before the job is
+	// handed out, and isn't changed after.
+	startLatency time.Duration
+	// username and id are the user that started the job and its jobID, set by the Manager like
+	// startLatency
+	username string
+	id       string
+
+	// killTimer sends the SIGKILL for stops with a grace period. exitCode is the process's
+	// exit code once it's done, -1 until then. orphanedPIDs are the processes left in the
+	// job's cgroup after it exited, and usage is what the cgroup used. events is the job's
+	// lifecycle event log, and eventsChanged is closed when an event is recorded, to wake
+	// the streams from WatchEvents. mu guards them.
+	mu            sync.Mutex
+	killTimer     *time.Timer
+	exitCode      int
+	orphanedPIDs  []int
+	usage         cgroup.Usage
+	events        []Event
+	eventsChanged chan struct{}
+
 	// doneCtx is a context that is closed when the job is done
 	// it is used to signal to the callers of Wait() that the job is done
 	doneCtx    context.Context
@@ -32,8 +313,11 @@ type Job struct {
 // If the underlying cmd.Start() call fails, an error is returned as well as
 // a nil pointer to ensure that the job is thrown away. This ensures that
 // callers cannot call exported methods on jobs that cannot be started.
-func StartNewJob(shutdownCtx context.Context, cgroupFD int, name string, args ...string) (*Job, error) {
-	j := newJob(shutdownCtx, cgroupFD, name, args...)
+//
+// The job's process is started in the cgroup referenced by cgroupFD. If cgroupFD
+// is negative, the process is started in the server's cgroup.
+func StartNewJob(shutdownCtx context.Context, cgroupFD int, name string, args []string, options ...JobOption) (*Job, error) {
+	j := newJob(shutdownCtx, cgroupFD, name, args, options...)
 	err := j.start()
 	if err != nil {
 		j.markAsDone()
@@ -43,8 +327,36 @@ func StartNewJob(shutdownCtx context.Context, cgroupFD int, name string, args ..
 	return j, nil
 }
 
-func newJob(shutdownCtx context.Context, cgroupFD int, name string, args ...string) *Job {
-	streamer := NewOutputStreamer()
+func newJob(shutdownCtx context.Context, cgroupFD int, name string, args []string, options ...JobOption) *Job {
+	cfg := defaultJobConfig()
+	for _, opt := range options {
+		opt(&cfg)
+	}
+
+	streamerOptions := []OutputStreamerOption{
+		WithOutputLimit(cfg.maxOutput), WithMaxBufferBytes(cfg.maxBuffer), WithSpillDir(cfg.spillDir),
+		WithInitialBufferCapacity(cfg.outputCapacity),
+	}
+	if cfg.spillErr != nil {
+		streamerOptions = append(streamerOptions, WithSpillErrorHandler(cfg.spillErr))
+	}
+	redaction, redactErr := compileRedaction(cfg.redact)
+	if redactErr == nil {
+		streamerOptions = append(streamerOptions, WithRedaction(redaction))
+	}
+	var fifo *fifoSink
+	var fifoErr error
+	if cfg.fifoPath != "" {
+		fifo, fifoErr = newFIFOSink(cfg.fifoPath)
+		if fifoErr == nil {
+			streamerOptions = append(streamerOptions, WithSink(fifo))
+		}
+	}
+	streamer := NewOutputStreamer(streamerOptions...)
+	var snapshot *snapshotter
+	if cfg.snapshot.uploader != nil {
+		snapshot = newSnapshotter(streamer, cfg.snapshot.uploader, cfg.snapshot.key, cfg.snapshot.interval, cfg.snapshot.onErr)
+	}
 
 	// doneCtx is a context that is closed when the job is done
 	// it is used to signal to the callers of Wait() that the job is done
@@ -52,51 +364,230 @@ func newJob(shutdownCtx context.Context, cgroupFD int, name string, args ...stri
 
 	ctx, cancel := context.WithCancel(shutdownCtx)
 
-	cmd := exec.CommandContext(ctx, name, args...)
+	command, commandArgs := name, args
+	if cfg.shell {
+		name, args = shellPath, []string{"-c", shellCommandLine(name, args)}
+	}
 
+	// Resolve the command against the configured PATH rather than the server's. exec.Cmd
+	// returns cmd.Err from Start(), so a failed lookup surfaces when the job is started.
+	path, lookErr := lookPath(name, cfg.path)
+	cmd := exec.CommandContext(ctx, path, args...)
+	if lookErr != nil {
+		cmd.Err = lookErr
+	}
+	if fifoErr != nil {
+		cmd.Err = fifoErr
+	}
+	if redactErr != nil {
+		cmd.Err = redactErr
+	}
+	cmd.Env = append(cmd.Environ(), "PATH="+cfg.path)
+	// the exec helper sets the umask and limits, then execs path in its place
+	if cfg.umaskSet || len(cfg.rlimits) > 0 {
+		cmd.Path, cmd.Args = execHelperPath, execHelperArgs(cfg, path, args)
+	}
+
+	stopSignal, waitDelay := unix.SIGTERM, CommandWaitDelay
+	if cfg.stopPolicy == StopImmediate {
+		stopSignal, waitDelay = unix.SIGKILL, ImmediateWaitDelay
+	}
 	cmd.Cancel = func() error {
 		// Internally, exec.Cmd depends on the error returned by the Signal call.
 		// Any error handling added here should be done with that in mind.
-		return cmd.Process.Signal(unix.SIGTERM)
+		return cmd.Process.Signal(stopSignal)
+	}
+	cmd.WaitDelay = waitDelay
+	// Streams that aren't captured are left nil, which connects them to the null device
+	// without a goroutine copying to io.Discard
+	if cfg.capture != CaptureStderr {
+		cmd.Stdout = streamer.Writer(jogv1.Stream_STDOUT)
+	}
+	if cfg.capture != CaptureStdout {
+		cmd.Stderr = streamer.Writer(jogv1.Stream_STDERR)
 	}
-	cmd.WaitDelay = CommandWaitDelay
-	cmd.Stdout = streamer
-	cmd.Stderr = streamer
 
 	// Set the cgroup file descriptor on the command
-	attrs := cmd.SysProcAttr
-	attrs.UseCgroupFD = true
-	attrs.CgroupFD = cgroupFD
-	cmd.SysProcAttr = attrs
+	if cgroupFD >= 0 {
+		cmd.SysProcAttr = &syscall.SysProcAttr{
+			UseCgroupFD: true,
+			CgroupFD:    cgroupFD,
+		}
+	}
+
+	var tty *jobTTY
+	if cfg.tty {
+		var err error
+		if tty, err = openTTY(); err != nil {
+			cmd.Err = err
+		} else {
+			tty.attach(cmd)
+		}
+	}
 
 	return &Job{
-		cmd:        cmd,
-		streamer:   streamer,
-		cancel:     cancel,
-		status:     &atomic.Value{},
-		doneCtx:    doneCtx,
-		markAsDone: markAsDone,
+		cmd:         cmd,
+		command:     command,
+		args:        append([]string(nil), commandArgs...),
+		commandPath: path,
+		name:        cfg.name,
+		sourceAddr:  cfg.sourceAddr,
+		stopPolicy:  cfg.stopPolicy,
+		streamer:    streamer,
+		fifo:        fifo,
+		snapshot:    snapshot,
+		tty:         tty,
+		cancel:      cancel,
+		status:      &atomic.Value{},
+		exitCode:    -1,
+		doneCtx:     doneCtx,
+		markAsDone:  markAsDone,
 	}
 }
 
+// lookPath searches for an executable named file in the directories of the given PATH list.
+// Like exec.LookPath, names containing a slash are returned as is. Empty PATH entries are
+// skipped rather than treated as the current directory.
+func lookPath(file string, path string) (string, error) {
+	if strings.Contains(file, "/") {
+		return file, nil
+	}
+	for _, dir := range filepath.SplitList(path) {
+		if dir == "" {
+			continue
+		}
+		p := filepath.Join(dir, file)
+		info, err := os.Stat(p)
+		if err != nil {
+			continue
+		}
+		if m := info.Mode(); !m.IsDir() && m&0111 != 0 {
+			return p, nil
+		}
+	}
+	return file, &exec.Error{Name: file, Err: exec.ErrNotFound}
+}
+
 func (j *Job) start() error {
 	err := j.cmd.Start()
 	if err != nil {
+		j.closeFIFO()
+		if j.snapshot != nil {
+			j.snapshot.abort()
+		}
+		if j.tty != nil {
+			j.tty.close(0)
+		}
 		return err
 	}
+	if j.tty != nil {
+		// the terminal combines stdout and stderr, its output is all stdout
+		j.tty.copyOutput(j.streamer.Writer(jogv1.Stream_STDOUT))
+	}
+	j.recordEvent(EventStarted, fmt.Sprintf("pid %d", j.cmd.Process.Pid))
 
 	go func() {
-		defer j.streamer.CloseWriter()
-		j.setDoneStatus(j.cmd.Wait())
+		err := j.cmd.Wait()
+		if j.tty != nil {
+			j.tty.close(j.cmd.WaitDelay)
+		}
+		j.stopKillTimer()
+		j.setDoneStatus(err)
+		j.streamer.CloseWriter()
+		// let the fifo receive the last of the output before it's closed. The snapshot reads
+		// the output itself, so its final upload has all of it once the writer is closed.
+		j.streamer.waitForSinks()
+		j.closeFIFO()
+		if j.snapshot != nil {
+			j.snapshot.Close()
+		}
 	}()
 
 	return nil
 }
 
+// closeFIFO closes and removes the job's output pipe, if it has one
+func (j *Job) closeFIFO() {
+	if j.fifo != nil {
+		j.fifo.Close()
+	}
+}
+
 // Stop calls the cancel function on the exec.Cmd internal context. Jobs are stopped
-// asynchronously, and will be sent a SIGKILL after the CommandWaitDelay has passed.
-func (j *Job) Stop() {
-	j.cancel()
+// asynchronously. With the StopGraceful policy, jobs are sent a SIGTERM, and will be
+// sent a SIGKILL after the CommandWaitDelay has passed, or after the grace period set
+// with WithGracePeriod. With the StopImmediate policy, jobs are sent a SIGKILL.
+//
+// A running job's status is STOPPING until its process exits, so a job that ignores the
+// SIGTERM isn't reported as RUNNING while it waits for the SIGKILL.
+func (j *Job) Stop(options ...StopOption) {
+	var cfg stopConfig
+	for _, opt := range options {
+		opt(&cfg)
+	}
+	// the swap fails once the job is done, its final status is kept
+	j.status.CompareAndSwap(jogv1.Status_RUNNING, jogv1.Status_STOPPING)
+	switch {
+	case j.stopPolicy == StopImmediate:
+		j.recordEvent(EventStopRequested, "SIGKILL")
+		j.cancel()
+	case cfg.gracePeriod <= 0:
+		j.recordEvent(EventStopRequested, fmt.Sprintf("SIGTERM, SIGKILL after %s", j.cmd.WaitDelay))
+		j.cancel()
+	default:
+		j.recordEvent(EventStopRequested, fmt.Sprintf("SIGTERM, SIGKILL after %s", cfg.gracePeriod))
+		j.stopWithGracePeriod(cfg.gracePeriod)
+	}
+}
+
+// stopWithGracePeriod sends a SIGTERM, and a SIGKILL once gracePeriod has passed.
+// exec.Cmd reads WaitDelay after the job starts, so it can't be changed per stop. Instead,
+// the command's context is left alone and the SIGKILL is sent by a timer. A later stop
+// with a grace period replaces the timer.
+func (j *Job) stopWithGracePeriod(gracePeriod time.Duration) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.doneCtx.Err() != nil {
+		return
+	}
+	if j.killTimer != nil {
+		j.killTimer.Stop()
+	}
+	// an error here means the process has already exited
+	if err := j.cmd.Process.Signal(unix.SIGTERM); err != nil {
+		return
+	}
+	j.killTimer = time.AfterFunc(gracePeriod, func() {
+		j.cmd.Process.Kill()
+		// canceling the context makes WaitDelay bound how long Wait blocks on output
+		// pipes that the job's children still hold open
+		j.cancel()
+	})
+}
+
+// stopKillTimer stops the timer started by a stop with a grace period, if there is one
+func (j *Job) stopKillTimer() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.killTimer != nil {
+		j.killTimer.Stop()
+	}
+}
+
+// Name returns the name the job was started with, empty if it wasn't given one
+func (j *Job) Name() string {
+	return j.name
+}
+
+// Command returns the command the job was started with, and its arguments. For shell jobs,
+// it's the command line given, not the shell.
+func (j *Job) Command() (string, []string) {
+	return j.command, append([]string(nil), j.args...)
+}
+
+// SourceAddr returns the address of the client that started the job, empty if it wasn't recorded
+func (j *Job) SourceAddr() string {
+	return j.sourceAddr
 }
 
 // Status returns the current status of the job
@@ -122,12 +613,23 @@ func (j *Job) Status() jogv1.Status {
 // Jogger differentiates between Stopped and Killed to give the user a better understanding of what happened.
 func (j *Job) setDoneStatus(err error) {
 	defer j.markAsDone()
+	// the event is recorded before the job is marked as done, so it's there for callers of Wait
+	defer func() {
+		detail := j.Status().String()
+		if err != nil {
+			detail += ": " + err.Error()
+		}
+		j.recordEvent(EventExited, detail)
+	}()
 	if err == nil {
+		j.setExitCode(0)
 		j.status.Store(jogv1.Status_COMPLETED)
 		return
 	}
 	var exitErr *exec.ExitError
 	if errors.As(err, &exitErr) {
+		// ExitCode is -1 if the process was killed by a signal
+		j.setExitCode(exitErr.ExitCode())
 		// Internally, ExitError holds information about the last signal it received
 		sig := exitErr.Sys().(syscall.WaitStatus).Signal()
 		switch sig {
@@ -143,9 +645,69 @@ func (j *Job) setDoneStatus(err error) {
 	}
 }
 
-// OutputStream returns a channel that streams the output of the job
-func (j *Job) OutputStream(ctx context.Context) <-chan []byte {
-	return j.streamer.NewStream(ctx)
+// ExitCode returns the exit code of the job's process. It's -1 while the job is running, and
+// if the process was killed by a signal or couldn't be waited on.
+func (j *Job) ExitCode() int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.exitCode
+}
+
+func (j *Job) setExitCode(code int) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.exitCode = code
+}
+
+// OrphanedPIDs returns the processes the job started that were still running after it
+// exited. It's set by the Manager's cgroup cleanup once the job is done, which kills them,
+// see Manager.cleanupCGroup. It's empty until then.
+func (j *Job) OrphanedPIDs() []int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.orphanedPIDs
+}
+
+func (j *Job) setOrphanedPIDs(pids []int) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.orphanedPIDs = pids
+}
+
+// CommandPath returns the path of the executable the job runs, resolved against the job's
+// PATH. It's the shell for jobs started WithShell.
+func (j *Job) CommandPath() string {
+	return j.commandPath
+}
+
+// Usage returns the peak memory and total CPU time of the job's cgroup. It's set by the
+// Manager once the job is done, and is zero until then, or if it couldn't be read.
+func (j *Job) Usage() cgroup.Usage {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.usage
+}
+
+func (j *Job) setUsage(usage cgroup.Usage) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.usage = usage
+}
+
+// OutputStream returns a channel that streams the output of the job, from the output streams
+// the selector selects, see WithSelector
+func (j *Job) OutputStream(ctx context.Context, selector jogv1.StreamSelector) <-chan []byte {
+	return j.streamer.NewStream(ctx, WithSelector(selector))
+}
+
+// ActiveStreams returns the number of open output streams for the job
+func (j *Job) ActiveStreams() int64 {
+	return j.streamer.ActiveStreams()
+}
+
+// OutputSize returns the number of bytes of output the job has produced so far
+func (j *Job) OutputSize() int64 {
+	return j.streamer.Len()
 }
 
 // Wait blocks until the job is done
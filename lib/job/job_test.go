@@ -0,0 +1,377 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	jogv1 "github.com/dustinevan/jogger/pkg/gen/jogger/v1"
+)
+
+// writeExecutable creates an executable shell script named name in dir
+func writeExecutable(t *testing.T, dir, name string) string {
+	t.Helper()
+	p := filepath.Join(dir, name)
+	if err := os.WriteFile(p, []byte("#!/bin/sh\necho hello\n"), 0755); err != nil {
+		t.Fatalf("writing executable: %v", err)
+	}
+	return p
+}
+
+func TestLookPath(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	toolPath := writeExecutable(t, dir, "jogger-test-tool")
+	if err := os.WriteFile(filepath.Join(dir, "not-executable"), []byte("data"), 0644); err != nil {
+		t.Fatalf("writing file: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		file string
+		path string
+		want string
+		err  bool
+	}{
+		{
+			name: "command on the configured path",
+			file: "jogger-test-tool",
+			path: DefaultPath + ":" + dir,
+			want: toolPath,
+		},
+		{
+			name: "command not on the configured path",
+			file: "jogger-test-tool",
+			path: DefaultPath,
+			err:  true,
+		},
+		{
+			name: "file is not executable",
+			file: "not-executable",
+			path: dir,
+			err:  true,
+		},
+		{
+			name: "empty path",
+			file: "jogger-test-tool",
+			path: "",
+			err:  true,
+		},
+		{
+			name: "names with a slash are not resolved",
+			file: "./jogger-test-tool",
+			path: dir,
+			want: "./jogger-test-tool",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := lookPath(tt.file, tt.path)
+			if tt.err {
+				if !errors.Is(err, exec.ErrNotFound) {
+					t.Fatalf("expected exec.ErrNotFound, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestNewJobUsesConfiguredPath(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	toolPath := writeExecutable(t, dir, "jogger-test-tool")
+
+	j := newJob(context.Background(), 0, "jogger-test-tool", nil, WithPath(dir))
+	if j.cmd.Err != nil {
+		t.Fatalf("unexpected lookup error: %v", j.cmd.Err)
+	}
+	if j.cmd.Path != toolPath {
+		t.Fatalf("expected command path %s, got %s", toolPath, j.cmd.Path)
+	}
+	if last := j.cmd.Env[len(j.cmd.Env)-1]; last != "PATH="+dir {
+		t.Fatalf("expected job environment to end with PATH=%s, got %s", dir, last)
+	}
+
+	j = newJob(context.Background(), 0, "jogger-test-tool", nil)
+	if !errors.Is(j.cmd.Err, exec.ErrNotFound) {
+		t.Fatalf("expected exec.ErrNotFound with the default path, got %v", j.cmd.Err)
+	}
+}
+
+func TestJobOutputSize(t *testing.T) {
+	t.Parallel()
+
+	j := newJob(context.Background(), 0, "echo", nil)
+	if size := j.OutputSize(); size != 0 {
+		t.Fatalf("expected output size 0, got %d", size)
+	}
+
+	writes := [][]byte{[]byte("hello\n"), make([]byte, 4096), []byte("world\n")}
+	var want int64
+	for _, w := range writes {
+		if _, err := j.streamer.Write(w); err != nil {
+			t.Fatalf("unexpected write error: %v", err)
+		}
+		want += int64(len(w))
+		if size := j.OutputSize(); size != want {
+			t.Fatalf("expected output size %d, got %d", want, size)
+		}
+	}
+}
+
+// waitForJob waits for the job to be done or fails the test
+func waitForJob(t *testing.T, j *Job, timeout time.Duration) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		j.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		t.Fatalf("job did not finish within %v", timeout)
+	}
+}
+
+func TestJobStopPolicy(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		policy StopPolicy
+		want   jogv1.Status
+	}{
+		{policy: StopGraceful, want: jogv1.Status_STOPPED},
+		{policy: StopImmediate, want: jogv1.Status_KILLED},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(string(tt.policy), func(t *testing.T) {
+			t.Parallel()
+
+			// sleep exits on SIGTERM, so the graceful policy ends with STOPPED rather than KILLED
+			j, err := StartNewJob(context.Background(), -1, "sleep", []string{"10"}, WithStopPolicy(tt.policy))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if status := j.Status(); status != jogv1.Status_RUNNING {
+				t.Fatalf("expected status %v, got %v", jogv1.Status_RUNNING, status)
+			}
+			j.Stop()
+			waitForJob(t, j, 5*time.Second)
+			if status := j.Status(); status != tt.want {
+				t.Fatalf("expected status %v, got %v", tt.want, status)
+			}
+		})
+	}
+}
+
+func TestJobStopGracePeriod(t *testing.T) {
+	t.Parallel()
+
+	// the job ignores SIGTERM, so it only exits once the SIGKILL is sent
+	const gracePeriod = 500 * time.Millisecond
+	j, err := StartNewJob(context.Background(), -1, "sh", []string{"-c", `trap "" TERM; exec sleep 10`})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// give the shell time to set the trap
+	time.Sleep(100 * time.Millisecond)
+
+	stopped := time.Now()
+	j.Stop(WithGracePeriod(gracePeriod))
+
+	select {
+	case <-j.doneCtx.Done():
+		t.Fatalf("job exited before the grace period")
+	case <-time.After(gracePeriod / 2):
+	}
+	// the job is stopping while it waits for the SIGKILL
+	if status := j.Status(); status != jogv1.Status_STOPPING {
+		t.Fatalf("expected status %v before the job exits, got %v", jogv1.Status_STOPPING, status)
+	}
+
+	// the grace period replaces CommandWaitDelay, so the job is killed well before that
+	waitForJob(t, j, CommandWaitDelay/2)
+	if elapsed := time.Since(stopped); elapsed < gracePeriod {
+		t.Fatalf("expected the SIGKILL after %v, the job exited after %v", gracePeriod, elapsed)
+	}
+	if status := j.Status(); status != jogv1.Status_KILLED {
+		t.Fatalf("expected status %v, got %v", jogv1.Status_KILLED, status)
+	}
+}
+
+func TestJobStopDoneJob(t *testing.T) {
+	t.Parallel()
+
+	j, err := StartNewJob(context.Background(), -1, "true", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	waitForJob(t, j, 5*time.Second)
+	// a done job keeps its final status
+	j.Stop()
+	if status := j.Status(); status != jogv1.Status_COMPLETED {
+		t.Fatalf("expected status %v, got %v", jogv1.Status_COMPLETED, status)
+	}
+}
+
+func TestParseStopPolicy(t *testing.T) {
+	t.Parallel()
+
+	for _, s := range []string{"graceful", "immediate"} {
+		if p, err := ParseStopPolicy(s); err != nil || string(p) != s {
+			t.Fatalf("ParseStopPolicy(%q): got %q, %v", s, p, err)
+		}
+	}
+	if _, err := ParseStopPolicy("eventually"); err == nil {
+		t.Fatalf("expected an error for an unsupported stop policy")
+	}
+}
+
+func TestJobCapture(t *testing.T) {
+	t.Parallel()
+
+	// stdout and stderr are read from separate pipes, so they're compared separately
+	tests := []struct {
+		name       string
+		options    []JobOption
+		wantStdout string
+		wantStderr string
+	}{
+		{name: "default", wantStdout: "out\n", wantStderr: "err\n"},
+		{name: "both", options: []JobOption{WithCapture(CaptureBoth)}, wantStdout: "out\n", wantStderr: "err\n"},
+		{name: "stdout", options: []JobOption{WithCapture(CaptureStdout)}, wantStdout: "out\n"},
+		{name: "stderr", options: []JobOption{WithCapture(CaptureStderr)}, wantStderr: "err\n"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			j, err := StartNewJob(context.Background(), -1, "sh", []string{"-c", "echo out; echo err >&2"}, tt.options...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			waitForJob(t, j, 5*time.Second)
+			if status := j.Status(); status != jogv1.Status_COMPLETED {
+				t.Fatalf("expected status %v, got %v", jogv1.Status_COMPLETED, status)
+			}
+
+			stdout := readAll(t, j.OutputStream(context.Background(), jogv1.StreamSelector_STREAM_SELECTOR_STDOUT), 5*time.Second)
+			if string(stdout) != tt.wantStdout {
+				t.Fatalf("expected stdout %q, got %q", tt.wantStdout, stdout)
+			}
+			stderr := readAll(t, j.OutputStream(context.Background(), jogv1.StreamSelector_STREAM_SELECTOR_STDERR), 5*time.Second)
+			if string(stderr) != tt.wantStderr {
+				t.Fatalf("expected stderr %q, got %q", tt.wantStderr, stderr)
+			}
+			out := readAll(t, j.OutputStream(context.Background(), jogv1.StreamSelector_STREAM_SELECTOR_BOTH), 5*time.Second)
+			if len(out) != len(tt.wantStdout)+len(tt.wantStderr) {
+				t.Fatalf("expected both streams, got %q", out)
+			}
+			if size := j.OutputSize(); size != int64(len(out)) {
+				t.Fatalf("expected %d bytes buffered, got %d", len(out), size)
+			}
+		})
+	}
+}
+
+func TestJobExitCode(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{name: "success", args: []string{"-c", "exit 0"}, want: 0},
+		{name: "failure", args: []string{"-c", "exit 3"}, want: 3},
+		{name: "killed by a signal", args: []string{"-c", "kill -KILL $$"}, want: -1},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			j, err := StartNewJob(context.Background(), -1, "sh", tt.args)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			waitForJob(t, j, 5*time.Second)
+			if got := j.ExitCode(); got != tt.want {
+				t.Fatalf("expected exit code %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestParseCapture(t *testing.T) {
+	t.Parallel()
+
+	for _, c := range []Capture{CaptureBoth, CaptureStdout, CaptureStderr} {
+		if got, err := ParseCapture(string(c)); err != nil || got != c {
+			t.Fatalf("ParseCapture(%q): expected %q, got %q, %v", c, c, got, err)
+		}
+	}
+	if _, err := ParseCapture("stdin"); err == nil {
+		t.Fatalf("expected error for an unsupported capture")
+	}
+}
+
+func TestJobTTY(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		options []JobOption
+		want    string
+	}{
+		{name: "without a tty", want: "notty notty"},
+		{name: "with a tty", options: []JobOption{WithTTY()}, want: "tty tty"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			// test -t is the shell's isatty, check both stdin and stdout
+			script := "test -t 0 && echo tty || echo notty; test -t 1 && echo tty || echo notty"
+			j, err := StartNewJob(context.Background(), -1, "sh", []string{"-c", script}, tt.options...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			waitForJob(t, j, 5*time.Second)
+			if status := j.Status(); status != jogv1.Status_COMPLETED {
+				t.Fatalf("expected status %v, got %v", jogv1.Status_COMPLETED, status)
+			}
+
+			out := readAll(t, j.OutputStream(context.Background(), jogv1.StreamSelector_STREAM_SELECTOR_BOTH), 5*time.Second)
+			if got := strings.Join(strings.Fields(string(out)), " "); got != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, out)
+			}
+		})
+	}
+}
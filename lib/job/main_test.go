@@ -0,0 +1,12 @@
+package job
+
+import (
+	"os"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	// jobs started with a umask or resource limits re-run the test binary as the exec helper
+	RunExecHelper()
+	os.Exit(m.Run())
+}
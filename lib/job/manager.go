@@ -2,100 +2,894 @@ package job
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/dustinevan/jogger/lib/cgroup"
 	jogv1 "github.com/dustinevan/jogger/pkg/gen/jogger/v1"
 	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"path/filepath"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 var ErrJobNotFound = fmt.Errorf("job not found")
 
+// ErrTooManyStreams is returned by OutputStream when the manager's stream limit has been reached
+var ErrTooManyStreams = errors.New("too many output streams")
+
+// ErrTooManyJobs is returned by Start when the manager's running job limit has been reached
+var ErrTooManyJobs = errors.New("too many running jobs")
+
+// ErrShellNotAllowed is returned by Start for jobs that run under a shell, see WithShell,
+// unless the manager allows them
+var ErrShellNotAllowed = errors.New("shell jobs are not allowed")
+
+// ErrJobNotRunning is returned for changes that only apply to running jobs, e.g. UpdateLimits
+var ErrJobNotRunning = errors.New("job is not running")
+
+// ErrNameInUse is returned by Start when one of the user's running jobs already has the name
+var ErrNameInUse = errors.New("job name is in use")
+
+// ErrOffsetOutOfRange is returned by OutputStream for a start offset past the end of the output
+var ErrOffsetOutOfRange = errors.New("offset is past the end of the output")
+
+// ErrNoCgroupManager is returned by Start when the manager has no cgroup manager to create
+// the job's cgroup with, see WithCgroupManager
+var ErrNoCgroupManager = errors.New("no cgroup manager")
+
+// CgroupManager creates and cleans up the cgroups jobs run in. It's implemented by
+// *cgroup.FSManager, and stubbed in tests that don't have a cgroup filesystem.
+type CgroupManager interface {
+	AddGroupWithLimits(username, name string, limits cgroup.ResourceLimits, options ...cgroup.GroupOption) (int, error)
+	Procs(name string) ([]int, error)
+	Kill(name string) error
+	Usage(name string) (cgroup.Usage, error)
+	UpdateGroup(name string, limits cgroup.Limits) error
+	RemoveGroup(name string) error
+}
+
 // Manager is a job manager that keeps track of jobs by username and jobID.
 // It also holds a context that the server uses to stop all jobs when during shut down
 type Manager struct {
 	// jobMap is a map[username]map[jobID]*Job
 	jobMap map[string]*Job
 
+	// names indexes the jobIDs of named jobs by keyString(username, name). A name points at
+	// the last job started with it, and can be reused once that job is done. mu guards names.
+	names map[string]string
+
 	mu          sync.RWMutex
 	shutdownCtx context.Context
 
-	cgroupFSManager *cgroup.FSManager
+	cgroupFSManager CgroupManager
+
+	// jobPath is the PATH used to resolve job commands
+	jobPath string
+	// stopPolicy is how jobs are signaled when they're stopped
+	stopPolicy StopPolicy
+	// capture is which output streams are kept for jobs that don't choose
+	capture Capture
+	// outputFIFODir is where per-job output pipes are created, empty if disabled
+	outputFIFODir string
+	// outputBuffer caps each job's output in memory, spilling the rest to outputSpillDir.
+	// 0 keeps all output in memory.
+	outputBuffer   int64
+	outputSpillDir string
+	// outputCapacity is preallocated for each job's output, 0 starts with an empty buffer
+	outputCapacity int
+	// redactLiterals and redactPatterns are masked in every job's output
+	redactLiterals []string
+	redactPatterns []string
+	// snapshots uploads job output, nil if disabled. Objects are named snapshotPrefix<username>/<jobID>/<offset>
+	snapshots        Uploader
+	snapshotPrefix   string
+	snapshotInterval time.Duration
+	// allowShell allows jobs that run under a shell
+	allowShell bool
+	// now times job starts
+	now func() time.Time
+	// log gets the summary of each job when it's done
+	log *zap.SugaredLogger
+
+	// drain is closed by DrainStreams. Every output stream handed out by
+	// the manager watches it, so it acts as a registry of active streams.
+	drain     chan struct{}
+	drainOnce sync.Once
+
+	// streams is the number of live output streams across all jobs, maxStreams caps it, 0 is no limit
+	streams    atomic.Int64
+	maxStreams int64
+
+	// running is the number of jobs that haven't finished, maxRunning caps it, 0 is no limit
+	running    atomic.Int64
+	maxRunning int64
+
+	// admissionLimits rejects new jobs when the host's load, read with readHostLoad, is too high
+	admissionLimits AdmissionLimits
+	readHostLoad    func() (HostLoad, error)
+
+	// finished holds the done jobs that are kept, by the same key as jobMap. When there are
+	// more than maxRetained, the least recently accessed are removed. accesses is a logical
+	// clock that orders job accesses. mu guards finished.
+	finished    map[string]finishedJob
+	maxRetained int
+	accesses    atomic.Int64
+
+	// failedStarts records jobs whose process couldn't be started, by the same key as jobMap.
+	// Records are removed after failedStartTTL. They're only kept when failedStartTTL > 0.
+	// mu guards failedStarts.
+	failedStarts   map[string]failedStart
+	failedStartTTL time.Duration
+
+	// pending holds the jobs waiting for the job they were started after, see WithAfter, by
+	// the same key as jobMap. mu guards pending.
+	pending map[string]*pendingJob
+
+	// cleanups queues the cgroup cleanup of done jobs for cleanupWorkers goroutines,
+	// which are started with the first job
+	cleanups            chan cleanupTask
+	cleanupWorkers      int
+	startCleanupWorkers sync.Once
+}
+
+// finishedJob identifies a done job that's kept
+type finishedJob struct {
+	username string
+	jobID    string
+	name     string
+}
+
+// failedStart is the record of a job whose process couldn't be started
+type failedStart struct {
+	err string
+}
+
+type ManagerOption func(*Manager)
+
+// WithCgroupManager sets the cgroup manager that creates the cgroup each job runs in, usually
+// a *cgroup.FSManager. Jobs can't be started without one, Start returns ErrNoCgroupManager.
+func WithCgroupManager(c CgroupManager) ManagerOption {
+	return func(m *Manager) {
+		m.cgroupFSManager = c
+	}
+}
+
+// WithJobPath sets the PATH used to resolve the commands of started jobs. An empty path keeps
+// the default, DefaultPath.
+func WithJobPath(path string) ManagerOption {
+	return func(m *Manager) {
+		if path != "" {
+			m.jobPath = path
+		}
+	}
+}
+
+// WithDefaultStopPolicy sets how jobs are signaled when they're stopped, or when the server shuts down
+func WithDefaultStopPolicy(p StopPolicy) ManagerOption {
+	return func(m *Manager) {
+		m.stopPolicy = p
+	}
+}
+
+// WithDefaultCapture sets which output streams are kept for jobs started without WithCapture
+func WithDefaultCapture(c Capture) ManagerOption {
+	return func(m *Manager) {
+		m.capture = c
+	}
+}
+
+// WithOutputFIFODir enables per-job output pipes. Each job's output is copied to a
+// named pipe at <dir>/<jobID>.fifo for external consumers, like log shippers.
+func WithOutputFIFODir(dir string) ManagerOption {
+	return func(m *Manager) {
+		m.outputFIFODir = dir
+	}
+}
+
+// WithJobOutputBuffer caps each job's output kept in memory at size bytes. Older output is
+// spilled to a temporary file in dir, or os.TempDir when it's empty, and still streamed in
+// full. A size <= 0 keeps all output in memory.
+func WithJobOutputBuffer(size int64, dir string) ManagerOption {
+	return func(m *Manager) {
+		m.outputBuffer, m.outputSpillDir = size, dir
+	}
+}
+
+// WithJobOutputCapacity preallocates size bytes for each job's output in memory, so the output
+// of chatty jobs isn't copied as often while it grows. Every job allocates it up front, even
+// jobs without output. A size <= 0 starts each job with an empty buffer.
+func WithJobOutputCapacity(size int) ManagerOption {
+	return func(m *Manager) {
+		m.outputCapacity = size
+	}
+}
+
+// WithJobOutputRedaction masks the literals, and the matches of the patterns, in every job's
+// output, along with the job's own, see WithOutputRedaction. They should be checked with
+// CompileRedaction first: jobs fail to start when they're invalid.
+func WithJobOutputRedaction(literals, patterns []string) ManagerOption {
+	return func(m *Manager) {
+		m.redactLiterals, m.redactPatterns = literals, patterns
+	}
+}
+
+// WithOutputSnapshots uploads each job's output to objects named <prefix><username>/<jobID>/<offset>,
+// every interval while the job runs and once more when it's done, for retention beyond the
+// server's lifetime. See WithOutputSnapshot for how the output is split into objects. Uploads that fail after their retries are logged, see WithLogger.
+func WithOutputSnapshots(uploader Uploader, prefix string, interval time.Duration) ManagerOption {
+	return func(m *Manager) {
+		m.snapshots, m.snapshotPrefix, m.snapshotInterval = uploader, prefix, interval
+	}
+}
+
+// WithMaxStreams caps the number of live output streams across all jobs. Once the cap is
+// reached, OutputStream returns ErrTooManyStreams until a stream closes. 0 means no limit.
+func WithMaxStreams(n int64) ManagerOption {
+	return func(m *Manager) {
+		m.maxStreams = n
+	}
+}
+
+// WithMaxRunningJobs caps the number of running jobs across all users, to protect the host.
+// Once the cap is reached, Start returns ErrTooManyJobs until a job finishes. 0 means no limit.
+func WithMaxRunningJobs(n int64) ManagerOption {
+	return func(m *Manager) {
+		m.maxRunning = n
+	}
+}
+
+// WithAdmissionLimits protects the host by rejecting new jobs with ErrHostOverloaded while
+// its load average is too high, or its available memory too low. It's independent of the
+// jobs' cgroup limits, and catches load from outside the server too. No limits are checked
+// by default.
+func WithAdmissionLimits(limits AdmissionLimits) ManagerOption {
+	return func(m *Manager) {
+		m.admissionLimits = limits
+	}
+}
+
+// WithMaxRetainedJobs caps the number of done jobs kept, across all users. When a job
+// finishes and the cap is exceeded, the done job that was least recently accessed is removed,
+// along with its output, and is no longer found. Running jobs are never removed. A cap <= 0
+// keeps every job.
+func WithMaxRetainedJobs(n int) ManagerOption {
+	return func(m *Manager) {
+		m.maxRetained = n
+	}
+}
+
+// WithAllowShell allows jobs that run their command under a shell, see WithShell. They're
+// not allowed by default, because a shell interprets its command line, so clients that build
+// commands from untrusted input are open to shell injection.
+func WithAllowShell(allowed bool) ManagerOption {
+	return func(m *Manager) {
+		m.allowShell = allowed
+	}
+}
+
+// WithManagerClock sets the function used to time job starts, see StatusInfo.StartLatency.
+// It defaults to time.Now.
+func WithManagerClock(now func() time.Time) ManagerOption {
+	return func(m *Manager) {
+		m.now = now
+	}
+}
+
+// WithLogger sets the logger that gets a summary line for each job when it's done, see
+// Manager.logFinished. Nothing is logged by default.
+func WithLogger(log *zap.SugaredLogger) ManagerOption {
+	return func(m *Manager) {
+		m.log = log
+	}
+}
+
+// WithFailedStartTTL records jobs whose process couldn't be started for ttl, so their status
+// reports START_FAILED with the reason, for auditing. A ttl <= 0 doesn't record them.
+func WithFailedStartTTL(ttl time.Duration) ManagerOption {
+	return func(m *Manager) {
+		m.failedStartTTL = ttl
+	}
+}
+
+// DefaultCleanupWorkers is the number of goroutines that clean up the cgroups of done jobs
+const DefaultCleanupWorkers = 4
+
+// WithCleanupWorkers sets the number of goroutines that clean up the cgroups of done jobs.
+// It bounds the cleanup load when many jobs finish at once. Values < 1 keep the default,
+// DefaultCleanupWorkers.
+func WithCleanupWorkers(n int) ManagerOption {
+	return func(m *Manager) {
+		if n > 0 {
+			m.cleanupWorkers = n
+		}
+	}
 }
 
 // NewManager creates a new Manager
-func NewManager(shutdownCtx context.Context) *Manager {
-	return &Manager{
-		jobMap:      make(map[string]*Job),
-		shutdownCtx: shutdownCtx,
+func NewManager(shutdownCtx context.Context, options ...ManagerOption) *Manager {
+	m := &Manager{
+		jobMap:         make(map[string]*Job),
+		names:          make(map[string]string),
+		finished:       make(map[string]finishedJob),
+		failedStarts:   make(map[string]failedStart),
+		pending:        make(map[string]*pendingJob),
+		cleanups:       make(chan cleanupTask),
+		cleanupWorkers: DefaultCleanupWorkers,
+		shutdownCtx:    shutdownCtx,
+		jobPath:        DefaultPath,
+		stopPolicy:     StopGraceful,
+		capture:        CaptureBoth,
+		drain:          make(chan struct{}),
+		now:            time.Now,
+		log:            zap.NewNop().Sugar(),
+		readHostLoad:   ReadHostLoad,
+	}
+
+	for _, opt := range options {
+		opt(m)
 	}
+
+	return m
 }
 
-// Start starts a new job and returns the jobID
-func (m *Manager) Start(ctx context.Context, username string, cmd string, args ...string) (string, error) {
+// Start starts a new job and returns the jobID. The options configure the job, and
+// override the defaults set by the manager.
+//
+// If ctx is done before the job's process is launched, the job isn't started and the
+// context's error is returned.
+//
+// When the job's process can't be started and failed starts are recorded, see
+// WithFailedStartTTL, the jobID of the record is returned along with the error.
+//
+// A job named with WithName can be referred to by its name in place of its jobID.
+// ErrNameInUse is returned if one of the user's running jobs already has the name.
+//
+// A job started with WithAfter is PENDING until the job it's started after is done, and is
+// started then. Its jobID is returned right away. ErrJobNotFound is returned if the user has
+// no job to start it after.
+func (m *Manager) Start(ctx context.Context, username string, cmd string, args []string, options ...JobOption) (string, error) {
+	begin := m.now()
+	if err := ValidateCommand(cmd, args); err != nil {
+		return "", fmt.Errorf("starting job: %w", err)
+	}
+	cfg := applyJobOptions(options)
+	name := cfg.name
+	if cfg.shell && !m.allowShell {
+		return "", fmt.Errorf("starting job: %w", ErrShellNotAllowed)
+	}
+	if name != "" {
+		if err := ValidateName(name); err != nil {
+			return "", fmt.Errorf("starting job: %w", err)
+		}
+	}
+	if err := validateProcessLimits(cfg); err != nil {
+		return "", fmt.Errorf("starting job: %w", err)
+	}
+	if _, err := compileRedaction(cfg.redact); err != nil {
+		return "", fmt.Errorf("starting job: %w", err)
+	}
+	// the client may have given up on the request, don't start a job nobody will know about
+	if err := ctx.Err(); err != nil {
+		return "", fmt.Errorf("starting job: %w", err)
+	}
+	if cfg.after != "" {
+		jobID, err := m.startAfter(username, cmd, args, cfg, options)
+		if err != nil {
+			return "", fmt.Errorf("starting job: %w", err)
+		}
+		return jobID, nil
+	}
 	jobID := uuid.NewString()
-
-	// Add a new cgroup for the job
-	cgroupFD, err := m.cgroupFSManager.AddGroup(jobID)
-	if err != nil {
+	if err := m.reserveName(username, name, jobID); err != nil {
+		return "", fmt.Errorf("starting job: %w", err)
+	}
+	if _, err := m.launch(ctx, begin, username, jobID, cmd, args, cfg, options); err != nil {
+		var startErr *processStartError
+		if errors.As(err, &startErr) && m.failedStartTTL > 0 {
+			m.recordFailedStart(username, jobID, startErr.err, m.failedStartTTL)
+			return jobID, fmt.Errorf("starting job: %w", err)
+		}
 		return "", fmt.Errorf("starting job: %w", err)
 	}
-	defer m.scheduleCGroupCleanup(jobID)
+	return jobID, nil
+}
+
+// processStartError is the error of a job whose process couldn't be started, rather than
+// one that wasn't admitted. They're the failed starts that are recorded, see
+// WithFailedStartTTL.
+type processStartError struct {
+	err error
+}
+
+func (e *processStartError) Error() string {
+	return e.err.Error()
+}
+
+func (e *processStartError) Unwrap() error {
+	return e.err
+}
 
-	j, err := StartNewJob(m.shutdownCtx, cgroupFD, cmd, args...)
+// launch creates the cgroup of a job whose name is reserved, and starts its process. The
+// name is released if the job isn't started.
+func (m *Manager) launch(ctx context.Context, begin time.Time, username, jobID, cmd string, args []string, cfg jobConfig, options []JobOption) (*Job, error) {
+	name := cfg.name
+	if m.cgroupFSManager == nil {
+		m.releaseName(username, name, jobID)
+		return nil, ErrNoCgroupManager
+	}
+	if err := m.admit(); err != nil {
+		m.releaseName(username, name, jobID)
+		return nil, err
+	}
+	if !reserve(&m.running, m.maxRunning) {
+		m.releaseName(username, name, jobID)
+		return nil, ErrTooManyJobs
+	}
+	// abort releases what was reserved for a job that isn't started
+	abort := func() {
+		m.running.Add(-1)
+		m.releaseName(username, name, jobID)
+	}
+
+	// Add a new cgroup for the job. Its directory is labeled with the job's name, or the
+	// command's, so it can be recognized when debugging, e.g. echo-<jobID>.
+	label := name
+	if label == "" {
+		label = filepath.Base(cmd)
+	}
+	cgroupFD, err := m.cgroupFSManager.AddGroupWithLimits(username, jobID, cfg.resourceLimits, cgroup.WithLabel(label))
 	if err != nil {
-		return "", fmt.Errorf("starting job: %w", err)
+		abort()
+		return nil, err
+	}
+	// creating the cgroup may be slow, check again before the process is launched
+	if err := ctx.Err(); err != nil {
+		abort()
+		m.removeUnusedGroup(username, jobID)
+		return nil, err
 	}
 
+	defaults := []JobOption{WithPath(m.jobPath), WithStopPolicy(m.stopPolicy), WithCapture(m.capture)}
+	if m.outputBuffer > 0 {
+		defaults = append(defaults, WithOutputBuffer(m.outputBuffer, m.outputSpillDir, func(err error) {
+			m.log.Warnw("job output spill failed", "jobID", jobID, "username", username, "error", err)
+		}))
+	}
+	if m.outputCapacity > 0 {
+		defaults = append(defaults, WithOutputCapacity(m.outputCapacity))
+	}
+	if len(m.redactLiterals) > 0 || len(m.redactPatterns) > 0 {
+		defaults = append(defaults, WithOutputRedaction(m.redactLiterals, m.redactPatterns))
+	}
+	if m.outputFIFODir != "" {
+		defaults = append(defaults, WithOutputFIFO(filepath.Join(m.outputFIFODir, jobID+".fifo")))
+	}
+	if m.snapshots != nil {
+		key := m.snapshotPrefix + username + "/" + jobID
+		defaults = append(defaults, WithOutputSnapshot(m.snapshots, key, m.snapshotInterval, func(err error) {
+			m.log.Warnw("job output snapshot failed", "jobID", jobID, "username", username, "key", key, "error", err)
+		}))
+	}
+	options = append(defaults, options...)
+	j, err := StartNewJob(m.shutdownCtx, cgroupFD, cmd, args, options...)
+	if err != nil {
+		abort()
+		// there's no job to schedule the cleanup for, see removeUnusedGroup
+		m.removeUnusedGroup(username, jobID)
+		return nil, &processStartError{err: err}
+	}
+	// these are set before the job is added, so they're never read while they're set
+	j.startLatency = m.now().Sub(begin)
+	j.username, j.id = username, jobID
+	// the job is added before it's retired, which may happen right away
 	m.mu.Lock()
-	defer m.mu.Unlock()
 	m.jobMap[keyString(username, jobID)] = j
+	m.mu.Unlock()
 
-	return jobID, nil
+	m.scheduleCGroupCleanup(username, jobID, j)
+	go func() {
+		// free the job's slot once it's done
+		j.Wait()
+		m.running.Add(-1)
+		m.retire(username, jobID, name, j)
+	}()
+
+	return j, nil
 }
 
-// Stop sends a stop signal to a job that will eventually be respected
-func (m *Manager) Stop(ctx context.Context, username string, jobID string) error {
+// Stop sends a stop signal to a job that will eventually be respected. The options
+// configure this stop only, e.g. WithGracePeriod. A pending job isn't started, see WithAfter.
+func (m *Manager) Stop(ctx context.Context, username string, jobID string, options ...StopOption) error {
+	if p := m.getPending(username, jobID); p != nil {
+		p.cancel()
+		// the job may have been started before it was canceled
+		<-p.done
+		if p.job != nil {
+			p.job.Stop(options...)
+		}
+		return nil
+	}
 	j, err := m.getJob(username, jobID)
 
 	if err != nil {
 		return fmt.Errorf("stopping job %s: %w", jobID, err)
 	}
-	j.Stop()
+	j.Stop(options...)
 
 	return nil
 }
 
+// UpdateLimits changes the resource limits of a running job while it runs. Zero limits are
+// left as they are. The limits are checked against the server's maxima and the job's usage,
+// see cgroup.FSManager.UpdateGroup.
+func (m *Manager) UpdateLimits(ctx context.Context, username string, jobID string, limits cgroup.Limits) error {
+	id, j, err := m.lookupJob(username, jobID)
+	if err != nil {
+		return fmt.Errorf("updating job limits: %w", err)
+	}
+	if j.Status() != jogv1.Status_RUNNING {
+		return fmt.Errorf("updating job limits: %w", ErrJobNotRunning)
+	}
+	if err := m.cgroupFSManager.UpdateGroup(id, limits); err != nil {
+		return fmt.Errorf("updating job limits: %w", err)
+	}
+	return nil
+}
+
+// StatusInfo is a snapshot of a job's status
+type StatusInfo struct {
+	Status jogv1.Status
+	// OutputBytes is the number of bytes of output the job has produced so far
+	OutputBytes int64
+	// Name is the name the job was started with, empty if it wasn't given one
+	Name string
+	// SourceAddr is the address of the client that started the job, see WithSourceAddr
+	SourceAddr string
+	// StartedAt is when the job's process was started, and CommandPath is the executable it runs
+	StartedAt   time.Time
+	CommandPath string
+	// StartLatency is how long Start took to launch the job's process, from the call to the
+	// process starting, which includes creating its cgroup. Slow starts point at a slow cgroup
+	// filesystem, or at fork and exec slowing down under load.
+	StartLatency time.Duration
+	// ExitCode is the exit code of the job's process, -1 while it's running, or if it was
+	// killed by a signal
+	ExitCode int
+	// StartError is why the job couldn't be started, it's only set for START_FAILED jobs
+	StartError string
+	// OrphanedPIDs are processes the job started that were still in its cgroup after it
+	// exited. They're killed by the cgroup cleanup, so this is reported for diagnosing leaks.
+	OrphanedPIDs []int
+	// PeakMemoryBytes and TotalCPUUsec are the most memory the job used at once, and the CPU
+	// time it used. They're read from the job's cgroup once it's done, and are 0 until then.
+	PeakMemoryBytes int64
+	TotalCPUUsec    int64
+}
+
 // Status gets the status of a job
 // Because stop signals are eventually respected, the internal state of a job process may not yet be
 // reflected in the status. Eventually consistency is guaranteed, though, and delays mostly depend on
 // the CommandWaitDelay constant in the job package.
-func (m *Manager) Status(ctx context.Context, username string, jobID string) (jogv1.Status, error) {
+func (m *Manager) Status(ctx context.Context, username string, jobID string) (StatusInfo, error) {
+	if p := m.getPending(username, jobID); p != nil {
+		return StatusInfo{Status: jogv1.Status_PENDING, Name: p.name, ExitCode: -1}, nil
+	}
+	j, err := m.getJob(username, jobID)
+	if err != nil {
+		if f, ok := m.getFailedStart(username, jobID); ok {
+			return StatusInfo{Status: jogv1.Status_START_FAILED, StartError: f.err, ExitCode: -1}, nil
+		}
+		return StatusInfo{Status: jogv1.Status_STATUS_UNSPECIFIED}, fmt.Errorf("getting job status: %w", err)
+	}
+	usage := j.Usage()
+	return StatusInfo{
+		Status:          j.Status(),
+		Name:            j.Name(),
+		SourceAddr:      j.SourceAddr(),
+		StartedAt:       j.StartedAt(),
+		CommandPath:     j.CommandPath(),
+		StartLatency:    j.startLatency,
+		ExitCode:        j.ExitCode(),
+		OutputBytes:     j.OutputSize(),
+		OrphanedPIDs:    j.OrphanedPIDs(),
+		PeakMemoryBytes: usage.PeakMemoryBytes,
+		TotalCPUUsec:    usage.CPUUsageUsec,
+	}, nil
+}
+
+// Events returns the lifecycle events of a job, oldest first. Like the job itself, the
+// events are scoped to the user that started the job.
+func (m *Manager) Events(ctx context.Context, username string, jobID string) ([]Event, error) {
 	j, err := m.getJob(username, jobID)
 	if err != nil {
-		return jogv1.Status_STATUS_UNSPECIFIED, fmt.Errorf("getting job status: %w", err)
+		return nil, fmt.Errorf("getting job events: %w", err)
 	}
-	return j.Status(), nil
+	return j.Events(), nil
 }
 
-func (m *Manager) OutputStream(ctx context.Context, username string, jobID string) (<-chan []byte, error) {
+// JobSummary describes one of a user's jobs in a list of them
+type JobSummary struct {
+	JobID string
+	Name  string
+	// Command and Args are what the job was started with
+	Command   string
+	Args      []string
+	Status    jogv1.Status
+	StartedAt time.Time
+}
+
+// List returns a summary of each of username's jobs, oldest first. Like the jobs themselves,
+// the list is scoped to the user, other users' jobs are never included. The jobs are read
+// under the manager's read lock, so the list is a consistent snapshot while jobs are started
+// and removed. Pending jobs, see WithAfter, are listed as PENDING without a start time, after
+// the jobs that have started. Jobs that failed to start aren't included.
+func (m *Manager) List(ctx context.Context, username string) []JobSummary {
+	m.mu.RLock()
+	var jobs []*Job
+	for _, j := range m.jobMap {
+		if j.username == username {
+			jobs = append(jobs, j)
+		}
+	}
+	var pending []*pendingJob
+	for key, p := range m.pending {
+		// a pending job is added to the job map before it's removed from the pending jobs
+		if _, started := m.jobMap[key]; !started && p.username == username {
+			pending = append(pending, p)
+		}
+	}
+	m.mu.RUnlock()
+
+	list := make([]JobSummary, 0, len(jobs)+len(pending))
+	for _, j := range jobs {
+		command, args := j.Command()
+		list = append(list, JobSummary{
+			JobID:     j.id,
+			Name:      j.Name(),
+			Command:   command,
+			Args:      args,
+			Status:    j.Status(),
+			StartedAt: j.StartedAt(),
+		})
+	}
+	for _, p := range pending {
+		list = append(list, JobSummary{
+			JobID:   p.jobID,
+			Name:    p.name,
+			Command: p.command,
+			Args:    append([]string(nil), p.args...),
+			Status:  jogv1.Status_PENDING,
+		})
+	}
+	slices.SortFunc(list, func(a, b JobSummary) int {
+		// pending jobs haven't started, they're listed last
+		if a.StartedAt.IsZero() != b.StartedAt.IsZero() {
+			if a.StartedAt.IsZero() {
+				return 1
+			}
+			return -1
+		}
+		if c := a.StartedAt.Compare(b.StartedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.JobID, b.JobID)
+	})
+	return list
+}
+
+// WatchEvents returns a channel that streams the lifecycle events of a job as they're
+// recorded, see Job.WatchEvents. The stream closes once the job is done, or when ctx is done.
+func (m *Manager) WatchEvents(ctx context.Context, username string, jobID string) (<-chan Event, error) {
+	j, err := m.getJob(username, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("watching job events: %w", err)
+	}
+	return j.WatchEvents(ctx), nil
+}
+
+// Exists reports whether username has a job with jobID. Jobs are scoped per user, so
+// other users' jobs are reported as missing. Jobs that failed to start are also missing,
+// pending jobs aren't.
+func (m *Manager) Exists(ctx context.Context, username string, jobID string) bool {
+	if m.getPending(username, jobID) != nil {
+		return true
+	}
+	_, err := m.getJob(username, jobID)
+	return err == nil
+}
+
+// OutputStream returns a channel that streams the output of a job. The stream closes when the job is done,
+// when ctx is canceled, or after flushing buffered output once DrainStreams is called. options set
+// how the output is chunked, see WithChunkSize and WithLineChunks, and which of its output streams
+// are read, see WithSelector. Each chunk says which output stream it's from, unless it may be from both.
+func (m *Manager) OutputStream(ctx context.Context, username string, jobID string, options ...StreamOption) (<-chan Chunk, error) {
 	j, err := m.getJob(username, jobID)
 	if err != nil {
 		return nil, fmt.Errorf("streaming output: %w", err)
 	}
-	return j.OutputStream(ctx), nil
+	// the offset is checked before a stream is reserved or started
+	var cfg streamConfig
+	for _, opt := range options {
+		opt(&cfg)
+	}
+	if length := j.streamer.selectedLen(cfg.selector); cfg.offset > length {
+		return nil, fmt.Errorf("streaming output: %w: offset %d, length %d", ErrOffsetOutOfRange, cfg.offset, length)
+	}
+	if !reserve(&m.streams, m.maxStreams) {
+		return nil, fmt.Errorf("streaming output: %w", ErrTooManyStreams)
+	}
+	return j.streamer.newChunkStream(ctx, m.drain, func() { m.streams.Add(-1) }, options...), nil
+}
+
+// reserve increments counter unless it has reached limit, a limit of 0 is no limit. false is
+// returned if the limit has been reached. It's used to count streams and jobs against their limits.
+func reserve(counter *atomic.Int64, limit int64) bool {
+	for {
+		n := counter.Load()
+		if limit > 0 && n >= limit {
+			return false
+		}
+		if counter.CompareAndSwap(n, n+1) {
+			return true
+		}
+	}
+}
+
+// ActiveStreams returns the number of live output streams across all jobs
+func (m *Manager) ActiveStreams() int64 {
+	return m.streams.Load()
+}
+
+// RunningJobs returns the number of jobs across all users that haven't finished
+func (m *Manager) RunningJobs() int64 {
+	return m.running.Load()
 }
 
+// DrainStreams signals every active output stream to send the output buffered so far and close.
+// Streams opened after DrainStreams is called do the same immediately. This is called during
+// shutdown so that clients receive buffered output before the grpc server stops.
+func (m *Manager) DrainStreams() {
+	m.drainOnce.Do(func() {
+		close(m.drain)
+	})
+}
+
+// getJob returns the user's job with jobID, which can also be the name of one of the user's jobs
 func (m *Manager) getJob(username, jobID string) (*Job, error) {
-	var j *Job
+	_, j, err := m.lookupJob(username, jobID)
+	return j, err
+}
+
+// lookupJob is getJob, and also returns the job's id, which differs from jobID when it's a name
+func (m *Manager) lookupJob(username, jobID string) (string, *Job, error) {
+	id := jobID
 	m.mu.RLock()
-	j = m.jobMap[keyString(username, jobID)]
+	j := m.jobMap[keyString(username, id)]
+	if j == nil {
+		if named, ok := m.names[keyString(username, jobID)]; ok {
+			id = named
+			j = m.jobMap[keyString(username, id)]
+		}
+	}
 	m.mu.RUnlock()
 
 	if j == nil {
-		return nil, ErrJobNotFound
+		return "", nil, ErrJobNotFound
 	}
-	return j, nil
+	m.touch(j)
+	return id, j, nil
+}
+
+// touch records that the job was accessed
+func (m *Manager) touch(j *Job) {
+	j.lastAccess.Store(m.accesses.Add(1))
+}
+
+// retire records that the job is done, and removes the least recently accessed done jobs
+// that are over the retained job cap. A job counts as accessed when it finishes, so a
+// newly finished job isn't removed first.
+func (m *Manager) retire(username, jobID, name string, j *Job) {
+	m.touch(j)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.finished[keyString(username, jobID)] = finishedJob{username: username, jobID: jobID, name: name}
+	for m.maxRetained > 0 && len(m.finished) > m.maxRetained {
+		m.evictLeastRecentlyAccessed()
+	}
+}
+
+// evictLeastRecentlyAccessed removes the done job that was accessed least recently, and
+// releases its output. It must be called with the lock held.
+func (m *Manager) evictLeastRecentlyAccessed() {
+	var oldestKey string
+	var oldest int64
+	for key := range m.finished {
+		if at := m.jobMap[key].lastAccess.Load(); oldestKey == "" || at < oldest {
+			oldestKey, oldest = key, at
+		}
+	}
+	f := m.finished[oldestKey]
+	m.jobMap[oldestKey].streamer.Release()
+	delete(m.finished, oldestKey)
+	delete(m.jobMap, oldestKey)
+	if f.name != "" && m.names[keyString(f.username, f.name)] == f.jobID {
+		delete(m.names, keyString(f.username, f.name))
+	}
+}
+
+// removeUnusedGroup removes the cgroup added for a job that wasn't started. The cgroup
+// cleanup waits for a job to be done, and there's no job, so the cgroup is removed right
+// away. Nothing ran in it, so it's empty and removing it doesn't wait.
+func (m *Manager) removeUnusedGroup(username, jobID string) {
+	if err := m.cgroupFSManager.RemoveGroup(jobID); err != nil {
+		m.log.Warnw("removing the cgroup of a job that wasn't started", "jobID", jobID, "username", username, "error", err)
+	}
+}
+
+// applyJobOptions returns the job configuration set by the options, without the defaults
+func applyJobOptions(options []JobOption) jobConfig {
+	var cfg jobConfig
+	for _, opt := range options {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// reserveName points the user's name at jobID, unless another of the user's jobs with the
+// name is running or being started. An empty name is a no-op.
+func (m *Manager) reserveName(username, name, jobID string) error {
+	if name == "" {
+		return nil
+	}
+	key := keyString(username, name)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if id, ok := m.names[key]; ok {
+		// the job isn't in the job map while it's being started
+		j := m.jobMap[keyString(username, id)]
+		if j == nil || j.Status() == jogv1.Status_RUNNING || j.Status() == jogv1.Status_STOPPING {
+			return fmt.Errorf("%w: %s", ErrNameInUse, name)
+		}
+	}
+	m.names[key] = jobID
+	return nil
+}
+
+// releaseName removes the name reserved for a job that couldn't be started
+func (m *Manager) releaseName(username, name, jobID string) {
+	if name == "" {
+		return
+	}
+	key := keyString(username, name)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.names[key] == jobID {
+		delete(m.names, key)
+	}
+}
+
+// recordFailedStart records that the job couldn't be started, and removes the record after ttl
+func (m *Manager) recordFailedStart(username, jobID string, err error, ttl time.Duration) {
+	key := keyString(username, jobID)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.failedStarts[key] = failedStart{err: err.Error()}
+	time.AfterFunc(ttl, func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		delete(m.failedStarts, key)
+	})
+}
+
+func (m *Manager) getFailedStart(username, jobID string) (failedStart, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	f, ok := m.failedStarts[keyString(username, jobID)]
+	return f, ok
 }
 
 func keyString(username, jobID string) string {
@@ -106,17 +900,86 @@ func keyString(username, jobID string) string {
 // cgroups can't be removed util the processes inside them have exited.
 // at the system level, a cgroup is removed by removing the directory.
 // before removing the directory the cgroup.events file must contain
-// 'populated 0'. The RemoveGroup(jobID) method kicks off a goroutine
-// the polls the cgroup.events file, and removes the directory once
-// it reads populated 0. To reduce load, we don't kick off this
-// goroutine until the job is done. This call kicks off a goroutine
-// that Waits on the job, and then makes a call to RemoveGroup.
+// 'populated 0'. The RemoveGroup(jobID) method polls the cgroup.events
+// file, and removes the directory once it reads populated 0. To reduce
+// load, we don't schedule the cleanup until the job is done. This call
+// starts the cleanup workers the first time it's called, then kicks off
+// a goroutine that Waits on the job, and queues the cleanup for them.
+// The workers run cleanupCGroup, which calls RemoveGroup. There are
+// DefaultCleanupWorkers of them unless WithCleanupWorkers sets another
+// number. It bounds how many cleanups run at once, so a burst of jobs
+// finishing doesn't start a burst of pollers.
+//
+// Processes still in the cgroup once the job is done outlived it, e.g.
+// children that were detached into their own session. They're recorded
+// on the job as orphaned PIDs, and killed so the cgroup can be removed.
+// The cgroup's peak memory and CPU time are recorded on the job first,
+// since they can't be read once the cgroup is removed.
 //
 // Note that these goroutines don't need to also listen for a
 // shutdown signal. This is because a shutdown of the system
 // will trigger shutdown of all the jobs. There should be a buffer
 // between CommandWaitDelay and the server shutdown timeout for all
 // this cleanup to occur.
-func (m *Manager) scheduleCGroupCleanup(jobID string) {
-	m.cgroupFSManager.RemoveGroup(jobID)
+func (m *Manager) scheduleCGroupCleanup(username, jobID string, j *Job) {
+	m.startCleanupWorkers.Do(func() {
+		for i := 0; i < m.cleanupWorkers; i++ {
+			go m.runCleanupWorker()
+		}
+	})
+	go func() {
+		j.Wait()
+		m.cleanups <- cleanupTask{username: username, jobID: jobID, job: j}
+	}()
+}
+
+// cleanupTask is the cleanup of a done job's cgroup
+type cleanupTask struct {
+	username string
+	jobID    string
+	job      *Job
+}
+
+// runCleanupWorker cleans up the cgroups of done jobs as they're queued
+func (m *Manager) runCleanupWorker() {
+	for task := range m.cleanups {
+		m.cleanupCGroup(task.username, task.jobID, task.job)
+		m.logFinished(task)
+	}
+}
+
+// logFinished logs a summary of a done job, once its cgroup has been cleaned up and its
+// resource usage read. It's the one record of each job in the logs, so the field names
+// are kept stable for log processors.
+func (m *Manager) logFinished(task cleanupTask) {
+	j := task.job
+	usage := j.Usage()
+	m.log.Infow("job finished",
+		"jobID", task.jobID,
+		"username", task.username,
+		"name", j.Name(),
+		"status", j.Status().String(),
+		"exitCode", j.ExitCode(),
+		"duration", j.ExitedAt().Sub(j.StartedAt()),
+		"peakMemoryBytes", usage.PeakMemoryBytes,
+		"cpuUsec", usage.CPUUsageUsec,
+		"outputBytes", j.OutputSize(),
+		"orphanedPIDs", len(j.OrphanedPIDs()),
+	)
+}
+
+// cleanupCGroup records a done job's resource usage, kills the processes left in its cgroup,
+// and removes the cgroup. The processes left are logged once, here, where they're found.
+func (m *Manager) cleanupCGroup(username, jobID string, j *Job) {
+	if usage, err := m.cgroupFSManager.Usage(jobID); err == nil {
+		j.setUsage(usage)
+	}
+	if pids, err := m.cgroupFSManager.Procs(jobID); err == nil && len(pids) > 0 {
+		m.log.Warnw("job left orphaned processes", "jobID", jobID, "username", username, "orphanedPIDs", pids)
+		j.setOrphanedPIDs(pids)
+		m.cgroupFSManager.Kill(jobID)
+	}
+	if err := m.cgroupFSManager.RemoveGroup(jobID); err != nil {
+		m.log.Warnw("removing the cgroup of a done job", "jobID", jobID, "error", err)
+	}
 }
@@ -0,0 +1,1332 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/dustinevan/jogger/lib/cgroup"
+	jogv1 "github.com/dustinevan/jogger/pkg/gen/jogger/v1"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// addTestJob adds an unstarted job to the manager so that its output can be written directly
+func addTestJob(m *Manager, username, jobID string) *Job {
+	j := newJob(m.shutdownCtx, 0, "echo", nil)
+	j.username, j.id = username, jobID
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.jobMap[keyString(username, jobID)] = j
+	return j
+}
+
+// noopCgroups is a CgroupManager that starts jobs in the server's cgroup, for tests
+// that don't have a cgroup filesystem
+type noopCgroups struct{}
+
+func (noopCgroups) AddGroupWithLimits(string, string, cgroup.ResourceLimits, ...cgroup.GroupOption) (int, error) {
+	return -1, nil
+}
+func (noopCgroups) Procs(string) ([]int, error)             { return nil, nil }
+func (noopCgroups) Kill(string) error                       { return nil }
+func (noopCgroups) Usage(string) (cgroup.Usage, error)      { return cgroup.Usage{}, nil }
+func (noopCgroups) UpdateGroup(string, cgroup.Limits) error { return nil }
+func (noopCgroups) RemoveGroup(string) error                { return nil }
+
+// cancelingCgroups is a CgroupManager that calls cancel when a cgroup is added, like a
+// client canceling its request while the cgroup is created. It records removed cgroups.
+type cancelingCgroups struct {
+	noopCgroups
+	cancel  context.CancelFunc
+	added   []string
+	removed []string
+}
+
+func (c *cancelingCgroups) AddGroupWithLimits(_ string, name string, _ cgroup.ResourceLimits, _ ...cgroup.GroupOption) (int, error) {
+	c.added = append(c.added, name)
+	c.cancel()
+	return -1, nil
+}
+
+func (c *cancelingCgroups) RemoveGroup(name string) error {
+	c.removed = append(c.removed, name)
+	return nil
+}
+
+// recordingCgroups is a CgroupManager that records the cgroups that are added, with their
+// limits, and removed
+type recordingCgroups struct {
+	noopCgroups
+	mu      sync.Mutex
+	added   []string
+	limits  []cgroup.ResourceLimits
+	removed []string
+}
+
+func (c *recordingCgroups) AddGroupWithLimits(_ string, name string, limits cgroup.ResourceLimits, _ ...cgroup.GroupOption) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.added = append(c.added, name)
+	c.limits = append(c.limits, limits)
+	return -1, nil
+}
+
+func (c *recordingCgroups) RemoveGroup(name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.removed = append(c.removed, name)
+	return nil
+}
+
+func (c *recordingCgroups) groups() (added, removed []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.added...), append([]string(nil), c.removed...)
+}
+
+// usageCgroups is a CgroupManager that reports usage for its cgroups until they're removed,
+// like the cgroup interface files that are gone once the directory is removed
+type usageCgroups struct {
+	noopCgroups
+	usage   cgroup.Usage
+	mu      sync.Mutex
+	removed map[string]bool
+}
+
+func (c *usageCgroups) Usage(name string) (cgroup.Usage, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.removed[name] {
+		return cgroup.Usage{}, fmt.Errorf("cgroup %s not found", name)
+	}
+	return c.usage, nil
+}
+
+func (c *usageCgroups) RemoveGroup(name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.removed[name] = true
+	return nil
+}
+
+func (c *usageCgroups) isRemoved(name string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.removed[name]
+}
+
+// orphanCgroups is a CgroupManager whose cgroups still have processes in them once their job is done
+type orphanCgroups struct {
+	noopCgroups
+	pids []int
+}
+
+func (c orphanCgroups) Procs(string) ([]int, error) {
+	return c.pids, nil
+}
+
+// limitsCgroups is a CgroupManager that records the limits its cgroups are updated with
+type limitsCgroups struct {
+	noopCgroups
+	mu      sync.Mutex
+	updated map[string]cgroup.Limits
+}
+
+func (c *limitsCgroups) UpdateGroup(name string, limits cgroup.Limits) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.updated[name] = limits
+	return nil
+}
+
+// slowCgroups is a cgroupManager whose RemoveGroup is slow, like polling cgroup.events
+// while the job's processes exit. It records how many removals run at once.
+type slowCgroups struct {
+	noopCgroups
+	mu         sync.Mutex
+	active     int
+	maxActive  int
+	removed    int
+	removeTime time.Duration
+}
+
+func (c *slowCgroups) RemoveGroup(string) error {
+	c.mu.Lock()
+	c.active++
+	c.maxActive = max(c.maxActive, c.active)
+	c.mu.Unlock()
+
+	time.Sleep(c.removeTime)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.active--
+	c.removed++
+	return nil
+}
+
+func TestManagerDrainStreams(t *testing.T) {
+	t.Parallel()
+
+	m := NewManager(context.Background())
+	j := addTestJob(m, "user1", "job1")
+
+	// write more than one message of output
+	want := make([]byte, 3000)
+	for i := range want {
+		want[i] = byte('a' + i%26)
+	}
+	j.streamer.Write(want)
+
+	stream, err := m.OutputStream(context.Background(), "user1", "job1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the job is still running, so the stream only ends because it's drained
+	m.DrainStreams()
+	got := chunkData(readChunks(t, stream, 500*time.Millisecond))
+	if string(got) != string(want) {
+		t.Fatalf("expected all %d buffered bytes, got %d", len(want), len(got))
+	}
+
+	// calling DrainStreams again is safe
+	m.DrainStreams()
+}
+
+func TestManagerMaxStreams(t *testing.T) {
+	t.Parallel()
+
+	const maxStreams = 3
+	m := NewManager(context.Background(), WithMaxStreams(maxStreams))
+	j1 := addTestJob(m, "user1", "job1")
+	j2 := addTestJob(m, "user1", "job2")
+
+	// streams count against the cap across jobs
+	var cancels []context.CancelFunc
+	var streams []<-chan Chunk
+	for i := 0; i < maxStreams; i++ {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		jobID := []string{"job1", "job2"}[i%2]
+		stream, err := m.OutputStream(ctx, "user1", jobID)
+		if err != nil {
+			t.Fatalf("stream %d: unexpected error: %v", i, err)
+		}
+		cancels = append(cancels, cancel)
+		streams = append(streams, stream)
+	}
+	if n := m.ActiveStreams(); n != maxStreams {
+		t.Fatalf("expected %d active streams, got %d", maxStreams, n)
+	}
+	if n1, n2 := j1.ActiveStreams(), j2.ActiveStreams(); n1 != 2 || n2 != 1 {
+		t.Fatalf("expected 2 and 1 active streams per job, got %d and %d", n1, n2)
+	}
+
+	if _, err := m.OutputStream(context.Background(), "user1", "job1"); !errors.Is(err, ErrTooManyStreams) {
+		t.Fatalf("expected ErrTooManyStreams, got %v", err)
+	}
+
+	// the gauges are decremented before the stream is closed
+	cancels[0]()
+	readChunks(t, streams[0], time.Second)
+	if n := m.ActiveStreams(); n != maxStreams-1 {
+		t.Fatalf("expected %d active streams after closing one, got %d", maxStreams-1, n)
+	}
+	if n := j1.ActiveStreams(); n != 1 {
+		t.Fatalf("expected 1 active stream for job1, got %d", n)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if _, err := m.OutputStream(ctx, "user1", "job1"); err != nil {
+		t.Fatalf("expected a stream after one closed, got %v", err)
+	}
+}
+
+func TestManagerOutputStreamOffsetOutOfRange(t *testing.T) {
+	t.Parallel()
+
+	m := NewManager(context.Background())
+	j := addTestJob(m, "user1", "job1")
+	j.streamer.Write([]byte("output"))
+
+	// an offset past the end of the output is rejected without starting a stream
+	if _, err := m.OutputStream(context.Background(), "user1", "job1", WithStartOffset(7)); !errors.Is(err, ErrOffsetOutOfRange) {
+		t.Fatalf("expected ErrOffsetOutOfRange, got %v", err)
+	}
+	if n, jn := m.ActiveStreams(), j.ActiveStreams(); n != 0 || jn != 0 {
+		t.Fatalf("expected no active streams, got %d and %d", n, jn)
+	}
+
+	// the end of the output is in range
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if _, err := m.OutputStream(ctx, "user1", "job1", WithStartOffset(6)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestManagerExists(t *testing.T) {
+	t.Parallel()
+
+	m := NewManager(context.Background())
+	addTestJob(m, "user1", "job1")
+
+	tests := []struct {
+		username string
+		jobID    string
+		want     bool
+	}{
+		{username: "user1", jobID: "job1", want: true},
+		{username: "user1", jobID: "job2", want: false},
+		// other users' jobs are reported as missing
+		{username: "user2", jobID: "job1", want: false},
+	}
+	for _, tt := range tests {
+		if got := m.Exists(context.Background(), tt.username, tt.jobID); got != tt.want {
+			t.Fatalf("Exists(%s, %s): expected %v, got %v", tt.username, tt.jobID, tt.want, got)
+		}
+	}
+}
+
+func TestManagerStartRejectsInvalidCommand(t *testing.T) {
+	t.Parallel()
+
+	// the command is validated before a cgroup is created, so no cgroup manager is needed
+	m := NewManager(context.Background())
+	if _, err := m.Start(context.Background(), "user1", "echo", []string{"a\x00b"}); !errors.Is(err, ErrInvalidCommand) {
+		t.Fatalf("expected ErrInvalidCommand, got %v", err)
+	}
+	if len(m.jobMap) != 0 {
+		t.Fatalf("expected no jobs, got %d", len(m.jobMap))
+	}
+}
+
+func TestManagerCgroupManager(t *testing.T) {
+	t.Parallel()
+
+	// without a cgroup manager, jobs can't be started
+	m := NewManager(context.Background())
+	if _, err := m.Start(context.Background(), "user1", "echo", []string{"hello"}, WithName("hello")); !errors.Is(err, ErrNoCgroupManager) {
+		t.Fatalf("expected ErrNoCgroupManager, got %v", err)
+	}
+	if n := m.RunningJobs(); n != 0 {
+		t.Fatalf("expected no running jobs, got %d", n)
+	}
+
+	cgroups := &recordingCgroups{}
+	m = NewManager(context.Background(), WithCgroupManager(cgroups))
+	jobID, err := m.Start(context.Background(), "user1", "echo", []string{"hello"}, WithName("hello"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	stream, err := m.OutputStream(context.Background(), "user1", jobID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out := string(chunkData(readChunks(t, stream, 5*time.Second))); out != "hello\n" {
+		t.Fatalf("expected output %q, got %q", "hello\n", out)
+	}
+	j, err := m.getJob("user1", jobID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	waitForJob(t, j, 5*time.Second)
+	if j.Status() != jogv1.Status_COMPLETED {
+		t.Fatalf("expected status %v, got %v", jogv1.Status_COMPLETED, j.Status())
+	}
+	if added, _ := cgroups.groups(); !slices.Equal(added, []string{jobID}) {
+		t.Fatalf("expected the job's cgroup to be added, got %v", added)
+	}
+}
+
+func TestManagerJobNames(t *testing.T) {
+	t.Parallel()
+
+	m := NewManager(context.Background())
+	m.cgroupFSManager = noopCgroups{}
+
+	jobID, err := m.Start(context.Background(), "user1", "sleep", []string{"10"}, WithName("nightly-build"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the name resolves to the job, but only for the user that started it
+	info, err := m.Status(context.Background(), "user1", "nightly-build")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if info.Status != jogv1.Status_RUNNING || info.Name != "nightly-build" {
+		t.Fatalf("expected a running job named nightly-build, got %+v", info)
+	}
+	if m.Exists(context.Background(), "user2", "nightly-build") {
+		t.Fatalf("expected the name to be scoped to user1")
+	}
+
+	// names are unique among the user's running jobs, other users can use the name
+	if _, err := m.Start(context.Background(), "user1", "sleep", []string{"10"}, WithName("nightly-build")); !errors.Is(err, ErrNameInUse) {
+		t.Fatalf("expected ErrNameInUse, got %v", err)
+	}
+	otherID, err := m.Start(context.Background(), "user2", "sleep", []string{"10"}, WithName("nightly-build"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer m.Stop(context.Background(), "user2", otherID)
+
+	if _, err := m.Start(context.Background(), "user1", "echo", nil, WithName("-bad")); !errors.Is(err, ErrInvalidName) {
+		t.Fatalf("expected ErrInvalidName, got %v", err)
+	}
+
+	// the name can be reused once the job is done, and then points at the new job
+	if err := m.Stop(context.Background(), "user1", "nightly-build"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	j, _ := m.getJob("user1", jobID)
+	waitForJob(t, j, 5*time.Second)
+	newID, err := m.Start(context.Background(), "user1", "sleep", []string{"10"}, WithName("nightly-build"))
+	if err != nil {
+		t.Fatalf("expected the name to be reusable, got %v", err)
+	}
+	defer m.Stop(context.Background(), "user1", newID)
+	if j, _ := m.getJob("user1", "nightly-build"); j == nil || j == m.jobMap[keyString("user1", jobID)] {
+		t.Fatalf("expected the name to point at the new job")
+	}
+	// the old job can still be looked up by its id
+	if !m.Exists(context.Background(), "user1", jobID) {
+		t.Fatalf("expected the old job to still exist")
+	}
+}
+
+func TestManagerJobNameReleasedOnFailedStart(t *testing.T) {
+	t.Parallel()
+
+	m := NewManager(context.Background())
+	m.cgroupFSManager = noopCgroups{}
+
+	if _, err := m.Start(context.Background(), "user1", "/does/not/exist", nil, WithName("build")); err == nil {
+		t.Fatalf("expected an error")
+	}
+	jobID, err := m.Start(context.Background(), "user1", "echo", nil, WithName("build"))
+	if err != nil {
+		t.Fatalf("expected the name of a failed start to be released, got %v", err)
+	}
+	if j, err := m.getJob("user1", "build"); err != nil || j != m.jobMap[keyString("user1", jobID)] {
+		t.Fatalf("expected the name to resolve to %s, got %v", jobID, err)
+	}
+}
+
+func TestManagerMaxRetainedJobs(t *testing.T) {
+	t.Parallel()
+
+	m := NewManager(context.Background(), WithMaxRetainedJobs(2))
+	m.cgroupFSManager = noopCgroups{}
+
+	// retained returns the number of done jobs being kept
+	retained := func() int {
+		m.mu.RLock()
+		defer m.mu.RUnlock()
+		return len(m.finished)
+	}
+	// startDone starts a job and waits for it to be retired
+	startDone := func(name string) string {
+		t.Helper()
+		before := retained()
+		jobID, err := m.Start(context.Background(), "user1", "echo", []string{name}, WithName(name))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		deadline := time.Now().Add(5 * time.Second)
+		for retained() == before {
+			if time.Now().After(deadline) {
+				t.Fatalf("job %s wasn't retired", name)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		return jobID
+	}
+
+	runningID, err := m.Start(context.Background(), "user1", "sleep", []string{"10"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer m.Stop(context.Background(), "user1", runningID)
+
+	first := startDone("first")
+	second := startDone("second")
+	// reading the first job's output makes the second the least recently accessed
+	if _, err := m.OutputStream(context.Background(), "user1", first); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// one more done job than the cap, the second is evicted
+	third, err := m.Start(context.Background(), "user1", "echo", []string{"third"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// the job map is checked directly, looking the job up would count as an access
+	kept := func(jobID string) bool {
+		m.mu.RLock()
+		defer m.mu.RUnlock()
+		return m.jobMap[keyString("user1", jobID)] != nil
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for kept(second) {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the least recently accessed job to be evicted")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if _, err := m.OutputStream(context.Background(), "user1", second); !errors.Is(err, ErrJobNotFound) {
+		t.Fatalf("expected ErrJobNotFound for the evicted job's output, got %v", err)
+	}
+	if m.Exists(context.Background(), "user1", "second") {
+		t.Fatalf("expected the evicted job's name to be removed")
+	}
+	for _, jobID := range []string{first, third, runningID} {
+		if !m.Exists(context.Background(), "user1", jobID) {
+			t.Fatalf("expected job %s to be kept", jobID)
+		}
+	}
+	if n := retained(); n != 2 {
+		t.Fatalf("expected 2 retained jobs, got %d", n)
+	}
+	if info, _ := m.Status(context.Background(), "user1", runningID); info.Status != jogv1.Status_RUNNING {
+		t.Fatalf("expected the running job to be untouched, got %v", info.Status)
+	}
+}
+
+func TestManagerMaxRunningJobs(t *testing.T) {
+	t.Parallel()
+
+	const maxRunning = 3
+	m := NewManager(context.Background(), WithMaxRunningJobs(maxRunning))
+	m.cgroupFSManager = noopCgroups{}
+
+	// start more jobs than the cap at once, exactly maxRunning of them are started
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var jobIDs []string
+	var rejected int
+	for i := 0; i < maxRunning+2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			jobID, err := m.Start(context.Background(), "user1", "sleep", []string{"10"})
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case errors.Is(err, ErrTooManyJobs):
+				rejected++
+			case err != nil:
+				t.Errorf("unexpected error: %v", err)
+			default:
+				jobIDs = append(jobIDs, jobID)
+			}
+		}()
+	}
+	wg.Wait()
+	if len(jobIDs) != maxRunning || rejected != 2 {
+		t.Fatalf("expected %d started and 2 rejected jobs, got %d and %d", maxRunning, len(jobIDs), rejected)
+	}
+	if n := m.RunningJobs(); n != maxRunning {
+		t.Fatalf("expected %d running jobs, got %d", maxRunning, n)
+	}
+	if _, err := m.Start(context.Background(), "user1", "sleep", []string{"10"}); !errors.Is(err, ErrTooManyJobs) {
+		t.Fatalf("expected ErrTooManyJobs, got %v", err)
+	}
+
+	// a job finishing frees a slot
+	if err := m.Stop(context.Background(), "user1", jobIDs[0]); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	j, _ := m.getJob("user1", jobIDs[0])
+	waitForJob(t, j, 5*time.Second)
+	deadline := time.Now().Add(time.Second)
+	for m.RunningJobs() != maxRunning-1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d running jobs after one finished, got %d", maxRunning-1, m.RunningJobs())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	jobID, err := m.Start(context.Background(), "user1", "sleep", []string{"10"})
+	if err != nil {
+		t.Fatalf("expected a job to start after one finished, got %v", err)
+	}
+	jobIDs = append(jobIDs[1:], jobID)
+
+	for _, jobID := range jobIDs {
+		m.Stop(context.Background(), "user1", jobID)
+	}
+}
+
+func TestManagerRecordsFailedStarts(t *testing.T) {
+	t.Parallel()
+
+	const ttl = 200 * time.Millisecond
+	m := NewManager(context.Background(), WithFailedStartTTL(ttl))
+	m.cgroupFSManager = noopCgroups{}
+
+	jobID, err := m.Start(context.Background(), "user1", "jogger-no-such-command", nil)
+	if !errors.Is(err, exec.ErrNotFound) {
+		t.Fatalf("expected exec.ErrNotFound, got %v", err)
+	}
+	if jobID == "" {
+		t.Fatalf("expected the job id of the failed start to be returned")
+	}
+
+	info, err := m.Status(context.Background(), "user1", jobID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if info.Status != jogv1.Status_START_FAILED {
+		t.Fatalf("expected status %v, got %v", jogv1.Status_START_FAILED, info.Status)
+	}
+	if !strings.Contains(info.StartError, "jogger-no-such-command") {
+		t.Fatalf("expected the start error to name the command, got %q", info.StartError)
+	}
+	// failed starts are scoped per user, and aren't jobs
+	if _, err := m.Status(context.Background(), "user2", jobID); !errors.Is(err, ErrJobNotFound) {
+		t.Fatalf("expected ErrJobNotFound for another user, got %v", err)
+	}
+	if m.Exists(context.Background(), "user1", jobID) {
+		t.Fatalf("expected a failed start not to exist as a job")
+	}
+	if n := m.RunningJobs(); n != 0 {
+		t.Fatalf("expected no running jobs, got %d", n)
+	}
+
+	// the record is removed after the ttl
+	time.Sleep(2 * ttl)
+	if _, err := m.Status(context.Background(), "user1", jobID); !errors.Is(err, ErrJobNotFound) {
+		t.Fatalf("expected ErrJobNotFound after the ttl, got %v", err)
+	}
+
+	// failed starts aren't recorded by default
+	m = NewManager(context.Background())
+	m.cgroupFSManager = noopCgroups{}
+	if jobID, err := m.Start(context.Background(), "user1", "jogger-no-such-command", nil); err == nil || jobID != "" {
+		t.Fatalf("expected an error and no job id, got %q, %v", jobID, err)
+	}
+	if len(m.failedStarts) != 0 {
+		t.Fatalf("expected no failed starts to be recorded, got %d", len(m.failedStarts))
+	}
+}
+
+func TestManagerStartCanceled(t *testing.T) {
+	t.Parallel()
+
+	// the context is canceled while the cgroup is created
+	ctx, cancel := context.WithCancel(context.Background())
+	cgroups := &cancelingCgroups{cancel: cancel}
+	m := NewManager(context.Background())
+	m.cgroupFSManager = cgroups
+
+	if _, err := m.Start(ctx, "user1", "sleep", []string{"10"}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if len(m.jobMap) != 0 {
+		t.Fatalf("expected no jobs, got %d", len(m.jobMap))
+	}
+	if len(cgroups.added) != 1 || len(cgroups.removed) != 1 || cgroups.added[0] != cgroups.removed[0] {
+		t.Fatalf("expected the cgroup to be removed, added %v and removed %v", cgroups.added, cgroups.removed)
+	}
+	if n := m.RunningJobs(); n != 0 {
+		t.Fatalf("expected no running jobs, got %d", n)
+	}
+
+	// a context that's already canceled doesn't create a cgroup
+	if _, err := m.Start(ctx, "user1", "sleep", []string{"10"}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if len(cgroups.added) != 1 {
+		t.Fatalf("expected no cgroup to be added, got %v", cgroups.added)
+	}
+}
+
+func TestManagerStartFailedRemovesCgroup(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		failedStartTTL time.Duration
+	}{
+		{name: "failed start not recorded"},
+		{name: "failed start recorded", failedStartTTL: time.Minute},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cgroups := &recordingCgroups{}
+			m := NewManager(context.Background(), WithFailedStartTTL(tt.failedStartTTL))
+			m.cgroupFSManager = cgroups
+
+			if _, err := m.Start(context.Background(), "user1", "/does/not/exist", nil); err == nil {
+				t.Fatal("expected an error starting a missing command")
+			}
+			// the cgroup is removed before Start returns, not by the cleanup for a done job
+			added, removed := cgroups.groups()
+			if len(added) != 1 || len(removed) != 1 || added[0] != removed[0] {
+				t.Fatalf("expected the cgroup to be removed, added %v and removed %v", added, removed)
+			}
+			// and it's removed once, there's no job for a cleanup to be scheduled for
+			time.Sleep(50 * time.Millisecond)
+			if _, removed := cgroups.groups(); len(removed) != 1 {
+				t.Fatalf("expected the cgroup to be removed once, got %v", removed)
+			}
+			if n := m.RunningJobs(); n != 0 {
+				t.Fatalf("expected no running jobs, got %d", n)
+			}
+		})
+	}
+}
+
+func TestManagerStartResourceLimits(t *testing.T) {
+	t.Parallel()
+
+	cgroups := &recordingCgroups{}
+	m := NewManager(context.Background())
+	m.cgroupFSManager = cgroups
+
+	limits := cgroup.ResourceLimits{MemoryBytes: 512 << 20, CPUMaxPercent: 50, IOWeight: 200}
+	if _, err := m.Start(context.Background(), "user1", "true", nil, WithResourceLimits(limits)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// without limits, the cgroup gets the defaults
+	if _, err := m.Start(context.Background(), "user1", "true", nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cgroups.mu.Lock()
+	defer cgroups.mu.Unlock()
+	want := []cgroup.ResourceLimits{limits, {}}
+	if !slices.Equal(cgroups.limits, want) {
+		t.Fatalf("expected the cgroups to be added with %+v, got %+v", want, cgroups.limits)
+	}
+}
+
+func TestManagerEvents(t *testing.T) {
+	t.Parallel()
+
+	m := NewManager(context.Background())
+	m.cgroupFSManager = noopCgroups{}
+
+	jobID, err := m.Start(context.Background(), "user1", "sleep", []string{"10"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := m.Stop(context.Background(), "user1", jobID, WithGracePeriod(time.Second)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	j, _ := m.getJob("user1", jobID)
+	waitForJob(t, j, 5*time.Second)
+
+	events, err := m.Events(context.Background(), "user1", jobID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []struct {
+		typ    EventType
+		detail string
+	}{
+		{typ: EventStarted, detail: "pid "},
+		{typ: EventStopRequested, detail: "SIGTERM, SIGKILL after 1s"},
+		{typ: EventExited, detail: "STOPPED"},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("expected %d events, got %+v", len(want), events)
+	}
+	for i, w := range want {
+		if events[i].Type != w.typ || !strings.Contains(events[i].Detail, w.detail) {
+			t.Fatalf("event %d: expected %s containing %q, got %+v", i, w.typ, w.detail, events[i])
+		}
+		if i > 0 && events[i].Time.Before(events[i-1].Time) {
+			t.Fatalf("expected events oldest first, got %+v", events)
+		}
+	}
+
+	// events are scoped to the job's owner
+	if _, err := m.Events(context.Background(), "user2", jobID); !errors.Is(err, ErrJobNotFound) {
+		t.Fatalf("expected ErrJobNotFound for another user, got %v", err)
+	}
+}
+
+func TestManagerList(t *testing.T) {
+	t.Parallel()
+
+	m := NewManager(context.Background(), WithAllowShell(true))
+	m.cgroupFSManager = noopCgroups{}
+
+	start := func(username, cmd string, args []string, options ...JobOption) string {
+		t.Helper()
+		jobID, err := m.Start(context.Background(), username, cmd, args, options...)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return jobID
+	}
+	first := start("user1", "true", nil)
+	j, _ := m.getJob("user1", first)
+	j.Wait()
+	second := start("user1", "sleep", []string{"10"}, WithName("nap"))
+	defer m.Stop(context.Background(), "user1", second)
+	third := start("user1", "echo $HOME", []string{"a b"}, WithShell())
+	other := start("user2", "sleep", []string{"10"})
+	defer m.Stop(context.Background(), "user2", other)
+
+	list := m.List(context.Background(), "user1")
+	want := []JobSummary{
+		{JobID: first, Command: "true", Args: []string{}, Status: jogv1.Status_COMPLETED},
+		{JobID: second, Name: "nap", Command: "sleep", Args: []string{"10"}, Status: jogv1.Status_RUNNING},
+		// shell jobs are listed with the command line they were started with
+		{JobID: third, Command: "echo $HOME", Args: []string{"a b"}},
+	}
+	if len(list) != len(want) {
+		t.Fatalf("expected %d jobs, got %+v", len(want), list)
+	}
+	for i, w := range want {
+		got := list[i]
+		if got.JobID != w.JobID || got.Name != w.Name || got.Command != w.Command || !slices.Equal(got.Args, w.Args) {
+			t.Fatalf("job %d: expected %+v, got %+v", i, w, got)
+		}
+		if w.Status != jogv1.Status_STATUS_UNSPECIFIED && got.Status != w.Status {
+			t.Fatalf("job %d: expected status %s, got %s", i, w.Status, got.Status)
+		}
+		if got.StartedAt.IsZero() {
+			t.Fatalf("job %d: expected a start time", i)
+		}
+	}
+
+	// users never see each other's jobs
+	if list := m.List(context.Background(), "user2"); len(list) != 1 || list[0].JobID != other {
+		t.Fatalf("expected only user2's job, got %+v", list)
+	}
+	if list := m.List(context.Background(), "user3"); len(list) != 0 {
+		t.Fatalf("expected no jobs, got %+v", list)
+	}
+}
+
+func TestManagerListPending(t *testing.T) {
+	t.Parallel()
+
+	m := NewManager(context.Background())
+	m.cgroupFSManager = noopCgroups{}
+
+	build, err := m.Start(context.Background(), "user1", "sleep", []string{"10"}, WithName("build"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer m.Stop(context.Background(), "user1", build)
+	test, err := m.Start(context.Background(), "user1", "echo", []string{"hi"}, WithAfter("build", true), WithName("test"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer m.Stop(context.Background(), "user1", test)
+
+	// the pending job is listed after the running one, without a start time
+	list := m.List(context.Background(), "user1")
+	if len(list) != 2 || list[0].JobID != build {
+		t.Fatalf("expected the running job, then the pending one, got %+v", list)
+	}
+	got := list[1]
+	if got.JobID != test || got.Name != "test" || got.Command != "echo" || !slices.Equal(got.Args, []string{"hi"}) {
+		t.Fatalf("expected the pending job, got %+v", got)
+	}
+	if got.Status != jogv1.Status_PENDING || !got.StartedAt.IsZero() {
+		t.Fatalf("expected a PENDING job without a start time, got %s started at %v", got.Status, got.StartedAt)
+	}
+
+	// other users don't see it
+	if list := m.List(context.Background(), "user2"); len(list) != 0 {
+		t.Fatalf("expected no jobs, got %+v", list)
+	}
+}
+
+func TestManagerListDuringStart(t *testing.T) {
+	t.Parallel()
+
+	m := NewManager(context.Background())
+	m.cgroupFSManager = noopCgroups{}
+
+	// run with -race, jobs are listed while they're started
+	const jobs = 20
+	var wg sync.WaitGroup
+	for i := 0; i < jobs; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if _, err := m.Start(context.Background(), "user1", "true", nil); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for _, j := range m.List(context.Background(), "user1") {
+				if j.JobID == "" || j.Command != "true" {
+					t.Errorf("unexpected job in list: %+v", j)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if list := m.List(context.Background(), "user1"); len(list) != jobs {
+		t.Fatalf("expected %d jobs, got %d", jobs, len(list))
+	}
+}
+
+func TestManagerWatchEvents(t *testing.T) {
+	t.Parallel()
+
+	m := NewManager(context.Background())
+	m.cgroupFSManager = noopCgroups{}
+
+	jobID, err := m.Start(context.Background(), "user1", "sleep", []string{"10"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// two watchers, each gets every event in order, starting with the ones recorded before it
+	// started watching
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var watchers []<-chan Event
+	for i := 0; i < 2; i++ {
+		events, err := m.WatchEvents(ctx, "user1", jobID)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		watchers = append(watchers, events)
+	}
+	started := <-watchers[0]
+	if started.Type != EventStarted {
+		t.Fatalf("expected the started event first, got %+v", started)
+	}
+	// the next events are recorded while the job is watched
+	if err := m.Stop(context.Background(), "user1", jobID, WithGracePeriod(time.Second)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []EventType{EventStarted, EventStopRequested, EventExited}
+	for i, events := range watchers {
+		var got []EventType
+		if i == 0 {
+			got = append(got, started.Type)
+		}
+		// the stream closes after the exited event
+		for e := range events {
+			got = append(got, e.Type)
+		}
+		if !slices.Equal(got, want) {
+			t.Fatalf("watcher %d: expected events %v, got %v", i, want, got)
+		}
+	}
+
+	// a done job's events are all sent, and the stream closes
+	events, err := m.WatchEvents(ctx, "user1", jobID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var got []EventType
+	for e := range events {
+		got = append(got, e.Type)
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("expected events %v for the done job, got %v", want, got)
+	}
+
+	// events are scoped to the job's owner
+	if _, err := m.WatchEvents(ctx, "user2", jobID); !errors.Is(err, ErrJobNotFound) {
+		t.Fatalf("expected ErrJobNotFound for another user, got %v", err)
+	}
+}
+
+func TestJobWatchEventsCanceled(t *testing.T) {
+	t.Parallel()
+
+	m := NewManager(context.Background())
+	addTestJob(m, "user1", "job1")
+	ctx, cancel := context.WithCancel(context.Background())
+	events, err := m.WatchEvents(ctx, "user1", "job1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// the job never starts, so the stream only closes when it's canceled
+	cancel()
+	select {
+	case _, ok := <-events:
+		if ok {
+			t.Fatal("expected no events")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the stream to close")
+	}
+}
+
+func TestManagerJobPathDefault(t *testing.T) {
+	t.Parallel()
+
+	if m := NewManager(context.Background(), WithJobPath("")); m.jobPath != DefaultPath {
+		t.Fatalf("expected an empty path to keep %q, got %q", DefaultPath, m.jobPath)
+	}
+	cfg := defaultJobConfig()
+	WithPath("")(&cfg)
+	if cfg.path != DefaultPath {
+		t.Fatalf("expected an empty job path to keep %q, got %q", DefaultPath, cfg.path)
+	}
+}
+
+func TestManagerCleanupWorkersDefault(t *testing.T) {
+	t.Parallel()
+
+	for _, n := range []int{0, -1} {
+		if m := NewManager(context.Background(), WithCleanupWorkers(n)); m.cleanupWorkers != DefaultCleanupWorkers {
+			t.Fatalf("expected %d workers for %d, got %d", DefaultCleanupWorkers, n, m.cleanupWorkers)
+		}
+	}
+}
+
+func TestManagerCleanupWorkers(t *testing.T) {
+	t.Parallel()
+
+	const workers, jobs = 2, 10
+	cgroups := &slowCgroups{removeTime: 20 * time.Millisecond}
+	m := NewManager(context.Background(), WithCleanupWorkers(workers))
+	m.cgroupFSManager = cgroups
+
+	// the jobs all finish at about the same time
+	for i := 0; i < jobs; i++ {
+		if _, err := m.Start(context.Background(), "user1", "true", nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		cgroups.mu.Lock()
+		removed, maxActive := cgroups.removed, cgroups.maxActive
+		cgroups.mu.Unlock()
+		if maxActive > workers {
+			t.Fatalf("expected at most %d concurrent cleanups, got %d", workers, maxActive)
+		}
+		if removed == jobs {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d cgroups to be removed, got %d", jobs, removed)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestManagerStatusStartInfo(t *testing.T) {
+	t.Parallel()
+
+	m := NewManager(context.Background())
+	m.cgroupFSManager = noopCgroups{}
+
+	before := time.Now()
+	jobID, err := m.Start(context.Background(), "user1", "sleep", []string{"5"}, WithSourceAddr("203.0.113.7:51234"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Cleanup(func() { m.Stop(context.Background(), "user1", jobID) })
+
+	info, err := m.Status(context.Background(), "user1", jobID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if info.Status != jogv1.Status_RUNNING {
+		t.Fatalf("expected status %v, got %v", jogv1.Status_RUNNING, info.Status)
+	}
+	if info.StartedAt.Before(before) || info.StartedAt.After(time.Now()) {
+		t.Fatalf("expected a start time after %v, got %v", before, info.StartedAt)
+	}
+	wantPath, err := lookPath("sleep", DefaultPath)
+	if err != nil {
+		t.Fatalf("finding sleep: %v", err)
+	}
+	if info.CommandPath != wantPath {
+		t.Fatalf("expected command path %s, got %s", wantPath, info.CommandPath)
+	}
+	if info.SourceAddr != "203.0.113.7:51234" {
+		t.Fatalf("expected source address 203.0.113.7:51234, got %q", info.SourceAddr)
+	}
+}
+
+func TestManagerStartLatency(t *testing.T) {
+	t.Parallel()
+
+	// each reading of the clock is 250ms after the last
+	const step = 250 * time.Millisecond
+	var mu sync.Mutex
+	now := time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		now = now.Add(step)
+		return now
+	}
+	m := NewManager(context.Background(), WithManagerClock(clock))
+	m.cgroupFSManager = noopCgroups{}
+
+	jobID, err := m.Start(context.Background(), "user1", "true", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	info, err := m.Status(context.Background(), "user1", jobID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if info.StartLatency != step {
+		t.Fatalf("expected a start latency of %v, got %v", step, info.StartLatency)
+	}
+}
+
+func TestManagerAllowShell(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		allowed bool
+		wantErr error
+	}{
+		{name: "not allowed by default", wantErr: ErrShellNotAllowed},
+		{name: "allowed", allowed: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var options []ManagerOption
+			if tt.allowed {
+				options = append(options, WithAllowShell(true))
+			}
+			m := NewManager(context.Background(), options...)
+			m.cgroupFSManager = noopCgroups{}
+
+			_, err := m.Start(context.Background(), "user1", "true && true", nil, WithShell())
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			// jobs that don't use a shell are always allowed
+			if _, err := m.Start(context.Background(), "user1", "true", nil); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestManagerReportsUsage(t *testing.T) {
+	t.Parallel()
+
+	want := cgroup.Usage{PeakMemoryBytes: 64 << 20, CPUUsageUsec: 1500000}
+	cgroups := &usageCgroups{usage: want, removed: make(map[string]bool)}
+	m := NewManager(context.Background())
+	m.cgroupFSManager = cgroups
+
+	jobID, err := m.Start(context.Background(), "user1", "true", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for !cgroups.isRemoved(jobID) {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the job's cgroup to be removed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// the usage was read before the cgroup was removed
+	info, err := m.Status(context.Background(), "user1", jobID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if info.PeakMemoryBytes != want.PeakMemoryBytes || info.TotalCPUUsec != want.CPUUsageUsec {
+		t.Fatalf("expected peak memory %d and cpu %d, got %d and %d",
+			want.PeakMemoryBytes, want.CPUUsageUsec, info.PeakMemoryBytes, info.TotalCPUUsec)
+	}
+}
+
+func TestManagerLogsFinishedJob(t *testing.T) {
+	t.Parallel()
+
+	usage := cgroup.Usage{PeakMemoryBytes: 64 << 20, CPUUsageUsec: 1500000}
+	core, logs := observer.New(zap.InfoLevel)
+	m := NewManager(context.Background(), WithLogger(zap.New(core).Sugar()))
+	m.cgroupFSManager = &usageCgroups{usage: usage, removed: make(map[string]bool)}
+
+	jobID, err := m.Start(context.Background(), "user1", "echo", []string{"hello"}, WithName("greeting"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for logs.FilterMessage("job finished").Len() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the job finished log line")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	entries := logs.FilterMessage("job finished").All()
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 job finished log line, got %d", len(entries))
+	}
+	fields := entries[0].ContextMap()
+	want := map[string]any{
+		"jobID":           jobID,
+		"username":        "user1",
+		"name":            "greeting",
+		"status":          "COMPLETED",
+		"exitCode":        int64(0),
+		"peakMemoryBytes": usage.PeakMemoryBytes,
+		"cpuUsec":         usage.CPUUsageUsec,
+		"outputBytes":     int64(len("hello\n")),
+		"orphanedPIDs":    int64(0),
+	}
+	for key, value := range want {
+		if fields[key] != value {
+			t.Fatalf("expected %s %v (%T), got %v (%T)", key, value, value, fields[key], fields[key])
+		}
+	}
+	if d, ok := fields["duration"].(time.Duration); !ok || d <= 0 {
+		t.Fatalf("expected a positive duration, got %v", fields["duration"])
+	}
+}
+
+func TestManagerLogsOrphanedPIDsOnce(t *testing.T) {
+	t.Parallel()
+
+	core, logs := observer.New(zap.InfoLevel)
+	m := NewManager(context.Background(), WithLogger(zap.New(core).Sugar()))
+	m.cgroupFSManager = orphanCgroups{pids: []int{4242}}
+
+	jobID, err := m.Start(context.Background(), "user1", "true", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for logs.FilterMessage("job finished").Len() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the job finished log line")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// reading the status doesn't log the orphans again
+	for i := 0; i < 3; i++ {
+		info, err := m.Status(context.Background(), "user1", jobID)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !slices.Equal(info.OrphanedPIDs, []int{4242}) {
+			t.Fatalf("expected orphaned pids %v, got %v", []int{4242}, info.OrphanedPIDs)
+		}
+	}
+	entries := logs.FilterMessage("job left orphaned processes").All()
+	if len(entries) != 1 {
+		t.Fatalf("expected the orphans to be logged once, got %d log lines", len(entries))
+	}
+	if fields := entries[0].ContextMap(); fields["jobID"] != jobID || fields["username"] != "user1" {
+		t.Fatalf("expected the job's id and user in the log line, got %v", fields)
+	}
+}
+
+func TestManagerUpdateLimits(t *testing.T) {
+	t.Parallel()
+
+	cgroups := &limitsCgroups{updated: make(map[string]cgroup.Limits)}
+	m := NewManager(context.Background())
+	m.cgroupFSManager = cgroups
+
+	jobID, err := m.Start(context.Background(), "user1", "sleep", []string{"5"}, WithName("nightly"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Cleanup(func() { m.Stop(context.Background(), "user1", jobID) })
+
+	// the job's name is resolved to the id its cgroup is named by
+	want := cgroup.Limits{MemoryMaxBytes: 8 << 30, CPUs: 2}
+	if err := m.UpdateLimits(context.Background(), "user1", "nightly", want); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := cgroups.updated[jobID]; got != want {
+		t.Fatalf("expected the job's cgroup to be updated with %+v, got %+v", want, got)
+	}
+	info, err := m.Status(context.Background(), "user1", jobID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if info.Status != jogv1.Status_RUNNING {
+		t.Fatalf("expected the job to keep running, got %v", info.Status)
+	}
+
+	if err := m.UpdateLimits(context.Background(), "user2", jobID, want); !errors.Is(err, ErrJobNotFound) {
+		t.Fatalf("expected ErrJobNotFound for another user's job, got %v", err)
+	}
+	j, err := m.getJob("user1", jobID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	m.Stop(context.Background(), "user1", jobID)
+	j.Wait()
+	if err := m.UpdateLimits(context.Background(), "user1", jobID, want); !errors.Is(err, ErrJobNotRunning) {
+		t.Fatalf("expected ErrJobNotRunning for a stopped job, got %v", err)
+	}
+}
+
+func TestManagerReportsOrphanedPIDs(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("creating cgroups requires root")
+	}
+	if _, err := os.Stat("/sys/fs/cgroup/cgroup.controllers"); err != nil {
+		t.Skip("creating cgroups requires a cgroup2 filesystem at /sys/fs/cgroup")
+	}
+
+	fsm, err := cgroup.NewFSManager(context.Background(), cgroup.WithServerCGroupName("jogger-test"))
+	if err != nil {
+		t.Fatalf("creating cgroup manager: %v", err)
+	}
+	m := NewManager(context.Background())
+	m.cgroupFSManager = fsm
+
+	// the child is detached into its own session, and doesn't hold the job's output open,
+	// so the job exits while the child keeps running in the job's cgroup
+	jobID, err := m.Start(context.Background(), "user1", "sh", []string{"-c", "setsid sleep 30 >/dev/null 2>&1 &"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var info StatusInfo
+	deadline := time.Now().Add(5 * time.Second)
+	for len(info.OrphanedPIDs) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the detached child to be reported as orphaned, got status %+v", info)
+		}
+		time.Sleep(50 * time.Millisecond)
+		if info, err = m.Status(context.Background(), "user1", jobID); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	// the cgroup cleanup kills the orphans, and init reaps them
+	for _, pid := range info.OrphanedPIDs {
+		deadline := time.Now().Add(5 * time.Second)
+		for syscall.Kill(pid, 0) == nil {
+			if time.Now().After(deadline) {
+				t.Fatalf("orphaned pid %d is still running", pid)
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+	}
+}
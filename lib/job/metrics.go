@@ -0,0 +1,88 @@
+package job
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	jogv1 "github.com/dustinevan/jogger/pkg/gen/jogger/v1"
+)
+
+// WriteMetrics writes the manager's job metrics in the Prometheus text exposition format:
+// the number of jobs by status, the durations of the done jobs, and the number of live
+// output streams. Every status is written, so a status without jobs reads 0 rather than
+// disappearing.
+func (m *Manager) WriteMetrics(w io.Writer) error {
+	counts := make(map[jogv1.Status]int)
+	var durationSum float64
+	var durationCount int
+
+	m.mu.RLock()
+	jobs := make([]*Job, 0, len(m.jobMap))
+	for _, j := range m.jobMap {
+		jobs = append(jobs, j)
+	}
+	counts[jogv1.Status_START_FAILED] = len(m.failedStarts)
+	counts[jogv1.Status_PENDING] = len(m.pending)
+	m.mu.RUnlock()
+
+	for _, j := range jobs {
+		counts[j.Status()]++
+		started, exited := j.StartedAt(), j.ExitedAt()
+		if !started.IsZero() && !exited.IsZero() {
+			durationSum += exited.Sub(started).Seconds()
+			durationCount++
+		}
+	}
+
+	b := bufio.NewWriter(w)
+	fmt.Fprintln(b, "# HELP jogger_jobs Number of jobs kept by the server, by status.")
+	fmt.Fprintln(b, "# TYPE jogger_jobs gauge")
+	for i := int32(1); i < int32(len(jogv1.Status_name)); i++ {
+		status := jogv1.Status(i)
+		fmt.Fprintf(b, "jogger_jobs{status=%q} %d\n", strings.ToLower(status.String()), counts[status])
+	}
+	fmt.Fprintln(b, "# HELP jogger_job_duration_seconds How long the done jobs kept by the server ran.")
+	fmt.Fprintln(b, "# TYPE jogger_job_duration_seconds summary")
+	fmt.Fprintf(b, "jogger_job_duration_seconds_sum %g\n", durationSum)
+	fmt.Fprintf(b, "jogger_job_duration_seconds_count %d\n", durationCount)
+	fmt.Fprintln(b, "# HELP jogger_output_streams Number of live output streams across all jobs.")
+	fmt.Fprintln(b, "# TYPE jogger_output_streams gauge")
+	fmt.Fprintf(b, "jogger_output_streams %d\n", m.ActiveStreams())
+	if err := b.Flush(); err != nil {
+		return fmt.Errorf("writing metrics: %w", err)
+	}
+	return nil
+}
+
+// WriteMetricsFile writes the manager's job metrics to path, see WriteMetrics, for
+// node_exporter's textfile collector. The metrics are written to a temporary file in the
+// same directory, and renamed to path, so the collector never reads a partial file. The
+// temporary file doesn't end in .prom, so the collector ignores it.
+func (m *Manager) WriteMetricsFile(path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("writing metrics file: %w", err)
+	}
+	// the rename below moves the file, after which this fails harmlessly
+	defer os.Remove(tmp.Name())
+
+	if err := m.WriteMetrics(tmp); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writing metrics file: %w", err)
+	}
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writing metrics file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("writing metrics file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("writing metrics file: %w", err)
+	}
+	return nil
+}
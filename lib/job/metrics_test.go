@@ -0,0 +1,129 @@
+package job
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	// metricComment matches the HELP and TYPE lines of the text exposition format
+	metricComment = regexp.MustCompile(`^# (HELP [a-zA-Z_:][a-zA-Z0-9_:]* .*|TYPE [a-zA-Z_:][a-zA-Z0-9_:]* (counter|gauge|histogram|summary|untyped))$`)
+	// metricSample matches a sample line, the name and its labels, then the value
+	metricSample = regexp.MustCompile(`^([a-zA-Z_:][a-zA-Z0-9_:]*(?:\{[a-zA-Z_][a-zA-Z0-9_]*="(?:[^"\\]|\\.)*"(?:,[a-zA-Z_][a-zA-Z0-9_]*="(?:[^"\\]|\\.)*")*\})?) (\S+)$`)
+)
+
+// parseMetrics parses the text exposition format, and returns the samples' values by their
+// name and labels, e.g. jogger_jobs{status="running"}
+func parseMetrics(t *testing.T, text string) map[string]float64 {
+	t.Helper()
+	samples := make(map[string]float64)
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "#") {
+			if !metricComment.MatchString(line) {
+				t.Fatalf("invalid comment line: %q", line)
+			}
+			continue
+		}
+		match := metricSample.FindStringSubmatch(line)
+		if match == nil {
+			t.Fatalf("invalid sample line: %q", line)
+		}
+		value, err := strconv.ParseFloat(match[2], 64)
+		if err != nil {
+			t.Fatalf("invalid sample value: %q: %v", line, err)
+		}
+		if _, ok := samples[match[1]]; ok {
+			t.Fatalf("duplicate sample: %q", line)
+		}
+		samples[match[1]] = value
+	}
+	if !strings.HasSuffix(text, "\n") {
+		t.Fatalf("expected the metrics to end with a newline")
+	}
+	return samples
+}
+
+func TestManagerWriteMetricsFile(t *testing.T) {
+	t.Parallel()
+
+	m := NewManager(context.Background(), WithFailedStartTTL(time.Minute))
+	m.cgroupFSManager = noopCgroups{}
+
+	running, err := m.Start(context.Background(), "user1", "sleep", []string{"10"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer m.Stop(context.Background(), "user1", running)
+	for _, cmd := range []string{"true", "false"} {
+		jobID, err := m.Start(context.Background(), "user1", cmd, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		j, _ := m.getJob("user1", jobID)
+		j.Wait()
+	}
+	if _, err := m.Start(context.Background(), "user1", "/does/not/exist", nil); err == nil {
+		t.Fatal("expected an error starting a missing command")
+	}
+
+	path := filepath.Join(t.TempDir(), "jogger.prom")
+	if err := m.WriteMetricsFile(path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	text, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	samples := parseMetrics(t, string(text))
+
+	want := map[string]float64{
+		`jogger_jobs{status="running"}`:      1,
+		`jogger_jobs{status="stopped"}`:      0,
+		`jogger_jobs{status="killed"}`:       0,
+		`jogger_jobs{status="failed"}`:       1,
+		`jogger_jobs{status="completed"}`:    1,
+		`jogger_jobs{status="start_failed"}`: 1,
+		`jogger_job_duration_seconds_count`:  2,
+		`jogger_output_streams`:              0,
+	}
+	for name, value := range want {
+		if got, ok := samples[name]; !ok || got != value {
+			t.Fatalf("expected %s %v, got %v in:\n%s", name, value, got, text)
+		}
+	}
+	if sum := samples["jogger_job_duration_seconds_sum"]; sum < 0 || sum > 10 {
+		t.Fatalf("expected the done jobs' durations to sum to a few seconds at most, got %v", sum)
+	}
+
+	// the temporary file is renamed, and a later write replaces the file
+	if err := m.WriteMetricsFile(path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "jogger.prom" {
+		t.Fatalf("expected only the metrics file, got %v", entries)
+	}
+}
+
+func TestManagerWriteMetricsFileMissingDir(t *testing.T) {
+	t.Parallel()
+
+	m := NewManager(context.Background())
+	path := filepath.Join(t.TempDir(), "missing", "jogger.prom")
+	if err := m.WriteMetricsFile(path); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected os.ErrNotExist, got %v", err)
+	}
+}
@@ -0,0 +1,178 @@
+package job
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// Rlimit is a resource limit a job's process can be started with, see WithRlimit. The
+// supported limits are RlimitNoFile and RlimitNProc.
+type Rlimit int
+
+const (
+	// RlimitNoFile is RLIMIT_NOFILE, the most file descriptors each of the job's processes
+	// can have open
+	RlimitNoFile Rlimit = unix.RLIMIT_NOFILE
+	// RlimitNProc is RLIMIT_NPROC, the most processes and threads the job's user can have.
+	// It counts all of the user's processes, not only the job's, and isn't enforced for
+	// root.
+	RlimitNProc Rlimit = unix.RLIMIT_NPROC
+)
+
+// rlimitNames are the names of the supported limits, as the exec helper takes them
+var rlimitNames = map[Rlimit]string{
+	RlimitNoFile: "nofile",
+	RlimitNProc:  "nproc",
+}
+
+func (r Rlimit) String() string {
+	if name, ok := rlimitNames[r]; ok {
+		return name
+	}
+	return "rlimit(" + strconv.Itoa(int(r)) + ")"
+}
+
+// parseRlimit returns the supported limit with the name, e.g. nofile
+func parseRlimit(name string) (Rlimit, bool) {
+	for r, n := range rlimitNames {
+		if n == name {
+			return r, true
+		}
+	}
+	return 0, false
+}
+
+// WithUmask sets the job's file mode creation mask, e.g. 0o022, instead of inheriting the
+// server's
+func WithUmask(mask int) JobOption {
+	return func(cfg *jobConfig) {
+		cfg.umask = mask
+		cfg.umaskSet = true
+	}
+}
+
+// WithRlimit sets both the soft and the hard limit of one of the job's resource limits,
+// instead of inheriting the server's
+func WithRlimit(resource Rlimit, limit uint64) JobOption {
+	return func(cfg *jobConfig) {
+		if cfg.rlimits == nil {
+			cfg.rlimits = make(map[Rlimit]uint64)
+		}
+		cfg.rlimits[resource] = limit
+	}
+}
+
+// ErrInvalidProcessLimit is returned when a job's umask or resource limits can't be set
+var ErrInvalidProcessLimit = errors.New("invalid process limit")
+
+// validateProcessLimits checks the job's umask and resource limits. The umask is 0 to 0777.
+// Each limit is at least 1, and at most the server's own hard limit, which the job's process
+// couldn't raise its limit over without privileges.
+func validateProcessLimits(cfg jobConfig) error {
+	if cfg.umaskSet && (cfg.umask < 0 || cfg.umask > 0o777) {
+		return fmt.Errorf("%w: the umask %#o isn't 0 to 0777", ErrInvalidProcessLimit, cfg.umask)
+	}
+	for resource, limit := range cfg.rlimits {
+		if _, ok := rlimitNames[resource]; !ok {
+			return fmt.Errorf("%w: %s isn't supported, use nofile or nproc", ErrInvalidProcessLimit, resource)
+		}
+		if limit < 1 {
+			return fmt.Errorf("%w: the %s limit must be at least 1", ErrInvalidProcessLimit, resource)
+		}
+		var server unix.Rlimit
+		if err := unix.Getrlimit(int(resource), &server); err != nil {
+			return fmt.Errorf("reading the server's %s limit: %w", resource, err)
+		}
+		if limit > server.Max {
+			return fmt.Errorf("%w: the %s limit %d is over the server's limit of %d", ErrInvalidProcessLimit, resource, limit, server.Max)
+		}
+	}
+	return nil
+}
+
+// execHelperName is the argv[0] of the server's binary when it's run as the exec helper, see
+// RunExecHelper
+const execHelperName = "jogger-exec"
+
+// execHelperPath is the path the exec helper is run from: the running server's binary, even
+// if the file was replaced since it started
+const execHelperPath = "/proc/self/exe"
+
+// RunExecHelper runs the exec helper, and doesn't return, when the process was started as
+// one. Otherwise it returns right away. Binaries that start jobs with WithUmask or WithRlimit
+// must call it first thing in main.
+//
+// Go can't change a child process's umask or resource limits between fork and exec, so jobs
+// with them are started by running the server's binary as a helper, which sets them on
+// itself and then execs the job's command in its place. The command keeps the helper's pid,
+// cgroup, terminal, and output. If the helper fails, it writes why to the job's stderr and
+// exits with 127, so the job fails rather than failing to start.
+func RunExecHelper() {
+	if len(os.Args) == 0 || os.Args[0] != execHelperName {
+		return
+	}
+	err := execWithLimits(os.Args[1:])
+	fmt.Fprintf(os.Stderr, "jogger: starting job: %v\n", err)
+	os.Exit(127)
+}
+
+// execHelperArgs returns the exec helper's arguments for running path with args under the
+// job's umask and resource limits, e.g.
+//
+//	jogger-exec umask=22 nofile=4096 -- /usr/bin/make test
+func execHelperArgs(cfg jobConfig, path string, args []string) []string {
+	helperArgs := []string{execHelperName}
+	if cfg.umaskSet {
+		helperArgs = append(helperArgs, "umask="+strconv.FormatInt(int64(cfg.umask), 8))
+	}
+	// sorted, so the arguments are the same for the same limits
+	resources := make([]Rlimit, 0, len(cfg.rlimits))
+	for resource := range cfg.rlimits {
+		resources = append(resources, resource)
+	}
+	sort.Slice(resources, func(i, k int) bool { return resources[i] < resources[k] })
+	for _, resource := range resources {
+		helperArgs = append(helperArgs, resource.String()+"="+strconv.FormatUint(cfg.rlimits[resource], 10))
+	}
+	return append(append(helperArgs, "--", path), args...)
+}
+
+// execWithLimits sets the umask and resource limits in the exec helper's arguments on the
+// process, then execs the command after the --. It only returns if that fails.
+func execWithLimits(args []string) error {
+	for len(args) > 0 && args[0] != "--" {
+		key, value, _ := strings.Cut(args[0], "=")
+		args = args[1:]
+		if key == "umask" {
+			mask, err := strconv.ParseUint(value, 8, 32)
+			if err != nil {
+				return fmt.Errorf("parsing umask: %w", err)
+			}
+			unix.Umask(int(mask))
+			continue
+		}
+		resource, ok := parseRlimit(key)
+		if !ok {
+			return fmt.Errorf("unsupported limit: %s", key)
+		}
+		limit, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("parsing %s limit: %w", key, err)
+		}
+		// setting the file descriptor limit also stops Exec from restoring the one the Go
+		// runtime started with
+		if err := unix.Setrlimit(int(resource), &unix.Rlimit{Cur: limit, Max: limit}); err != nil {
+			return fmt.Errorf("setting %s limit: %w", key, err)
+		}
+	}
+	if len(args) < 2 {
+		return fmt.Errorf("no command to run")
+	}
+	return unix.Exec(args[1], args[1:], os.Environ())
+}
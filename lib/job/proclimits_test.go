@@ -0,0 +1,116 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	jogv1 "github.com/dustinevan/jogger/pkg/gen/jogger/v1"
+	"golang.org/x/sys/unix"
+)
+
+// procLimit returns the soft and hard limit in the /proc/<pid>/limits line starting with name
+func procLimit(t *testing.T, limits, name string) (string, string) {
+	t.Helper()
+	for _, line := range strings.Split(limits, "\n") {
+		if strings.HasPrefix(line, name) {
+			fields := strings.Fields(line)
+			return fields[len(fields)-3], fields[len(fields)-2]
+		}
+	}
+	t.Fatalf("no %q line in:\n%s", name, limits)
+	return "", ""
+}
+
+func TestJobProcessLimits(t *testing.T) {
+	t.Parallel()
+
+	// the server's hard limit is the highest the job can be given without privileges
+	var server unix.Rlimit
+	if err := unix.Getrlimit(unix.RLIMIT_NPROC, &server); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	nproc := min(server.Max, 1<<20)
+
+	j, err := StartNewJob(context.Background(), -1, "sh", []string{"-c", "umask; cat /proc/self/limits"},
+		WithUmask(0o027), WithRlimit(RlimitNoFile, 512), WithRlimit(RlimitNProc, nproc))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := string(readAll(t, j.OutputStream(context.Background(), jogv1.StreamSelector_STREAM_SELECTOR_BOTH), 5*time.Second))
+	waitForJob(t, j, 5*time.Second)
+	if code := j.ExitCode(); code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, out)
+	}
+	// the job runs the command, not the exec helper
+	if !strings.HasSuffix(j.CommandPath(), "/sh") {
+		t.Fatalf("expected the command path of sh, got %q", j.CommandPath())
+	}
+
+	umask, limits, _ := strings.Cut(out, "\n")
+	if umask != "0027" {
+		t.Fatalf("expected umask 0027, got %q", umask)
+	}
+	if soft, hard := procLimit(t, limits, "Max open files"); soft != "512" || hard != "512" {
+		t.Fatalf("expected an open files limit of 512, got %s and %s", soft, hard)
+	}
+	want := "unlimited"
+	if nproc != unix.RLIM_INFINITY {
+		want = strconv.FormatUint(nproc, 10)
+	}
+	if soft, hard := procLimit(t, limits, "Max processes"); soft != want || hard != want {
+		t.Fatalf("expected a processes limit of %s, got %s and %s", want, soft, hard)
+	}
+}
+
+func TestExecHelperArgs(t *testing.T) {
+	t.Parallel()
+
+	cfg := applyJobOptions([]JobOption{WithRlimit(RlimitNProc, 64), WithUmask(0o022), WithRlimit(RlimitNoFile, 4096)})
+	got := strings.Join(execHelperArgs(cfg, "/usr/bin/make", []string{"-j", "4"}), " ")
+	want := "jogger-exec umask=22 nproc=64 nofile=4096 -- /usr/bin/make -j 4"
+	if got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestValidateProcessLimits(t *testing.T) {
+	t.Parallel()
+
+	// the file descriptor limit is never unlimited, it's at most fs.nr_open
+	var server unix.Rlimit
+	if err := unix.Getrlimit(unix.RLIMIT_NOFILE, &server); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tests := []struct {
+		name    string
+		options []JobOption
+		wantErr bool
+	}{
+		{name: "no limits"},
+		{name: "umask and limits", options: []JobOption{WithUmask(0o077), WithRlimit(RlimitNoFile, 1024), WithRlimit(RlimitNProc, 1)}},
+		{name: "umask of 0", options: []JobOption{WithUmask(0)}},
+		{name: "umask too large", options: []JobOption{WithUmask(0o1000)}, wantErr: true},
+		{name: "negative umask", options: []JobOption{WithUmask(-1)}, wantErr: true},
+		{name: "limit of 0", options: []JobOption{WithRlimit(RlimitNoFile, 0)}, wantErr: true},
+		{name: "over the server's limit", options: []JobOption{WithRlimit(RlimitNoFile, server.Max+1)}, wantErr: true},
+		{name: "unsupported limit", options: []JobOption{WithRlimit(Rlimit(unix.RLIMIT_CORE), 1)}, wantErr: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := validateProcessLimits(applyJobOptions(tt.options))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidProcessLimit) {
+				t.Fatalf("expected ErrInvalidProcessLimit, got %v", err)
+			}
+		})
+	}
+}
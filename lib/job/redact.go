@@ -0,0 +1,105 @@
+package job
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// RedactionMask replaces each match of a job's redaction patterns in its output
+const RedactionMask = "***"
+
+// redactLookback is the most output after the last newline that's held back from the job's
+// output while redaction waits to see if it's the start of a match, see WithRedaction
+const redactLookback = 256
+
+// ErrInvalidRedaction is returned when a job's redaction patterns can't be used
+var ErrInvalidRedaction = errors.New("invalid redaction pattern")
+
+// WithOutputRedaction masks secrets in the job's output: each of the literals, and each match of
+// the patterns, which are regular expressions, is replaced with RedactionMask before the output
+// is kept or streamed. It's best-effort, see WithRedaction. It can be used more than once, e.g.
+// by the server and by the client that starts the job, see WithJobOutputRedaction.
+func WithOutputRedaction(literals, patterns []string) JobOption {
+	return func(cfg *jobConfig) {
+		for _, l := range literals {
+			cfg.redact = append(cfg.redact, regexp.QuoteMeta(l))
+		}
+		cfg.redact = append(cfg.redact, patterns...)
+	}
+}
+
+// CompileRedaction returns a regular expression that matches any of the literals, or any of
+// the patterns, for WithRedaction. It's nil when there are none. Patterns that match the empty
+// string, like an empty literal, aren't allowed, since they'd mask between every byte.
+func CompileRedaction(literals, patterns []string) (*regexp.Regexp, error) {
+	var cfg jobConfig
+	WithOutputRedaction(literals, patterns)(&cfg)
+	return compileRedaction(cfg.redact)
+}
+
+// compileRedaction returns a regular expression that matches any of the patterns, or nil
+func compileRedaction(patterns []string) (*regexp.Regexp, error) {
+	if len(patterns) == 0 {
+		return nil, nil
+	}
+	alternatives := make([]string, len(patterns))
+	for i, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidRedaction, err)
+		}
+		if re.MatchString("") {
+			return nil, fmt.Errorf("%w: %q matches empty output", ErrInvalidRedaction, p)
+		}
+		alternatives[i] = "(?:" + p + ")"
+	}
+	return regexp.Compile(strings.Join(alternatives, "|"))
+}
+
+// redactor masks the matches of a pattern in output written in pieces, see WithRedaction
+type redactor struct {
+	pattern *regexp.Regexp
+	// pending is the end of the output written so far, which isn't masked and released yet
+	pending []byte
+}
+
+// redact masks the matches in the output pending before b and b, and returns the output
+// that's ready. Output after the last newline is held back, up to redactLookback bytes, since
+// it may be the start of a match the next write completes. A match that runs into the held
+// back output is released whole.
+func (r *redactor) redact(b []byte) []byte {
+	output := b
+	if len(r.pending) > 0 {
+		output = append(r.pending, b...)
+	}
+	cut := len(output) - redactLookback
+	if i := bytes.LastIndexByte(output, '\n'); i+1 > cut {
+		cut = i + 1
+	}
+	if cut <= 0 {
+		r.pending = append(r.pending[:0], output...)
+		return nil
+	}
+	for _, loc := range r.pattern.FindAllIndex(output, -1) {
+		if loc[0] < cut && loc[1] > cut {
+			cut = loc[1]
+		}
+	}
+	ready := r.pattern.ReplaceAllLiteral(output[:cut], []byte(RedactionMask))
+	r.pending = append(r.pending[:0], output[cut:]...)
+	return ready
+}
+
+// flush masks the matches in the output that's held back and returns it, for when there's no
+// more output
+func (r *redactor) flush() []byte {
+	if len(r.pending) == 0 {
+		return nil
+	}
+	ready := r.pattern.ReplaceAllLiteral(r.pending, []byte(RedactionMask))
+	r.pending = nil
+	return ready
+}
@@ -0,0 +1,190 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	jogv1 "github.com/dustinevan/jogger/pkg/gen/jogger/v1"
+)
+
+func TestOutputStreamerRedaction(t *testing.T) {
+	t.Parallel()
+
+	long := strings.Repeat("x", 2*redactLookback)
+	tests := []struct {
+		name     string
+		literals []string
+		patterns []string
+		writes   []string
+		want     string
+	}{
+		{
+			name:     "literal",
+			literals: []string{"hunter2"},
+			writes:   []string{"password: hunter2\n", "again hunter2 and hunter2\n"},
+			want:     "password: ***\nagain *** and ***\n",
+		},
+		{
+			name:     "secret written across two writes",
+			literals: []string{"s3cr3t-t0k3n"},
+			writes:   []string{"token=s3cr", "3t-t0k3n\n"},
+			want:     "token=***\n",
+		},
+		{
+			name:     "secret written a byte at a time",
+			literals: []string{"hunter2"},
+			writes:   strings.Split("login hunter2 done", ""),
+			want:     "login *** done",
+		},
+		{
+			name:     "literals aren't patterns",
+			literals: []string{"a.b"},
+			writes:   []string{"axb a.b\n"},
+			want:     "axb ***\n",
+		},
+		{
+			name:     "pattern",
+			patterns: []string{`ghp_[A-Za-z0-9]{8}`},
+			writes:   []string{"using ghp_", "abcd1234 and ghp_zz\n"},
+			want:     "using *** and ghp_zz\n",
+		},
+		{
+			name:     "secret after a long line",
+			literals: []string{"hunter2"},
+			writes:   []string{long + "hun", "ter2" + long},
+			want:     long + "***" + long,
+		},
+		{
+			name:     "secret over the held back output",
+			patterns: []string{`x+`},
+			writes:   []string{long, "\n"},
+			want:     "***\n",
+		},
+		{
+			name:   "no patterns",
+			writes: []string{"password: hunter2\n"},
+			want:   "password: hunter2\n",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			pattern, err := CompileRedaction(tt.literals, tt.patterns)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			sink := &syncBuffer{}
+			o := NewOutputStreamer(WithRedaction(pattern), WithSink(sink))
+			for _, w := range tt.writes {
+				if n, err := o.Write([]byte(w)); err != nil || n != len(w) {
+					t.Fatalf("expected to write %d bytes, wrote %d: %v", len(w), n, err)
+				}
+			}
+			o.CloseWriter()
+			o.waitForSinks()
+
+			if got := readAll(t, o.NewStream(context.Background()), time.Second); string(got) != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, got)
+			}
+			if got := sink.String(); got != tt.want {
+				t.Fatalf("expected the sink to receive %q, got %q", tt.want, got)
+			}
+			if got := o.Len(); got != int64(len(tt.want)) {
+				t.Fatalf("expected a length of %d, got %d", len(tt.want), got)
+			}
+		})
+	}
+}
+
+func TestOutputStreamerRedactionHoldsBackPartialLine(t *testing.T) {
+	t.Parallel()
+
+	pattern, err := CompileRedaction([]string{"hunter2"}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	o := NewOutputStreamer(WithRedaction(pattern))
+	stream := o.NewStream(context.Background())
+
+	// complete lines are released right away, the rest of the line waits for more output
+	o.Write([]byte("logging in\npassword: hun"))
+	if got := readN(t, stream, len("logging in\n")); string(got) != "logging in\n" {
+		t.Fatalf("expected %q, got %q", "logging in\n", got)
+	}
+	if got := o.Len(); got != int64(len("logging in\n")) {
+		t.Fatalf("expected the partial line to be held back, got a length of %d", got)
+	}
+	o.Write([]byte("ter2"))
+	o.CloseWriter()
+	if got := readAll(t, stream, time.Second); string(got) != "password: ***" {
+		t.Fatalf("expected %q, got %q", "password: ***", got)
+	}
+}
+
+func TestCompileRedaction(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		literals []string
+		patterns []string
+		wantNil  bool
+		wantErr  bool
+	}{
+		{name: "none", wantNil: true},
+		{name: "literals and patterns", literals: []string{"hunter2", "(a+"}, patterns: []string{`AKIA[0-9A-Z]{16}`}},
+		{name: "empty literal", literals: []string{""}, wantErr: true},
+		{name: "invalid pattern", patterns: []string{"(a+"}, wantErr: true},
+		{name: "pattern that matches empty output", patterns: []string{"a*"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			pattern, err := CompileRedaction(tt.literals, tt.patterns)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if err != nil {
+				if !errors.Is(err, ErrInvalidRedaction) {
+					t.Fatalf("expected ErrInvalidRedaction, got %v", err)
+				}
+				return
+			}
+			if (pattern == nil) != tt.wantNil {
+				t.Fatalf("expected a nil pattern %v, got %v", tt.wantNil, pattern)
+			}
+		})
+	}
+}
+
+func TestManagerStartRedaction(t *testing.T) {
+	t.Parallel()
+
+	m := NewManager(context.Background(), WithJobOutputRedaction([]string{"server-secret"}, nil))
+	m.cgroupFSManager = noopCgroups{}
+
+	jobID, err := m.Start(context.Background(), "user1", "sh", []string{"-c", "echo server-secret; printf client-; printf secret"},
+		WithOutputRedaction([]string{"client-secret"}, nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	j, err := m.getJob("user1", jobID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := readAll(t, j.OutputStream(context.Background(), jogv1.StreamSelector_STREAM_SELECTOR_BOTH), 5*time.Second); string(got) != "***\n***" {
+		t.Fatalf("expected both secrets to be masked, got %q", got)
+	}
+
+	_, err = m.Start(context.Background(), "user1", "true", nil, WithOutputRedaction(nil, []string{"(a+"}))
+	if !errors.Is(err, ErrInvalidRedaction) {
+		t.Fatalf("expected ErrInvalidRedaction, got %v", err)
+	}
+}
@@ -0,0 +1,29 @@
+package job
+
+import "strings"
+
+// shellPath is the shell jobs started WithShell run under. It's an absolute path, so the
+// job's PATH can't choose a different shell.
+const shellPath = "/bin/sh"
+
+// shellCommandLine builds the command line a shell job's shell runs. The command is passed
+// to the shell as is, so it can use variables, pipes, and &&. Each argument is quoted, so
+// the shell passes it to the command literally, e.g. an argument of $HOME isn't expanded.
+func shellCommandLine(name string, args []string) string {
+	var sb strings.Builder
+	sb.WriteString(name)
+	for _, arg := range args {
+		sb.WriteByte(' ')
+		sb.WriteString(shellQuote(arg))
+	}
+	return sb.String()
+}
+
+// shellQuote quotes s so a POSIX shell reads it as a single literal word. Nothing inside
+// single quotes is special, so s is wrapped in them, and each single quote in s ends the
+// quoted string, adds an escaped quote, and starts a new one, e.g. it's becomes
+//
+//	'it'\''s'
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
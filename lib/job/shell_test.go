@@ -0,0 +1,71 @@
+package job
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	jogv1 "github.com/dustinevan/jogger/pkg/gen/jogger/v1"
+)
+
+func TestShellCommandLine(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		command string
+		args    []string
+		want    string
+	}{
+		{name: "command only", command: "echo $HOME && ls", want: "echo $HOME && ls"},
+		{name: "plain arguments", command: "echo", args: []string{"a", "b"}, want: "echo 'a' 'b'"},
+		{name: "empty argument", command: "printf %s", args: []string{""}, want: "printf %s ''"},
+		{name: "single quote", command: "echo", args: []string{"it's"}, want: `echo 'it'\''s'`},
+		{name: "shell syntax", command: "echo", args: []string{"$HOME; rm -rf / `id` \"x\""}, want: "echo '$HOME; rm -rf / `id` \"x\"'"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := shellCommandLine(tt.command, tt.args); got != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestJobShell(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		command string
+		args    []string
+		want    string
+	}{
+		{name: "variables and operators", command: "X=shell; echo $X && echo ok", want: "shell\nok\n"},
+		{name: "pipes", command: "printf 'b\\na\\n' | sort", want: "a\nb\n"},
+		{name: "arguments are literal", command: "printf '%s|'", args: []string{"$HOME", "it's", "a b", "`id`", ""}, want: "$HOME|it's|a b|`id`||"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			j, err := StartNewJob(context.Background(), -1, tt.command, tt.args, WithShell())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			waitForJob(t, j, 5*time.Second)
+			if status := j.Status(); status != jogv1.Status_COMPLETED {
+				t.Fatalf("expected status %v, got %v", jogv1.Status_COMPLETED, status)
+			}
+			if out := readAll(t, j.OutputStream(context.Background(), jogv1.StreamSelector_STREAM_SELECTOR_BOTH), 5*time.Second); string(out) != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, out)
+			}
+		})
+	}
+}
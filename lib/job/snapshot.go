@@ -0,0 +1,203 @@
+package job
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Uploader stores objects, e.g. in an S3-compatible bucket, see objstore.S3
+type Uploader interface {
+	Upload(ctx context.Context, key string, data []byte) error
+}
+
+const (
+	// snapshotAttempts is how many times each snapshot upload is tried
+	snapshotAttempts = 3
+	// snapshotTimeout bounds each upload attempt
+	snapshotTimeout = 30 * time.Second
+	// snapshotPartSize is the most output uploaded as each object
+	snapshotPartSize = 8 << 20
+)
+
+// snapshotter uploads the output of a job every interval while the job runs, and once more
+// when it's closed. Uploads run on their own goroutine, so a slow or failing object store
+// never blocks the job's writes. Failed uploads are retried with backoff, and then left for
+// the next interval.
+//
+// The output is read from the job's OutputStreamer, from where the last upload ended, and
+// uploaded in parts of up to partSize bytes, each to the object key/<offset>, where offset
+// is the offset of its first byte, zero-padded so the objects sort in the order of the
+// output. Each upload replaces the last, growing part, until it's full. Only one part is
+// held in memory at a time. Output discarded because of WithMaxOutputBytes before it's
+// uploaded is missing, the next part starts at the oldest output that's kept.
+type snapshotter struct {
+	output     *OutputStreamer
+	uploader   Uploader
+	key        string
+	interval   time.Duration
+	retryDelay time.Duration
+	partSize   int
+	onErr      func(error)
+
+	// part holds the output being uploaded. It's reused for each upload, and released once
+	// the snapshotter is closed. The fields below are only used by upload, which isn't called
+	// concurrently: run calls it, then Close once run has returned.
+	part []byte
+	// start is the offset of the part that's uploaded next, and end is where its output ended
+	// in the last successful upload
+	start int64
+	end   int64
+	// attempted is set once an upload has been tried, so a job without output gets an empty object
+	attempted bool
+
+	done    chan struct{}
+	stopped chan struct{}
+	once    sync.Once
+}
+
+// newSnapshotter starts uploading snapshots of output to objects under key
+func newSnapshotter(output *OutputStreamer, uploader Uploader, key string, interval time.Duration, onErr func(error)) *snapshotter {
+	s := &snapshotter{
+		output:     output,
+		uploader:   uploader,
+		key:        key,
+		interval:   interval,
+		retryDelay: time.Second,
+		partSize:   snapshotPartSize,
+		onErr:      onErr,
+		done:       make(chan struct{}),
+		stopped:    make(chan struct{}),
+	}
+	// the output is read until the final upload, so its spill file isn't closed before then
+	output.addReader()
+	go s.run()
+	return s
+}
+
+// run uploads a snapshot every interval until the snapshotter is closed. With an interval <= 0,
+// the output is only uploaded when it's closed.
+func (s *snapshotter) run() {
+	defer close(s.stopped)
+	if s.interval <= 0 {
+		<-s.done
+		return
+	}
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			s.upload(false)
+		}
+	}
+}
+
+// upload uploads the output written since the last upload, one part at a time. Until the
+// final upload, empty output isn't uploaded, and retries stop early when the snapshotter is closed,
+// so a pending retry doesn't hold up the final upload.
+func (s *snapshotter) upload(final bool) {
+	for {
+		if s.output.Len() == s.end && (s.attempted || !final) {
+			return
+		}
+		s.attempted = true
+		start, err := s.readPart()
+		if err != nil {
+			s.onErr(fmt.Errorf("reading output snapshot: %w", err))
+			return
+		}
+		if !s.uploadPart(fmt.Sprintf("%s/%020d", s.key, start), final) {
+			return
+		}
+		s.start, s.end = start, start+int64(len(s.part))
+		if len(s.part) < s.partSize {
+			return
+		}
+		// the part is full, the output after it goes in the next one
+		s.start = s.end
+	}
+}
+
+// readPart reads the output of the part that's uploaded next into part, and returns the
+// offset it starts at. That's later than the part's start if the output there was discarded.
+func (s *snapshotter) readPart() (int64, error) {
+	if s.part == nil {
+		s.part = make([]byte, 0, s.partSize)
+	}
+	s.part = s.part[:0]
+	start := s.start
+	for len(s.part) < s.partSize {
+		index := int(start) + len(s.part)
+		data, next, err := s.output.next(index, s.partSize-len(s.part))
+		if err != nil {
+			return 0, err
+		}
+		if len(data) == 0 {
+			break
+		}
+		if next != index {
+			// the output was discarded, the part starts at the oldest output that's kept
+			s.part = s.part[:0]
+			start = int64(next)
+		}
+		s.part = append(s.part, data...)
+	}
+	return start, nil
+}
+
+// uploadPart uploads part as the object key, and reports whether it succeeded
+func (s *snapshotter) uploadPart(key string, final bool) bool {
+	cancel := s.done
+	if final {
+		cancel = nil
+	}
+	delay := s.retryDelay
+	for attempt := 1; ; attempt++ {
+		ctx, cancelCtx := context.WithTimeout(context.Background(), snapshotTimeout)
+		err := s.uploader.Upload(ctx, key, s.part)
+		cancelCtx()
+		if err == nil {
+			return true
+		}
+		if attempt == snapshotAttempts {
+			s.onErr(fmt.Errorf("uploading output snapshot after %d attempts: %w", attempt, err))
+			return false
+		}
+		select {
+		case <-cancel:
+			s.onErr(fmt.Errorf("uploading output snapshot: %w", err))
+			return false
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// Close stops the periodic uploads, uploads the final output, and releases the part. It blocks until the upload
+// succeeds, or fails after its retries, so it should be called after the job is done.
+func (s *snapshotter) Close() error {
+	s.once.Do(func() {
+		s.stop()
+		s.upload(true)
+		s.part = nil
+		s.output.removeReader()
+	})
+	return nil
+}
+
+// abort stops the periodic uploads without a final upload, for jobs that weren't started
+func (s *snapshotter) abort() {
+	s.once.Do(func() {
+		s.stop()
+		s.output.removeReader()
+	})
+}
+
+func (s *snapshotter) stop() {
+	close(s.done)
+	<-s.stopped
+}
@@ -0,0 +1,280 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dustinevan/jogger/lib/objstore"
+)
+
+// fakeObjectStore is an S3-compatible server that keeps every version of the objects PUT to it
+type fakeObjectStore struct {
+	mu       sync.Mutex
+	versions map[string][]string
+}
+
+func (f *fakeObjectStore) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, _ := io.ReadAll(r.Body)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.versions[r.URL.Path] = append(f.versions[r.URL.Path], string(body))
+}
+
+func (f *fakeObjectStore) objectVersions(path string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.versions[path]...)
+}
+
+func TestJobOutputSnapshot(t *testing.T) {
+	t.Parallel()
+
+	store := &fakeObjectStore{versions: make(map[string][]string)}
+	server := httptest.NewServer(store)
+	t.Cleanup(server.Close)
+	s3, err := objstore.NewS3(server.URL, "jobs", objstore.WithCredentials("AKID", "secret"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the job writes binary output, then waits long enough for a snapshot to be uploaded
+	j, err := StartNewJob(context.Background(), -1, "sh", []string{"-c", `printf 'first\000\n'; sleep 0.5; printf 'second\n'`},
+		WithOutputSnapshot(s3, "user1/123", 50*time.Millisecond, nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	j.Wait()
+
+	// the final upload happens after the job is done
+	const want = "first\x00\nsecond\n"
+	deadline := time.Now().Add(5 * time.Second)
+	var versions []string
+	for {
+		versions = store.objectVersions("/jobs/user1/123/00000000000000000000")
+		if len(versions) > 0 && versions[len(versions)-1] == want {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the final object to be %q, got versions %q", want, versions)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !slices.Contains(versions, "first\x00\n") {
+		t.Fatalf("expected a snapshot of the output while the job ran, got versions %q", versions)
+	}
+}
+
+// fakeUploader fails the first fail uploads, and records the keys and data of the ones that succeed
+type fakeUploader struct {
+	mu       sync.Mutex
+	fail     int
+	attempts int
+	keys     []string
+	uploaded []string
+}
+
+func (f *fakeUploader) Upload(_ context.Context, key string, data []byte) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.attempts++
+	if f.fail > 0 {
+		f.fail--
+		return errors.New("503 Service Unavailable")
+	}
+	f.keys = append(f.keys, key)
+	f.uploaded = append(f.uploaded, string(data))
+	return nil
+}
+
+// objects returns the last upload of each key, in the order of the keys
+func (f *fakeUploader) objects() (keys []string, data string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	latest := make(map[string]string)
+	for i, key := range f.keys {
+		latest[key] = f.uploaded[i]
+	}
+	for key := range latest {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	var b strings.Builder
+	for _, key := range keys {
+		b.WriteString(latest[key])
+	}
+	return keys, b.String()
+}
+
+// newTestSnapshotter returns a snapshotter of the output written to o, whose interval is long
+// enough that it only uploads when it's told to
+func newTestSnapshotter(o *OutputStreamer, uploader Uploader, onErr func(error)) *snapshotter {
+	if onErr == nil {
+		onErr = func(error) {}
+	}
+	s := newSnapshotter(o, uploader, "user1/123", time.Hour, onErr)
+	s.retryDelay = time.Millisecond
+	return s
+}
+
+func TestSnapshotterRetries(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		fail         int
+		wantAttempts int
+		wantUploaded []string
+		wantErrs     int
+	}{
+		{name: "first attempt succeeds", wantAttempts: 1, wantUploaded: []string{"output"}},
+		{name: "retried until it succeeds", fail: 2, wantAttempts: 3, wantUploaded: []string{"output"}},
+		{name: "gives up after the last attempt", fail: 10, wantAttempts: snapshotAttempts, wantErrs: 1},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			uploader := &fakeUploader{fail: tt.fail}
+			var errs []error
+			o := NewOutputStreamer()
+			o.Write([]byte("out"))
+			o.Write([]byte("put"))
+			o.CloseWriter()
+			s := newTestSnapshotter(o, uploader, func(err error) { errs = append(errs, err) })
+			s.Close()
+
+			if uploader.attempts != tt.wantAttempts {
+				t.Fatalf("expected %d attempts, got %d", tt.wantAttempts, uploader.attempts)
+			}
+			if strings.Join(uploader.uploaded, ",") != strings.Join(tt.wantUploaded, ",") {
+				t.Fatalf("expected uploads %q, got %q", tt.wantUploaded, uploader.uploaded)
+			}
+			if len(errs) != tt.wantErrs {
+				t.Fatalf("expected %d errors, got %v", tt.wantErrs, errs)
+			}
+		})
+	}
+}
+
+func TestSnapshotterSkipsUnchangedOutput(t *testing.T) {
+	t.Parallel()
+
+	uploader := &fakeUploader{}
+	o := NewOutputStreamer()
+	s := newTestSnapshotter(o, uploader, nil)
+	o.Write([]byte("output"))
+	s.upload(false)
+	s.upload(false)
+	s.Close()
+	if len(uploader.uploaded) != 1 {
+		t.Fatalf("expected unchanged output to be uploaded once, got %q", uploader.uploaded)
+	}
+
+	// a snapshotter closed without output uploads an empty object
+	uploader = &fakeUploader{}
+	s = newTestSnapshotter(NewOutputStreamer(), uploader, nil)
+	s.upload(false)
+	s.Close()
+	if len(uploader.uploaded) != 1 || uploader.uploaded[0] != "" || uploader.attempts != 1 {
+		t.Fatalf("expected one empty upload, got %q", uploader.uploaded)
+	}
+
+	// an aborted snapshotter doesn't upload
+	uploader = &fakeUploader{}
+	s = newTestSnapshotter(NewOutputStreamer(), uploader, nil)
+	s.abort()
+	s.Close()
+	if uploader.attempts != 0 {
+		t.Fatalf("expected no uploads, got %d", uploader.attempts)
+	}
+}
+
+func TestSnapshotterParts(t *testing.T) {
+	t.Parallel()
+
+	uploader := &fakeUploader{}
+	o := NewOutputStreamer()
+	s := newTestSnapshotter(o, uploader, nil)
+	s.partSize = 4
+
+	// the growing part is uploaded again, full parts aren't
+	o.Write([]byte("ab"))
+	s.upload(false)
+	o.Write([]byte("cdefghi"))
+	s.upload(false)
+	o.CloseWriter()
+	s.Close()
+
+	want := []string{"user1/123/00000000000000000000", "user1/123/00000000000000000000", "user1/123/00000000000000000004", "user1/123/00000000000000000008"}
+	if !slices.Equal(uploader.keys, want) {
+		t.Fatalf("expected uploads to %q, got %q", want, uploader.keys)
+	}
+	if _, data := uploader.objects(); data != "abcdefghi" {
+		t.Fatalf("expected the objects to hold %q, got %q", "abcdefghi", data)
+	}
+}
+
+func TestSnapshotterBoundedMemory(t *testing.T) {
+	t.Parallel()
+
+	const limit, partSize = 1 << 10, 256
+	uploader := &fakeUploader{}
+	o := NewOutputStreamer(WithOutputLimit(limit))
+	s := newTestSnapshotter(o, uploader, nil)
+	s.partSize = partSize
+
+	// far more output than the limit keeps is written, and uploaded as it's written
+	var want strings.Builder
+	for i := 0; want.Len() < 64*limit; i++ {
+		line := fmt.Sprintf("line %d: %s\n", i, strings.Repeat("x", i%50))
+		want.WriteString(line)
+		o.Write([]byte(line))
+		if i%10 == 0 {
+			s.upload(false)
+		}
+		if cap(s.part) > partSize {
+			t.Fatalf("expected at most %d bytes held for the upload, got %d", partSize, cap(s.part))
+		}
+		if len(o.output) > 2*limit {
+			t.Fatalf("expected at most %d bytes of output in memory, got %d", 2*limit, len(o.output))
+		}
+	}
+	o.CloseWriter()
+	s.Close()
+	if s.part != nil {
+		t.Fatal("expected the part to be released once the snapshotter is closed")
+	}
+
+	keys, data := uploader.objects()
+	if data != want.String() {
+		t.Fatalf("expected the objects to hold all %d bytes of output, got %d", want.Len(), len(data))
+	}
+	if len(keys) != (want.Len()+partSize-1)/partSize {
+		t.Fatalf("expected the output in %d byte parts, got %d parts", partSize, len(keys))
+	}
+
+	// output discarded before it's uploaded is missing, the next part starts after it
+	uploader = &fakeUploader{}
+	o = NewOutputStreamer(WithOutputLimit(4))
+	s = newTestSnapshotter(o, uploader, nil)
+	o.Write([]byte("0123456789"))
+	o.CloseWriter()
+	s.Close()
+	if keys, data := uploader.objects(); !slices.Equal(keys, []string{"user1/123/00000000000000000006"}) || data != "6789" {
+		t.Fatalf("expected the kept output at offset 6, got %q: %q", keys, data)
+	}
+}
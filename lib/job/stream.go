@@ -1,11 +1,19 @@
 package job
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
+
+	jogv1 "github.com/dustinevan/jogger/pkg/gen/jogger/v1"
 )
 
 var ErrOutputStreamerClosed = errors.New("output streamer is closed")
@@ -21,6 +29,187 @@ func WithStreamMessageSize(size int) OutputStreamerOption {
 	}
 }
 
+// WithClock sets the function used to timestamp writes. It defaults to time.Now.
+func WithClock(now func() time.Time) OutputStreamerOption {
+	return func(o *OutputStreamer) {
+		o.now = now
+	}
+}
+
+// WithOutputLimit bounds the output kept in memory to the last limit bytes. Earlier output
+// is discarded as new output is written, so streams opened late, or that fall behind, skip
+// ahead to the oldest output still kept. A limit <= 0 keeps all output.
+//
+// Offsets are still counted from the start of the job's output. Len reports every byte
+// written, including discarded ones, and Next and TimeAt don't return data for discarded
+// offsets. Discarded output is released in batches, so up to twice the limit may be held.
+func WithOutputLimit(limit int64) OutputStreamerOption {
+	return func(o *OutputStreamer) {
+		o.limit = max(limit, 0)
+	}
+}
+
+// WithMaxBufferBytes caps the output kept in memory at size bytes. Once it's reached, the older
+// half is spilled to a temporary file and released, and reads of it, see Next, come from the
+// file. Unlike WithOutputLimit, no output is lost. A size <= 0 keeps all output in memory.
+//
+// The file is removed as soon as it's created, so it's never left behind, and its space is
+// freed when the OutputStreamer is released, see Release. If it can't be created or written,
+// the older half is discarded instead, as with WithOutputLimit, and the error is passed to the
+// handler set with WithSpillErrorHandler.
+//
+// Spilled output keeps its write times and output streams at a coarser grain: the writes to
+// the same output stream one after another are merged, and TimeAt returns the time of the
+// first of them.
+func WithMaxBufferBytes(size int64) OutputStreamerOption {
+	return func(o *OutputStreamer) {
+		o.maxBuffer = max(size, 0)
+	}
+}
+
+// WithInitialBufferCapacity preallocates size bytes for the output kept in memory, so the
+// output of chatty jobs isn't copied each time the buffer grows while it's small. It's capped
+// at what the output limit and the buffer cap, see WithOutputLimit and WithMaxBufferBytes,
+// can keep. A size <= 0 starts with an empty buffer.
+func WithInitialBufferCapacity(size int) OutputStreamerOption {
+	return func(o *OutputStreamer) {
+		o.initialCapacity = max(size, 0)
+	}
+}
+
+// WithRedaction masks each match of pattern in the output with RedactionMask before it's kept
+// or streamed, e.g. to keep secrets a job prints out of its output. A nil pattern masks nothing.
+//
+// It's best-effort. A match split across writes is still masked, since the output after the
+// last newline is held back, up to a few hundred bytes, until the rest of its line is written
+// or the writer is closed. Longer matches split across writes, matches that span lines, and
+// secrets the job transforms, e.g. encodes, can get through. Len, offsets, and write times
+// are of the masked output.
+func WithRedaction(pattern *regexp.Regexp) OutputStreamerOption {
+	return func(o *OutputStreamer) {
+		o.redaction = pattern
+	}
+}
+
+// WithSpillErrorHandler sets a function that's called when output can't be spilled, see
+// WithMaxBufferBytes. It's called once, with the lock held, so it mustn't use the
+// OutputStreamer. It defaults to ignoring the error.
+func WithSpillErrorHandler(handle func(err error)) OutputStreamerOption {
+	return func(o *OutputStreamer) {
+		o.spillErr = handle
+	}
+}
+
+// WithSpillDir sets the directory output is spilled to, see WithMaxBufferBytes. It defaults
+// to os.TempDir.
+func WithSpillDir(dir string) OutputStreamerOption {
+	return func(o *OutputStreamer) {
+		o.spillDir = dir
+	}
+}
+
+// StreamOption configures a single stream returned by NewStream
+type StreamOption func(*streamConfig)
+
+// streamConfig is how a stream splits the output into messages
+type streamConfig struct {
+	// size is the most bytes in each message, the streamer's message size when 0
+	size int
+	// lines ends each message at a newline
+	lines bool
+	// offset is where in the output the stream starts
+	offset int64
+	// selector is which of the output streams are read
+	selector jogv1.StreamSelector
+}
+
+// WithChunkSize sets the most bytes sent in each of the stream's messages. It defaults to
+// the streamer's message size, see WithStreamMessageSize.
+func WithChunkSize(size int) StreamOption {
+	return func(c *streamConfig) {
+		if size < 1 {
+			panic("chunk size must be greater than 0")
+		}
+		c.size = size
+	}
+}
+
+// WithLineChunks makes the stream send each line in its own message, once the line's newline
+// has been written. Lines longer than the chunk size are split. A final line without a newline
+// is sent when the writer is closed, or when the stream is drained.
+func WithLineChunks() StreamOption {
+	return func(c *streamConfig) {
+		c.lines = true
+	}
+}
+
+// WithStartOffset starts the stream at offset in the output, instead of at the start, e.g. to
+// resume a stream. Each stream keeps its own position, so streams at different offsets of the
+// same output don't affect each other.
+func WithStartOffset(offset int64) StreamOption {
+	return func(c *streamConfig) {
+		if offset < 0 {
+			panic("start offset must not be negative")
+		}
+		c.offset = offset
+	}
+}
+
+// ErrSinkFull is passed to the sink error handler when output is dropped because a sink
+// has fallen too far behind
+var ErrSinkFull = errors.New("sink buffer is full, output was dropped")
+
+// sinkBufferSize is the number of writes buffered for each sink before output is dropped
+const sinkBufferSize = 256
+
+// WithSelector sets which of the output streams the stream reads, see Writer.
+// STREAM_SELECTOR_STDOUT and STREAM_SELECTOR_STDERR only read that stream's output, and the
+// start offset, see WithStartOffset, counts only its bytes. STREAM_SELECTOR_MERGED reads both,
+// like STREAM_SELECTOR_BOTH, the default, except that each chunk is from one of them. A line
+// interrupted by the other stream's output is chunked in pieces then, even with WithLineChunks.
+func WithSelector(selector jogv1.StreamSelector) StreamOption {
+	return func(c *streamConfig) {
+		c.selector = selector
+	}
+}
+
+// selectedStream returns the output stream a selector reads by itself, false if it reads both
+func selectedStream(selector jogv1.StreamSelector) (jogv1.Stream, bool) {
+	switch selector {
+	case jogv1.StreamSelector_STREAM_SELECTOR_STDOUT:
+		return jogv1.Stream_STDOUT, true
+	case jogv1.StreamSelector_STREAM_SELECTOR_STDERR:
+		return jogv1.Stream_STDERR, true
+	}
+	return jogv1.Stream_STREAM_UNSPECIFIED, false
+}
+
+// Chunk is a message of a stream that's told which output stream its data is from
+type Chunk struct {
+	Data []byte
+	// Stream is the output stream the data is from. It's STREAM_UNSPECIFIED when the stream
+	// reads STREAM_SELECTOR_BOTH, since the data may combine stdout and stderr.
+	Stream jogv1.Stream
+	// Err is set on the last chunk of a stream that ended because its output couldn't be read,
+	// see WithMaxBufferBytes. The chunk has no data.
+	Err error
+}
+
+// WithSink adds a sink when the OutputStreamer is created, see AddSink
+func WithSink(w io.Writer) OutputStreamerOption {
+	return func(o *OutputStreamer) {
+		o.initialSinks = append(o.initialSinks, w)
+	}
+}
+
+// WithSinkErrorHandler sets a function that's called when a sink's Write fails, or when
+// output is dropped because a sink is full. It defaults to ignoring the error.
+func WithSinkErrorHandler(handle func(w io.Writer, err error)) OutputStreamerOption {
+	return func(o *OutputStreamer) {
+		o.sinkErr = handle
+	}
+}
+
 // A OutputStreamer is an io.Writer that collects data written to it and fans it out
 // to clients who want to read that data as a stream. Callers of NewStream() are provided
 // a channel that will receive all data written since the streamer was created.
@@ -33,102 +222,755 @@ func WithStreamMessageSize(size int) OutputStreamerOption {
 // instance is closed, any calls to Write() will return an error. And channels returned
 // from NewStream() will be closed after all data has been written to them.
 type OutputStreamer struct {
+	// output holds the output in memory, starting at offset base. base is greater than 0
+	// when earlier output has been discarded because of the limit, or spilled. kept is the
+	// offset of the oldest output kept, in memory or in the spill file.
 	output            []byte
+	base              int64
+	kept              int64
+	limit             int64
 	mu                sync.RWMutex
 	writerClosed      atomic.Bool
 	streamMessageSize int
 
+	// closedOutput is set when the writer is closed. The output never changes after that,
+	// so reads use it without taking the lock.
+	closedOutput atomic.Pointer[outputSnapshot]
+
 	length atomic.Int64
+	// written is closed when output is written or the writer is closed, to wake the streams
+	// waiting for either. It's made when a stream waits, and cleared when it's closed, so
+	// writes nobody waits for don't allocate. mu guards it.
+	written chan struct{}
+
+	// spill holds the output from kept to base once the output in memory reaches maxBuffer.
+	// It's written at the output's offsets, and never changes below base, so it's read
+	// without the lock. Once spillFailed is set, older output is discarded instead.
+	maxBuffer   int64
+	spillDir    string
+	spill       *os.File
+	spillFailed bool
+	spillErr    func(err error)
+	// released is set by Release. The spill file is closed once it's set and no readers, the
+	// streams and the output's snapshots, are left to read it. mu guards them.
+	released bool
+	readers  int
+	// initialCapacity is preallocated for output, see WithInitialBufferCapacity
+	initialCapacity int
+	// redaction is masked in the output before it's appended, see WithRedaction. Each output
+	// stream has its own redactor, since each holds back the end of its stream's output. mu
+	// guards them.
+	redaction *regexp.Regexp
+	redactors map[jogv1.Stream]*redactor
+
+	// streamLens is the number of bytes written to each output stream, see Writer. mu guards it.
+	streamLens map[jogv1.Stream]int64
+
+	// streams is the number of live stream goroutines
+	streams atomic.Int64
+
+	// writes records the offset and time of each Write. One entry is kept per
+	// Write, not per byte, so the overhead is bounded by the number of writes.
+	writes []writeMark
+	now    func() time.Time
+
+	// sinks receive a copy of each Write
+	sinks        map[int]*sink
+	nextSinkID   int
+	sinksMu      sync.RWMutex
+	sinksWG      sync.WaitGroup
+	sinkErr      func(w io.Writer, err error)
+	initialSinks []io.Writer
+}
+
+// sink is a writer fed by its own goroutine, so a slow writer can't block the OutputStreamer
+type sink struct {
+	w      io.Writer
+	writes chan []byte
+}
+
+// outputSnapshot is the output of an OutputStreamer whose writer is closed
+type outputSnapshot struct {
+	output []byte
+	base   int64
+	// start is the offset of the oldest output that can be read, see windowStart
+	start int64
+	// spill holds the output from start to base, nil if none was spilled
+	spill *os.File
+}
+
+// writeMark records the time data starting at offset was written, and the output stream it
+// was written to. streamOffset is where the data starts in that stream's output.
+type writeMark struct {
+	offset       int64
+	at           time.Time
+	stream       jogv1.Stream
+	streamOffset int64
 }
 
 func NewOutputStreamer(options ...OutputStreamerOption) *OutputStreamer {
 	o := &OutputStreamer{
 		streamMessageSize: 1024,
 		output:            make([]byte, 0),
+		now:               time.Now,
+		sinks:             make(map[int]*sink),
+		sinkErr:           func(io.Writer, error) {},
+		spillErr:          func(error) {},
+		streamLens:        make(map[jogv1.Stream]int64),
 	}
 
 	for _, opt := range options {
 		opt(o)
 	}
+	if capacity := int64(o.initialCapacity); capacity > 0 {
+		// the output limit keeps up to twice the limit before discarding, see discard
+		if o.limit > 0 {
+			capacity = min(capacity, 2*o.limit)
+		}
+		if o.maxBuffer > 0 {
+			capacity = min(capacity, o.maxBuffer)
+		}
+		o.output = make([]byte, 0, capacity)
+	}
+	for _, w := range o.initialSinks {
+		o.AddSink(w)
+	}
+	o.initialSinks = nil
 
 	return o
 }
 
-// Write appends data to the internal buffer. This implements the io.Writer interface,
-// making an instance of OutputStreamer usable as the STDOUT and STDERR fields in an exec.Cmd.
+// AddSink fans out a copy of everything written to the OutputStreamer from now on to w.
+// Sinks are best-effort: each one is written by its own goroutine through a buffer, and
+// output is dropped if the buffer fills, so a slow or failing sink never blocks or fails
+// the job's writes. Errors are passed to the handler set with WithSinkErrorHandler.
+//
+// The returned function removes the sink. Writes already buffered for it are still written.
+// Sinks are removed when the writer is closed.
+func (o *OutputStreamer) AddSink(w io.Writer) (remove func()) {
+	s := &sink{w: w, writes: make(chan []byte, sinkBufferSize)}
+
+	o.sinksMu.Lock()
+	defer o.sinksMu.Unlock()
+	if o.writerClosed.Load() {
+		return func() {}
+	}
+	id := o.nextSinkID
+	o.nextSinkID++
+	o.sinks[id] = s
+
+	o.sinksWG.Add(1)
+	go func() {
+		defer o.sinksWG.Done()
+		for b := range s.writes {
+			if _, err := s.w.Write(b); err != nil {
+				o.sinkErr(s.w, err)
+			}
+		}
+	}()
+
+	return func() {
+		o.sinksMu.Lock()
+		defer o.sinksMu.Unlock()
+		if _, ok := o.sinks[id]; ok {
+			delete(o.sinks, id)
+			close(s.writes)
+		}
+	}
+}
+
+// waitForSinks blocks until the sinks have written everything buffered for them.
+// It should only be called after the writer is closed.
+func (o *OutputStreamer) waitForSinks() {
+	o.sinksWG.Wait()
+}
+
+// Write appends data to the internal buffer. This implements the io.Writer interface. The data
+// isn't from either output stream, so it's only read by streams that read both, see Writer.
+// Zero-length writes are no-ops: they don't change the length or timestamp index.
 func (o *OutputStreamer) Write(b []byte) (int, error) {
+	return o.writeStream(jogv1.Stream_STREAM_UNSPECIFIED, b)
+}
+
+// Writer returns a writer for one of the job's output streams, e.g. for exec.Cmd's Stdout and
+// Stderr. Its output is appended to the internal buffer like Write's, in the order the writes
+// land, and tagged with the stream, so streams can read one stream's output, or tell them
+// apart, see WithSelector.
+func (o *OutputStreamer) Writer(stream jogv1.Stream) io.Writer {
+	return &streamWriter{o: o, stream: stream}
+}
+
+// streamWriter writes one output stream's output to an OutputStreamer, see Writer
+type streamWriter struct {
+	o      *OutputStreamer
+	stream jogv1.Stream
+}
+
+func (w *streamWriter) Write(b []byte) (int, error) {
+	return w.o.writeStream(w.stream, b)
+}
+
+// writeStream is Write for the output stream's output
+func (o *OutputStreamer) writeStream(stream jogv1.Stream, b []byte) (int, error) {
+	written, err := o.write(stream, b)
+	if err != nil {
+		return 0, err
+	}
+	if len(written) > 0 {
+		o.sinksMu.RLock()
+		defer o.sinksMu.RUnlock()
+		for _, s := range o.sinks {
+			select {
+			case s.writes <- written:
+			default:
+				o.sinkErr(s.w, ErrSinkFull)
+			}
+		}
+	}
+	return len(b), nil
+}
+
+// write appends b to the internal buffer, and returns the appended data. The returned slice
+// is a view of the buffer, which is append-only, so it's safe to hand to sinks without a copy.
+// Callers of Write may reuse b after Write returns, so b itself can't be handed to sinks.
+func (o *OutputStreamer) write(stream jogv1.Stream, b []byte) ([]byte, error) {
 	o.mu.Lock()
 	defer o.mu.Unlock()
 	if o.writerClosed.Load() {
-		return 0, ErrOutputStreamerClosed
+		return nil, ErrOutputStreamerClosed
 	}
+	if o.redaction != nil {
+		r, ok := o.redactors[stream]
+		if !ok {
+			r = &redactor{pattern: o.redaction}
+			if o.redactors == nil {
+				o.redactors = make(map[jogv1.Stream]*redactor)
+			}
+			o.redactors[stream] = r
+		}
+		b = r.redact(b)
+	}
+	if len(b) == 0 {
+		return nil, nil
+	}
+	return o.append(stream, b), nil
+}
+
+// append appends b, written to the output stream, to the internal buffer and wakes the
+// streams. It must be called with the lock held.
+func (o *OutputStreamer) append(stream jogv1.Stream, b []byte) []byte {
+	start := len(o.output)
+	o.writes = append(o.writes, writeMark{offset: o.base + int64(start), at: o.now(), stream: stream, streamOffset: o.streamLens[stream]})
+	o.streamLens[stream] += int64(len(b))
 	o.output = append(o.output, b...)
-	o.length.Store(int64(len(o.output)))
-	return len(b), nil
+	o.length.Store(o.base + int64(len(o.output)))
+	written := o.output[start:len(o.output):len(o.output)]
+	if o.limit > 0 && int64(len(o.output)) > 2*o.limit {
+		o.discard(o.limit, 2*o.limit)
+	}
+	if o.maxBuffer > 0 && int64(len(o.output)) > o.maxBuffer {
+		o.spillOutput()
+	}
+	o.wakeStreams()
+	return written
+}
+
+// wakeStreams wakes the streams waiting for output. It must be called with the lock held.
+func (o *OutputStreamer) wakeStreams() {
+	if o.written != nil {
+		close(o.written)
+		o.written = nil
+	}
 }
 
+// waitForWrite returns a channel that's closed when output is written past length, or the
+// writer is closed. nil is returned if either already happened, so the stream doesn't wait.
+// Checking and making the channel under the lock means a write that lands between a stream
+// reading length and waiting still wakes it.
+func (o *OutputStreamer) waitForWrite(length int64) <-chan struct{} {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.writerClosed.Load() || o.base+int64(len(o.output)) > length {
+		return nil
+	}
+	if o.written == nil {
+		o.written = make(chan struct{})
+	}
+	return o.written
+}
+
+// discard releases the output before the last keep bytes, into a buffer of size bytes. The
+// kept output is copied into a new buffer, rather than moved within the old one, because
+// streams and sinks may still hold slices of the old buffer.
+func (o *OutputStreamer) discard(keep, size int64) {
+	drop := int64(len(o.output)) - keep
+	kept := make([]byte, keep, size)
+	copy(kept, o.output[drop:])
+	o.output = kept
+	o.base += drop
+	o.kept = o.base
+
+	// keep the write that contains the new base and the ones after it
+	i := sort.Search(len(o.writes), func(i int) bool {
+		return o.writes[i].offset > o.base
+	})
+	o.writes = append([]writeMark(nil), o.writes[i-1:]...)
+}
+
+// spillOutput writes the output in memory, except the last half of maxBuffer, to the spill
+// file, and releases it. Like discard, the rest is copied into a new buffer. Output the limit
+// has already put out of reach isn't written. If the output can't be spilled, it's discarded.
+func (o *OutputStreamer) spillOutput() {
+	keep := o.maxBuffer / 2
+	if o.spillFailed {
+		o.discard(keep, o.maxBuffer)
+		return
+	}
+	if err := o.writeSpill(keep); err != nil {
+		o.spillFailed = true
+		o.spillErr(fmt.Errorf("spilling output, older output is discarded from now on: %w", err))
+		o.discard(keep, o.maxBuffer)
+		return
+	}
+	drop := int64(len(o.output)) - keep
+	start := o.windowStart()
+	kept := make([]byte, keep, o.maxBuffer)
+	copy(kept, o.output[drop:])
+	o.output = kept
+	o.kept = start
+	o.base += drop
+	o.compactWrites()
+}
+
+// writeSpill writes the output in memory that can be read, except the last keep bytes, to
+// the spill file, which is created the first time
+func (o *OutputStreamer) writeSpill(keep int64) error {
+	if o.spill == nil {
+		f, err := os.CreateTemp(o.spillDir, "jogger-output-*")
+		if err != nil {
+			return err
+		}
+		// the open file is all that's needed, its space is freed once it's closed
+		os.Remove(f.Name())
+		o.spill = f
+	}
+	drop := int64(len(o.output)) - keep
+	from := max(o.windowStart()-o.base, 0)
+	if from < drop {
+		if _, err := o.spill.WriteAt(o.output[from:drop], o.base+from); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compactWrites bounds the write marks of the spilled output. The marks of output before the
+// oldest that's kept are dropped, and consecutive marks of the same output stream are merged
+// into the first, which still gives the right stream offsets, since nothing was written to
+// the stream in between. It must be called with the lock held.
+func (o *OutputStreamer) compactWrites() {
+	// the write that contains kept is the first one kept
+	first := max(sort.Search(len(o.writes), func(i int) bool {
+		return o.writes[i].offset > o.kept
+	})-1, 0)
+	spilled := sort.Search(len(o.writes), func(i int) bool {
+		return o.writes[i].offset >= o.base
+	})
+	writes := make([]writeMark, 0, len(o.writes)-first)
+	for _, w := range o.writes[first:spilled] {
+		if n := len(writes); n > 0 && writes[n-1].stream == w.stream {
+			continue
+		}
+		writes = append(writes, w)
+	}
+	o.writes = append(writes, o.writes[spilled:]...)
+}
+
+// windowStart returns the offset of the oldest output that can be read. It must be
+// called with the lock held.
+func (o *OutputStreamer) windowStart() int64 {
+	length := o.base + int64(len(o.output))
+	if o.limit > 0 && length-o.limit > o.kept {
+		return length - o.limit
+	}
+	return o.kept
+}
+
+// view returns the output as it is now, to be read without the lock. Output in memory is
+// append-only, and spilled output never changes, so what it covers stays valid.
+func (o *OutputStreamer) view() *outputSnapshot {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return &outputSnapshot{output: o.output[:len(o.output):len(o.output)], base: o.base, start: o.windowStart(), spill: o.spill}
+}
+
+// TimeAt returns the wall-clock time of the Write that produced the byte at offset.
+// false is returned if nothing has been written at offset yet.
+func (o *OutputStreamer) TimeAt(offset int64) (time.Time, bool) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	if offset < o.windowStart() || offset >= o.base+int64(len(o.output)) {
+		return time.Time{}, false
+	}
+	// find the first write that starts after offset, the write before it contains offset
+	i := sort.Search(len(o.writes), func(i int) bool {
+		return o.writes[i].offset > offset
+	})
+	return o.writes[i-1].at, true
+}
+
+// Len returns the number of bytes written to the OutputStreamer, including output
+// discarded because of an output limit
+func (o *OutputStreamer) Len() int64 {
+	return o.length.Load()
+}
+
+// selectedLen is Len for the output the selector reads, see WithSelector
+func (o *OutputStreamer) selectedLen(selector jogv1.StreamSelector) int64 {
+	stream, ok := selectedStream(selector)
+	if !ok {
+		return o.Len()
+	}
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return o.streamLens[stream]
+}
+
+// streamIndex returns the offset in the output of the byte at offset in the output stream's
+// output. An offset of output that was discarded returns the offset of the stream's oldest
+// output that's kept, one at the end of the stream's output returns the end of the output.
+func (o *OutputStreamer) streamIndex(stream jogv1.Stream, offset int64) int64 {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	length := o.base + int64(len(o.output))
+	if offset >= o.streamLens[stream] {
+		return length
+	}
+	for i, w := range o.writes {
+		if w.stream != stream {
+			continue
+		}
+		end := length
+		if i+1 < len(o.writes) {
+			end = o.writes[i+1].offset
+		}
+		if offset < w.streamOffset+end-w.offset {
+			return w.offset + max(offset-w.streamOffset, 0)
+		}
+	}
+	return length
+}
+
+// segment returns the output stream of the output at index, and where that stream's output
+// ends before limit, or limit if it doesn't.
+func (o *OutputStreamer) segment(index, limit int64) (jogv1.Stream, int64) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	// find the first write that starts after index, the write before it contains index
+	i := sort.Search(len(o.writes), func(i int) bool {
+		return o.writes[i].offset > index
+	})
+	if i == 0 {
+		return jogv1.Stream_STREAM_UNSPECIFIED, limit
+	}
+	stream := o.writes[i-1].stream
+	for ; i < len(o.writes) && o.writes[i].offset < limit; i++ {
+		if o.writes[i].stream != stream {
+			return stream, o.writes[i].offset
+		}
+	}
+	return stream, limit
+}
+
+// CloseWriter closes the OutputStreamer to writes, and removes its sinks once they've
+// written everything buffered for them.
 func (o *OutputStreamer) CloseWriter() {
 	o.writerClosed.Store(true)
+
+	// a Write that took the lock before the writer was closed finishes first, later ones fail
+	o.mu.Lock()
+	// the output the redactors held back is released, there's nothing left to complete it
+	var rest [][]byte
+	for _, stream := range []jogv1.Stream{jogv1.Stream_STREAM_UNSPECIFIED, jogv1.Stream_STDOUT, jogv1.Stream_STDERR} {
+		if r, ok := o.redactors[stream]; ok {
+			if b := r.flush(); len(b) > 0 {
+				rest = append(rest, o.append(stream, b))
+			}
+		}
+	}
+	if o.closedOutput.Load() == nil {
+		o.closedOutput.Store(&outputSnapshot{output: o.output, base: o.base, start: o.windowStart(), spill: o.spill})
+	}
+	o.wakeStreams()
+	o.mu.Unlock()
+
+	o.sinksMu.Lock()
+	defer o.sinksMu.Unlock()
+	for id, s := range o.sinks {
+		for _, b := range rest {
+			select {
+			case s.writes <- b:
+			default:
+				o.sinkErr(s.w, ErrSinkFull)
+			}
+		}
+		delete(o.sinks, id)
+		close(s.writes)
+	}
+}
+
+// Release closes the spill file, see WithMaxBufferBytes, once the streams reading the output
+// have closed. It's called when the output is no longer needed, e.g. its job was removed.
+// Spilled output can't be read after that, streams that try end with an error.
+func (o *OutputStreamer) Release() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.released = true
+	if o.readers == 0 {
+		o.closeSpill()
+	}
+}
+
+// closeSpill closes the spill file, if output was spilled. It must be called with the lock held.
+func (o *OutputStreamer) closeSpill() {
+	if o.spill != nil {
+		o.spill.Close()
+		// nothing more can be spilled, the output stays in memory
+		o.spillFailed = true
+	}
+}
+
+// addReader records a reader of the output, so the spill file stays open for it after Release
+func (o *OutputStreamer) addReader() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.readers++
+}
+
+// removeReader records that a reader is done, and closes the spill file if it was the last
+// reader of the released output
+func (o *OutputStreamer) removeReader() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.readers--
+	if o.readers == 0 && o.released {
+		o.closeSpill()
+	}
 }
 
 // Next returns the next chunk of data to be read from the OutputStreamer.
 // Note: no copies of the data are made, so the caller should not modify the returned slice.
 // This design enables large output buffers to be read by many clients without incurring the cost of
-// copying the data.
+// copying the data. Once the writer is closed the output can't change, and it's read without
+// taking the lock, so many streams reading a finished job's output don't contend.
+// Output spilled to disk, see WithMaxBufferBytes, is read from the file into a new slice.
+//
+// nil is returned if there is no data at index yet, if it was discarded because of an output limit,
+// or if it was spilled and can't be read.
 func (o *OutputStreamer) Next(index int) []byte {
 	if int64(index) >= o.length.Load() {
 		return nil
 	}
-	o.mu.RLock()
-	defer o.mu.RUnlock()
-	if index+o.streamMessageSize > len(o.output) {
-		return o.output[index:]
+	s := o.closedOutput.Load()
+	if s == nil {
+		s = o.view()
+	}
+	if int64(index) < s.start {
+		return nil
+	}
+	data, _ := s.chunk(index, o.streamMessageSize)
+	return data
+}
+
+// next is like Next, except that an index of discarded data skips ahead to the oldest data
+// that can be read, and chunks are up to size bytes. The index of the returned data is
+// returned with it. An error is returned if the data was spilled and can't be read.
+func (o *OutputStreamer) next(index int, size int) ([]byte, int, error) {
+	if int64(index) >= o.length.Load() {
+		return nil, index, nil
+	}
+	s := o.closedOutput.Load()
+	if s == nil {
+		s = o.view()
+	}
+	index = max(index, int(s.start))
+	data, err := s.chunk(index, size)
+	return data, index, err
+}
+
+// chunk returns up to size bytes of the output starting at index. Output in memory is
+// returned without a copy. Spilled output is read from the file, followed by output in
+// memory if there's room, so a chunk isn't cut short where the two meet. An error is
+// returned if the spilled output can't be read, e.g. because the spill file was closed.
+func (s *outputSnapshot) chunk(index int, size int) ([]byte, error) {
+	i := index - int(s.base)
+	if i < 0 {
+		buf := make([]byte, min(size, -i))
+		if _, err := s.spill.ReadAt(buf, int64(index)); err != nil {
+			return nil, fmt.Errorf("reading spilled output at offset %d: %w", index, err)
+		}
+		return append(buf, s.output[:min(size-len(buf), len(s.output))]...), nil
 	}
-	return o.output[index : index+o.streamMessageSize]
+	if i+size > len(s.output) {
+		return s.output[i:], nil
+	}
+	return s.output[i : i+size], nil
 }
 
 // NewStream returns a channel that will receive all data written to the OutputStreamer.
 // When a job is running and writing data to the OutputStreamer, the channel will
-// receive data in chunks of, at most, streamMessageSize bytes.
+// receive data in chunks of, at most, streamMessageSize bytes. Options can change how the
+// data is chunked, see WithChunkSize and WithLineChunks.
 //
-// The reader is configured to check for new data at least once per second. When there
-// is new data, it catches up to the end of stream without waiting.
+// The stream wakes as soon as data is written, and catches up to the end of the stream
+// without waiting.
 //
 // When the job exits, the OutputStreamer is closed to writes, but the data remains
 // available to NewStream() callers until the server is shutdown.
-func (o *OutputStreamer) NewStream(ctx context.Context) <-chan []byte {
-	stream := make(chan []byte, 2)
+//
+// With an output limit, the stream starts from the oldest output that's kept, and skips
+// ahead if it falls behind the output that's kept.
+func (o *OutputStreamer) NewStream(ctx context.Context, options ...StreamOption) <-chan []byte {
+	return o.NewDrainableStream(ctx, nil, options...)
+}
+
+// NewDrainableStream is the same as NewStream, except that when the drain channel is closed,
+// the stream sends the data written up to that point and then closes, without waiting for
+// the writer to close. This is used to flush streams to clients during server shutdown.
+// A nil drain channel is never closed.
+func (o *OutputStreamer) NewDrainableStream(ctx context.Context, drain <-chan struct{}, options ...StreamOption) <-chan []byte {
+	return o.newStream(ctx, drain, nil, options...)
+}
+
+// ActiveStreams returns the number of streams that haven't been closed yet
+func (o *OutputStreamer) ActiveStreams() int64 {
+	return o.streams.Load()
+}
+
+// newStream is NewDrainableStream with a function that's called when the stream's goroutine
+// exits. done is called before the stream is closed, so it has run by the time readers see the
+// closed channel. A nil done is ignored.
+func (o *OutputStreamer) newStream(ctx context.Context, drain <-chan struct{}, done func(), options ...StreamOption) <-chan []byte {
+	return openStream(o, ctx, drain, done, func(data []byte, _ jogv1.Stream) []byte { return data }, nil, options)
+}
+
+// newChunkStream is newStream, except that each chunk says which output stream it's from
+func (o *OutputStreamer) newChunkStream(ctx context.Context, drain <-chan struct{}, done func(), options ...StreamOption) <-chan Chunk {
+	return openStream(o, ctx, drain, done, func(data []byte, stream jogv1.Stream) Chunk {
+		return Chunk{Data: data, Stream: stream}
+	}, func(err error) Chunk {
+		return Chunk{Err: err}
+	}, options)
+}
+
+// openStream starts the goroutine behind a stream, see newStream. message makes each of the
+// stream's messages from a chunk of output and the output stream it's from. fail makes the
+// last message of a stream whose output couldn't be read, a nil fail closes the stream without one.
+func openStream[T any](o *OutputStreamer, ctx context.Context, drain <-chan struct{}, done func(), message func([]byte, jogv1.Stream) T, fail func(error) T, options []StreamOption) <-chan T {
+	cfg := streamConfig{size: o.streamMessageSize}
+	for _, opt := range options {
+		opt(&cfg)
+	}
+	only, selected := selectedStream(cfg.selector)
+	// chunks read both output streams, unless they're told apart
+	split := selected || cfg.selector == jogv1.StreamSelector_STREAM_SELECTOR_MERGED
+	stream := make(chan T, 2)
 
+	o.streams.Add(1)
+	o.addReader()
 	go func() {
-		// Note: internally the ticker channel has a buffer of 1, so we won't
-		// build up a backlog of ticks if there is a lot of initial data to
-		// send, or some other delay.
-		ticker := time.NewTicker(1 * time.Second)
-		defer ticker.Stop()
-		index := 0
+		defer close(stream)
+		defer func() {
+			o.streams.Add(-1)
+			o.removeReader()
+			if done != nil {
+				done()
+			}
+		}()
+
+		index := int(cfg.offset)
+		if selected {
+			index = int(o.streamIndex(only, cfg.offset))
+		}
+		// drainAt is the length of the output when the stream was drained, -1 until then
+		drainAt := int64(-1)
 		for {
-			// send more data if there is any
-			if int64(index) < o.length.Load() {
-				msg := o.Next(index)
-				index += len(msg)
-				stream <- msg
-				// this loops so that we don't wait on the ticker to check for more data
-				continue
+			// writerClosed must be loaded before length. The writer is closed after the last
+			// write, so if it is closed, length is final.
+			closed := o.writerClosed.Load()
+			length := o.length.Load()
+			if drainAt >= 0 {
+				length = drainAt
 			}
-			if int64(index) == o.length.Load() {
-				// only close the channel if the OutputStreamer is no longer being written to
-				// this happens when the job has exited
-				if o.writerClosed.Load() {
-					close(stream)
+			// send more data if there is any
+			if int64(index) < length {
+				// with an output limit, the stream skips ahead if its index was discarded
+				msg, next, err := o.next(index, cfg.size)
+				if err != nil {
+					if fail != nil {
+						select {
+						case stream <- fail(err):
+						case <-ctx.Done():
+						}
+					}
 					return
 				}
+				index = next
+				if int64(index) >= length {
+					// the stream was drained before the data it skipped ahead to was written
+					msg = nil
+				} else if int64(index+len(msg)) > length {
+					msg = msg[:length-int64(index)]
+				}
+				final := closed || drainAt >= 0
+				source := jogv1.Stream_STREAM_UNSPECIFIED
+				if split && len(msg) > 0 {
+					var end int64
+					source, end = o.segment(int64(index), int64(index+len(msg)))
+					if end < int64(index+len(msg)) {
+						// the other stream's output follows, the rest of a line comes after it
+						msg = msg[:end-int64(index)]
+						final = true
+					}
+					if selected && source != only {
+						index = int(end)
+						continue
+					}
+				}
+				if cfg.lines {
+					msg = lineChunk(msg, cfg.size, final)
+				}
+				// Next only returns an empty chunk if there's no data at index. Never send
+				// an empty chunk to the client, and never loop without waiting if one is returned.
+				if len(msg) > 0 {
+					index += len(msg)
+					select {
+					case stream <- message(msg, source):
+					case <-ctx.Done():
+						return
+					}
+					// this loops so that we don't wait to check for more data
+					continue
+				}
+			}
+			// only close the channel if the OutputStreamer is no longer being written to
+			// this happens when the job has exited, or when the stream was drained
+			if closed || drainAt >= 0 {
+				return
+			}
+			// wait for more data, a drain, or the context to be canceled
+			written := o.waitForWrite(length)
+			if written == nil {
+				continue
 			}
-			// wait for the next tick or the context to be canceled
 			select {
 			case <-ctx.Done():
-				close(stream)
 				return
-			case <-ticker.C:
+			case <-drain:
+				drainAt = o.length.Load()
+				// stop selecting on the closed channel
+				drain = nil
+			case <-written:
 				// check for more data by looping again
 			}
 		}
@@ -136,3 +978,16 @@ func (o *OutputStreamer) NewStream(ctx context.Context) <-chan []byte {
 
 	return stream
 }
+
+// lineChunk returns the first line in msg, including its newline. A line that fills the
+// chunk size is returned without one. The rest of a line that's still being written is held
+// back, nil is returned, unless final is true because nothing more will be written.
+func lineChunk(msg []byte, size int, final bool) []byte {
+	if i := bytes.IndexByte(msg, '\n'); i >= 0 {
+		return msg[:i+1]
+	}
+	if len(msg) >= size || final {
+		return msg
+	}
+	return nil
+}
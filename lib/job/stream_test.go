@@ -0,0 +1,1134 @@
+package job
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	jogv1 "github.com/dustinevan/jogger/pkg/gen/jogger/v1"
+)
+
+// fakeClock returns a clock that starts at start and advances by step each time it's called
+func fakeClock(start time.Time, step time.Duration) func() time.Time {
+	current := start
+	return func() time.Time {
+		t := current
+		current = current.Add(step)
+		return t
+	}
+}
+
+func TestOutputStreamerTimeAt(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)
+	o := NewOutputStreamer(WithClock(fakeClock(start, time.Second)))
+
+	if _, ok := o.TimeAt(0); ok {
+		t.Fatalf("expected no timestamp before any writes")
+	}
+
+	// write at t+0s: offsets 0-5, t+1s: nothing (empty write), t+1s: offsets 6-7, t+2s: offsets 8-17
+	for _, w := range []string{"hello\n", "", "hi", "0123456789"} {
+		if _, err := o.Write([]byte(w)); err != nil {
+			t.Fatalf("unexpected write error: %v", err)
+		}
+	}
+
+	tests := []struct {
+		offset int64
+		want   time.Time
+		ok     bool
+	}{
+		{offset: -1, ok: false},
+		{offset: 0, want: start, ok: true},
+		{offset: 5, want: start, ok: true},
+		{offset: 6, want: start.Add(time.Second), ok: true},
+		{offset: 7, want: start.Add(time.Second), ok: true},
+		{offset: 8, want: start.Add(2 * time.Second), ok: true},
+		{offset: 17, want: start.Add(2 * time.Second), ok: true},
+		{offset: 18, ok: false},
+	}
+
+	for _, tt := range tests {
+		got, ok := o.TimeAt(tt.offset)
+		if ok != tt.ok {
+			t.Fatalf("offset %d: expected ok=%v, got %v", tt.offset, tt.ok, ok)
+		}
+		if ok && !got.Equal(tt.want) {
+			t.Fatalf("offset %d: expected %v, got %v", tt.offset, tt.want, got)
+		}
+	}
+
+	if len(o.writes) != 3 {
+		t.Fatalf("expected one timestamp per non-empty write, got %d", len(o.writes))
+	}
+}
+
+func TestOutputStreamerTimeAtWithDelays(t *testing.T) {
+	t.Parallel()
+
+	o := NewOutputStreamer()
+	before := time.Now()
+	o.Write([]byte("first"))
+	time.Sleep(20 * time.Millisecond)
+	middle := time.Now()
+	o.Write([]byte("second"))
+
+	first, ok := o.TimeAt(0)
+	if !ok {
+		t.Fatalf("expected a timestamp for offset 0")
+	}
+	second, ok := o.TimeAt(int64(len("first")))
+	if !ok {
+		t.Fatalf("expected a timestamp for the second write")
+	}
+	if first.Before(before) || !first.Before(middle) {
+		t.Fatalf("first write timestamp %v is outside of [%v, %v)", first, before, middle)
+	}
+	if second.Before(middle) {
+		t.Fatalf("second write timestamp %v is before %v", second, middle)
+	}
+}
+
+// readAll reads from stream until it's closed or the timeout is reached
+func readAll(t *testing.T, stream <-chan []byte, timeout time.Duration) []byte {
+	t.Helper()
+	var out []byte
+	deadline := time.After(timeout)
+	for {
+		select {
+		case msg, ok := <-stream:
+			if !ok {
+				return out
+			}
+			out = append(out, msg...)
+		case <-deadline:
+			t.Fatalf("stream was not closed within %v, received %q", timeout, out)
+		}
+	}
+}
+
+// readChunks reads a chunk stream until it's closed, or fails the test after timeout
+func readChunks(t *testing.T, stream <-chan Chunk, timeout time.Duration) []Chunk {
+	t.Helper()
+	var chunks []Chunk
+	deadline := time.After(timeout)
+	for {
+		select {
+		case c, ok := <-stream:
+			if !ok {
+				return chunks
+			}
+			chunks = append(chunks, c)
+		case <-deadline:
+			t.Fatalf("stream was not closed within %v, received %d chunks", timeout, len(chunks))
+		}
+	}
+}
+
+// chunkData returns the data of the chunks
+func chunkData(chunks []Chunk) []byte {
+	var out []byte
+	for _, c := range chunks {
+		out = append(out, c.Data...)
+	}
+	return out
+}
+
+// readN reads n bytes from stream or fails the test
+func readN(t *testing.T, stream <-chan []byte, n int) []byte {
+	t.Helper()
+	var out []byte
+	for len(out) < n {
+		select {
+		case msg, ok := <-stream:
+			if !ok {
+				t.Fatalf("stream closed after %d of %d bytes", len(out), n)
+			}
+			out = append(out, msg...)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out after %d of %d bytes", len(out), n)
+		}
+	}
+	return out
+}
+
+func TestOutputStreamerNewDrainableStream(t *testing.T) {
+	t.Parallel()
+
+	const buffered = "buffered output"
+	o := NewOutputStreamer(WithStreamMessageSize(4))
+	o.Write([]byte(buffered))
+
+	drain := make(chan struct{})
+	stream := o.NewDrainableStream(context.Background(), drain)
+
+	got := readN(t, stream, len(buffered))
+	if string(got) != buffered {
+		t.Fatalf("expected %q, got %q", buffered, got)
+	}
+
+	// the writer is still open, so the stream waits for more data
+	select {
+	case msg, ok := <-stream:
+		t.Fatalf("expected the stream to wait, got msg=%q ok=%v", msg, ok)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(drain)
+	if rest := readAll(t, stream, 500*time.Millisecond); len(rest) != 0 {
+		t.Fatalf("expected no more data after draining, got %q", rest)
+	}
+
+	// streams opened after the drain send the buffered output and close immediately
+	got = readAll(t, o.NewDrainableStream(context.Background(), drain), 500*time.Millisecond)
+	if string(got) != buffered {
+		t.Fatalf("expected %q, got %q", buffered, got)
+	}
+}
+
+func TestOutputStreamerChunkPolicy(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		output  string
+		options []StreamOption
+		want    []string
+	}{
+		{
+			name:   "default message size",
+			output: "hello world",
+			want:   []string{"hello world"},
+		},
+		{
+			name:    "size of 1",
+			output:  "hello",
+			options: []StreamOption{WithChunkSize(1)},
+			want:    []string{"h", "e", "l", "l", "o"},
+		},
+		{
+			name:    "size smaller than the output",
+			output:  "hello world",
+			options: []StreamOption{WithChunkSize(3)},
+			want:    []string{"hel", "lo ", "wor", "ld"},
+		},
+		{
+			name:    "lines",
+			output:  "one\ntwo\n\nthree\n",
+			options: []StreamOption{WithLineChunks()},
+			want:    []string{"one\n", "two\n", "\n", "three\n"},
+		},
+		{
+			name:    "lines without a trailing newline",
+			output:  "one\ntwo",
+			options: []StreamOption{WithLineChunks()},
+			want:    []string{"one\n", "two"},
+		},
+		{
+			name:    "lines longer than the size are split",
+			output:  "abcdefgh\nxy",
+			options: []StreamOption{WithLineChunks(), WithChunkSize(4)},
+			want:    []string{"abcd", "efgh", "\n", "xy"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			o := NewOutputStreamer()
+			o.Write([]byte(tt.output))
+			o.CloseWriter()
+
+			var got []string
+			for msg := range o.NewStream(context.Background(), tt.options...) {
+				got = append(got, string(msg))
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("expected messages %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestOutputStreamerLineChunksHoldPartialLine(t *testing.T) {
+	t.Parallel()
+
+	o := NewOutputStreamer()
+	o.Write([]byte("partial"))
+	stream := o.NewStream(context.Background(), WithLineChunks())
+
+	select {
+	case msg := <-stream:
+		t.Fatalf("expected the partial line to be held back, got %q", msg)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	o.Write([]byte(" line\nnext"))
+	select {
+	case msg := <-stream:
+		if string(msg) != "partial line\n" {
+			t.Fatalf("expected %q, got %q", "partial line\n", msg)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("timed out waiting for the completed line")
+	}
+
+	// the last line is sent without a newline once the writer is closed
+	o.CloseWriter()
+	if msg := <-stream; string(msg) != "next" {
+		t.Fatalf("expected %q, got %q", "next", msg)
+	}
+	if _, ok := <-stream; ok {
+		t.Fatal("expected the stream to be closed")
+	}
+}
+
+func TestOutputStreamerWakesOnWrite(t *testing.T) {
+	t.Parallel()
+
+	o := NewOutputStreamer()
+	stream := o.NewStream(context.Background())
+	// let the stream find there's no output and wait for some
+	time.Sleep(50 * time.Millisecond)
+
+	for _, b := range []string{"a", "b"} {
+		written := time.Now()
+		o.Write([]byte(b))
+		select {
+		case msg := <-stream:
+			if string(msg) != b {
+				t.Fatalf("expected %q, got %q", b, msg)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for %q", b)
+		}
+		if d := time.Since(written); d > 100*time.Millisecond {
+			t.Fatalf("expected %q to be received right after it was written, it took %v", b, d)
+		}
+	}
+
+	closed := time.Now()
+	o.CloseWriter()
+	select {
+	case msg, ok := <-stream:
+		if ok {
+			t.Fatalf("expected the stream to be closed, got %q", msg)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the stream to close")
+	}
+	if d := time.Since(closed); d > 100*time.Millisecond {
+		t.Fatalf("expected the stream to close right after the writer, it took %v", d)
+	}
+}
+
+func TestOutputStreamerZeroLengthWrite(t *testing.T) {
+	t.Parallel()
+
+	o := NewOutputStreamer()
+	o.Write([]byte("data"))
+
+	stream := o.NewStream(context.Background())
+	if got := readN(t, stream, 4); string(got) != "data" {
+		t.Fatalf("expected %q, got %q", "data", got)
+	}
+
+	for _, b := range [][]byte{nil, {}} {
+		n, err := o.Write(b)
+		if n != 0 || err != nil {
+			t.Fatalf("expected a no-op write, got n=%d err=%v", n, err)
+		}
+	}
+	if o.Len() != 4 {
+		t.Fatalf("expected length 4, got %d", o.Len())
+	}
+	if len(o.writes) != 1 {
+		t.Fatalf("expected 1 timestamped write, got %d", len(o.writes))
+	}
+
+	// the consumer isn't sent anything for the empty writes
+	select {
+	case msg, ok := <-stream:
+		t.Fatalf("expected no message, got msg=%q ok=%v", msg, ok)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	o.CloseWriter()
+	if _, err := o.Write(nil); err != ErrOutputStreamerClosed {
+		t.Fatalf("expected ErrOutputStreamerClosed, got %v", err)
+	}
+	if rest := readAll(t, stream, 2*time.Second); len(rest) != 0 {
+		t.Fatalf("expected no more data, got %q", rest)
+	}
+}
+
+func TestOutputStreamerWithSink(t *testing.T) {
+	t.Parallel()
+
+	r, w := io.Pipe()
+	o := NewOutputStreamer(WithSink(w))
+
+	received := make(chan []byte)
+	go func() {
+		b, _ := io.ReadAll(r)
+		received <- b
+	}()
+
+	for _, s := range []string{"hello ", "", "sink"} {
+		if _, err := o.Write([]byte(s)); err != nil {
+			t.Fatalf("unexpected write error: %v", err)
+		}
+	}
+	o.CloseWriter()
+	o.waitForSinks()
+	w.Close()
+
+	if got := <-received; string(got) != "hello sink" {
+		t.Fatalf("expected the sink to receive %q, got %q", "hello sink", got)
+	}
+	if got := readAll(t, o.NewStream(context.Background()), time.Second); string(got) != "hello sink" {
+		t.Fatalf("expected the buffer to hold %q, got %q", "hello sink", got)
+	}
+}
+
+// syncBuffer is a bytes.Buffer that is safe to use from the sink goroutine and the test
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestOutputStreamerAddRemoveSink(t *testing.T) {
+	t.Parallel()
+
+	o := NewOutputStreamer()
+	o.Write([]byte("before "))
+
+	var first, second syncBuffer
+	removeFirst := o.AddSink(&first)
+	o.AddSink(&second)
+	o.Write([]byte("both "))
+
+	// removing a sink stops new writes, but keeps the buffered ones
+	removeFirst()
+	removeFirst()
+	o.Write([]byte("second"))
+
+	o.CloseWriter()
+	o.waitForSinks()
+
+	if got := first.String(); got != "both " {
+		t.Fatalf("expected the removed sink to receive %q, got %q", "both ", got)
+	}
+	if got := second.String(); got != "both second" {
+		t.Fatalf("expected the sink to receive %q, got %q", "both second", got)
+	}
+
+	// sinks added after the writer is closed receive nothing
+	var late syncBuffer
+	o.AddSink(&late)()
+	if got := late.String(); got != "" {
+		t.Fatalf("expected no output for a sink added after close, got %q", got)
+	}
+}
+
+// blockingWriter blocks every Write until unblock is closed
+type blockingWriter struct {
+	unblock chan struct{}
+}
+
+func (w blockingWriter) Write(p []byte) (int, error) {
+	<-w.unblock
+	return len(p), nil
+}
+
+// failingWriter fails every Write
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("sink failed")
+}
+
+func TestOutputStreamerSlowSinkIsolation(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	var slowDropped, failed int
+	slow := blockingWriter{unblock: make(chan struct{})}
+	var fast syncBuffer
+	o := NewOutputStreamer(
+		WithSink(slow),
+		WithSink(failingWriter{}),
+		WithSink(&fast),
+		WithSinkErrorHandler(func(w io.Writer, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case w == slow && errors.Is(err, ErrSinkFull):
+				slowDropped++
+			case w == failingWriter{}:
+				failed++
+			case w == &fast:
+				t.Errorf("unexpected error for the fast sink: %v", err)
+			}
+		}),
+	)
+
+	// write enough to fill the slow sink's buffer twice, none of the writes block or fail.
+	// The fast sink is given time to catch up halfway through, so it doesn't drop anything.
+	write := func(n int) {
+		t.Helper()
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for i := 0; i < n; i++ {
+				if _, err := o.Write([]byte("x")); err != nil {
+					t.Errorf("unexpected write error: %v", err)
+					return
+				}
+			}
+		}()
+		select {
+		case <-done:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("writes were blocked by a slow sink")
+		}
+	}
+	write(sinkBufferSize)
+	for deadline := time.Now().Add(2 * time.Second); len(fast.String()) < sinkBufferSize; {
+		if time.Now().After(deadline) {
+			t.Fatalf("the fast sink didn't catch up")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	write(sinkBufferSize)
+
+	writes := 2 * sinkBufferSize
+	if o.Len() != int64(writes) {
+		t.Fatalf("expected %d bytes in the buffer, got %d", writes, o.Len())
+	}
+
+	close(slow.unblock)
+	o.CloseWriter()
+	o.waitForSinks()
+
+	if got := fast.String(); len(got) != writes {
+		t.Fatalf("expected the fast sink to receive %d bytes, got %d", writes, len(got))
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if slowDropped == 0 {
+		t.Fatalf("expected output to be dropped for the slow sink")
+	}
+	if failed == 0 {
+		t.Fatalf("expected the failing sink's errors to be handled")
+	}
+}
+
+func TestOutputStreamerOutputLimit(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)
+	o := NewOutputStreamer(WithOutputLimit(8), WithStreamMessageSize(3), WithClock(fakeClock(start, time.Second)))
+
+	// before the limit is reached nothing is discarded
+	o.Write([]byte("abcdef"))
+	if got := readN(t, o.NewStream(context.Background()), 6); string(got) != "abcdef" {
+		t.Fatalf("expected %q, got %q", "abcdef", got)
+	}
+
+	// wrap around the limit several times, with writes smaller and larger than the limit
+	for _, w := range []string{"ghij", "klmnopqrst", "uvwxyz0123456789"} {
+		o.Write([]byte(w))
+	}
+	const total = "abcdefghijklmnopqrstuvwxyz0123456789"
+	if o.Len() != int64(len(total)) {
+		t.Fatalf("expected Len to count all %d bytes written, got %d", len(total), o.Len())
+	}
+	if len(o.output) > 16 {
+		t.Fatalf("expected at most twice the limit to be held, got %d bytes", len(o.output))
+	}
+
+	tests := []struct {
+		offset   int
+		wantNext string
+		wantTime time.Time
+		ok       bool
+	}{
+		{offset: 0, ok: false},
+		{offset: 27, ok: false},
+		{offset: 28, wantNext: "234", wantTime: start.Add(3 * time.Second), ok: true},
+		{offset: 34, wantNext: "89", wantTime: start.Add(3 * time.Second), ok: true},
+		{offset: 36, ok: false},
+	}
+	for _, tt := range tests {
+		if got := o.Next(tt.offset); string(got) != tt.wantNext {
+			t.Fatalf("Next(%d): expected %q, got %q", tt.offset, tt.wantNext, got)
+		}
+		got, ok := o.TimeAt(int64(tt.offset))
+		if ok != tt.ok || (ok && !got.Equal(tt.wantTime)) {
+			t.Fatalf("TimeAt(%d): expected %v %v, got %v %v", tt.offset, tt.wantTime, tt.ok, got, ok)
+		}
+	}
+
+	// new streams start from the oldest output that's kept
+	o.CloseWriter()
+	if got := readAll(t, o.NewStream(context.Background()), time.Second); string(got) != total[len(total)-8:] {
+		t.Fatalf("expected %q, got %q", total[len(total)-8:], got)
+	}
+}
+
+func TestOutputStreamerOutputLimitSlowReader(t *testing.T) {
+	t.Parallel()
+
+	o := NewOutputStreamer(WithOutputLimit(4), WithStreamMessageSize(2))
+	o.Write([]byte("0123"))
+
+	// the stream sends everything written so far, then waits for the next tick
+	stream := o.NewStream(context.Background())
+	if got := readN(t, stream, 2); string(got) != "01" {
+		t.Fatalf("expected %q, got %q", "01", got)
+	}
+
+	// the stream falls behind the window while it's waiting, and skips ahead to it
+	o.Write([]byte("456789abcdef"))
+	o.CloseWriter()
+	got := readAll(t, stream, 3*time.Second)
+	if !strings.HasPrefix(string(got), "23") || !strings.HasSuffix(string(got), "cdef") {
+		t.Fatalf("expected the buffered messages, then the last 4 bytes, got %q", got)
+	}
+	if strings.Contains(string(got), "4567") {
+		t.Fatalf("expected discarded output to be skipped, got %q", got)
+	}
+}
+
+func TestOutputStreamerConcurrentReadsAcrossClose(t *testing.T) {
+	t.Parallel()
+
+	o := NewOutputStreamer(WithStreamMessageSize(7))
+	var want bytes.Buffer
+	for i := 0; i < 500; i++ {
+		want.WriteString(strings.Repeat(string(rune('a'+i%26)), i%13+1))
+	}
+
+	// streams opened before, during, and after the writes and the close all get every byte.
+	// Run with -race, this reads the output while it's being written and while it's closed.
+	const streams = 30
+	results := make(chan []byte, streams)
+	var wg sync.WaitGroup
+	opened := 0
+	open := func() {
+		opened++
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var got []byte
+			for msg := range o.NewStream(context.Background()) {
+				got = append(got, msg...)
+			}
+			results <- got
+		}()
+	}
+	for i := 0; i < streams/3; i++ {
+		open()
+	}
+	written := want.Bytes()
+	for len(written) > 0 {
+		n := min(len(written), 97)
+		o.Write(written[:n])
+		written = written[n:]
+		if len(written)%3 == 0 && opened < 2*streams/3 {
+			open()
+		}
+	}
+	var closers sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		closers.Add(1)
+		go func() {
+			defer closers.Done()
+			o.CloseWriter()
+		}()
+	}
+	for opened < streams {
+		open()
+	}
+	closers.Wait()
+	wg.Wait()
+	close(results)
+
+	for got := range results {
+		if !bytes.Equal(got, want.Bytes()) {
+			t.Fatalf("expected %d bytes of output, got %d: %q", want.Len(), len(got), got)
+		}
+	}
+	if got := o.Next(want.Len() - 3); string(got) != want.String()[want.Len()-3:] {
+		t.Fatalf("expected Next to read the end of the closed output, got %q", got)
+	}
+}
+
+func TestOutputStreamerClosedOutputLimit(t *testing.T) {
+	t.Parallel()
+
+	o := NewOutputStreamer(WithOutputLimit(4), WithStreamMessageSize(3))
+	o.Write([]byte("0123456789"))
+	o.CloseWriter()
+	// the closed output keeps the same window as the open output
+	if got := o.Next(5); got != nil {
+		t.Fatalf("expected nil for discarded output, got %q", got)
+	}
+	if got := o.Next(6); string(got) != "678" {
+		t.Fatalf("expected %q, got %q", "678", got)
+	}
+	if got := readAll(t, o.NewStream(context.Background()), time.Second); string(got) != "6789" {
+		t.Fatalf("expected %q, got %q", "6789", got)
+	}
+}
+
+func TestOutputStreamerSpill(t *testing.T) {
+	t.Parallel()
+
+	const maxBuffer = 64 << 10
+	o := NewOutputStreamer(WithMaxBufferBytes(maxBuffer), WithSpillDir(t.TempDir()), WithStreamMessageSize(4096))
+
+	// a stream that reads while the output is written, and falls behind the memory cap
+	early := o.NewStream(context.Background())
+	var want bytes.Buffer
+	for i := 0; want.Len() < 4<<20; i++ {
+		line := fmt.Sprintf("line %d: %s\n", i, strings.Repeat("x", i%100))
+		want.WriteString(line)
+		o.Write([]byte(line))
+		if len(o.output) > maxBuffer {
+			t.Fatalf("expected at most %d bytes in memory, got %d", maxBuffer, len(o.output))
+		}
+	}
+	if o.spill == nil || o.base == 0 {
+		t.Fatal("expected older output to be spilled")
+	}
+
+	// streams opened late still get the full output in order, including line chunks that
+	// cross from the file to memory
+	late := o.NewStream(context.Background())
+	lines := o.NewStream(context.Background(), WithLineChunks())
+	o.CloseWriter()
+	for name, stream := range map[string]<-chan []byte{"early": early, "late": late, "lines": lines} {
+		if got := readAll(t, stream, 5*time.Second); !bytes.Equal(got, want.Bytes()) {
+			t.Fatalf("%s stream: expected %d bytes of output, got %d that differ", name, want.Len(), len(got))
+		}
+	}
+
+	if got := o.Next(0); string(got) != want.String()[:4096] {
+		t.Fatalf("expected the first chunk from the spill file, got %q", got)
+	}
+	if _, ok := o.TimeAt(0); !ok {
+		t.Fatal("expected the time of spilled output")
+	}
+}
+
+func TestOutputStreamerSpillBoundsWrites(t *testing.T) {
+	t.Parallel()
+
+	const maxBuffer = 1 << 10
+	o := NewOutputStreamer(WithMaxBufferBytes(maxBuffer), WithSpillDir(t.TempDir()))
+	stdout, stderr := o.Writer(jogv1.Stream_STDOUT), o.Writer(jogv1.Stream_STDERR)
+
+	// many small writes, mostly to stdout, with a line to stderr now and then
+	var wantStdout, wantStderr bytes.Buffer
+	for i := 0; i < 100000; i++ {
+		if i%1000 == 999 {
+			line := fmt.Sprintf("warning %d\n", i)
+			wantStderr.WriteString(line)
+			stderr.Write([]byte(line))
+			continue
+		}
+		wantStdout.WriteString("x")
+		stdout.Write([]byte("x"))
+		// the marks of the output in memory, one per write, and about two per stderr line spilled
+		if n := len(o.writes); n > maxBuffer+2*(i/1000+1) {
+			t.Fatalf("expected the write marks to stay bounded, got %d after %d writes", n, i+1)
+		}
+	}
+	o.CloseWriter()
+
+	// the merged marks still tell the output streams apart
+	for _, tt := range []struct {
+		selector jogv1.StreamSelector
+		want     []byte
+	}{
+		{jogv1.StreamSelector_STREAM_SELECTOR_STDOUT, wantStdout.Bytes()},
+		{jogv1.StreamSelector_STREAM_SELECTOR_STDERR, wantStderr.Bytes()},
+	} {
+		if got := readAll(t, o.NewStream(context.Background(), WithSelector(tt.selector)), 5*time.Second); !bytes.Equal(got, tt.want) {
+			t.Fatalf("%s: expected %d bytes of output, got %d that differ", tt.selector, len(tt.want), len(got))
+		}
+	}
+	if _, ok := o.TimeAt(0); !ok {
+		t.Fatal("expected the time of spilled output")
+	}
+}
+
+func TestOutputStreamerSpillFailure(t *testing.T) {
+	t.Parallel()
+
+	const maxBuffer = 1 << 10
+	var errs []error
+	// the spill file can't be created in a directory that doesn't exist
+	o := NewOutputStreamer(WithMaxBufferBytes(maxBuffer), WithSpillDir(filepath.Join(t.TempDir(), "missing")),
+		WithSpillErrorHandler(func(err error) { errs = append(errs, err) }))
+	for i := 0; i < 1000; i++ {
+		o.Write([]byte("0123456789"))
+		if len(o.output) > maxBuffer {
+			t.Fatalf("expected at most %d bytes in memory, got %d", maxBuffer, len(o.output))
+		}
+	}
+	if len(errs) != 1 {
+		t.Fatalf("expected the spill error to be reported once, got %v", errs)
+	}
+
+	// older output was discarded, streams start at the oldest output that's kept
+	o.CloseWriter()
+	if got := o.Next(0); got != nil {
+		t.Fatalf("expected nil for discarded output, got %q", got)
+	}
+	got := readAll(t, o.NewStream(context.Background()), time.Second)
+	if len(got) == 0 || len(got) > maxBuffer || int64(len(got)) == o.Len() {
+		t.Fatalf("expected the last %d bytes of output at most, got %d", maxBuffer, len(got))
+	}
+}
+
+func TestOutputStreamerSpillRelease(t *testing.T) {
+	t.Parallel()
+
+	o := NewOutputStreamer(WithMaxBufferBytes(8), WithSpillDir(t.TempDir()), WithStreamMessageSize(4))
+	o.Write([]byte("0123456789abcdef"))
+	o.CloseWriter()
+	if o.spill == nil {
+		t.Fatal("expected older output to be spilled")
+	}
+
+	// the spill file stays open for the stream that's still reading it
+	stream := o.newChunkStream(context.Background(), nil, nil)
+	o.Release()
+	var got []byte
+	for chunk := range stream {
+		if chunk.Err != nil {
+			t.Fatalf("expected the output to be read before the spill file is closed, got %v", chunk.Err)
+		}
+		got = append(got, chunk.Data...)
+	}
+	if string(got) != "0123456789abcdef" {
+		t.Fatalf("expected %q, got %q", "0123456789abcdef", got)
+	}
+	if _, err := o.spill.Stat(); !errors.Is(err, os.ErrClosed) {
+		t.Fatalf("expected the spill file to be closed after the last stream, got %v", err)
+	}
+
+	// streams that read the spilled output after that end with an error
+	var chunks []Chunk
+	for chunk := range o.newChunkStream(context.Background(), nil, nil) {
+		chunks = append(chunks, chunk)
+	}
+	if len(chunks) != 1 || !errors.Is(chunks[0].Err, os.ErrClosed) {
+		t.Fatalf("expected the stream to end with the spill file's error, got %+v", chunks)
+	}
+}
+
+func TestOutputStreamerSpillOutputLimit(t *testing.T) {
+	t.Parallel()
+
+	// output the limit discards isn't spilled or read
+	o := NewOutputStreamer(WithOutputLimit(8), WithMaxBufferBytes(4), WithStreamMessageSize(6))
+	o.Write([]byte("0123456789"))
+	o.Write([]byte("abcdef"))
+	if got := o.Next(7); got != nil {
+		t.Fatalf("expected nil for discarded output, got %q", got)
+	}
+	if got := o.Next(8); string(got) != "89abcd" {
+		t.Fatalf("expected %q, got %q", "89abcd", got)
+	}
+	o.CloseWriter()
+	if got := readAll(t, o.NewStream(context.Background()), time.Second); string(got) != "89abcdef" {
+		t.Fatalf("expected %q, got %q", "89abcdef", got)
+	}
+}
+
+func TestOutputStreamerStartOffset(t *testing.T) {
+	t.Parallel()
+
+	o := NewOutputStreamer(WithStreamMessageSize(5))
+	var want bytes.Buffer
+	for i := 0; i < 200; i++ {
+		want.WriteString(strings.Repeat(string(rune('a'+i%26)), i%7+1))
+	}
+	o.Write(want.Bytes()[:100])
+
+	// two streams at different offsets of the same output, read while it's written, each get
+	// the output from their own offset
+	offsets := []int64{10, 90}
+	results := make([][]byte, len(offsets))
+	var wg sync.WaitGroup
+	for i, offset := range offsets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for msg := range o.NewStream(context.Background(), WithStartOffset(offset)) {
+				results[i] = append(results[i], msg...)
+			}
+		}()
+	}
+	written := want.Bytes()[100:]
+	for len(written) > 0 {
+		n := min(len(written), 31)
+		o.Write(written[:n])
+		written = written[n:]
+	}
+	o.CloseWriter()
+	wg.Wait()
+
+	for i, offset := range offsets {
+		if !bytes.Equal(results[i], want.Bytes()[offset:]) {
+			t.Fatalf("offset %d: expected %q, got %q", offset, want.Bytes()[offset:], results[i])
+		}
+	}
+	// a stream at the end of the closed output ends without output
+	if got := readAll(t, o.NewStream(context.Background(), WithStartOffset(o.Len())), time.Second); len(got) != 0 {
+		t.Fatalf("expected no output, got %q", got)
+	}
+}
+
+func TestOutputStreamerInitialBufferCapacity(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		options []OutputStreamerOption
+		want    int
+	}{
+		{name: "default", want: 0},
+		{name: "capacity", options: []OutputStreamerOption{WithInitialBufferCapacity(64 << 10)}, want: 64 << 10},
+		{name: "negative capacity", options: []OutputStreamerOption{WithInitialBufferCapacity(-1)}, want: 0},
+		{name: "output limit", options: []OutputStreamerOption{WithInitialBufferCapacity(64 << 10), WithOutputLimit(1 << 10)}, want: 2 << 10},
+		{name: "buffer cap", options: []OutputStreamerOption{WithMaxBufferBytes(4 << 10), WithInitialBufferCapacity(64 << 10)}, want: 4 << 10},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			o := NewOutputStreamer(tt.options...)
+			if got := cap(o.output); got != tt.want {
+				t.Fatalf("expected a capacity of %d, got %d", tt.want, got)
+			}
+			o.Write([]byte("hello\n"))
+			o.CloseWriter()
+			if got := readAll(t, o.NewStream(context.Background()), time.Second); string(got) != "hello\n" {
+				t.Fatalf("expected %q, got %q", "hello\n", got)
+			}
+		})
+	}
+}
+
+// BenchmarkOutputStreamerSmallWrites writes a chatty job's output, many small chunks, with
+// and without the buffer preallocated
+func BenchmarkOutputStreamerSmallWrites(b *testing.B) {
+	chunk := []byte("2024-01-01T00:00:00Z INFO step done\n")
+	for _, capacity := range []int{0, 64 << 10} {
+		b.Run(fmt.Sprintf("capacity=%d", capacity), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				o := NewOutputStreamer(WithInitialBufferCapacity(capacity))
+				for written := 0; written+len(chunk) <= 64<<10; written += len(chunk) {
+					o.Write(chunk)
+				}
+				o.CloseWriter()
+			}
+		})
+	}
+}
+
+// BenchmarkOutputStreamerConcurrentNext reads a large output with many concurrent readers,
+// while the writer is open, which takes the lock, and after it's closed, which doesn't
+func BenchmarkOutputStreamerConcurrentNext(b *testing.B) {
+	for _, closed := range []bool{false, true} {
+		name := "open"
+		if closed {
+			name = "closed"
+		}
+		b.Run(name, func(b *testing.B) {
+			o := NewOutputStreamer()
+			o.Write(bytes.Repeat([]byte("0123456789abcdef"), 1<<16))
+			if closed {
+				o.CloseWriter()
+			}
+			length := int(o.Len())
+			b.SetParallelism(16)
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				index := 0
+				for pb.Next() {
+					index += len(o.Next(index))
+					if index >= length {
+						index = 0
+					}
+				}
+			})
+		})
+	}
+}
+
+func TestOutputStreamerSelector(t *testing.T) {
+	t.Parallel()
+
+	stdout, stderr := jogv1.Stream_STDOUT, jogv1.Stream_STDERR
+	tests := []struct {
+		name     string
+		selector jogv1.StreamSelector
+		options  []StreamOption
+		want     []Chunk
+	}{
+		{
+			name:     "both",
+			selector: jogv1.StreamSelector_STREAM_SELECTOR_BOTH,
+			want:     []Chunk{{Data: []byte("building\nwarning: deprecated\nstep 2\nnote\ndone\n")}},
+		},
+		{
+			name:     "unspecified is both",
+			selector: jogv1.StreamSelector_STREAM_SELECTOR_UNSPECIFIED,
+			want:     []Chunk{{Data: []byte("building\nwarning: deprecated\nstep 2\nnote\ndone\n")}},
+		},
+		{
+			name:     "stdout",
+			selector: jogv1.StreamSelector_STREAM_SELECTOR_STDOUT,
+			want:     []Chunk{{Data: []byte("building\n"), Stream: stdout}, {Data: []byte("step 2\n"), Stream: stdout}, {Data: []byte("done\n"), Stream: stdout}},
+		},
+		{
+			name:     "stderr",
+			selector: jogv1.StreamSelector_STREAM_SELECTOR_STDERR,
+			want:     []Chunk{{Data: []byte("warning: deprecated\n"), Stream: stderr}},
+		},
+		{
+			name:     "merged",
+			selector: jogv1.StreamSelector_STREAM_SELECTOR_MERGED,
+			want: []Chunk{
+				{Data: []byte("building\n"), Stream: stdout},
+				{Data: []byte("warning: deprecated\n"), Stream: stderr},
+				{Data: []byte("step 2\n"), Stream: stdout},
+				{Data: []byte("note\n")},
+				{Data: []byte("done\n"), Stream: stdout},
+			},
+		},
+		{
+			name:     "stdout from an offset in stdout",
+			selector: jogv1.StreamSelector_STREAM_SELECTOR_STDOUT,
+			options:  []StreamOption{WithStartOffset(int64(len("building\nstep "))), WithLineChunks()},
+			want:     []Chunk{{Data: []byte("2\n"), Stream: stdout}, {Data: []byte("done\n"), Stream: stdout}},
+		},
+		{
+			name:     "stderr from its end",
+			selector: jogv1.StreamSelector_STREAM_SELECTOR_STDERR,
+			options:  []StreamOption{WithStartOffset(int64(len("warning: deprecated\n")))},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			o := NewOutputStreamer()
+			o.Writer(stdout).Write([]byte("building\n"))
+			o.Writer(stderr).Write([]byte("warning: "))
+			o.Writer(stderr).Write([]byte("deprecated\n"))
+			o.Writer(stdout).Write([]byte("step 2\n"))
+			// output written with Write isn't from either stream
+			o.Write([]byte("note\n"))
+			o.Writer(stdout).Write([]byte("done\n"))
+			o.CloseWriter()
+
+			options := append([]StreamOption{WithSelector(tt.selector)}, tt.options...)
+			got := readChunks(t, o.newChunkStream(context.Background(), nil, nil, options...), time.Second)
+			if fmt.Sprintf("%q", got) != fmt.Sprintf("%q", tt.want) {
+				t.Fatalf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestOutputStreamerSelectorInterruptedLine(t *testing.T) {
+	t.Parallel()
+
+	o := NewOutputStreamer()
+	merged := o.newChunkStream(context.Background(), nil, nil, WithSelector(jogv1.StreamSelector_STREAM_SELECTOR_MERGED), WithLineChunks())
+	stdout := o.NewStream(context.Background(), WithSelector(jogv1.StreamSelector_STREAM_SELECTOR_STDOUT), WithLineChunks())
+	stderr := o.NewStream(context.Background(), WithSelector(jogv1.StreamSelector_STREAM_SELECTOR_STDERR))
+
+	// the streams wait for their own output while the other stream writes
+	o.Writer(jogv1.Stream_STDOUT).Write([]byte("compiling... "))
+	o.Writer(jogv1.Stream_STDERR).Write([]byte("warning: unused variable\n"))
+	o.Writer(jogv1.Stream_STDOUT).Write([]byte("ok\n"))
+	if got := readN(t, stderr, len("warning: unused variable\n")); string(got) != "warning: unused variable\n" {
+		t.Fatalf("expected the stderr stream to get %q, got %q", "warning: unused variable\n", got)
+	}
+	o.CloseWriter()
+
+	want := []Chunk{
+		{Data: []byte("compiling... "), Stream: jogv1.Stream_STDOUT},
+		{Data: []byte("warning: unused variable\n"), Stream: jogv1.Stream_STDERR},
+		{Data: []byte("ok\n"), Stream: jogv1.Stream_STDOUT},
+	}
+	if got := readChunks(t, merged, time.Second); fmt.Sprintf("%q", got) != fmt.Sprintf("%q", want) {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+	var lines []string
+	for msg := range stdout {
+		lines = append(lines, string(msg))
+	}
+	if want := []string{"compiling... ", "ok\n"}; !slices.Equal(lines, want) {
+		t.Fatalf("expected the interrupted line in pieces, %q, got %q", want, lines)
+	}
+	if rest := readAll(t, stderr, time.Second); len(rest) != 0 {
+		t.Fatalf("expected no more stderr, got %q", rest)
+	}
+	if got := o.selectedLen(jogv1.StreamSelector_STREAM_SELECTOR_STDOUT); got != int64(len("compiling... ok\n")) {
+		t.Fatalf("expected %d bytes of stdout, got %d", len("compiling... ok\n"), got)
+	}
+}
+
+func TestOutputStreamerRedactionPerStream(t *testing.T) {
+	t.Parallel()
+
+	pattern, err := CompileRedaction([]string{"hunter2"}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	o := NewOutputStreamer(WithRedaction(pattern))
+	// stderr output between the pieces of the secret doesn't keep it from being masked
+	o.Writer(jogv1.Stream_STDOUT).Write([]byte("password: hun"))
+	o.Writer(jogv1.Stream_STDERR).Write([]byte("prompting\n"))
+	o.Writer(jogv1.Stream_STDOUT).Write([]byte("ter2\n"))
+	o.CloseWriter()
+
+	if got := readAll(t, o.NewStream(context.Background(), WithSelector(jogv1.StreamSelector_STREAM_SELECTOR_STDOUT)), time.Second); string(got) != "password: ***\n" {
+		t.Fatalf("expected %q, got %q", "password: ***\n", got)
+	}
+}
@@ -0,0 +1,77 @@
+package job
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+
+	"github.com/creack/pty"
+)
+
+// The window size of job terminals. Jobs aren't attached to a real terminal, so these
+// are the classic defaults.
+const (
+	defaultTTYRows = 24
+	defaultTTYCols = 80
+)
+
+// jobTTY is a pseudo-terminal that a job runs in. The job's stdio is the terminal's slave
+// side, and the job's output is read from the master side.
+type jobTTY struct {
+	master *os.File
+	slave  *os.File
+	// copied is closed when all the job's output has been read from master
+	copied chan struct{}
+}
+
+// openTTY allocates a pseudo-terminal
+func openTTY() (*jobTTY, error) {
+	master, slave, err := pty.Open()
+	if err != nil {
+		return nil, fmt.Errorf("opening pty: %w", err)
+	}
+	if err := pty.Setsize(master, &pty.Winsize{Rows: defaultTTYRows, Cols: defaultTTYCols}); err != nil {
+		master.Close()
+		slave.Close()
+		return nil, fmt.Errorf("setting pty window size: %w", err)
+	}
+	return &jobTTY{master: master, slave: slave, copied: make(chan struct{})}, nil
+}
+
+// attach makes the terminal the command's stdio and controlling terminal
+func (t *jobTTY) attach(cmd *exec.Cmd) {
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = t.slave, t.slave, t.slave
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	// the terminal can only be the controlling terminal of a session leader.
+	// Ctty is the descriptor number in the child, which is stdin.
+	cmd.SysProcAttr.Setsid = true
+	cmd.SysProcAttr.Setctty = true
+	cmd.SysProcAttr.Ctty = 0
+}
+
+// copyOutput copies the job's output to w until every process using the terminal has
+// closed it. It's called after the job is started, once the job has its own copy of the slave.
+func (t *jobTTY) copyOutput(w io.Writer) {
+	t.slave.Close()
+	go func() {
+		defer close(t.copied)
+		// reading the master fails with EIO once the slave is closed, that's the end of the output
+		io.Copy(w, t.master)
+	}()
+}
+
+// close waits up to timeout for the job's output to be copied, then closes the terminal.
+// The timeout bounds the wait when processes started by the job keep the terminal open.
+func (t *jobTTY) close(timeout time.Duration) {
+	select {
+	case <-t.copied:
+	case <-time.After(timeout):
+	}
+	t.master.Close()
+	t.slave.Close()
+}
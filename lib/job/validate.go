@@ -0,0 +1,60 @@
+package job
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidCommand is returned when a job's command or arguments can't be run
+var ErrInvalidCommand = errors.New("invalid command")
+
+// ValidateCommand checks a job's command and arguments before they're passed to exec.
+// The command must be non-empty, valid UTF-8, and free of null bytes. Arguments may be
+// any bytes except null, since they're passed to the process as C strings and a null
+// byte would silently truncate them.
+func ValidateCommand(name string, args []string) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("%w: the command is empty", ErrInvalidCommand)
+	}
+	if !utf8.ValidString(name) {
+		return fmt.Errorf("%w: the command is not valid UTF-8", ErrInvalidCommand)
+	}
+	if strings.IndexByte(name, 0) >= 0 {
+		return fmt.Errorf("%w: the command contains a null byte", ErrInvalidCommand)
+	}
+	for i, arg := range args {
+		if strings.IndexByte(arg, 0) >= 0 {
+			return fmt.Errorf("%w: argument %d contains a null byte", ErrInvalidCommand, i)
+		}
+	}
+	return nil
+}
+
+// ErrInvalidName is returned when a job's name can't be used to refer to it
+var ErrInvalidName = errors.New("invalid job name")
+
+// maxNameLength is the longest job name allowed
+const maxNameLength = 64
+
+// ValidateName checks a job's name. Names are 1 to 64 letters, digits, '.', '_', or '-',
+// starting with a letter or digit, e.g. nightly-build. Names that look like job ids are
+// rejected, so a name never shadows a job id.
+func ValidateName(name string) error {
+	if name == "" || len(name) > maxNameLength {
+		return fmt.Errorf("%w: names are 1 to %d characters", ErrInvalidName, maxNameLength)
+	}
+	for i, r := range name {
+		alnum := r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9'
+		if !alnum && (i == 0 || r != '.' && r != '_' && r != '-') {
+			return fmt.Errorf("%w: use letters, digits, '.', '_', or '-', starting with a letter or digit", ErrInvalidName)
+		}
+	}
+	if _, err := uuid.Parse(name); err == nil {
+		return fmt.Errorf("%w: the name looks like a job id", ErrInvalidName)
+	}
+	return nil
+}
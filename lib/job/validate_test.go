@@ -0,0 +1,84 @@
+package job
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateCommand(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		cmd  string
+		args []string
+		err  bool
+	}{
+		{name: "simple command", cmd: "echo", args: []string{"hello", "world"}},
+		{name: "absolute path", cmd: "/usr/bin/env"},
+		{name: "shell script", cmd: "sh", args: []string{"-c", "echo $HOME | tr a-z A-Z > /dev/null; exit 0"}},
+		{name: "empty args", cmd: "printf", args: []string{"", "%s\n"}},
+		{name: "unicode", cmd: "echo", args: []string{"héllo ωorld ✓"}},
+		{name: "non-UTF-8 argument", cmd: "printf", args: []string{"\xff\xfe"}},
+		{name: "empty command", cmd: "", err: true},
+		{name: "blank command", cmd: "  \t", err: true},
+		{name: "non-UTF-8 command", cmd: "ech\xffo", err: true},
+		{name: "null byte in command", cmd: "echo\x00rm", err: true},
+		{name: "null byte in argument", cmd: "echo", args: []string{"ok", "a\x00b"}, err: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := ValidateCommand(tt.cmd, tt.args)
+			if tt.err {
+				if !errors.Is(err, ErrInvalidCommand) {
+					t.Fatalf("expected ErrInvalidCommand, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateName(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		err  bool
+	}{
+		{name: "nightly-build"},
+		{name: "a"},
+		{name: "build_2024.10"},
+		{name: "9lives"},
+		{name: strings.Repeat("a", 64)},
+		{name: "", err: true},
+		{name: strings.Repeat("a", 65), err: true},
+		{name: "-flag", err: true},
+		{name: ".hidden", err: true},
+		{name: "has space", err: true},
+		{name: "slash/name", err: true},
+		{name: "héllo", err: true},
+		{name: "6ba7b810-9dad-11d1-80b4-00c04fd430c8", err: true},
+	}
+
+	for _, tt := range tests {
+		err := ValidateName(tt.name)
+		if tt.err {
+			if !errors.Is(err, ErrInvalidName) {
+				t.Fatalf("ValidateName(%q): expected ErrInvalidName, got %v", tt.name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("ValidateName(%q): unexpected error: %v", tt.name, err)
+		}
+	}
+}
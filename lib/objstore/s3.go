@@ -0,0 +1,178 @@
+// Package objstore uploads objects to S3-compatible object stores, e.g. AWS S3 or MinIO.
+// Only what jogger needs is implemented: single PUTs, signed with AWS Signature Version 4.
+package objstore
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// S3 is a client for a bucket in an S3-compatible object store. Objects are addressed
+// path-style, <endpoint>/<bucket>/<key>, which every S3-compatible store supports.
+type S3 struct {
+	endpoint  *url.URL
+	bucket    string
+	region    string
+	accessKey string
+	secretKey string
+	client    *http.Client
+	now       func() time.Time
+}
+
+type S3Option func(*S3)
+
+// WithCredentials sets the access key and secret key requests are signed with. Requests
+// aren't signed without them, which only works with buckets that allow anonymous writes.
+func WithCredentials(accessKey, secretKey string) S3Option {
+	return func(s *S3) {
+		s.accessKey, s.secretKey = accessKey, secretKey
+	}
+}
+
+// WithRegion sets the region requests are signed for. It defaults to us-east-1, which
+// most S3-compatible stores accept.
+func WithRegion(region string) S3Option {
+	return func(s *S3) {
+		s.region = region
+	}
+}
+
+// WithHTTPClient sets the client requests are sent with. It defaults to one with a 30s timeout.
+func WithHTTPClient(client *http.Client) S3Option {
+	return func(s *S3) {
+		s.client = client
+	}
+}
+
+// NewS3 returns a client for bucket at endpoint, e.g. https://s3.us-east-1.amazonaws.com
+func NewS3(endpoint, bucket string, options ...S3Option) (*S3, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("parsing endpoint: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return nil, fmt.Errorf("invalid endpoint: %q: use http(s)://host[:port]", endpoint)
+	}
+	if bucket == "" {
+		return nil, fmt.Errorf("no bucket provided")
+	}
+	s := &S3{
+		endpoint: u,
+		bucket:   bucket,
+		region:   "us-east-1",
+		client:   &http.Client{Timeout: 30 * time.Second},
+		now:      time.Now,
+	}
+	for _, opt := range options {
+		opt(s)
+	}
+	return s, nil
+}
+
+// Upload stores data as the object key, replacing it if it exists
+func (s *S3) Upload(ctx context.Context, key string, data []byte) error {
+	u := *s.endpoint
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + s.bucket + "/" + strings.TrimPrefix(key, "/")
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u.String(), bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("uploading %s: %w", key, err)
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	if s.accessKey != "" {
+		sum := sha256.Sum256(data)
+		signRequest(req, hex.EncodeToString(sum[:]), s.accessKey, s.secretKey, s.region, "s3", s.now())
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("uploading %s: %w", key, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("uploading %s: %s: %s", key, resp.Status, bytes.TrimSpace(body))
+	}
+	return nil
+}
+
+// signRequest adds an AWS Signature Version 4 Authorization header to req. The host and the
+// x-amz-* headers are signed. payloadHash is the hex SHA-256 of the body.
+// See https://docs.aws.amazon.com/IAM/latest/UserGuide/create-signed-request.html
+func signRequest(req *http.Request, payloadHash, accessKey, secretKey, region, service string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	if service == "s3" {
+		req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for k, v := range req.Header {
+		if k = strings.ToLower(k); strings.HasPrefix(k, "x-amz-") {
+			headers[k] = strings.TrimSpace(strings.Join(v, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, k := range names {
+		canonicalHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), date)
+	for _, part := range []string{region, service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", accessKey, scope, signedHeaders, signature))
+}
+
+// canonicalQuery returns the query sorted by name, then value, with each escaped
+func canonicalQuery(q url.Values) string {
+	var params []string
+	for k, vs := range q {
+		for _, v := range vs {
+			params = append(params, escape(k)+"="+escape(v))
+		}
+	}
+	sort.Strings(params)
+	return strings.Join(params, "&")
+}
+
+// escape percent-encodes everything but the unreserved characters, as SigV4 requires
+func escape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
@@ -0,0 +1,126 @@
+package objstore
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSignRequest(t *testing.T) {
+	t.Parallel()
+
+	// get-vanilla from the AWS Signature Version 4 test suite
+	req := httptest.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	emptyHash := sha256.Sum256(nil)
+	now := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+	signRequest(req, hex.EncodeToString(emptyHash[:]), "AKIDEXAMPLE", "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", "us-east-1", "service", now)
+
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Fatalf("expected Authorization %q, got %q", want, got)
+	}
+}
+
+// fakeStore is an S3-compatible server that keeps the objects PUT to it in memory
+type fakeStore struct {
+	mu      sync.Mutex
+	objects map[string]string
+	auth    []string
+	// fail is the number of uploads to fail before accepting them
+	fail int
+}
+
+func (f *fakeStore) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if r.Method != http.MethodPut {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	f.auth = append(f.auth, r.Header.Get("Authorization"))
+	if f.fail > 0 {
+		f.fail--
+		http.Error(w, "SlowDown", http.StatusServiceUnavailable)
+		return
+	}
+	body, _ := io.ReadAll(r.Body)
+	f.objects[r.URL.Path] = string(body)
+}
+
+func TestS3Upload(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		options  []S3Option
+		fail     int
+		wantErr  bool
+		wantAuth string
+	}{
+		{
+			name:     "signed upload",
+			options:  []S3Option{WithCredentials("AKID", "secret"), WithRegion("eu-west-1")},
+			wantAuth: "AWS4-HMAC-SHA256 Credential=AKID/",
+		},
+		{
+			name: "anonymous upload",
+		},
+		{
+			name:    "error status",
+			fail:    1,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			store := &fakeStore{objects: make(map[string]string), fail: tt.fail}
+			server := httptest.NewServer(store)
+			t.Cleanup(server.Close)
+
+			s3, err := NewS3(server.URL, "jobs", tt.options...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			err = s3.Upload(context.Background(), "output/user1/123", []byte("hello\x00world\n"))
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "SlowDown") {
+					t.Fatalf("expected an error with the response body, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := store.objects["/jobs/output/user1/123"]; got != "hello\x00world\n" {
+				t.Fatalf("expected the object to be stored, got %q", store.objects)
+			}
+			if !strings.HasPrefix(store.auth[0], tt.wantAuth) || (tt.wantAuth == "") != (store.auth[0] == "") {
+				t.Fatalf("expected Authorization to start with %q, got %q", tt.wantAuth, store.auth[0])
+			}
+		})
+	}
+}
+
+func TestNewS3RejectsInvalidConfig(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct{ endpoint, bucket string }{
+		{endpoint: "s3.amazonaws.com", bucket: "jobs"},
+		{endpoint: "ftp://s3.amazonaws.com", bucket: "jobs"},
+		{endpoint: "https://s3.amazonaws.com", bucket: ""},
+	} {
+		if _, err := NewS3(tt.endpoint, tt.bucket); err == nil {
+			t.Fatalf("expected an error for endpoint %q and bucket %q", tt.endpoint, tt.bucket)
+		}
+	}
+}
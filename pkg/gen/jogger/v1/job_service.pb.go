@@ -41,6 +41,17 @@ const (
 	Status_FAILED Status = 4
 	// COMPLETED: The job exited with status = 0
 	Status_COMPLETED Status = 5
+	// START_FAILED: The job's process couldn't be started. These are only
+	// reported by servers that record failed starts, and only for a while.
+	Status_START_FAILED Status = 6
+	// STOPPING: The job was asked to stop, and its process hasn't exited yet.
+	// It becomes STOPPED or KILLED once it exits, or COMPLETED or FAILED if it
+	// exited on its own first.
+	Status_STOPPING Status = 7
+	// PENDING: The job was started after another job, see after_job_id, and
+	// is waiting for it to be done. It has no process or output yet. It
+	// becomes RUNNING once it's started, or START_FAILED if it isn't.
+	Status_PENDING Status = 8
 )
 
 // Enum value maps for Status.
@@ -52,6 +63,9 @@ var (
 		3: "KILLED",
 		4: "FAILED",
 		5: "COMPLETED",
+		6: "START_FAILED",
+		7: "STOPPING",
+		8: "PENDING",
 	}
 	Status_value = map[string]int32{
 		"STATUS_UNSPECIFIED": 0,
@@ -60,6 +74,9 @@ var (
 		"KILLED":             3,
 		"FAILED":             4,
 		"COMPLETED":          5,
+		"START_FAILED":       6,
+		"STOPPING":           7,
+		"PENDING":            8,
 	}
 )
 
@@ -90,33 +107,1149 @@ func (Status) EnumDescriptor() ([]byte, []int) {
 	return file_jogger_v1_job_service_proto_rawDescGZIP(), []int{0}
 }
 
-// Request to start a job
+// StreamSelector is which of a job's output streams an output request reads.
+// stdout and stderr are read from separate pipes, so the order of their output
+// is the order the server read it in, which is as close as it can tell to the
+// order the job wrote it in. A job with a terminal only has stdout.
+type StreamSelector int32
+
+const (
+	// STREAM_SELECTOR_UNSPECIFIED: use STREAM_SELECTOR_BOTH
+	StreamSelector_STREAM_SELECTOR_UNSPECIFIED StreamSelector = 0
+	// STREAM_SELECTOR_BOTH: stdout and stderr combined, messages may hold both
+	StreamSelector_STREAM_SELECTOR_BOTH StreamSelector = 1
+	// STREAM_SELECTOR_STDOUT: only the job's stdout
+	StreamSelector_STREAM_SELECTOR_STDOUT StreamSelector = 2
+	// STREAM_SELECTOR_STDERR: only the job's stderr
+	StreamSelector_STREAM_SELECTOR_STDERR StreamSelector = 3
+	// STREAM_SELECTOR_MERGED: stdout and stderr combined, each message holds only
+	//one of them, and its stream says which. A line interrupted by the other
+	//stream's output is sent in pieces.
+	StreamSelector_STREAM_SELECTOR_MERGED StreamSelector = 4
+)
+
+// Enum value maps for StreamSelector.
+var (
+	StreamSelector_name = map[int32]string{
+		0: "STREAM_SELECTOR_UNSPECIFIED",
+		1: "STREAM_SELECTOR_BOTH",
+		2: "STREAM_SELECTOR_STDOUT",
+		3: "STREAM_SELECTOR_STDERR",
+		4: "STREAM_SELECTOR_MERGED",
+	}
+	StreamSelector_value = map[string]int32{
+		"STREAM_SELECTOR_UNSPECIFIED": 0,
+		"STREAM_SELECTOR_BOTH":        1,
+		"STREAM_SELECTOR_STDOUT":      2,
+		"STREAM_SELECTOR_STDERR":      3,
+		"STREAM_SELECTOR_MERGED":      4,
+	}
+)
+
+func (x StreamSelector) Enum() *StreamSelector {
+	p := new(StreamSelector)
+	*p = x
+	return p
+}
+
+func (x StreamSelector) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (StreamSelector) Descriptor() protoreflect.EnumDescriptor {
+	return file_jogger_v1_job_service_proto_enumTypes[1].Descriptor()
+}
+
+func (StreamSelector) Type() protoreflect.EnumType {
+	return &file_jogger_v1_job_service_proto_enumTypes[1]
+}
+
+func (x StreamSelector) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use StreamSelector.Descriptor instead.
+func (StreamSelector) EnumDescriptor() ([]byte, []int) {
+	return file_jogger_v1_job_service_proto_rawDescGZIP(), []int{1}
+}
+
+// Stream identifies one of a job's output streams
+type Stream int32
+
+const (
+	// STREAM_UNSPECIFIED: the output is stdout and stderr combined
+	Stream_STREAM_UNSPECIFIED Stream = 0
+	// STDOUT: the job's standard output
+	Stream_STDOUT Stream = 1
+	// STDERR: the job's standard error
+	Stream_STDERR Stream = 2
+)
+
+// Enum value maps for Stream.
+var (
+	Stream_name = map[int32]string{
+		0: "STREAM_UNSPECIFIED",
+		1: "STDOUT",
+		2: "STDERR",
+	}
+	Stream_value = map[string]int32{
+		"STREAM_UNSPECIFIED": 0,
+		"STDOUT":             1,
+		"STDERR":             2,
+	}
+)
+
+func (x Stream) Enum() *Stream {
+	p := new(Stream)
+	*p = x
+	return p
+}
+
+func (x Stream) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Stream) Descriptor() protoreflect.EnumDescriptor {
+	return file_jogger_v1_job_service_proto_enumTypes[2].Descriptor()
+}
+
+func (Stream) Type() protoreflect.EnumType {
+	return &file_jogger_v1_job_service_proto_enumTypes[2]
+}
+
+func (x Stream) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Stream.Descriptor instead.
+func (Stream) EnumDescriptor() ([]byte, []int) {
+	return file_jogger_v1_job_service_proto_rawDescGZIP(), []int{2}
+}
+
+type Capture int32
+
+const (
+	// CAPTURE_UNSPECIFIED: use the server's default
+	Capture_CAPTURE_UNSPECIFIED Capture = 0
+	// CAPTURE_BOTH: keep stdout and stderr
+	Capture_CAPTURE_BOTH Capture = 1
+	// CAPTURE_STDOUT: keep stdout, discard stderr
+	Capture_CAPTURE_STDOUT Capture = 2
+	// CAPTURE_STDERR: keep stderr, discard stdout
+	Capture_CAPTURE_STDERR Capture = 3
+)
+
+// Enum value maps for Capture.
+var (
+	Capture_name = map[int32]string{
+		0: "CAPTURE_UNSPECIFIED",
+		1: "CAPTURE_BOTH",
+		2: "CAPTURE_STDOUT",
+		3: "CAPTURE_STDERR",
+	}
+	Capture_value = map[string]int32{
+		"CAPTURE_UNSPECIFIED": 0,
+		"CAPTURE_BOTH":        1,
+		"CAPTURE_STDOUT":      2,
+		"CAPTURE_STDERR":      3,
+	}
+)
+
+func (x Capture) Enum() *Capture {
+	p := new(Capture)
+	*p = x
+	return p
+}
+
+func (x Capture) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Capture) Descriptor() protoreflect.EnumDescriptor {
+	return file_jogger_v1_job_service_proto_enumTypes[3].Descriptor()
+}
+
+func (Capture) Type() protoreflect.EnumType {
+	return &file_jogger_v1_job_service_proto_enumTypes[3]
+}
+
+func (x Capture) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Capture.Descriptor instead.
+func (Capture) EnumDescriptor() ([]byte, []int) {
+	return file_jogger_v1_job_service_proto_rawDescGZIP(), []int{3}
+}
+
+// Chunking is how a job's output is split into messages
+type Chunking int32
+
+const (
+	// CHUNKING_UNSPECIFIED: use CHUNKING_SIZE
+	Chunking_CHUNKING_UNSPECIFIED Chunking = 0
+	// CHUNKING_SIZE: send the output as soon as it's available, in messages of up to chunk_size bytes
+	Chunking_CHUNKING_SIZE Chunking = 1
+	// CHUNKING_LINE: send each line in its own message once it's complete. A final line without
+	//a newline is sent when the job exits.
+	Chunking_CHUNKING_LINE Chunking = 2
+)
+
+// Enum value maps for Chunking.
+var (
+	Chunking_name = map[int32]string{
+		0: "CHUNKING_UNSPECIFIED",
+		1: "CHUNKING_SIZE",
+		2: "CHUNKING_LINE",
+	}
+	Chunking_value = map[string]int32{
+		"CHUNKING_UNSPECIFIED": 0,
+		"CHUNKING_SIZE":        1,
+		"CHUNKING_LINE":        2,
+	}
+)
+
+func (x Chunking) Enum() *Chunking {
+	p := new(Chunking)
+	*p = x
+	return p
+}
+
+func (x Chunking) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Chunking) Descriptor() protoreflect.EnumDescriptor {
+	return file_jogger_v1_job_service_proto_enumTypes[4].Descriptor()
+}
+
+func (Chunking) Type() protoreflect.EnumType {
+	return &file_jogger_v1_job_service_proto_enumTypes[4]
+}
+
+func (x Chunking) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Chunking.Descriptor instead.
+func (Chunking) EnumDescriptor() ([]byte, []int) {
+	return file_jogger_v1_job_service_proto_rawDescGZIP(), []int{4}
+}
+
+// Encoding is how the data in output messages is encoded. chunk_size and
+// max_bytes count the output's bytes before it's encoded.
+type Encoding int32
+
+const (
+	// ENCODING_UNSPECIFIED: use ENCODING_RAW
+	Encoding_ENCODING_UNSPECIFIED Encoding = 0
+	// ENCODING_RAW: the output's bytes as is
+	Encoding_ENCODING_RAW Encoding = 1
+	// ENCODING_BASE64: standard base64, with padding
+	Encoding_ENCODING_BASE64 Encoding = 2
+	// ENCODING_HEX: lower case hexadecimal, two digits per byte
+	Encoding_ENCODING_HEX Encoding = 3
+)
+
+// Enum value maps for Encoding.
+var (
+	Encoding_name = map[int32]string{
+		0: "ENCODING_UNSPECIFIED",
+		1: "ENCODING_RAW",
+		2: "ENCODING_BASE64",
+		3: "ENCODING_HEX",
+	}
+	Encoding_value = map[string]int32{
+		"ENCODING_UNSPECIFIED": 0,
+		"ENCODING_RAW":         1,
+		"ENCODING_BASE64":      2,
+		"ENCODING_HEX":         3,
+	}
+)
+
+func (x Encoding) Enum() *Encoding {
+	p := new(Encoding)
+	*p = x
+	return p
+}
+
+func (x Encoding) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Encoding) Descriptor() protoreflect.EnumDescriptor {
+	return file_jogger_v1_job_service_proto_enumTypes[5].Descriptor()
+}
+
+func (Encoding) Type() protoreflect.EnumType {
+	return &file_jogger_v1_job_service_proto_enumTypes[5]
+}
+
+func (x Encoding) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Encoding.Descriptor instead.
+func (Encoding) EnumDescriptor() ([]byte, []int) {
+	return file_jogger_v1_job_service_proto_rawDescGZIP(), []int{5}
+}
+
+// Request to start a job. The job is given either by spec, or by job and the
+// other fields, which are kept for older clients. A request can't set both.
 type StartRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	// The job to start
-	Job *Job `protobuf:"bytes,1,opt,name=job,proto3" json:"job,omitempty"`
+	// The job to start
+	Job *Job `protobuf:"bytes,1,opt,name=job,proto3" json:"job,omitempty"`
+	// when greater than 0, the server only keeps the last output_limit_bytes of the
+	// job's output. Earlier output is discarded, and can't be streamed.
+	OutputLimitBytes int64 `protobuf:"varint,2,opt,name=output_limit_bytes,json=outputLimitBytes,proto3" json:"output_limit_bytes,omitempty"`
+	// which of the job's output streams are kept, the server's default when unspecified.
+	// Output that isn't captured is discarded. Ignored for tty jobs.
+	Capture Capture `protobuf:"varint,3,opt,name=capture,proto3,enum=jogger.v1.Capture" json:"capture,omitempty"`
+	// an optional name the job can be referred to by in place of its job_id, in
+	// every request that takes one. Names are 1 to 64 letters, digits, '.', '_',
+	// or '-', and must be unique among the user's running jobs.
+	Name string `protobuf:"bytes,4,opt,name=name,proto3" json:"name,omitempty"`
+	// the job to start and how it's run. New job options are only added to the spec.
+	Spec *JobSpec `protobuf:"bytes,5,opt,name=spec,proto3" json:"spec,omitempty"`
+}
+
+func (x *StartRequest) Reset() {
+	*x = StartRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_jogger_v1_job_service_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StartRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StartRequest) ProtoMessage() {}
+
+func (x *StartRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_jogger_v1_job_service_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StartRequest.ProtoReflect.Descriptor instead.
+func (*StartRequest) Descriptor() ([]byte, []int) {
+	return file_jogger_v1_job_service_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *StartRequest) GetJob() *Job {
+	if x != nil {
+		return x.Job
+	}
+	return nil
+}
+
+func (x *StartRequest) GetOutputLimitBytes() int64 {
+	if x != nil {
+		return x.OutputLimitBytes
+	}
+	return 0
+}
+
+func (x *StartRequest) GetCapture() Capture {
+	if x != nil {
+		return x.Capture
+	}
+	return Capture_CAPTURE_UNSPECIFIED
+}
+
+func (x *StartRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *StartRequest) GetSpec() *JobSpec {
+	if x != nil {
+		return x.Spec
+	}
+	return nil
+}
+
+// Job represents a command and arguments to run on the server.
+type Job struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// the command to run
+	Cmd string `protobuf:"bytes,1,opt,name=cmd,proto3" json:"cmd,omitempty"`
+	// the arguments to pass to the command
+	Args []string `protobuf:"bytes,2,rep,name=args,proto3" json:"args,omitempty"`
+	// run the job in a pseudo-terminal, so tools that check for a terminal behave
+	// interactively. Output line endings become \r\n, and stdout and stderr are combined.
+	Tty bool `protobuf:"varint,4,opt,name=tty,proto3" json:"tty,omitempty"`
+	// run the command under a shell, with sh -c, so it can use variables, pipes,
+	// and &&. The arguments are quoted and appended to the command, so they're
+	// passed to it literally. The server must be configured to allow shell jobs.
+	Shell bool `protobuf:"varint,5,opt,name=shell,proto3" json:"shell,omitempty"`
+}
+
+func (x *Job) Reset() {
+	*x = Job{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_jogger_v1_job_service_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Job) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Job) ProtoMessage() {}
+
+func (x *Job) ProtoReflect() protoreflect.Message {
+	mi := &file_jogger_v1_job_service_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Job.ProtoReflect.Descriptor instead.
+func (*Job) Descriptor() ([]byte, []int) {
+	return file_jogger_v1_job_service_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Job) GetCmd() string {
+	if x != nil {
+		return x.Cmd
+	}
+	return ""
+}
+
+func (x *Job) GetArgs() []string {
+	if x != nil {
+		return x.Args
+	}
+	return nil
+}
+
+func (x *Job) GetTty() bool {
+	if x != nil {
+		return x.Tty
+	}
+	return false
+}
+
+func (x *Job) GetShell() bool {
+	if x != nil {
+		return x.Shell
+	}
+	return false
+}
+
+// JobSpec is everything the server needs to start a job: the command to run,
+// how it's run, how its output is kept, and how it can be referred to. The
+// fields are the same as the Job and StartRequest fields of the same names.
+type JobSpec struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// the command to run
+	Cmd string `protobuf:"bytes,1,opt,name=cmd,proto3" json:"cmd,omitempty"`
+	// the arguments to pass to the command
+	Args []string `protobuf:"bytes,2,rep,name=args,proto3" json:"args,omitempty"`
+	// run the job in a pseudo-terminal
+	Tty bool `protobuf:"varint,4,opt,name=tty,proto3" json:"tty,omitempty"`
+	// run the command under a shell, with sh -c
+	Shell bool `protobuf:"varint,5,opt,name=shell,proto3" json:"shell,omitempty"`
+	// an optional name the job can be referred to by in place of its job_id
+	Name string `protobuf:"bytes,6,opt,name=name,proto3" json:"name,omitempty"`
+	// which of the job's output streams are kept, the server's default when unspecified
+	Capture Capture `protobuf:"varint,7,opt,name=capture,proto3,enum=jogger.v1.Capture" json:"capture,omitempty"`
+	// when greater than 0, the server only keeps the last output_limit_bytes of the
+	// job's output
+	OutputLimitBytes int64 `protobuf:"varint,8,opt,name=output_limit_bytes,json=outputLimitBytes,proto3" json:"output_limit_bytes,omitempty"`
+	// the job's file mode creation mask in octal, e.g. "022". The job inherits the
+	// server's umask when it's empty.
+	Umask string `protobuf:"bytes,9,opt,name=umask,proto3" json:"umask,omitempty"`
+	// when greater than 0, the job's RLIMIT_NOFILE, the most file descriptors each
+	// of its processes can have open. It can't be more than the server's own limit.
+	RlimitNofile uint64 `protobuf:"varint,10,opt,name=rlimit_nofile,json=rlimitNofile,proto3" json:"rlimit_nofile,omitempty"`
+	// when greater than 0, the job's RLIMIT_NPROC, the most processes its user can
+	// have. It can't be more than the server's own limit, and isn't enforced for
+	// jobs run as root.
+	RlimitNproc uint64 `protobuf:"varint,11,opt,name=rlimit_nproc,json=rlimitNproc,proto3" json:"rlimit_nproc,omitempty"`
+	// the job_id or name of one of the user's jobs to start the job after. The
+	// job is PENDING until that job is done, which may be pending itself. It's
+	// started however that job ended, unless after_completed is set.
+	AfterJobId string `protobuf:"bytes,12,opt,name=after_job_id,json=afterJobId,proto3" json:"after_job_id,omitempty"`
+	// only start the job if the after_job_id job COMPLETED. Otherwise it isn't
+	// started, and its status is START_FAILED, with why in its start_error.
+	AfterCompleted bool `protobuf:"varint,13,opt,name=after_completed,json=afterCompleted,proto3" json:"after_completed,omitempty"`
+	// secrets to mask in the job's output: each occurrence is replaced with ***
+	// before the output is kept or streamed, along with any the server masks.
+	// It's best-effort, e.g. a secret the job encodes isn't masked. The server
+	// never logs them.
+	Redact []string `protobuf:"bytes,14,rep,name=redact,proto3" json:"redact,omitempty"`
+	// like redact, but RE2 regular expressions, e.g. ghp_[A-Za-z0-9]+. Patterns
+	// that match empty output aren't allowed.
+	RedactPatterns []string `protobuf:"bytes,15,rep,name=redact_patterns,json=redactPatterns,proto3" json:"redact_patterns,omitempty"`
+}
+
+func (x *JobSpec) Reset() {
+	*x = JobSpec{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_jogger_v1_job_service_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *JobSpec) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JobSpec) ProtoMessage() {}
+
+func (x *JobSpec) ProtoReflect() protoreflect.Message {
+	mi := &file_jogger_v1_job_service_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JobSpec.ProtoReflect.Descriptor instead.
+func (*JobSpec) Descriptor() ([]byte, []int) {
+	return file_jogger_v1_job_service_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *JobSpec) GetCmd() string {
+	if x != nil {
+		return x.Cmd
+	}
+	return ""
+}
+
+func (x *JobSpec) GetArgs() []string {
+	if x != nil {
+		return x.Args
+	}
+	return nil
+}
+
+func (x *JobSpec) GetTty() bool {
+	if x != nil {
+		return x.Tty
+	}
+	return false
+}
+
+func (x *JobSpec) GetShell() bool {
+	if x != nil {
+		return x.Shell
+	}
+	return false
+}
+
+func (x *JobSpec) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *JobSpec) GetCapture() Capture {
+	if x != nil {
+		return x.Capture
+	}
+	return Capture_CAPTURE_UNSPECIFIED
+}
+
+func (x *JobSpec) GetOutputLimitBytes() int64 {
+	if x != nil {
+		return x.OutputLimitBytes
+	}
+	return 0
+}
+
+func (x *JobSpec) GetUmask() string {
+	if x != nil {
+		return x.Umask
+	}
+	return ""
+}
+
+func (x *JobSpec) GetRlimitNofile() uint64 {
+	if x != nil {
+		return x.RlimitNofile
+	}
+	return 0
+}
+
+func (x *JobSpec) GetRlimitNproc() uint64 {
+	if x != nil {
+		return x.RlimitNproc
+	}
+	return 0
+}
+
+func (x *JobSpec) GetAfterJobId() string {
+	if x != nil {
+		return x.AfterJobId
+	}
+	return ""
+}
+
+func (x *JobSpec) GetAfterCompleted() bool {
+	if x != nil {
+		return x.AfterCompleted
+	}
+	return false
+}
+
+func (x *JobSpec) GetRedact() []string {
+	if x != nil {
+		return x.Redact
+	}
+	return nil
+}
+
+func (x *JobSpec) GetRedactPatterns() []string {
+	if x != nil {
+		return x.RedactPatterns
+	}
+	return nil
+}
+
+// Response to starting a job
+type StartResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// the job_id of the job that was started
+	JobId string `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	// the status of the job when the response was sent. It's RUNNING, unless the
+	// job already finished.
+	Status Status `protobuf:"varint,2,opt,name=status,proto3,enum=jogger.v1.Status" json:"status,omitempty"`
+	// when the server started the job's process, in nanoseconds since the Unix epoch
+	StartedAtUnixNano int64 `protobuf:"varint,3,opt,name=started_at_unix_nano,json=startedAtUnixNano,proto3" json:"started_at_unix_nano,omitempty"`
+	// the path of the executable the job runs, resolved against the server's job
+	// PATH. It's the shell for shell jobs.
+	CommandPath string `protobuf:"bytes,4,opt,name=command_path,json=commandPath,proto3" json:"command_path,omitempty"`
+}
+
+func (x *StartResponse) Reset() {
+	*x = StartResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_jogger_v1_job_service_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StartResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StartResponse) ProtoMessage() {}
+
+func (x *StartResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_jogger_v1_job_service_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StartResponse.ProtoReflect.Descriptor instead.
+func (*StartResponse) Descriptor() ([]byte, []int) {
+	return file_jogger_v1_job_service_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *StartResponse) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+func (x *StartResponse) GetStatus() Status {
+	if x != nil {
+		return x.Status
+	}
+	return Status_STATUS_UNSPECIFIED
+}
+
+func (x *StartResponse) GetStartedAtUnixNano() int64 {
+	if x != nil {
+		return x.StartedAtUnixNano
+	}
+	return 0
+}
+
+func (x *StartResponse) GetCommandPath() string {
+	if x != nil {
+		return x.CommandPath
+	}
+	return ""
+}
+
+// Request to stop a job
+type StopRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// the job_id of the job to stop
+	JobId string `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	// grace_period_ms overrides the time the job has to exit after the SIGTERM,
+	// before the server sends a SIGKILL. 0 uses the server's default. It's
+	// ignored by servers configured with the immediate stop policy.
+	GracePeriodMs int64 `protobuf:"varint,2,opt,name=grace_period_ms,json=gracePeriodMs,proto3" json:"grace_period_ms,omitempty"`
+}
+
+func (x *StopRequest) Reset() {
+	*x = StopRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_jogger_v1_job_service_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StopRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StopRequest) ProtoMessage() {}
+
+func (x *StopRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_jogger_v1_job_service_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StopRequest.ProtoReflect.Descriptor instead.
+func (*StopRequest) Descriptor() ([]byte, []int) {
+	return file_jogger_v1_job_service_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *StopRequest) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+func (x *StopRequest) GetGracePeriodMs() int64 {
+	if x != nil {
+		return x.GracePeriodMs
+	}
+	return 0
+}
+
+// Response to stopping a job
+type StopResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// the status of the job after stopping: STOPPING until its process exits
+	Status Status `protobuf:"varint,1,opt,name=status,proto3,enum=jogger.v1.Status" json:"status,omitempty"`
+}
+
+func (x *StopResponse) Reset() {
+	*x = StopResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_jogger_v1_job_service_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StopResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StopResponse) ProtoMessage() {}
+
+func (x *StopResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_jogger_v1_job_service_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StopResponse.ProtoReflect.Descriptor instead.
+func (*StopResponse) Descriptor() ([]byte, []int) {
+	return file_jogger_v1_job_service_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *StopResponse) GetStatus() Status {
+	if x != nil {
+		return x.Status
+	}
+	return Status_STATUS_UNSPECIFIED
+}
+
+// Request to get the status of a job
+type StatusRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// the job_id of the job to get the status of
+	JobId string `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+}
+
+func (x *StatusRequest) Reset() {
+	*x = StatusRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_jogger_v1_job_service_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatusRequest) ProtoMessage() {}
+
+func (x *StatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_jogger_v1_job_service_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatusRequest.ProtoReflect.Descriptor instead.
+func (*StatusRequest) Descriptor() ([]byte, []int) {
+	return file_jogger_v1_job_service_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *StatusRequest) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+// Response to getting the status of a job
+type StatusResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// the status of the job
+	Status Status `protobuf:"varint,1,opt,name=status,proto3,enum=jogger.v1.Status" json:"status,omitempty"`
+	// the number of bytes of output the job has produced so far
+	OutputBytes int64 `protobuf:"varint,2,opt,name=output_bytes,json=outputBytes,proto3" json:"output_bytes,omitempty"`
+	// processes the job started that were still running after it exited. The
+	// server kills them, so this is normally empty and is reported to help
+	// diagnose leaks. It's only set once the job is done.
+	OrphanedPids []int32 `protobuf:"varint,3,rep,packed,name=orphaned_pids,json=orphanedPids,proto3" json:"orphaned_pids,omitempty"`
+	// why the job couldn't be started, only set for START_FAILED jobs
+	StartError string `protobuf:"bytes,4,opt,name=start_error,json=startError,proto3" json:"start_error,omitempty"`
+	// the name the job was started with, if it was given one
+	Name string `protobuf:"bytes,5,opt,name=name,proto3" json:"name,omitempty"`
+	// the most memory the job used at once, from its cgroup's memory.peak. It's
+	// only set once the job is done, and is 0 on kernels without memory.peak.
+	PeakMemoryBytes int64 `protobuf:"varint,6,opt,name=peak_memory_bytes,json=peakMemoryBytes,proto3" json:"peak_memory_bytes,omitempty"`
+	// the CPU time the job used, in microseconds, from its cgroup's cpu.stat.
+	// It's only set once the job is done.
+	TotalCpuUsec int64 `protobuf:"varint,7,opt,name=total_cpu_usec,json=totalCpuUsec,proto3" json:"total_cpu_usec,omitempty"`
+	// how long the server took to start the job, in microseconds, from receiving
+	// the request to the process starting. It includes creating the job's cgroup.
+	StartLatencyUsec int64 `protobuf:"varint,8,opt,name=start_latency_usec,json=startLatencyUsec,proto3" json:"start_latency_usec,omitempty"`
+	// the exit code of the job's process. It's -1 while the job is running, and
+	// for jobs killed by a signal.
+	ExitCode int32 `protobuf:"varint,9,opt,name=exit_code,json=exitCode,proto3" json:"exit_code,omitempty"`
+	// the network address of the client that started the job. It's only set for
+	// admins.
+	SourceAddr string `protobuf:"bytes,10,opt,name=source_addr,json=sourceAddr,proto3" json:"source_addr,omitempty"`
+}
+
+func (x *StatusResponse) Reset() {
+	*x = StatusResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_jogger_v1_job_service_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StatusResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatusResponse) ProtoMessage() {}
+
+func (x *StatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_jogger_v1_job_service_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatusResponse.ProtoReflect.Descriptor instead.
+func (*StatusResponse) Descriptor() ([]byte, []int) {
+	return file_jogger_v1_job_service_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *StatusResponse) GetStatus() Status {
+	if x != nil {
+		return x.Status
+	}
+	return Status_STATUS_UNSPECIFIED
+}
+
+func (x *StatusResponse) GetOutputBytes() int64 {
+	if x != nil {
+		return x.OutputBytes
+	}
+	return 0
+}
+
+func (x *StatusResponse) GetOrphanedPids() []int32 {
+	if x != nil {
+		return x.OrphanedPids
+	}
+	return nil
+}
+
+func (x *StatusResponse) GetStartError() string {
+	if x != nil {
+		return x.StartError
+	}
+	return ""
+}
+
+func (x *StatusResponse) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *StatusResponse) GetPeakMemoryBytes() int64 {
+	if x != nil {
+		return x.PeakMemoryBytes
+	}
+	return 0
+}
+
+func (x *StatusResponse) GetTotalCpuUsec() int64 {
+	if x != nil {
+		return x.TotalCpuUsec
+	}
+	return 0
+}
+
+func (x *StatusResponse) GetStartLatencyUsec() int64 {
+	if x != nil {
+		return x.StartLatencyUsec
+	}
+	return 0
+}
+
+func (x *StatusResponse) GetExitCode() int32 {
+	if x != nil {
+		return x.ExitCode
+	}
+	return 0
+}
+
+func (x *StatusResponse) GetSourceAddr() string {
+	if x != nil {
+		return x.SourceAddr
+	}
+	return ""
+}
+
+// Request to change the resource limits of a running job. Limits that are 0
+// are left as they are, and at least one must be set.
+type UpdateLimitsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// the job_id of the job to update
+	JobId string `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	// the most memory the job can use, in bytes
+	MemoryMaxBytes int64 `protobuf:"varint,2,opt,name=memory_max_bytes,json=memoryMaxBytes,proto3" json:"memory_max_bytes,omitempty"`
+	// the number of CPUs the job can use, e.g. 1.5
+	Cpus float64 `protobuf:"fixed64,3,opt,name=cpus,proto3" json:"cpus,omitempty"`
+}
+
+func (x *UpdateLimitsRequest) Reset() {
+	*x = UpdateLimitsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_jogger_v1_job_service_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *UpdateLimitsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateLimitsRequest) ProtoMessage() {}
+
+func (x *UpdateLimitsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_jogger_v1_job_service_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateLimitsRequest.ProtoReflect.Descriptor instead.
+func (*UpdateLimitsRequest) Descriptor() ([]byte, []int) {
+	return file_jogger_v1_job_service_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *UpdateLimitsRequest) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+func (x *UpdateLimitsRequest) GetMemoryMaxBytes() int64 {
+	if x != nil {
+		return x.MemoryMaxBytes
+	}
+	return 0
+}
+
+func (x *UpdateLimitsRequest) GetCpus() float64 {
+	if x != nil {
+		return x.Cpus
+	}
+	return 0
+}
+
+// Response to changing the resource limits of a job
+type UpdateLimitsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *UpdateLimitsResponse) Reset() {
+	*x = UpdateLimitsResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_jogger_v1_job_service_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
 }
 
-func (x *StartRequest) Reset() {
-	*x = StartRequest{}
+func (x *UpdateLimitsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateLimitsResponse) ProtoMessage() {}
+
+func (x *UpdateLimitsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_jogger_v1_job_service_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateLimitsResponse.ProtoReflect.Descriptor instead.
+func (*UpdateLimitsResponse) Descriptor() ([]byte, []int) {
+	return file_jogger_v1_job_service_proto_rawDescGZIP(), []int{9}
+}
+
+// Request to check whether a job exists
+type ExistsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// the job_id of the job to check
+	JobId string `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+}
+
+func (x *ExistsRequest) Reset() {
+	*x = ExistsRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_jogger_v1_job_service_proto_msgTypes[0]
+		mi := &file_jogger_v1_job_service_proto_msgTypes[10]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *StartRequest) String() string {
+func (x *ExistsRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*StartRequest) ProtoMessage() {}
+func (*ExistsRequest) ProtoMessage() {}
 
-func (x *StartRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_jogger_v1_job_service_proto_msgTypes[0]
+func (x *ExistsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_jogger_v1_job_service_proto_msgTypes[10]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -127,47 +1260,45 @@ func (x *StartRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use StartRequest.ProtoReflect.Descriptor instead.
-func (*StartRequest) Descriptor() ([]byte, []int) {
-	return file_jogger_v1_job_service_proto_rawDescGZIP(), []int{0}
+// Deprecated: Use ExistsRequest.ProtoReflect.Descriptor instead.
+func (*ExistsRequest) Descriptor() ([]byte, []int) {
+	return file_jogger_v1_job_service_proto_rawDescGZIP(), []int{10}
 }
 
-func (x *StartRequest) GetJob() *Job {
+func (x *ExistsRequest) GetJobId() string {
 	if x != nil {
-		return x.Job
+		return x.JobId
 	}
-	return nil
+	return ""
 }
 
-// Job represents a command and arguments to run on the server.
-type Job struct {
+// Response to checking whether a job exists
+type ExistsResponse struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	// the command to run
-	Cmd string `protobuf:"bytes,1,opt,name=cmd,proto3" json:"cmd,omitempty"`
-	// the arguments to pass to the command
-	Args []string `protobuf:"bytes,2,rep,name=args,proto3" json:"args,omitempty"`
+	// true if the caller has a job with the job_id
+	Exists bool `protobuf:"varint,1,opt,name=exists,proto3" json:"exists,omitempty"`
 }
 
-func (x *Job) Reset() {
-	*x = Job{}
+func (x *ExistsResponse) Reset() {
+	*x = ExistsResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_jogger_v1_job_service_proto_msgTypes[1]
+		mi := &file_jogger_v1_job_service_proto_msgTypes[11]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *Job) String() string {
+func (x *ExistsResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Job) ProtoMessage() {}
+func (*ExistsResponse) ProtoMessage() {}
 
-func (x *Job) ProtoReflect() protoreflect.Message {
-	mi := &file_jogger_v1_job_service_proto_msgTypes[1]
+func (x *ExistsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_jogger_v1_job_service_proto_msgTypes[11]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -178,52 +1309,182 @@ func (x *Job) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Job.ProtoReflect.Descriptor instead.
-func (*Job) Descriptor() ([]byte, []int) {
-	return file_jogger_v1_job_service_proto_rawDescGZIP(), []int{1}
+// Deprecated: Use ExistsResponse.ProtoReflect.Descriptor instead.
+func (*ExistsResponse) Descriptor() ([]byte, []int) {
+	return file_jogger_v1_job_service_proto_rawDescGZIP(), []int{11}
 }
 
-func (x *Job) GetCmd() string {
+func (x *ExistsResponse) GetExists() bool {
 	if x != nil {
-		return x.Cmd
+		return x.Exists
+	}
+	return false
+}
+
+// Request to get the lifecycle events of a job
+type EventsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// the job_id of the job to get the events of
+	JobId string `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+}
+
+func (x *EventsRequest) Reset() {
+	*x = EventsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_jogger_v1_job_service_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *EventsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EventsRequest) ProtoMessage() {}
+
+func (x *EventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_jogger_v1_job_service_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EventsRequest.ProtoReflect.Descriptor instead.
+func (*EventsRequest) Descriptor() ([]byte, []int) {
+	return file_jogger_v1_job_service_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *EventsRequest) GetJobId() string {
+	if x != nil {
+		return x.JobId
 	}
 	return ""
 }
 
-func (x *Job) GetArgs() []string {
+// Response to getting the lifecycle events of a job
+type EventsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// the job's events, oldest first
+	Events []*Event `protobuf:"bytes,1,rep,name=events,proto3" json:"events,omitempty"`
+}
+
+func (x *EventsResponse) Reset() {
+	*x = EventsResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_jogger_v1_job_service_proto_msgTypes[13]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *EventsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EventsResponse) ProtoMessage() {}
+
+func (x *EventsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_jogger_v1_job_service_proto_msgTypes[13]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EventsResponse.ProtoReflect.Descriptor instead.
+func (*EventsResponse) Descriptor() ([]byte, []int) {
+	return file_jogger_v1_job_service_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *EventsResponse) GetEvents() []*Event {
 	if x != nil {
-		return x.Args
+		return x.Events
 	}
 	return nil
 }
 
-// Response to starting a job
-type StartResponse struct {
+// Request to list the caller's jobs
+type ListJobsRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
+}
 
-	// the job_id of the job that was started
-	JobId string `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+func (x *ListJobsRequest) Reset() {
+	*x = ListJobsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_jogger_v1_job_service_proto_msgTypes[14]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
 }
 
-func (x *StartResponse) Reset() {
-	*x = StartResponse{}
+func (x *ListJobsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListJobsRequest) ProtoMessage() {}
+
+func (x *ListJobsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_jogger_v1_job_service_proto_msgTypes[14]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListJobsRequest.ProtoReflect.Descriptor instead.
+func (*ListJobsRequest) Descriptor() ([]byte, []int) {
+	return file_jogger_v1_job_service_proto_rawDescGZIP(), []int{14}
+}
+
+// Response to listing the caller's jobs
+type ListJobsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// the caller's jobs, oldest first
+	Jobs []*JobSummary `protobuf:"bytes,1,rep,name=jobs,proto3" json:"jobs,omitempty"`
+}
+
+func (x *ListJobsResponse) Reset() {
+	*x = ListJobsResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_jogger_v1_job_service_proto_msgTypes[2]
+		mi := &file_jogger_v1_job_service_proto_msgTypes[15]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *StartResponse) String() string {
+func (x *ListJobsResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*StartResponse) ProtoMessage() {}
+func (*ListJobsResponse) ProtoMessage() {}
 
-func (x *StartResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_jogger_v1_job_service_proto_msgTypes[2]
+func (x *ListJobsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_jogger_v1_job_service_proto_msgTypes[15]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -234,45 +1495,53 @@ func (x *StartResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use StartResponse.ProtoReflect.Descriptor instead.
-func (*StartResponse) Descriptor() ([]byte, []int) {
-	return file_jogger_v1_job_service_proto_rawDescGZIP(), []int{2}
+// Deprecated: Use ListJobsResponse.ProtoReflect.Descriptor instead.
+func (*ListJobsResponse) Descriptor() ([]byte, []int) {
+	return file_jogger_v1_job_service_proto_rawDescGZIP(), []int{15}
 }
 
-func (x *StartResponse) GetJobId() string {
+func (x *ListJobsResponse) GetJobs() []*JobSummary {
 	if x != nil {
-		return x.JobId
+		return x.Jobs
 	}
-	return ""
+	return nil
 }
 
-// Request to stop a job
-type StopRequest struct {
+// JobSummary describes a job in a list of jobs
+type JobSummary struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	// the job_id of the job to stop
 	JobId string `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
-}
-
-func (x *StopRequest) Reset() {
-	*x = StopRequest{}
+	// the name the job was started with, if it was given one
+	Name string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	// the command and arguments the job was started with
+	Cmd    string   `protobuf:"bytes,3,opt,name=cmd,proto3" json:"cmd,omitempty"`
+	Args   []string `protobuf:"bytes,4,rep,name=args,proto3" json:"args,omitempty"`
+	Status Status   `protobuf:"varint,5,opt,name=status,proto3,enum=jogger.v1.Status" json:"status,omitempty"`
+	// when the job's process was started, in nanoseconds since the Unix epoch,
+	// 0 for PENDING jobs
+	StartedAtUnixNano int64 `protobuf:"varint,6,opt,name=started_at_unix_nano,json=startedAtUnixNano,proto3" json:"started_at_unix_nano,omitempty"`
+}
+
+func (x *JobSummary) Reset() {
+	*x = JobSummary{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_jogger_v1_job_service_proto_msgTypes[3]
+		mi := &file_jogger_v1_job_service_proto_msgTypes[16]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *StopRequest) String() string {
+func (x *JobSummary) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*StopRequest) ProtoMessage() {}
+func (*JobSummary) ProtoMessage() {}
 
-func (x *StopRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_jogger_v1_job_service_proto_msgTypes[3]
+func (x *JobSummary) ProtoReflect() protoreflect.Message {
+	mi := &file_jogger_v1_job_service_proto_msgTypes[16]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -283,45 +1552,85 @@ func (x *StopRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use StopRequest.ProtoReflect.Descriptor instead.
-func (*StopRequest) Descriptor() ([]byte, []int) {
-	return file_jogger_v1_job_service_proto_rawDescGZIP(), []int{3}
+// Deprecated: Use JobSummary.ProtoReflect.Descriptor instead.
+func (*JobSummary) Descriptor() ([]byte, []int) {
+	return file_jogger_v1_job_service_proto_rawDescGZIP(), []int{16}
 }
 
-func (x *StopRequest) GetJobId() string {
+func (x *JobSummary) GetJobId() string {
 	if x != nil {
 		return x.JobId
 	}
 	return ""
 }
 
-// Response to stopping a job
-type StopResponse struct {
+func (x *JobSummary) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *JobSummary) GetCmd() string {
+	if x != nil {
+		return x.Cmd
+	}
+	return ""
+}
+
+func (x *JobSummary) GetArgs() []string {
+	if x != nil {
+		return x.Args
+	}
+	return nil
+}
+
+func (x *JobSummary) GetStatus() Status {
+	if x != nil {
+		return x.Status
+	}
+	return Status_STATUS_UNSPECIFIED
+}
+
+func (x *JobSummary) GetStartedAtUnixNano() int64 {
+	if x != nil {
+		return x.StartedAtUnixNano
+	}
+	return 0
+}
+
+// Event is something that happened in a job's lifecycle
+type Event struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	// the status of the job after stopping
-	Status Status `protobuf:"varint,1,opt,name=status,proto3,enum=jogger.v1.Status" json:"status,omitempty"`
+	// when the event happened, in nanoseconds since the Unix epoch
+	TimeUnixNano int64 `protobuf:"varint,1,opt,name=time_unix_nano,json=timeUnixNano,proto3" json:"time_unix_nano,omitempty"`
+	// the kind of event: started, stop_requested, or exited
+	Type string `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	// describes the event, e.g. the job's pid, the signal sent to stop it,
+	// or the status it exited with
+	Detail string `protobuf:"bytes,3,opt,name=detail,proto3" json:"detail,omitempty"`
 }
 
-func (x *StopResponse) Reset() {
-	*x = StopResponse{}
+func (x *Event) Reset() {
+	*x = Event{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_jogger_v1_job_service_proto_msgTypes[4]
+		mi := &file_jogger_v1_job_service_proto_msgTypes[17]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *StopResponse) String() string {
+func (x *Event) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*StopResponse) ProtoMessage() {}
+func (*Event) ProtoMessage() {}
 
-func (x *StopResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_jogger_v1_job_service_proto_msgTypes[4]
+func (x *Event) ProtoReflect() protoreflect.Message {
+	mi := &file_jogger_v1_job_service_proto_msgTypes[17]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -332,45 +1641,81 @@ func (x *StopResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use StopResponse.ProtoReflect.Descriptor instead.
-func (*StopResponse) Descriptor() ([]byte, []int) {
-	return file_jogger_v1_job_service_proto_rawDescGZIP(), []int{4}
+// Deprecated: Use Event.ProtoReflect.Descriptor instead.
+func (*Event) Descriptor() ([]byte, []int) {
+	return file_jogger_v1_job_service_proto_rawDescGZIP(), []int{17}
 }
 
-func (x *StopResponse) GetStatus() Status {
+func (x *Event) GetTimeUnixNano() int64 {
 	if x != nil {
-		return x.Status
+		return x.TimeUnixNano
 	}
-	return Status_STATUS_UNSPECIFIED
+	return 0
 }
 
-// Request to get the status of a job
-type StatusRequest struct {
+func (x *Event) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Event) GetDetail() string {
+	if x != nil {
+		return x.Detail
+	}
+	return ""
+}
+
+// Request to get the output of a job
+type OutputRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	// the job_id of the job to get the status of
+	// the job_id of the job to get the output of
 	JobId string `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	// when greater than 0, the stream ends after max_bytes bytes of output have
+	// been sent. The number of bytes sent is reported in the jogger-sent-bytes
+	// trailer, so clients can tell whether the cap was reached.
+	MaxBytes int64 `protobuf:"varint,2,opt,name=max_bytes,json=maxBytes,proto3" json:"max_bytes,omitempty"`
+	// how the output is split into messages, CHUNKING_SIZE when unspecified
+	Chunking Chunking `protobuf:"varint,3,opt,name=chunking,proto3,enum=jogger.v1.Chunking" json:"chunking,omitempty"`
+	// when greater than 0, the most bytes of output sent in each message, up to
+	// 65536. The server's default is used when it's 0. With CHUNKING_LINE, lines
+	// longer than chunk_size are split across messages.
+	ChunkSize int64 `protobuf:"varint,4,opt,name=chunk_size,json=chunkSize,proto3" json:"chunk_size,omitempty"`
+	// how the data in each message is encoded, ENCODING_RAW when unspecified.
+	// Use a text encoding to pass output through transports that only carry text.
+	Encoding Encoding `protobuf:"varint,5,opt,name=encoding,proto3,enum=jogger.v1.Encoding" json:"encoding,omitempty"`
+	// the byte offset in the job's output to start streaming from, e.g. the
+	// number of bytes already received, to resume a stream. It can't be more than
+	// the length of the output so far. With an output limit, an offset of output
+	// that was discarded starts at the oldest output that's kept.
+	Offset int64 `protobuf:"varint,6,opt,name=offset,proto3" json:"offset,omitempty"`
+	// which of the job's output streams to send, STREAM_SELECTOR_BOTH when
+	// unspecified. With STREAM_SELECTOR_STDOUT or STREAM_SELECTOR_STDERR, offset
+	// and max_bytes count only that stream's bytes.
+	Stream StreamSelector `protobuf:"varint,7,opt,name=stream,proto3,enum=jogger.v1.StreamSelector" json:"stream,omitempty"`
 }
-
-func (x *StatusRequest) Reset() {
-	*x = StatusRequest{}
+
+func (x *OutputRequest) Reset() {
+	*x = OutputRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_jogger_v1_job_service_proto_msgTypes[5]
+		mi := &file_jogger_v1_job_service_proto_msgTypes[18]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *StatusRequest) String() string {
+func (x *OutputRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*StatusRequest) ProtoMessage() {}
+func (*OutputRequest) ProtoMessage() {}
 
-func (x *StatusRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_jogger_v1_job_service_proto_msgTypes[5]
+func (x *OutputRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_jogger_v1_job_service_proto_msgTypes[18]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -381,45 +1726,90 @@ func (x *StatusRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use StatusRequest.ProtoReflect.Descriptor instead.
-func (*StatusRequest) Descriptor() ([]byte, []int) {
-	return file_jogger_v1_job_service_proto_rawDescGZIP(), []int{5}
+// Deprecated: Use OutputRequest.ProtoReflect.Descriptor instead.
+func (*OutputRequest) Descriptor() ([]byte, []int) {
+	return file_jogger_v1_job_service_proto_rawDescGZIP(), []int{18}
 }
 
-func (x *StatusRequest) GetJobId() string {
+func (x *OutputRequest) GetJobId() string {
 	if x != nil {
 		return x.JobId
 	}
 	return ""
 }
 
-// Response to getting the status of a job
-type StatusResponse struct {
+func (x *OutputRequest) GetMaxBytes() int64 {
+	if x != nil {
+		return x.MaxBytes
+	}
+	return 0
+}
+
+func (x *OutputRequest) GetChunking() Chunking {
+	if x != nil {
+		return x.Chunking
+	}
+	return Chunking_CHUNKING_UNSPECIFIED
+}
+
+func (x *OutputRequest) GetChunkSize() int64 {
+	if x != nil {
+		return x.ChunkSize
+	}
+	return 0
+}
+
+func (x *OutputRequest) GetEncoding() Encoding {
+	if x != nil {
+		return x.Encoding
+	}
+	return Encoding_ENCODING_UNSPECIFIED
+}
+
+func (x *OutputRequest) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *OutputRequest) GetStream() StreamSelector {
+	if x != nil {
+		return x.Stream
+	}
+	return StreamSelector_STREAM_SELECTOR_UNSPECIFIED
+}
+
+// Request to start a job and stream its output
+type StartAndStreamRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	// the status of the job
-	Status Status `protobuf:"varint,1,opt,name=status,proto3,enum=jogger.v1.Status" json:"status,omitempty"`
+	// the job to start. after_job_id can't be set.
+	Start *StartRequest `protobuf:"bytes,1,opt,name=start,proto3" json:"start,omitempty"`
+	// how the job's output is streamed. job_id and offset must be unset, the
+	// output is the started job's, from its beginning.
+	Output *OutputRequest `protobuf:"bytes,2,opt,name=output,proto3" json:"output,omitempty"`
 }
 
-func (x *StatusResponse) Reset() {
-	*x = StatusResponse{}
+func (x *StartAndStreamRequest) Reset() {
+	*x = StartAndStreamRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_jogger_v1_job_service_proto_msgTypes[6]
+		mi := &file_jogger_v1_job_service_proto_msgTypes[19]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *StatusResponse) String() string {
+func (x *StartAndStreamRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*StatusResponse) ProtoMessage() {}
+func (*StartAndStreamRequest) ProtoMessage() {}
 
-func (x *StatusResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_jogger_v1_job_service_proto_msgTypes[6]
+func (x *StartAndStreamRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_jogger_v1_job_service_proto_msgTypes[19]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -430,45 +1820,54 @@ func (x *StatusResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use StatusResponse.ProtoReflect.Descriptor instead.
-func (*StatusResponse) Descriptor() ([]byte, []int) {
-	return file_jogger_v1_job_service_proto_rawDescGZIP(), []int{6}
+// Deprecated: Use StartAndStreamRequest.ProtoReflect.Descriptor instead.
+func (*StartAndStreamRequest) Descriptor() ([]byte, []int) {
+	return file_jogger_v1_job_service_proto_rawDescGZIP(), []int{19}
 }
 
-func (x *StatusResponse) GetStatus() Status {
+func (x *StartAndStreamRequest) GetStart() *StartRequest {
 	if x != nil {
-		return x.Status
+		return x.Start
 	}
-	return Status_STATUS_UNSPECIFIED
+	return nil
 }
 
-// Request to get the output of a job
-type OutputRequest struct {
+func (x *StartAndStreamRequest) GetOutput() *OutputRequest {
+	if x != nil {
+		return x.Output
+	}
+	return nil
+}
+
+// Response to starting a job and streaming its output
+type StartAndStreamResponse struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	// the job_id of the job to get the output of
-	JobId string `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	// Types that are assignable to Response:
+	//	*StartAndStreamResponse_Start
+	//	*StartAndStreamResponse_Data
+	Response isStartAndStreamResponse_Response `protobuf_oneof:"response"`
 }
 
-func (x *OutputRequest) Reset() {
-	*x = OutputRequest{}
+func (x *StartAndStreamResponse) Reset() {
+	*x = StartAndStreamResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_jogger_v1_job_service_proto_msgTypes[7]
+		mi := &file_jogger_v1_job_service_proto_msgTypes[20]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *OutputRequest) String() string {
+func (x *StartAndStreamResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*OutputRequest) ProtoMessage() {}
+func (*StartAndStreamResponse) ProtoMessage() {}
 
-func (x *OutputRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_jogger_v1_job_service_proto_msgTypes[7]
+func (x *StartAndStreamResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_jogger_v1_job_service_proto_msgTypes[20]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -479,18 +1878,50 @@ func (x *OutputRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use OutputRequest.ProtoReflect.Descriptor instead.
-func (*OutputRequest) Descriptor() ([]byte, []int) {
-	return file_jogger_v1_job_service_proto_rawDescGZIP(), []int{7}
+// Deprecated: Use StartAndStreamResponse.ProtoReflect.Descriptor instead.
+func (*StartAndStreamResponse) Descriptor() ([]byte, []int) {
+	return file_jogger_v1_job_service_proto_rawDescGZIP(), []int{20}
 }
 
-func (x *OutputRequest) GetJobId() string {
-	if x != nil {
-		return x.JobId
+func (m *StartAndStreamResponse) GetResponse() isStartAndStreamResponse_Response {
+	if m != nil {
+		return m.Response
 	}
-	return ""
+	return nil
+}
+
+func (x *StartAndStreamResponse) GetStart() *StartResponse {
+	if x, ok := x.GetResponse().(*StartAndStreamResponse_Start); ok {
+		return x.Start
+	}
+	return nil
+}
+
+func (x *StartAndStreamResponse) GetData() *OutputData {
+	if x, ok := x.GetResponse().(*StartAndStreamResponse_Data); ok {
+		return x.Data
+	}
+	return nil
+}
+
+type isStartAndStreamResponse_Response interface {
+	isStartAndStreamResponse_Response()
+}
+
+type StartAndStreamResponse_Start struct {
+	// the first message: the job that was started
+	Start *StartResponse `protobuf:"bytes,1,opt,name=start,proto3,oneof"`
+}
+
+type StartAndStreamResponse_Data struct {
+	// every message after the first: a chunk of the job's output
+	Data *OutputData `protobuf:"bytes,2,opt,name=data,proto3,oneof"`
 }
 
+func (*StartAndStreamResponse_Start) isStartAndStreamResponse_Response() {}
+
+func (*StartAndStreamResponse_Data) isStartAndStreamResponse_Response() {}
+
 // Response to getting the output of a job
 type OutputResponse struct {
 	state         protoimpl.MessageState
@@ -503,7 +1934,7 @@ type OutputResponse struct {
 func (x *OutputResponse) Reset() {
 	*x = OutputResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_jogger_v1_job_service_proto_msgTypes[8]
+		mi := &file_jogger_v1_job_service_proto_msgTypes[21]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -516,7 +1947,7 @@ func (x *OutputResponse) String() string {
 func (*OutputResponse) ProtoMessage() {}
 
 func (x *OutputResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_jogger_v1_job_service_proto_msgTypes[8]
+	mi := &file_jogger_v1_job_service_proto_msgTypes[21]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -529,7 +1960,7 @@ func (x *OutputResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use OutputResponse.ProtoReflect.Descriptor instead.
 func (*OutputResponse) Descriptor() ([]byte, []int) {
-	return file_jogger_v1_job_service_proto_rawDescGZIP(), []int{8}
+	return file_jogger_v1_job_service_proto_rawDescGZIP(), []int{21}
 }
 
 func (x *OutputResponse) GetData() *OutputData {
@@ -549,13 +1980,18 @@ type OutputData struct {
 	// this is the combination of STDIN and STDERR outputs
 	// This is currently limited server-side to 64KB based on the tcp max packet size
 	// this will need to be revisited to improve performance.
+	// It's encoded with the request's encoding. Each message is encoded on its
+	// own, so it's decoded on its own too.
 	Data []byte `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	// the stream the chunk is from, see StreamSelector. It's STREAM_UNSPECIFIED
+	// with STREAM_SELECTOR_BOTH, when the chunk may combine stdout and stderr.
+	Stream Stream `protobuf:"varint,2,opt,name=stream,proto3,enum=jogger.v1.Stream" json:"stream,omitempty"`
 }
 
 func (x *OutputData) Reset() {
 	*x = OutputData{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_jogger_v1_job_service_proto_msgTypes[9]
+		mi := &file_jogger_v1_job_service_proto_msgTypes[22]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -568,7 +2004,7 @@ func (x *OutputData) String() string {
 func (*OutputData) ProtoMessage() {}
 
 func (x *OutputData) ProtoReflect() protoreflect.Message {
-	mi := &file_jogger_v1_job_service_proto_msgTypes[9]
+	mi := &file_jogger_v1_job_service_proto_msgTypes[22]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -581,7 +2017,7 @@ func (x *OutputData) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use OutputData.ProtoReflect.Descriptor instead.
 func (*OutputData) Descriptor() ([]byte, []int) {
-	return file_jogger_v1_job_service_proto_rawDescGZIP(), []int{9}
+	return file_jogger_v1_job_service_proto_rawDescGZIP(), []int{22}
 }
 
 func (x *OutputData) GetData() []byte {
@@ -591,74 +2027,287 @@ func (x *OutputData) GetData() []byte {
 	return nil
 }
 
+func (x *OutputData) GetStream() Stream {
+	if x != nil {
+		return x.Stream
+	}
+	return Stream_STREAM_UNSPECIFIED
+}
+
 var File_jogger_v1_job_service_proto protoreflect.FileDescriptor
 
 var file_jogger_v1_job_service_proto_rawDesc = []byte{
 	0x0a, 0x1b, 0x6a, 0x6f, 0x67, 0x67, 0x65, 0x72, 0x2f, 0x76, 0x31, 0x2f, 0x6a, 0x6f, 0x62, 0x5f,
 	0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x09, 0x6a,
-	0x6f, 0x67, 0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x22, 0x30, 0x0a, 0x0c, 0x53, 0x74, 0x61, 0x72,
-	0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x20, 0x0a, 0x03, 0x6a, 0x6f, 0x62, 0x18,
-	0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0e, 0x2e, 0x6a, 0x6f, 0x67, 0x67, 0x65, 0x72, 0x2e, 0x76,
-	0x31, 0x2e, 0x4a, 0x6f, 0x62, 0x52, 0x03, 0x6a, 0x6f, 0x62, 0x22, 0x2b, 0x0a, 0x03, 0x4a, 0x6f,
-	0x62, 0x12, 0x10, 0x0a, 0x03, 0x63, 0x6d, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03,
-	0x63, 0x6d, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x61, 0x72, 0x67, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28,
-	0x09, 0x52, 0x04, 0x61, 0x72, 0x67, 0x73, 0x22, 0x26, 0x0a, 0x0d, 0x53, 0x74, 0x61, 0x72, 0x74,
-	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x15, 0x0a, 0x06, 0x6a, 0x6f, 0x62, 0x5f,
-	0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x6a, 0x6f, 0x62, 0x49, 0x64, 0x22,
-	0x24, 0x0a, 0x0b, 0x53, 0x74, 0x6f, 0x70, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x15,
-	0x0a, 0x06, 0x6a, 0x6f, 0x62, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05,
-	0x6a, 0x6f, 0x62, 0x49, 0x64, 0x22, 0x39, 0x0a, 0x0c, 0x53, 0x74, 0x6f, 0x70, 0x52, 0x65, 0x73,
-	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x29, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18,
-	0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x11, 0x2e, 0x6a, 0x6f, 0x67, 0x67, 0x65, 0x72, 0x2e, 0x76,
-	0x31, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73,
-	0x22, 0x26, 0x0a, 0x0d, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
-	0x74, 0x12, 0x15, 0x0a, 0x06, 0x6a, 0x6f, 0x62, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28,
-	0x09, 0x52, 0x05, 0x6a, 0x6f, 0x62, 0x49, 0x64, 0x22, 0x3b, 0x0a, 0x0e, 0x53, 0x74, 0x61, 0x74,
-	0x75, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x29, 0x0a, 0x06, 0x73, 0x74,
-	0x61, 0x74, 0x75, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x11, 0x2e, 0x6a, 0x6f, 0x67,
-	0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x06, 0x73,
-	0x74, 0x61, 0x74, 0x75, 0x73, 0x22, 0x26, 0x0a, 0x0d, 0x4f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x52,
-	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x15, 0x0a, 0x06, 0x6a, 0x6f, 0x62, 0x5f, 0x69, 0x64,
-	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x6a, 0x6f, 0x62, 0x49, 0x64, 0x22, 0x3b, 0x0a,
-	0x0e, 0x4f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12,
-	0x29, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x61, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e,
-	0x6a, 0x6f, 0x67, 0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x4f, 0x75, 0x74, 0x70, 0x75, 0x74,
-	0x44, 0x61, 0x74, 0x61, 0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x22, 0x20, 0x0a, 0x0a, 0x4f, 0x75,
-	0x74, 0x70, 0x75, 0x74, 0x44, 0x61, 0x74, 0x61, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x61,
-	0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x2a, 0x61, 0x0a, 0x06,
-	0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x16, 0x0a, 0x12, 0x53, 0x54, 0x41, 0x54, 0x55, 0x53,
-	0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x0b,
-	0x0a, 0x07, 0x52, 0x55, 0x4e, 0x4e, 0x49, 0x4e, 0x47, 0x10, 0x01, 0x12, 0x0b, 0x0a, 0x07, 0x53,
-	0x54, 0x4f, 0x50, 0x50, 0x45, 0x44, 0x10, 0x02, 0x12, 0x0a, 0x0a, 0x06, 0x4b, 0x49, 0x4c, 0x4c,
-	0x45, 0x44, 0x10, 0x03, 0x12, 0x0a, 0x0a, 0x06, 0x46, 0x41, 0x49, 0x4c, 0x45, 0x44, 0x10, 0x04,
-	0x12, 0x0d, 0x0a, 0x09, 0x43, 0x4f, 0x4d, 0x50, 0x4c, 0x45, 0x54, 0x45, 0x44, 0x10, 0x05, 0x32,
-	0x81, 0x02, 0x0a, 0x0a, 0x4a, 0x6f, 0x62, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x3a,
-	0x0a, 0x05, 0x53, 0x74, 0x61, 0x72, 0x74, 0x12, 0x17, 0x2e, 0x6a, 0x6f, 0x67, 0x67, 0x65, 0x72,
-	0x2e, 0x76, 0x31, 0x2e, 0x53, 0x74, 0x61, 0x72, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
-	0x1a, 0x18, 0x2e, 0x6a, 0x6f, 0x67, 0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x74, 0x61,
-	0x72, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x37, 0x0a, 0x04, 0x53, 0x74,
-	0x6f, 0x70, 0x12, 0x16, 0x2e, 0x6a, 0x6f, 0x67, 0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x53,
-	0x74, 0x6f, 0x70, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x17, 0x2e, 0x6a, 0x6f, 0x67,
-	0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x74, 0x6f, 0x70, 0x52, 0x65, 0x73, 0x70, 0x6f,
-	0x6e, 0x73, 0x65, 0x12, 0x3d, 0x0a, 0x06, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x18, 0x2e,
-	0x6a, 0x6f, 0x67, 0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73,
-	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x19, 0x2e, 0x6a, 0x6f, 0x67, 0x67, 0x65, 0x72,
-	0x2e, 0x76, 0x31, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
-	0x73, 0x65, 0x12, 0x3f, 0x0a, 0x06, 0x4f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x12, 0x18, 0x2e, 0x6a,
-	0x6f, 0x67, 0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x4f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x52,
-	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x19, 0x2e, 0x6a, 0x6f, 0x67, 0x67, 0x65, 0x72, 0x2e,
-	0x76, 0x31, 0x2e, 0x4f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
-	0x65, 0x30, 0x01, 0x42, 0x9e, 0x01, 0x0a, 0x0d, 0x63, 0x6f, 0x6d, 0x2e, 0x6a, 0x6f, 0x67, 0x67,
-	0x65, 0x72, 0x2e, 0x76, 0x31, 0x42, 0x0f, 0x4a, 0x6f, 0x62, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63,
-	0x65, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x50, 0x01, 0x5a, 0x37, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62,
-	0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x64, 0x75, 0x73, 0x74, 0x69, 0x6e, 0x65, 0x76, 0x61, 0x6e, 0x2f,
-	0x6a, 0x6f, 0x67, 0x67, 0x65, 0x72, 0x2f, 0x70, 0x6b, 0x67, 0x2f, 0x67, 0x65, 0x6e, 0x2f, 0x6a,
-	0x6f, 0x67, 0x67, 0x65, 0x72, 0x2f, 0x76, 0x31, 0x3b, 0x6a, 0x6f, 0x67, 0x67, 0x65, 0x72, 0x76,
-	0x31, 0xa2, 0x02, 0x03, 0x4a, 0x58, 0x58, 0xaa, 0x02, 0x09, 0x4a, 0x6f, 0x67, 0x67, 0x65, 0x72,
-	0x2e, 0x56, 0x31, 0xca, 0x02, 0x09, 0x4a, 0x6f, 0x67, 0x67, 0x65, 0x72, 0x5c, 0x56, 0x31, 0xe2,
-	0x02, 0x15, 0x4a, 0x6f, 0x67, 0x67, 0x65, 0x72, 0x5c, 0x56, 0x31, 0x5c, 0x47, 0x50, 0x42, 0x4d,
-	0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0xea, 0x02, 0x0a, 0x4a, 0x6f, 0x67, 0x67, 0x65, 0x72,
-	0x3a, 0x3a, 0x56, 0x31, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x6f, 0x67, 0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x22, 0xc8, 0x01, 0x0a, 0x0c, 0x53, 0x74, 0x61,
+	0x72, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x20, 0x0a, 0x03, 0x6a, 0x6f, 0x62,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0e, 0x2e, 0x6a, 0x6f, 0x67, 0x67, 0x65, 0x72, 0x2e,
+	0x76, 0x31, 0x2e, 0x4a, 0x6f, 0x62, 0x52, 0x03, 0x6a, 0x6f, 0x62, 0x12, 0x2c, 0x0a, 0x12, 0x6f,
+	0x75, 0x74, 0x70, 0x75, 0x74, 0x5f, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x5f, 0x62, 0x79, 0x74, 0x65,
+	0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x10, 0x6f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x4c,
+	0x69, 0x6d, 0x69, 0x74, 0x42, 0x79, 0x74, 0x65, 0x73, 0x12, 0x2c, 0x0a, 0x07, 0x63, 0x61, 0x70,
+	0x74, 0x75, 0x72, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x12, 0x2e, 0x6a, 0x6f, 0x67,
+	0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x52, 0x07,
+	0x63, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x26, 0x0a, 0x04, 0x73,
+	0x70, 0x65, 0x63, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x6a, 0x6f, 0x67, 0x67,
+	0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x4a, 0x6f, 0x62, 0x53, 0x70, 0x65, 0x63, 0x52, 0x04, 0x73,
+	0x70, 0x65, 0x63, 0x22, 0x5e, 0x0a, 0x03, 0x4a, 0x6f, 0x62, 0x12, 0x10, 0x0a, 0x03, 0x63, 0x6d,
+	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x63, 0x6d, 0x64, 0x12, 0x12, 0x0a, 0x04,
+	0x61, 0x72, 0x67, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x04, 0x61, 0x72, 0x67, 0x73,
+	0x12, 0x10, 0x0a, 0x03, 0x74, 0x74, 0x79, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x03, 0x74,
+	0x74, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x68, 0x65, 0x6c, 0x6c, 0x18, 0x05, 0x20, 0x01, 0x28,
+	0x08, 0x52, 0x05, 0x73, 0x68, 0x65, 0x6c, 0x6c, 0x4a, 0x04, 0x08, 0x03, 0x10, 0x04, 0x52, 0x03,
+	0x65, 0x6e, 0x76, 0x22, 0xbc, 0x03, 0x0a, 0x07, 0x4a, 0x6f, 0x62, 0x53, 0x70, 0x65, 0x63, 0x12,
+	0x10, 0x0a, 0x03, 0x63, 0x6d, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x63, 0x6d,
+	0x64, 0x12, 0x12, 0x0a, 0x04, 0x61, 0x72, 0x67, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52,
+	0x04, 0x61, 0x72, 0x67, 0x73, 0x12, 0x10, 0x0a, 0x03, 0x74, 0x74, 0x79, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x08, 0x52, 0x03, 0x74, 0x74, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x68, 0x65, 0x6c, 0x6c,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x08, 0x52, 0x05, 0x73, 0x68, 0x65, 0x6c, 0x6c, 0x12, 0x12, 0x0a,
+	0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d,
+	0x65, 0x12, 0x2c, 0x0a, 0x07, 0x63, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x18, 0x07, 0x20, 0x01,
+	0x28, 0x0e, 0x32, 0x12, 0x2e, 0x6a, 0x6f, 0x67, 0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x43,
+	0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x52, 0x07, 0x63, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x12,
+	0x2c, 0x0a, 0x12, 0x6f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x5f, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x5f,
+	0x62, 0x79, 0x74, 0x65, 0x73, 0x18, 0x08, 0x20, 0x01, 0x28, 0x03, 0x52, 0x10, 0x6f, 0x75, 0x74,
+	0x70, 0x75, 0x74, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x42, 0x79, 0x74, 0x65, 0x73, 0x12, 0x14, 0x0a,
+	0x05, 0x75, 0x6d, 0x61, 0x73, 0x6b, 0x18, 0x09, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x75, 0x6d,
+	0x61, 0x73, 0x6b, 0x12, 0x23, 0x0a, 0x0d, 0x72, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x5f, 0x6e, 0x6f,
+	0x66, 0x69, 0x6c, 0x65, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0c, 0x72, 0x6c, 0x69, 0x6d,
+	0x69, 0x74, 0x4e, 0x6f, 0x66, 0x69, 0x6c, 0x65, 0x12, 0x21, 0x0a, 0x0c, 0x72, 0x6c, 0x69, 0x6d,
+	0x69, 0x74, 0x5f, 0x6e, 0x70, 0x72, 0x6f, 0x63, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0b,
+	0x72, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x4e, 0x70, 0x72, 0x6f, 0x63, 0x12, 0x20, 0x0a, 0x0c, 0x61,
+	0x66, 0x74, 0x65, 0x72, 0x5f, 0x6a, 0x6f, 0x62, 0x5f, 0x69, 0x64, 0x18, 0x0c, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x0a, 0x61, 0x66, 0x74, 0x65, 0x72, 0x4a, 0x6f, 0x62, 0x49, 0x64, 0x12, 0x27, 0x0a,
+	0x0f, 0x61, 0x66, 0x74, 0x65, 0x72, 0x5f, 0x63, 0x6f, 0x6d, 0x70, 0x6c, 0x65, 0x74, 0x65, 0x64,
+	0x18, 0x0d, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0e, 0x61, 0x66, 0x74, 0x65, 0x72, 0x43, 0x6f, 0x6d,
+	0x70, 0x6c, 0x65, 0x74, 0x65, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x72, 0x65, 0x64, 0x61, 0x63, 0x74,
+	0x18, 0x0e, 0x20, 0x03, 0x28, 0x09, 0x52, 0x06, 0x72, 0x65, 0x64, 0x61, 0x63, 0x74, 0x12, 0x27,
+	0x0a, 0x0f, 0x72, 0x65, 0x64, 0x61, 0x63, 0x74, 0x5f, 0x70, 0x61, 0x74, 0x74, 0x65, 0x72, 0x6e,
+	0x73, 0x18, 0x0f, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0e, 0x72, 0x65, 0x64, 0x61, 0x63, 0x74, 0x50,
+	0x61, 0x74, 0x74, 0x65, 0x72, 0x6e, 0x73, 0x4a, 0x04, 0x08, 0x03, 0x10, 0x04, 0x52, 0x03, 0x65,
+	0x6e, 0x76, 0x22, 0xa5, 0x01, 0x0a, 0x0d, 0x53, 0x74, 0x61, 0x72, 0x74, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x12, 0x15, 0x0a, 0x06, 0x6a, 0x6f, 0x62, 0x5f, 0x69, 0x64, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x6a, 0x6f, 0x62, 0x49, 0x64, 0x12, 0x29, 0x0a, 0x06, 0x73,
+	0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x11, 0x2e, 0x6a, 0x6f,
+	0x67, 0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x06,
+	0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x2f, 0x0a, 0x14, 0x73, 0x74, 0x61, 0x72, 0x74, 0x65,
+	0x64, 0x5f, 0x61, 0x74, 0x5f, 0x75, 0x6e, 0x69, 0x78, 0x5f, 0x6e, 0x61, 0x6e, 0x6f, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x11, 0x73, 0x74, 0x61, 0x72, 0x74, 0x65, 0x64, 0x41, 0x74, 0x55,
+	0x6e, 0x69, 0x78, 0x4e, 0x61, 0x6e, 0x6f, 0x12, 0x21, 0x0a, 0x0c, 0x63, 0x6f, 0x6d, 0x6d, 0x61,
+	0x6e, 0x64, 0x5f, 0x70, 0x61, 0x74, 0x68, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x63,
+	0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x50, 0x61, 0x74, 0x68, 0x22, 0x4c, 0x0a, 0x0b, 0x53, 0x74,
+	0x6f, 0x70, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x15, 0x0a, 0x06, 0x6a, 0x6f, 0x62,
+	0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x6a, 0x6f, 0x62, 0x49, 0x64,
+	0x12, 0x26, 0x0a, 0x0f, 0x67, 0x72, 0x61, 0x63, 0x65, 0x5f, 0x70, 0x65, 0x72, 0x69, 0x6f, 0x64,
+	0x5f, 0x6d, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0d, 0x67, 0x72, 0x61, 0x63, 0x65,
+	0x50, 0x65, 0x72, 0x69, 0x6f, 0x64, 0x4d, 0x73, 0x22, 0x39, 0x0a, 0x0c, 0x53, 0x74, 0x6f, 0x70,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x29, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74,
+	0x75, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x11, 0x2e, 0x6a, 0x6f, 0x67, 0x67, 0x65,
+	0x72, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x06, 0x73, 0x74, 0x61,
+	0x74, 0x75, 0x73, 0x22, 0x26, 0x0a, 0x0d, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x12, 0x15, 0x0a, 0x06, 0x6a, 0x6f, 0x62, 0x5f, 0x69, 0x64, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x6a, 0x6f, 0x62, 0x49, 0x64, 0x22, 0xf6, 0x02, 0x0a, 0x0e,
+	0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x29,
+	0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x11,
+	0x2e, 0x6a, 0x6f, 0x67, 0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x75,
+	0x73, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x21, 0x0a, 0x0c, 0x6f, 0x75, 0x74,
+	0x70, 0x75, 0x74, 0x5f, 0x62, 0x79, 0x74, 0x65, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x0b, 0x6f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x42, 0x79, 0x74, 0x65, 0x73, 0x12, 0x23, 0x0a, 0x0d,
+	0x6f, 0x72, 0x70, 0x68, 0x61, 0x6e, 0x65, 0x64, 0x5f, 0x70, 0x69, 0x64, 0x73, 0x18, 0x03, 0x20,
+	0x03, 0x28, 0x05, 0x52, 0x0c, 0x6f, 0x72, 0x70, 0x68, 0x61, 0x6e, 0x65, 0x64, 0x50, 0x69, 0x64,
+	0x73, 0x12, 0x1f, 0x0a, 0x0b, 0x73, 0x74, 0x61, 0x72, 0x74, 0x5f, 0x65, 0x72, 0x72, 0x6f, 0x72,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x73, 0x74, 0x61, 0x72, 0x74, 0x45, 0x72, 0x72,
+	0x6f, 0x72, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x2a, 0x0a, 0x11, 0x70, 0x65, 0x61, 0x6b, 0x5f, 0x6d,
+	0x65, 0x6d, 0x6f, 0x72, 0x79, 0x5f, 0x62, 0x79, 0x74, 0x65, 0x73, 0x18, 0x06, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x0f, 0x70, 0x65, 0x61, 0x6b, 0x4d, 0x65, 0x6d, 0x6f, 0x72, 0x79, 0x42, 0x79, 0x74,
+	0x65, 0x73, 0x12, 0x24, 0x0a, 0x0e, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x5f, 0x63, 0x70, 0x75, 0x5f,
+	0x75, 0x73, 0x65, 0x63, 0x18, 0x07, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0c, 0x74, 0x6f, 0x74, 0x61,
+	0x6c, 0x43, 0x70, 0x75, 0x55, 0x73, 0x65, 0x63, 0x12, 0x2c, 0x0a, 0x12, 0x73, 0x74, 0x61, 0x72,
+	0x74, 0x5f, 0x6c, 0x61, 0x74, 0x65, 0x6e, 0x63, 0x79, 0x5f, 0x75, 0x73, 0x65, 0x63, 0x18, 0x08,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x10, 0x73, 0x74, 0x61, 0x72, 0x74, 0x4c, 0x61, 0x74, 0x65, 0x6e,
+	0x63, 0x79, 0x55, 0x73, 0x65, 0x63, 0x12, 0x1b, 0x0a, 0x09, 0x65, 0x78, 0x69, 0x74, 0x5f, 0x63,
+	0x6f, 0x64, 0x65, 0x18, 0x09, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x65, 0x78, 0x69, 0x74, 0x43,
+	0x6f, 0x64, 0x65, 0x12, 0x1f, 0x0a, 0x0b, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x5f, 0x61, 0x64,
+	0x64, 0x72, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65,
+	0x41, 0x64, 0x64, 0x72, 0x22, 0x6a, 0x0a, 0x13, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x4c, 0x69,
+	0x6d, 0x69, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x15, 0x0a, 0x06, 0x6a,
+	0x6f, 0x62, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x6a, 0x6f, 0x62,
+	0x49, 0x64, 0x12, 0x28, 0x0a, 0x10, 0x6d, 0x65, 0x6d, 0x6f, 0x72, 0x79, 0x5f, 0x6d, 0x61, 0x78,
+	0x5f, 0x62, 0x79, 0x74, 0x65, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0e, 0x6d, 0x65,
+	0x6d, 0x6f, 0x72, 0x79, 0x4d, 0x61, 0x78, 0x42, 0x79, 0x74, 0x65, 0x73, 0x12, 0x12, 0x0a, 0x04,
+	0x63, 0x70, 0x75, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x01, 0x52, 0x04, 0x63, 0x70, 0x75, 0x73,
+	0x22, 0x16, 0x0a, 0x14, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x73,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x26, 0x0a, 0x0d, 0x45, 0x78, 0x69, 0x73,
+	0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x15, 0x0a, 0x06, 0x6a, 0x6f, 0x62,
+	0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x6a, 0x6f, 0x62, 0x49, 0x64,
+	0x22, 0x28, 0x0a, 0x0e, 0x45, 0x78, 0x69, 0x73, 0x74, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x65, 0x78, 0x69, 0x73, 0x74, 0x73, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x08, 0x52, 0x06, 0x65, 0x78, 0x69, 0x73, 0x74, 0x73, 0x22, 0x26, 0x0a, 0x0d, 0x45, 0x76,
+	0x65, 0x6e, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x15, 0x0a, 0x06, 0x6a,
+	0x6f, 0x62, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x6a, 0x6f, 0x62,
+	0x49, 0x64, 0x22, 0x3a, 0x0a, 0x0e, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x12, 0x28, 0x0a, 0x06, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x18, 0x01,
+	0x20, 0x03, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x6a, 0x6f, 0x67, 0x67, 0x65, 0x72, 0x2e, 0x76, 0x31,
+	0x2e, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x52, 0x06, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x22, 0x11,
+	0x0a, 0x0f, 0x4c, 0x69, 0x73, 0x74, 0x4a, 0x6f, 0x62, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x22, 0x3d, 0x0a, 0x10, 0x4c, 0x69, 0x73, 0x74, 0x4a, 0x6f, 0x62, 0x73, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x29, 0x0a, 0x04, 0x6a, 0x6f, 0x62, 0x73, 0x18, 0x01, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x6a, 0x6f, 0x67, 0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e,
+	0x4a, 0x6f, 0x62, 0x53, 0x75, 0x6d, 0x6d, 0x61, 0x72, 0x79, 0x52, 0x04, 0x6a, 0x6f, 0x62, 0x73,
+	0x22, 0xb9, 0x01, 0x0a, 0x0a, 0x4a, 0x6f, 0x62, 0x53, 0x75, 0x6d, 0x6d, 0x61, 0x72, 0x79, 0x12,
+	0x15, 0x0a, 0x06, 0x6a, 0x6f, 0x62, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x05, 0x6a, 0x6f, 0x62, 0x49, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x10, 0x0a, 0x03, 0x63, 0x6d,
+	0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x63, 0x6d, 0x64, 0x12, 0x12, 0x0a, 0x04,
+	0x61, 0x72, 0x67, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x09, 0x52, 0x04, 0x61, 0x72, 0x67, 0x73,
+	0x12, 0x29, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0e,
+	0x32, 0x11, 0x2e, 0x6a, 0x6f, 0x67, 0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x74, 0x61,
+	0x74, 0x75, 0x73, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x2f, 0x0a, 0x14, 0x73,
+	0x74, 0x61, 0x72, 0x74, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x5f, 0x75, 0x6e, 0x69, 0x78, 0x5f, 0x6e,
+	0x61, 0x6e, 0x6f, 0x18, 0x06, 0x20, 0x01, 0x28, 0x03, 0x52, 0x11, 0x73, 0x74, 0x61, 0x72, 0x74,
+	0x65, 0x64, 0x41, 0x74, 0x55, 0x6e, 0x69, 0x78, 0x4e, 0x61, 0x6e, 0x6f, 0x22, 0x59, 0x0a, 0x05,
+	0x45, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x24, 0x0a, 0x0e, 0x74, 0x69, 0x6d, 0x65, 0x5f, 0x75, 0x6e,
+	0x69, 0x78, 0x5f, 0x6e, 0x61, 0x6e, 0x6f, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0c, 0x74,
+	0x69, 0x6d, 0x65, 0x55, 0x6e, 0x69, 0x78, 0x4e, 0x61, 0x6e, 0x6f, 0x12, 0x12, 0x0a, 0x04, 0x74,
+	0x79, 0x70, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12,
+	0x16, 0x0a, 0x06, 0x64, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x06, 0x64, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x22, 0x8f, 0x02, 0x0a, 0x0d, 0x4f, 0x75, 0x74, 0x70,
+	0x75, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x15, 0x0a, 0x06, 0x6a, 0x6f, 0x62,
+	0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x6a, 0x6f, 0x62, 0x49, 0x64,
+	0x12, 0x1b, 0x0a, 0x09, 0x6d, 0x61, 0x78, 0x5f, 0x62, 0x79, 0x74, 0x65, 0x73, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x08, 0x6d, 0x61, 0x78, 0x42, 0x79, 0x74, 0x65, 0x73, 0x12, 0x2f, 0x0a,
+	0x08, 0x63, 0x68, 0x75, 0x6e, 0x6b, 0x69, 0x6e, 0x67, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0e, 0x32,
+	0x13, 0x2e, 0x6a, 0x6f, 0x67, 0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x68, 0x75, 0x6e,
+	0x6b, 0x69, 0x6e, 0x67, 0x52, 0x08, 0x63, 0x68, 0x75, 0x6e, 0x6b, 0x69, 0x6e, 0x67, 0x12, 0x1d,
+	0x0a, 0x0a, 0x63, 0x68, 0x75, 0x6e, 0x6b, 0x5f, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x09, 0x63, 0x68, 0x75, 0x6e, 0x6b, 0x53, 0x69, 0x7a, 0x65, 0x12, 0x2f, 0x0a,
+	0x08, 0x65, 0x6e, 0x63, 0x6f, 0x64, 0x69, 0x6e, 0x67, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0e, 0x32,
+	0x13, 0x2e, 0x6a, 0x6f, 0x67, 0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x45, 0x6e, 0x63, 0x6f,
+	0x64, 0x69, 0x6e, 0x67, 0x52, 0x08, 0x65, 0x6e, 0x63, 0x6f, 0x64, 0x69, 0x6e, 0x67, 0x12, 0x16,
+	0x0a, 0x06, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x18, 0x06, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06,
+	0x6f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x12, 0x31, 0x0a, 0x06, 0x73, 0x74, 0x72, 0x65, 0x61, 0x6d,
+	0x18, 0x07, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x19, 0x2e, 0x6a, 0x6f, 0x67, 0x67, 0x65, 0x72, 0x2e,
+	0x76, 0x31, 0x2e, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x53, 0x65, 0x6c, 0x65, 0x63, 0x74, 0x6f,
+	0x72, 0x52, 0x06, 0x73, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x22, 0x78, 0x0a, 0x15, 0x53, 0x74, 0x61,
+	0x72, 0x74, 0x41, 0x6e, 0x64, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x12, 0x2d, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x72, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x17, 0x2e, 0x6a, 0x6f, 0x67, 0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x74,
+	0x61, 0x72, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x52, 0x05, 0x73, 0x74, 0x61, 0x72,
+	0x74, 0x12, 0x30, 0x0a, 0x06, 0x6f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x18, 0x2e, 0x6a, 0x6f, 0x67, 0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x4f, 0x75,
+	0x74, 0x70, 0x75, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x52, 0x06, 0x6f, 0x75, 0x74,
+	0x70, 0x75, 0x74, 0x22, 0x83, 0x01, 0x0a, 0x16, 0x53, 0x74, 0x61, 0x72, 0x74, 0x41, 0x6e, 0x64,
+	0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x30,
+	0x0a, 0x05, 0x73, 0x74, 0x61, 0x72, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e,
+	0x6a, 0x6f, 0x67, 0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x74, 0x61, 0x72, 0x74, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x48, 0x00, 0x52, 0x05, 0x73, 0x74, 0x61, 0x72, 0x74,
+	0x12, 0x2b, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x61, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15,
+	0x2e, 0x6a, 0x6f, 0x67, 0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x4f, 0x75, 0x74, 0x70, 0x75,
+	0x74, 0x44, 0x61, 0x74, 0x61, 0x48, 0x00, 0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x42, 0x0a, 0x0a,
+	0x08, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x3b, 0x0a, 0x0e, 0x4f, 0x75, 0x74,
+	0x70, 0x75, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x29, 0x0a, 0x04, 0x64,
+	0x61, 0x74, 0x61, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x6a, 0x6f, 0x67, 0x67,
+	0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x4f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x44, 0x61, 0x74, 0x61,
+	0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x22, 0x4b, 0x0a, 0x0a, 0x4f, 0x75, 0x74, 0x70, 0x75, 0x74,
+	0x44, 0x61, 0x74, 0x61, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x61, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x0c, 0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x12, 0x29, 0x0a, 0x06, 0x73, 0x74, 0x72, 0x65,
+	0x61, 0x6d, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x11, 0x2e, 0x6a, 0x6f, 0x67, 0x67, 0x65,
+	0x72, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x52, 0x06, 0x73, 0x74, 0x72,
+	0x65, 0x61, 0x6d, 0x2a, 0x8e, 0x01, 0x0a, 0x06, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x16,
+	0x0a, 0x12, 0x53, 0x54, 0x41, 0x54, 0x55, 0x53, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49,
+	0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x0b, 0x0a, 0x07, 0x52, 0x55, 0x4e, 0x4e, 0x49, 0x4e,
+	0x47, 0x10, 0x01, 0x12, 0x0b, 0x0a, 0x07, 0x53, 0x54, 0x4f, 0x50, 0x50, 0x45, 0x44, 0x10, 0x02,
+	0x12, 0x0a, 0x0a, 0x06, 0x4b, 0x49, 0x4c, 0x4c, 0x45, 0x44, 0x10, 0x03, 0x12, 0x0a, 0x0a, 0x06,
+	0x46, 0x41, 0x49, 0x4c, 0x45, 0x44, 0x10, 0x04, 0x12, 0x0d, 0x0a, 0x09, 0x43, 0x4f, 0x4d, 0x50,
+	0x4c, 0x45, 0x54, 0x45, 0x44, 0x10, 0x05, 0x12, 0x10, 0x0a, 0x0c, 0x53, 0x54, 0x41, 0x52, 0x54,
+	0x5f, 0x46, 0x41, 0x49, 0x4c, 0x45, 0x44, 0x10, 0x06, 0x12, 0x0c, 0x0a, 0x08, 0x53, 0x54, 0x4f,
+	0x50, 0x50, 0x49, 0x4e, 0x47, 0x10, 0x07, 0x12, 0x0b, 0x0a, 0x07, 0x50, 0x45, 0x4e, 0x44, 0x49,
+	0x4e, 0x47, 0x10, 0x08, 0x2a, 0x9f, 0x01, 0x0a, 0x0e, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x53,
+	0x65, 0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x12, 0x1f, 0x0a, 0x1b, 0x53, 0x54, 0x52, 0x45, 0x41,
+	0x4d, 0x5f, 0x53, 0x45, 0x4c, 0x45, 0x43, 0x54, 0x4f, 0x52, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45,
+	0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x18, 0x0a, 0x14, 0x53, 0x54, 0x52, 0x45,
+	0x41, 0x4d, 0x5f, 0x53, 0x45, 0x4c, 0x45, 0x43, 0x54, 0x4f, 0x52, 0x5f, 0x42, 0x4f, 0x54, 0x48,
+	0x10, 0x01, 0x12, 0x1a, 0x0a, 0x16, 0x53, 0x54, 0x52, 0x45, 0x41, 0x4d, 0x5f, 0x53, 0x45, 0x4c,
+	0x45, 0x43, 0x54, 0x4f, 0x52, 0x5f, 0x53, 0x54, 0x44, 0x4f, 0x55, 0x54, 0x10, 0x02, 0x12, 0x1a,
+	0x0a, 0x16, 0x53, 0x54, 0x52, 0x45, 0x41, 0x4d, 0x5f, 0x53, 0x45, 0x4c, 0x45, 0x43, 0x54, 0x4f,
+	0x52, 0x5f, 0x53, 0x54, 0x44, 0x45, 0x52, 0x52, 0x10, 0x03, 0x12, 0x1a, 0x0a, 0x16, 0x53, 0x54,
+	0x52, 0x45, 0x41, 0x4d, 0x5f, 0x53, 0x45, 0x4c, 0x45, 0x43, 0x54, 0x4f, 0x52, 0x5f, 0x4d, 0x45,
+	0x52, 0x47, 0x45, 0x44, 0x10, 0x04, 0x2a, 0x38, 0x0a, 0x06, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d,
+	0x12, 0x16, 0x0a, 0x12, 0x53, 0x54, 0x52, 0x45, 0x41, 0x4d, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45,
+	0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x0a, 0x0a, 0x06, 0x53, 0x54, 0x44, 0x4f,
+	0x55, 0x54, 0x10, 0x01, 0x12, 0x0a, 0x0a, 0x06, 0x53, 0x54, 0x44, 0x45, 0x52, 0x52, 0x10, 0x02,
+	0x2a, 0x5c, 0x0a, 0x07, 0x43, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x12, 0x17, 0x0a, 0x13, 0x43,
+	0x41, 0x50, 0x54, 0x55, 0x52, 0x45, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49,
+	0x45, 0x44, 0x10, 0x00, 0x12, 0x10, 0x0a, 0x0c, 0x43, 0x41, 0x50, 0x54, 0x55, 0x52, 0x45, 0x5f,
+	0x42, 0x4f, 0x54, 0x48, 0x10, 0x01, 0x12, 0x12, 0x0a, 0x0e, 0x43, 0x41, 0x50, 0x54, 0x55, 0x52,
+	0x45, 0x5f, 0x53, 0x54, 0x44, 0x4f, 0x55, 0x54, 0x10, 0x02, 0x12, 0x12, 0x0a, 0x0e, 0x43, 0x41,
+	0x50, 0x54, 0x55, 0x52, 0x45, 0x5f, 0x53, 0x54, 0x44, 0x45, 0x52, 0x52, 0x10, 0x03, 0x2a, 0x4a,
+	0x0a, 0x08, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x69, 0x6e, 0x67, 0x12, 0x18, 0x0a, 0x14, 0x43, 0x48,
+	0x55, 0x4e, 0x4b, 0x49, 0x4e, 0x47, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49,
+	0x45, 0x44, 0x10, 0x00, 0x12, 0x11, 0x0a, 0x0d, 0x43, 0x48, 0x55, 0x4e, 0x4b, 0x49, 0x4e, 0x47,
+	0x5f, 0x53, 0x49, 0x5a, 0x45, 0x10, 0x01, 0x12, 0x11, 0x0a, 0x0d, 0x43, 0x48, 0x55, 0x4e, 0x4b,
+	0x49, 0x4e, 0x47, 0x5f, 0x4c, 0x49, 0x4e, 0x45, 0x10, 0x02, 0x2a, 0x5d, 0x0a, 0x08, 0x45, 0x6e,
+	0x63, 0x6f, 0x64, 0x69, 0x6e, 0x67, 0x12, 0x18, 0x0a, 0x14, 0x45, 0x4e, 0x43, 0x4f, 0x44, 0x49,
+	0x4e, 0x47, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00,
+	0x12, 0x10, 0x0a, 0x0c, 0x45, 0x4e, 0x43, 0x4f, 0x44, 0x49, 0x4e, 0x47, 0x5f, 0x52, 0x41, 0x57,
+	0x10, 0x01, 0x12, 0x13, 0x0a, 0x0f, 0x45, 0x4e, 0x43, 0x4f, 0x44, 0x49, 0x4e, 0x47, 0x5f, 0x42,
+	0x41, 0x53, 0x45, 0x36, 0x34, 0x10, 0x02, 0x12, 0x10, 0x0a, 0x0c, 0x45, 0x4e, 0x43, 0x4f, 0x44,
+	0x49, 0x4e, 0x47, 0x5f, 0x48, 0x45, 0x58, 0x10, 0x03, 0x32, 0xab, 0x05, 0x0a, 0x0a, 0x4a, 0x6f,
+	0x62, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x3a, 0x0a, 0x05, 0x53, 0x74, 0x61, 0x72,
+	0x74, 0x12, 0x17, 0x2e, 0x6a, 0x6f, 0x67, 0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x74,
+	0x61, 0x72, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x18, 0x2e, 0x6a, 0x6f, 0x67,
+	0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x74, 0x61, 0x72, 0x74, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x12, 0x37, 0x0a, 0x04, 0x53, 0x74, 0x6f, 0x70, 0x12, 0x16, 0x2e, 0x6a,
+	0x6f, 0x67, 0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x74, 0x6f, 0x70, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x1a, 0x17, 0x2e, 0x6a, 0x6f, 0x67, 0x67, 0x65, 0x72, 0x2e, 0x76, 0x31,
+	0x2e, 0x53, 0x74, 0x6f, 0x70, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x3d, 0x0a,
+	0x06, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x18, 0x2e, 0x6a, 0x6f, 0x67, 0x67, 0x65, 0x72,
+	0x2e, 0x76, 0x31, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x19, 0x2e, 0x6a, 0x6f, 0x67, 0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x74,
+	0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x3f, 0x0a, 0x06,
+	0x4f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x12, 0x18, 0x2e, 0x6a, 0x6f, 0x67, 0x67, 0x65, 0x72, 0x2e,
+	0x76, 0x31, 0x2e, 0x4f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x1a, 0x19, 0x2e, 0x6a, 0x6f, 0x67, 0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x4f, 0x75, 0x74,
+	0x70, 0x75, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x30, 0x01, 0x12, 0x57, 0x0a,
+	0x0e, 0x53, 0x74, 0x61, 0x72, 0x74, 0x41, 0x6e, 0x64, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x12,
+	0x20, 0x2e, 0x6a, 0x6f, 0x67, 0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x74, 0x61, 0x72,
+	0x74, 0x41, 0x6e, 0x64, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x21, 0x2e, 0x6a, 0x6f, 0x67, 0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x74,
+	0x61, 0x72, 0x74, 0x41, 0x6e, 0x64, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x30, 0x01, 0x12, 0x3d, 0x0a, 0x06, 0x45, 0x78, 0x69, 0x73, 0x74, 0x73,
+	0x12, 0x18, 0x2e, 0x6a, 0x6f, 0x67, 0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x45, 0x78, 0x69,
+	0x73, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x19, 0x2e, 0x6a, 0x6f, 0x67,
+	0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x45, 0x78, 0x69, 0x73, 0x74, 0x73, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x3d, 0x0a, 0x06, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x12,
+	0x18, 0x2e, 0x6a, 0x6f, 0x67, 0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x45, 0x76, 0x65, 0x6e,
+	0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x19, 0x2e, 0x6a, 0x6f, 0x67, 0x67,
+	0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x12, 0x3b, 0x0a, 0x0b, 0x57, 0x61, 0x74, 0x63, 0x68, 0x45, 0x76, 0x65,
+	0x6e, 0x74, 0x73, 0x12, 0x18, 0x2e, 0x6a, 0x6f, 0x67, 0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e,
+	0x45, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x10, 0x2e,
+	0x6a, 0x6f, 0x67, 0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x30,
+	0x01, 0x12, 0x4f, 0x0a, 0x0c, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x4c, 0x69, 0x6d, 0x69, 0x74,
+	0x73, 0x12, 0x1e, 0x2e, 0x6a, 0x6f, 0x67, 0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x55, 0x70,
+	0x64, 0x61, 0x74, 0x65, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x1f, 0x2e, 0x6a, 0x6f, 0x67, 0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x55, 0x70,
+	0x64, 0x61, 0x74, 0x65, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x12, 0x43, 0x0a, 0x08, 0x4c, 0x69, 0x73, 0x74, 0x4a, 0x6f, 0x62, 0x73, 0x12, 0x1a,
+	0x2e, 0x6a, 0x6f, 0x67, 0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x4a,
+	0x6f, 0x62, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1b, 0x2e, 0x6a, 0x6f, 0x67,
+	0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x4a, 0x6f, 0x62, 0x73, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x42, 0x9e, 0x01, 0x0a, 0x0d, 0x63, 0x6f, 0x6d, 0x2e,
+	0x6a, 0x6f, 0x67, 0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x42, 0x0f, 0x4a, 0x6f, 0x62, 0x53, 0x65,
+	0x72, 0x76, 0x69, 0x63, 0x65, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x50, 0x01, 0x5a, 0x37, 0x67, 0x69,
+	0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x64, 0x75, 0x73, 0x74, 0x69, 0x6e, 0x65,
+	0x76, 0x61, 0x6e, 0x2f, 0x6a, 0x6f, 0x67, 0x67, 0x65, 0x72, 0x2f, 0x70, 0x6b, 0x67, 0x2f, 0x67,
+	0x65, 0x6e, 0x2f, 0x6a, 0x6f, 0x67, 0x67, 0x65, 0x72, 0x2f, 0x76, 0x31, 0x3b, 0x6a, 0x6f, 0x67,
+	0x67, 0x65, 0x72, 0x76, 0x31, 0xa2, 0x02, 0x03, 0x4a, 0x58, 0x58, 0xaa, 0x02, 0x09, 0x4a, 0x6f,
+	0x67, 0x67, 0x65, 0x72, 0x2e, 0x56, 0x31, 0xca, 0x02, 0x09, 0x4a, 0x6f, 0x67, 0x67, 0x65, 0x72,
+	0x5c, 0x56, 0x31, 0xe2, 0x02, 0x15, 0x4a, 0x6f, 0x67, 0x67, 0x65, 0x72, 0x5c, 0x56, 0x31, 0x5c,
+	0x47, 0x50, 0x42, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0xea, 0x02, 0x0a, 0x4a, 0x6f,
+	0x67, 0x67, 0x65, 0x72, 0x3a, 0x3a, 0x56, 0x31, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -673,39 +2322,84 @@ func file_jogger_v1_job_service_proto_rawDescGZIP() []byte {
 	return file_jogger_v1_job_service_proto_rawDescData
 }
 
-var file_jogger_v1_job_service_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_jogger_v1_job_service_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
+var file_jogger_v1_job_service_proto_enumTypes = make([]protoimpl.EnumInfo, 6)
+var file_jogger_v1_job_service_proto_msgTypes = make([]protoimpl.MessageInfo, 23)
 var file_jogger_v1_job_service_proto_goTypes = []any{
-	(Status)(0),            // 0: jogger.v1.Status
-	(*StartRequest)(nil),   // 1: jogger.v1.StartRequest
-	(*Job)(nil),            // 2: jogger.v1.Job
-	(*StartResponse)(nil),  // 3: jogger.v1.StartResponse
-	(*StopRequest)(nil),    // 4: jogger.v1.StopRequest
-	(*StopResponse)(nil),   // 5: jogger.v1.StopResponse
-	(*StatusRequest)(nil),  // 6: jogger.v1.StatusRequest
-	(*StatusResponse)(nil), // 7: jogger.v1.StatusResponse
-	(*OutputRequest)(nil),  // 8: jogger.v1.OutputRequest
-	(*OutputResponse)(nil), // 9: jogger.v1.OutputResponse
-	(*OutputData)(nil),     // 10: jogger.v1.OutputData
+	(Status)(0),                    // 0: jogger.v1.Status
+	(StreamSelector)(0),            // 1: jogger.v1.StreamSelector
+	(Stream)(0),                    // 2: jogger.v1.Stream
+	(Capture)(0),                   // 3: jogger.v1.Capture
+	(Chunking)(0),                  // 4: jogger.v1.Chunking
+	(Encoding)(0),                  // 5: jogger.v1.Encoding
+	(*StartRequest)(nil),           // 6: jogger.v1.StartRequest
+	(*Job)(nil),                    // 7: jogger.v1.Job
+	(*JobSpec)(nil),                // 8: jogger.v1.JobSpec
+	(*StartResponse)(nil),          // 9: jogger.v1.StartResponse
+	(*StopRequest)(nil),            // 10: jogger.v1.StopRequest
+	(*StopResponse)(nil),           // 11: jogger.v1.StopResponse
+	(*StatusRequest)(nil),          // 12: jogger.v1.StatusRequest
+	(*StatusResponse)(nil),         // 13: jogger.v1.StatusResponse
+	(*UpdateLimitsRequest)(nil),    // 14: jogger.v1.UpdateLimitsRequest
+	(*UpdateLimitsResponse)(nil),   // 15: jogger.v1.UpdateLimitsResponse
+	(*ExistsRequest)(nil),          // 16: jogger.v1.ExistsRequest
+	(*ExistsResponse)(nil),         // 17: jogger.v1.ExistsResponse
+	(*EventsRequest)(nil),          // 18: jogger.v1.EventsRequest
+	(*EventsResponse)(nil),         // 19: jogger.v1.EventsResponse
+	(*ListJobsRequest)(nil),        // 20: jogger.v1.ListJobsRequest
+	(*ListJobsResponse)(nil),       // 21: jogger.v1.ListJobsResponse
+	(*JobSummary)(nil),             // 22: jogger.v1.JobSummary
+	(*Event)(nil),                  // 23: jogger.v1.Event
+	(*OutputRequest)(nil),          // 24: jogger.v1.OutputRequest
+	(*StartAndStreamRequest)(nil),  // 25: jogger.v1.StartAndStreamRequest
+	(*StartAndStreamResponse)(nil), // 26: jogger.v1.StartAndStreamResponse
+	(*OutputResponse)(nil),         // 27: jogger.v1.OutputResponse
+	(*OutputData)(nil),             // 28: jogger.v1.OutputData
 }
 var file_jogger_v1_job_service_proto_depIdxs = []int32{
-	2,  // 0: jogger.v1.StartRequest.job:type_name -> jogger.v1.Job
-	0,  // 1: jogger.v1.StopResponse.status:type_name -> jogger.v1.Status
-	0,  // 2: jogger.v1.StatusResponse.status:type_name -> jogger.v1.Status
-	10, // 3: jogger.v1.OutputResponse.data:type_name -> jogger.v1.OutputData
-	1,  // 4: jogger.v1.JobService.Start:input_type -> jogger.v1.StartRequest
-	4,  // 5: jogger.v1.JobService.Stop:input_type -> jogger.v1.StopRequest
-	6,  // 6: jogger.v1.JobService.Status:input_type -> jogger.v1.StatusRequest
-	8,  // 7: jogger.v1.JobService.Output:input_type -> jogger.v1.OutputRequest
-	3,  // 8: jogger.v1.JobService.Start:output_type -> jogger.v1.StartResponse
-	5,  // 9: jogger.v1.JobService.Stop:output_type -> jogger.v1.StopResponse
-	7,  // 10: jogger.v1.JobService.Status:output_type -> jogger.v1.StatusResponse
-	9,  // 11: jogger.v1.JobService.Output:output_type -> jogger.v1.OutputResponse
-	8,  // [8:12] is the sub-list for method output_type
-	4,  // [4:8] is the sub-list for method input_type
-	4,  // [4:4] is the sub-list for extension type_name
-	4,  // [4:4] is the sub-list for extension extendee
-	0,  // [0:4] is the sub-list for field type_name
+	7,  // 0: jogger.v1.StartRequest.job:type_name -> jogger.v1.Job
+	3,  // 1: jogger.v1.StartRequest.capture:type_name -> jogger.v1.Capture
+	8,  // 2: jogger.v1.StartRequest.spec:type_name -> jogger.v1.JobSpec
+	3,  // 3: jogger.v1.JobSpec.capture:type_name -> jogger.v1.Capture
+	0,  // 4: jogger.v1.StartResponse.status:type_name -> jogger.v1.Status
+	0,  // 5: jogger.v1.StopResponse.status:type_name -> jogger.v1.Status
+	0,  // 6: jogger.v1.StatusResponse.status:type_name -> jogger.v1.Status
+	23, // 7: jogger.v1.EventsResponse.events:type_name -> jogger.v1.Event
+	22, // 8: jogger.v1.ListJobsResponse.jobs:type_name -> jogger.v1.JobSummary
+	0,  // 9: jogger.v1.JobSummary.status:type_name -> jogger.v1.Status
+	4,  // 10: jogger.v1.OutputRequest.chunking:type_name -> jogger.v1.Chunking
+	5,  // 11: jogger.v1.OutputRequest.encoding:type_name -> jogger.v1.Encoding
+	1,  // 12: jogger.v1.OutputRequest.stream:type_name -> jogger.v1.StreamSelector
+	6,  // 13: jogger.v1.StartAndStreamRequest.start:type_name -> jogger.v1.StartRequest
+	24, // 14: jogger.v1.StartAndStreamRequest.output:type_name -> jogger.v1.OutputRequest
+	9,  // 15: jogger.v1.StartAndStreamResponse.start:type_name -> jogger.v1.StartResponse
+	28, // 16: jogger.v1.StartAndStreamResponse.data:type_name -> jogger.v1.OutputData
+	28, // 17: jogger.v1.OutputResponse.data:type_name -> jogger.v1.OutputData
+	2,  // 18: jogger.v1.OutputData.stream:type_name -> jogger.v1.Stream
+	6,  // 19: jogger.v1.JobService.Start:input_type -> jogger.v1.StartRequest
+	10, // 20: jogger.v1.JobService.Stop:input_type -> jogger.v1.StopRequest
+	12, // 21: jogger.v1.JobService.Status:input_type -> jogger.v1.StatusRequest
+	24, // 22: jogger.v1.JobService.Output:input_type -> jogger.v1.OutputRequest
+	25, // 23: jogger.v1.JobService.StartAndStream:input_type -> jogger.v1.StartAndStreamRequest
+	16, // 24: jogger.v1.JobService.Exists:input_type -> jogger.v1.ExistsRequest
+	18, // 25: jogger.v1.JobService.Events:input_type -> jogger.v1.EventsRequest
+	18, // 26: jogger.v1.JobService.WatchEvents:input_type -> jogger.v1.EventsRequest
+	14, // 27: jogger.v1.JobService.UpdateLimits:input_type -> jogger.v1.UpdateLimitsRequest
+	20, // 28: jogger.v1.JobService.ListJobs:input_type -> jogger.v1.ListJobsRequest
+	9,  // 29: jogger.v1.JobService.Start:output_type -> jogger.v1.StartResponse
+	11, // 30: jogger.v1.JobService.Stop:output_type -> jogger.v1.StopResponse
+	13, // 31: jogger.v1.JobService.Status:output_type -> jogger.v1.StatusResponse
+	27, // 32: jogger.v1.JobService.Output:output_type -> jogger.v1.OutputResponse
+	26, // 33: jogger.v1.JobService.StartAndStream:output_type -> jogger.v1.StartAndStreamResponse
+	17, // 34: jogger.v1.JobService.Exists:output_type -> jogger.v1.ExistsResponse
+	19, // 35: jogger.v1.JobService.Events:output_type -> jogger.v1.EventsResponse
+	23, // 36: jogger.v1.JobService.WatchEvents:output_type -> jogger.v1.Event
+	15, // 37: jogger.v1.JobService.UpdateLimits:output_type -> jogger.v1.UpdateLimitsResponse
+	21, // 38: jogger.v1.JobService.ListJobs:output_type -> jogger.v1.ListJobsResponse
+	29, // [29:39] is the sub-list for method output_type
+	19, // [19:29] is the sub-list for method input_type
+	19, // [19:19] is the sub-list for extension type_name
+	19, // [19:19] is the sub-list for extension extendee
+	0,  // [0:19] is the sub-list for field type_name
 }
 
 func init() { file_jogger_v1_job_service_proto_init() }
@@ -739,7 +2433,7 @@ func file_jogger_v1_job_service_proto_init() {
 			}
 		}
 		file_jogger_v1_job_service_proto_msgTypes[2].Exporter = func(v any, i int) any {
-			switch v := v.(*StartResponse); i {
+			switch v := v.(*JobSpec); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -751,7 +2445,7 @@ func file_jogger_v1_job_service_proto_init() {
 			}
 		}
 		file_jogger_v1_job_service_proto_msgTypes[3].Exporter = func(v any, i int) any {
-			switch v := v.(*StopRequest); i {
+			switch v := v.(*StartResponse); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -763,7 +2457,7 @@ func file_jogger_v1_job_service_proto_init() {
 			}
 		}
 		file_jogger_v1_job_service_proto_msgTypes[4].Exporter = func(v any, i int) any {
-			switch v := v.(*StopResponse); i {
+			switch v := v.(*StopRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -775,7 +2469,7 @@ func file_jogger_v1_job_service_proto_init() {
 			}
 		}
 		file_jogger_v1_job_service_proto_msgTypes[5].Exporter = func(v any, i int) any {
-			switch v := v.(*StatusRequest); i {
+			switch v := v.(*StopResponse); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -787,7 +2481,7 @@ func file_jogger_v1_job_service_proto_init() {
 			}
 		}
 		file_jogger_v1_job_service_proto_msgTypes[6].Exporter = func(v any, i int) any {
-			switch v := v.(*StatusResponse); i {
+			switch v := v.(*StatusRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -799,7 +2493,7 @@ func file_jogger_v1_job_service_proto_init() {
 			}
 		}
 		file_jogger_v1_job_service_proto_msgTypes[7].Exporter = func(v any, i int) any {
-			switch v := v.(*OutputRequest); i {
+			switch v := v.(*StatusResponse); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -811,7 +2505,7 @@ func file_jogger_v1_job_service_proto_init() {
 			}
 		}
 		file_jogger_v1_job_service_proto_msgTypes[8].Exporter = func(v any, i int) any {
-			switch v := v.(*OutputResponse); i {
+			switch v := v.(*UpdateLimitsRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -823,6 +2517,162 @@ func file_jogger_v1_job_service_proto_init() {
 			}
 		}
 		file_jogger_v1_job_service_proto_msgTypes[9].Exporter = func(v any, i int) any {
+			switch v := v.(*UpdateLimitsResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_jogger_v1_job_service_proto_msgTypes[10].Exporter = func(v any, i int) any {
+			switch v := v.(*ExistsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_jogger_v1_job_service_proto_msgTypes[11].Exporter = func(v any, i int) any {
+			switch v := v.(*ExistsResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_jogger_v1_job_service_proto_msgTypes[12].Exporter = func(v any, i int) any {
+			switch v := v.(*EventsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_jogger_v1_job_service_proto_msgTypes[13].Exporter = func(v any, i int) any {
+			switch v := v.(*EventsResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_jogger_v1_job_service_proto_msgTypes[14].Exporter = func(v any, i int) any {
+			switch v := v.(*ListJobsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_jogger_v1_job_service_proto_msgTypes[15].Exporter = func(v any, i int) any {
+			switch v := v.(*ListJobsResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_jogger_v1_job_service_proto_msgTypes[16].Exporter = func(v any, i int) any {
+			switch v := v.(*JobSummary); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_jogger_v1_job_service_proto_msgTypes[17].Exporter = func(v any, i int) any {
+			switch v := v.(*Event); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_jogger_v1_job_service_proto_msgTypes[18].Exporter = func(v any, i int) any {
+			switch v := v.(*OutputRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_jogger_v1_job_service_proto_msgTypes[19].Exporter = func(v any, i int) any {
+			switch v := v.(*StartAndStreamRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_jogger_v1_job_service_proto_msgTypes[20].Exporter = func(v any, i int) any {
+			switch v := v.(*StartAndStreamResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_jogger_v1_job_service_proto_msgTypes[21].Exporter = func(v any, i int) any {
+			switch v := v.(*OutputResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_jogger_v1_job_service_proto_msgTypes[22].Exporter = func(v any, i int) any {
 			switch v := v.(*OutputData); i {
 			case 0:
 				return &v.state
@@ -835,13 +2685,17 @@ func file_jogger_v1_job_service_proto_init() {
 			}
 		}
 	}
+	file_jogger_v1_job_service_proto_msgTypes[20].OneofWrappers = []any{
+		(*StartAndStreamResponse_Start)(nil),
+		(*StartAndStreamResponse_Data)(nil),
+	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_jogger_v1_job_service_proto_rawDesc,
-			NumEnums:      1,
-			NumMessages:   10,
+			NumEnums:      6,
+			NumMessages:   23,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
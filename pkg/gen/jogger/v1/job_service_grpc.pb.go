@@ -19,10 +19,16 @@ import (
 const _ = grpc.SupportPackageIsVersion8
 
 const (
-	JobService_Start_FullMethodName  = "/jogger.v1.JobService/Start"
-	JobService_Stop_FullMethodName   = "/jogger.v1.JobService/Stop"
-	JobService_Status_FullMethodName = "/jogger.v1.JobService/Status"
-	JobService_Output_FullMethodName = "/jogger.v1.JobService/Output"
+	JobService_Start_FullMethodName          = "/jogger.v1.JobService/Start"
+	JobService_Stop_FullMethodName           = "/jogger.v1.JobService/Stop"
+	JobService_Status_FullMethodName         = "/jogger.v1.JobService/Status"
+	JobService_Output_FullMethodName         = "/jogger.v1.JobService/Output"
+	JobService_StartAndStream_FullMethodName = "/jogger.v1.JobService/StartAndStream"
+	JobService_Exists_FullMethodName         = "/jogger.v1.JobService/Exists"
+	JobService_Events_FullMethodName         = "/jogger.v1.JobService/Events"
+	JobService_WatchEvents_FullMethodName    = "/jogger.v1.JobService/WatchEvents"
+	JobService_UpdateLimits_FullMethodName   = "/jogger.v1.JobService/UpdateLimits"
+	JobService_ListJobs_FullMethodName       = "/jogger.v1.JobService/ListJobs"
 )
 
 // JobServiceClient is the client API for JobService service.
@@ -34,13 +40,37 @@ type JobServiceClient interface {
 	// Start runs a job on the server and responds with the job_id
 	Start(ctx context.Context, in *StartRequest, opts ...grpc.CallOption) (*StartResponse, error)
 	// Stop stops a job that is running on the server. The server sends a
-	// SIGTERM signal to the job and waits for it to exit. The job has 10 seconds
-	// to exit before the server sends a SIGKILL signal to the job.
+	// SIGTERM signal to the job and waits for it to exit. The job has 10 seconds,
+	// or the request's grace_period_ms, to exit before the server sends a SIGKILL
+	// signal to the job. Servers
+	// configured with the immediate stop policy send a SIGKILL right away.
 	Stop(ctx context.Context, in *StopRequest, opts ...grpc.CallOption) (*StopResponse, error)
 	// Status returns the status of a job
 	Status(ctx context.Context, in *StatusRequest, opts ...grpc.CallOption) (*StatusResponse, error)
 	// Output streams the output of a job, including running jobs.
 	Output(ctx context.Context, in *OutputRequest, opts ...grpc.CallOption) (JobService_OutputClient, error)
+	// StartAndStream starts a job like Start, and streams its output like Output,
+	// in one call. The first message has the start response, with the job_id, and
+	// the rest have the job's output from its beginning, so none of it is missed
+	// between starting the job and asking for its output. A job started after
+	// another one can't be streamed this way, it has no output until it starts.
+	StartAndStream(ctx context.Context, in *StartAndStreamRequest, opts ...grpc.CallOption) (JobService_StartAndStreamClient, error)
+	// Exists reports whether the caller has a job with the job_id. It's cheaper
+	// than Status, and a missing job is a false response rather than an error.
+	Exists(ctx context.Context, in *ExistsRequest, opts ...grpc.CallOption) (*ExistsResponse, error)
+	// Events returns the lifecycle events of a job, like when it was started,
+	// stopped, and exited. Only the job's owner can get its events.
+	Events(ctx context.Context, in *EventsRequest, opts ...grpc.CallOption) (*EventsResponse, error)
+	// WatchEvents streams the lifecycle events of a job, the ones so far and
+	// then each one as it happens. The stream ends after the job's exited event.
+	WatchEvents(ctx context.Context, in *EventsRequest, opts ...grpc.CallOption) (JobService_WatchEventsClient, error)
+	// UpdateLimits changes the resource limits of a running job without
+	// restarting it. Limits can't be raised over the server's maxima, and the
+	// memory limit can't be lowered below what the job is using.
+	UpdateLimits(ctx context.Context, in *UpdateLimitsRequest, opts ...grpc.CallOption) (*UpdateLimitsResponse, error)
+	// ListJobs lists the caller's jobs, oldest first, then the PENDING ones.
+	// Other users' jobs are never listed.
+	ListJobs(ctx context.Context, in *ListJobsRequest, opts ...grpc.CallOption) (*ListJobsResponse, error)
 }
 
 type jobServiceClient struct {
@@ -114,6 +144,112 @@ func (x *jobServiceOutputClient) Recv() (*OutputResponse, error) {
 	return m, nil
 }
 
+func (c *jobServiceClient) StartAndStream(ctx context.Context, in *StartAndStreamRequest, opts ...grpc.CallOption) (JobService_StartAndStreamClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &JobService_ServiceDesc.Streams[1], JobService_StartAndStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &jobServiceStartAndStreamClient{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type JobService_StartAndStreamClient interface {
+	Recv() (*StartAndStreamResponse, error)
+	grpc.ClientStream
+}
+
+type jobServiceStartAndStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *jobServiceStartAndStreamClient) Recv() (*StartAndStreamResponse, error) {
+	m := new(StartAndStreamResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *jobServiceClient) Exists(ctx context.Context, in *ExistsRequest, opts ...grpc.CallOption) (*ExistsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ExistsResponse)
+	err := c.cc.Invoke(ctx, JobService_Exists_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *jobServiceClient) Events(ctx context.Context, in *EventsRequest, opts ...grpc.CallOption) (*EventsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(EventsResponse)
+	err := c.cc.Invoke(ctx, JobService_Events_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *jobServiceClient) WatchEvents(ctx context.Context, in *EventsRequest, opts ...grpc.CallOption) (JobService_WatchEventsClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &JobService_ServiceDesc.Streams[2], JobService_WatchEvents_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &jobServiceWatchEventsClient{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type JobService_WatchEventsClient interface {
+	Recv() (*Event, error)
+	grpc.ClientStream
+}
+
+type jobServiceWatchEventsClient struct {
+	grpc.ClientStream
+}
+
+func (x *jobServiceWatchEventsClient) Recv() (*Event, error) {
+	m := new(Event)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *jobServiceClient) UpdateLimits(ctx context.Context, in *UpdateLimitsRequest, opts ...grpc.CallOption) (*UpdateLimitsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UpdateLimitsResponse)
+	err := c.cc.Invoke(ctx, JobService_UpdateLimits_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *jobServiceClient) ListJobs(ctx context.Context, in *ListJobsRequest, opts ...grpc.CallOption) (*ListJobsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListJobsResponse)
+	err := c.cc.Invoke(ctx, JobService_ListJobs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // JobServiceServer is the server API for JobService service.
 // All implementations must embed UnimplementedJobServiceServer
 // for forward compatibility
@@ -123,13 +259,37 @@ type JobServiceServer interface {
 	// Start runs a job on the server and responds with the job_id
 	Start(context.Context, *StartRequest) (*StartResponse, error)
 	// Stop stops a job that is running on the server. The server sends a
-	// SIGTERM signal to the job and waits for it to exit. The job has 10 seconds
-	// to exit before the server sends a SIGKILL signal to the job.
+	// SIGTERM signal to the job and waits for it to exit. The job has 10 seconds,
+	// or the request's grace_period_ms, to exit before the server sends a SIGKILL
+	// signal to the job. Servers
+	// configured with the immediate stop policy send a SIGKILL right away.
 	Stop(context.Context, *StopRequest) (*StopResponse, error)
 	// Status returns the status of a job
 	Status(context.Context, *StatusRequest) (*StatusResponse, error)
 	// Output streams the output of a job, including running jobs.
 	Output(*OutputRequest, JobService_OutputServer) error
+	// StartAndStream starts a job like Start, and streams its output like Output,
+	// in one call. The first message has the start response, with the job_id, and
+	// the rest have the job's output from its beginning, so none of it is missed
+	// between starting the job and asking for its output. A job started after
+	// another one can't be streamed this way, it has no output until it starts.
+	StartAndStream(*StartAndStreamRequest, JobService_StartAndStreamServer) error
+	// Exists reports whether the caller has a job with the job_id. It's cheaper
+	// than Status, and a missing job is a false response rather than an error.
+	Exists(context.Context, *ExistsRequest) (*ExistsResponse, error)
+	// Events returns the lifecycle events of a job, like when it was started,
+	// stopped, and exited. Only the job's owner can get its events.
+	Events(context.Context, *EventsRequest) (*EventsResponse, error)
+	// WatchEvents streams the lifecycle events of a job, the ones so far and
+	// then each one as it happens. The stream ends after the job's exited event.
+	WatchEvents(*EventsRequest, JobService_WatchEventsServer) error
+	// UpdateLimits changes the resource limits of a running job without
+	// restarting it. Limits can't be raised over the server's maxima, and the
+	// memory limit can't be lowered below what the job is using.
+	UpdateLimits(context.Context, *UpdateLimitsRequest) (*UpdateLimitsResponse, error)
+	// ListJobs lists the caller's jobs, oldest first, then the PENDING ones.
+	// Other users' jobs are never listed.
+	ListJobs(context.Context, *ListJobsRequest) (*ListJobsResponse, error)
 	mustEmbedUnimplementedJobServiceServer()
 }
 
@@ -149,6 +309,24 @@ func (UnimplementedJobServiceServer) Status(context.Context, *StatusRequest) (*S
 func (UnimplementedJobServiceServer) Output(*OutputRequest, JobService_OutputServer) error {
 	return status.Errorf(codes.Unimplemented, "method Output not implemented")
 }
+func (UnimplementedJobServiceServer) StartAndStream(*StartAndStreamRequest, JobService_StartAndStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method StartAndStream not implemented")
+}
+func (UnimplementedJobServiceServer) Exists(context.Context, *ExistsRequest) (*ExistsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Exists not implemented")
+}
+func (UnimplementedJobServiceServer) Events(context.Context, *EventsRequest) (*EventsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Events not implemented")
+}
+func (UnimplementedJobServiceServer) WatchEvents(*EventsRequest, JobService_WatchEventsServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchEvents not implemented")
+}
+func (UnimplementedJobServiceServer) UpdateLimits(context.Context, *UpdateLimitsRequest) (*UpdateLimitsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateLimits not implemented")
+}
+func (UnimplementedJobServiceServer) ListJobs(context.Context, *ListJobsRequest) (*ListJobsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListJobs not implemented")
+}
 func (UnimplementedJobServiceServer) mustEmbedUnimplementedJobServiceServer() {}
 
 // UnsafeJobServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -237,6 +415,120 @@ func (x *jobServiceOutputServer) Send(m *OutputResponse) error {
 	return x.ServerStream.SendMsg(m)
 }
 
+func _JobService_StartAndStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StartAndStreamRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(JobServiceServer).StartAndStream(m, &jobServiceStartAndStreamServer{ServerStream: stream})
+}
+
+type JobService_StartAndStreamServer interface {
+	Send(*StartAndStreamResponse) error
+	grpc.ServerStream
+}
+
+type jobServiceStartAndStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *jobServiceStartAndStreamServer) Send(m *StartAndStreamResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _JobService_Exists_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExistsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(JobServiceServer).Exists(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: JobService_Exists_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(JobServiceServer).Exists(ctx, req.(*ExistsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _JobService_Events_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EventsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(JobServiceServer).Events(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: JobService_Events_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(JobServiceServer).Events(ctx, req.(*EventsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _JobService_WatchEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(EventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(JobServiceServer).WatchEvents(m, &jobServiceWatchEventsServer{ServerStream: stream})
+}
+
+type JobService_WatchEventsServer interface {
+	Send(*Event) error
+	grpc.ServerStream
+}
+
+type jobServiceWatchEventsServer struct {
+	grpc.ServerStream
+}
+
+func (x *jobServiceWatchEventsServer) Send(m *Event) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _JobService_UpdateLimits_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateLimitsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(JobServiceServer).UpdateLimits(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: JobService_UpdateLimits_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(JobServiceServer).UpdateLimits(ctx, req.(*UpdateLimitsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _JobService_ListJobs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListJobsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(JobServiceServer).ListJobs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: JobService_ListJobs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(JobServiceServer).ListJobs(ctx, req.(*ListJobsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // JobService_ServiceDesc is the grpc.ServiceDesc for JobService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -256,6 +548,22 @@ var JobService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Status",
 			Handler:    _JobService_Status_Handler,
 		},
+		{
+			MethodName: "Exists",
+			Handler:    _JobService_Exists_Handler,
+		},
+		{
+			MethodName: "Events",
+			Handler:    _JobService_Events_Handler,
+		},
+		{
+			MethodName: "UpdateLimits",
+			Handler:    _JobService_UpdateLimits_Handler,
+		},
+		{
+			MethodName: "ListJobs",
+			Handler:    _JobService_ListJobs_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -263,6 +571,16 @@ var JobService_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _JobService_Output_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "StartAndStream",
+			Handler:       _JobService_StartAndStream_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "WatchEvents",
+			Handler:       _JobService_WatchEvents_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "jogger/v1/job_service.proto",
 }
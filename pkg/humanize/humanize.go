@@ -0,0 +1,57 @@
+// Package humanize parses the human-readable sizes and durations accepted by jog flags and
+// the server config, so the same value means the same thing everywhere.
+package humanize
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// ParseBytes parses a positive number of bytes, with an optional K, M, or G suffix for
+// KiB, MiB, and GiB, e.g. 512, 64K, 10M. Suffixes are case-insensitive. Fractions,
+// signs, and any other suffix are rejected.
+func ParseBytes(s string) (int64, error) {
+	multiplier := int64(1)
+	digits := s
+	if n := len(s); n > 0 {
+		switch s[n-1] {
+		case 'K', 'k':
+			multiplier = 1 << 10
+		case 'M', 'm':
+			multiplier = 1 << 20
+		case 'G', 'g':
+			multiplier = 1 << 30
+		}
+		if multiplier > 1 {
+			digits = s[:n-1]
+		}
+	}
+	if digits == "" {
+		return 0, fmt.Errorf("use a positive number of bytes, optionally followed by K, M, or G")
+	}
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return 0, fmt.Errorf("use a positive number of bytes, optionally followed by K, M, or G")
+		}
+	}
+	n, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil || n > math.MaxInt64/multiplier {
+		return 0, fmt.Errorf("size %s is too large", s)
+	}
+	if n == 0 {
+		return 0, fmt.Errorf("use a positive number of bytes, optionally followed by K, M, or G")
+	}
+	return n * multiplier, nil
+}
+
+// ParseDuration parses a positive duration like 30s, 1m30s, or 500ms. It's
+// time.ParseDuration, except zero and negative durations are rejected.
+func ParseDuration(s string) (time.Duration, error) {
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("use a positive duration with a unit, like 30s, 5m, or 1h")
+	}
+	return d, nil
+}
@@ -0,0 +1,105 @@
+package humanize
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseBytes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input string
+		want  int64
+		err   bool
+	}{
+		{input: "1", want: 1},
+		{input: "512", want: 512},
+		{input: "64K", want: 64 * 1024},
+		{input: "64k", want: 64 * 1024},
+		{input: "10M", want: 10 * 1024 * 1024},
+		{input: "10m", want: 10 * 1024 * 1024},
+		{input: "1G", want: 1024 * 1024 * 1024},
+		{input: "1g", want: 1024 * 1024 * 1024},
+		{input: "007K", want: 7 * 1024},
+		{input: "8589934591G", want: 8589934591 * 1024 * 1024 * 1024},
+		{input: "9223372036854775807", want: 9223372036854775807},
+		{input: "", err: true},
+		{input: "K", err: true},
+		{input: "M", err: true},
+		{input: "0", err: true},
+		{input: "0K", err: true},
+		{input: "-1", err: true},
+		{input: "-1K", err: true},
+		{input: "+1K", err: true},
+		{input: " 1K", err: true},
+		{input: "1K ", err: true},
+		{input: "1 K", err: true},
+		{input: "1.5M", err: true},
+		{input: "10X", err: true},
+		{input: "10MB", err: true},
+		{input: "10KiB", err: true},
+		{input: "1T", err: true},
+		{input: "8589934592G", err: true},
+		{input: "9223372036854775808", err: true},
+		{input: "9999999999999G", err: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseBytes(tt.input)
+		if tt.err {
+			if err == nil {
+				t.Fatalf("ParseBytes(%q): expected error, got %d", tt.input, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("ParseBytes(%q): unexpected error: %v", tt.input, err)
+		}
+		if got != tt.want {
+			t.Fatalf("ParseBytes(%q): expected %d, got %d", tt.input, tt.want, got)
+		}
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input string
+		want  time.Duration
+		err   bool
+	}{
+		{input: "30s", want: 30 * time.Second},
+		{input: "500ms", want: 500 * time.Millisecond},
+		{input: "1m30s", want: 90 * time.Second},
+		{input: "1.5h", want: 90 * time.Minute},
+		{input: "1ns", want: time.Nanosecond},
+		{input: "", err: true},
+		{input: "30", err: true},
+		{input: "0", err: true},
+		{input: "0s", err: true},
+		{input: "-1s", err: true},
+		{input: "30S", err: true},
+		{input: "30 s", err: true},
+		{input: "soon", err: true},
+		{input: "10d", err: true},
+		{input: "9999999999h", err: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseDuration(tt.input)
+		if tt.err {
+			if err == nil {
+				t.Fatalf("ParseDuration(%q): expected error, got %v", tt.input, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("ParseDuration(%q): unexpected error: %v", tt.input, err)
+		}
+		if got != tt.want {
+			t.Fatalf("ParseDuration(%q): expected %v, got %v", tt.input, tt.want, got)
+		}
+	}
+}